@@ -0,0 +1,158 @@
+// Copyright 2025 Alessandro Pitocchi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/alessandropitocchi/lazyhelm/internal/config"
+	"github.com/alessandropitocchi/lazyhelm/internal/export"
+	"github.com/alessandropitocchi/lazyhelm/internal/ui"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Exit codes of the diff commands, like diff(1)
+const (
+	diffSame    = 0
+	diffChanged = 1
+	diffTrouble = 2
+)
+
+// runDiff runs "lazyhelm diff <repo/chart> <v1> <v2>", which diffs the
+// default values of two chart versions, or "lazyhelm diff-release <release>
+// <rev1> <rev2>", which diffs the values or manifests of two revisions. The
+// output is the diff the TUI shows, so it reads the same in CI logs.
+func runDiff(command string, args []string) int {
+	var opts options
+	var noColor, exitCode, manifest bool
+	var output string
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	opts.register(fs)
+	fs.BoolVar(&noColor, "no-color", false, "print the diff without colors")
+	fs.BoolVar(&exitCode, "exit-code", false, "exit with 1 when the documents differ")
+	fs.StringVar(&output, "output", "-", "where to write the diff: -, a file, @clipboard or |command")
+	if command == "diff-release" {
+		fs.BoolVar(&manifest, "manifest", false, "compare rendered manifests instead of values")
+	}
+
+	// Flags may come before, between or after the arguments
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return diffUsageError(command, err)
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if len(positional) != 3 {
+		return diffUsageError(command, fmt.Errorf("expected 3 arguments, got %d", len(positional)))
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return diffFailed(err)
+	}
+	client, err := newClient(opts, cfg)
+	if err != nil {
+		return diffFailed(err)
+	}
+	sink, err := export.Parse(expandHome(output), export.Options{
+		ClipboardMode:  cfg.Clipboard,
+		DefaultCommand: cfg.ExportCommand,
+		AllowStdout:    true,
+	})
+	if err != nil {
+		return diffUsageError(command, err)
+	}
+	applyTheme(cfg.Theme, lipgloss.HasDarkBackground())
+
+	var oldDoc, newDoc, label1, label2 string
+	var lines []ui.DiffLine
+	if command == "diff" {
+		chart, version1, version2 := positional[0], positional[1], positional[2]
+		if oldDoc, err = client.GetChartValuesByVersion(chart, version1); err != nil {
+			return diffFailed(err)
+		}
+		if newDoc, err = client.GetChartValuesByVersion(chart, version2); err != nil {
+			return diffFailed(err)
+		}
+		label1, label2 = "v"+version1, "v"+version2
+		lines = ui.DiffYAML(oldDoc, newDoc)
+	} else {
+		release := positional[0]
+		var revisions [2]int
+		for i, arg := range positional[1:] {
+			if revisions[i], err = strconv.Atoi(arg); err != nil || revisions[i] < 1 {
+				return diffUsageError(command, fmt.Errorf("invalid revision %q", arg))
+			}
+		}
+		namespace := client.Namespace()
+		get := client.GetReleaseValuesByRevision
+		label1, label2 = fmt.Sprintf("Revision %d", revisions[0]), fmt.Sprintf("Revision %d", revisions[1])
+		if manifest {
+			get = client.GetReleaseManifest
+			label1, label2 = label1+" manifest", label2+" manifest"
+		}
+		if oldDoc, err = get(context.Background(), release, namespace, revisions[0]); err != nil {
+			return diffFailed(err)
+		}
+		if newDoc, err = get(context.Background(), release, namespace, revisions[1]); err != nil {
+			return diffFailed(err)
+		}
+		if manifest {
+			lines = ui.DiffManifests(oldDoc, newDoc)
+		} else {
+			lines = ui.DiffYAML(oldDoc, newDoc)
+		}
+	}
+
+	content := renderDiffContent(lines, label1, label2)
+	// Colors are only for a terminal
+	if noColor || output != "-" {
+		content = ansi.Strip(content)
+	}
+	if err := sink.Write([]byte(content)); err != nil {
+		return diffFailed(fmt.Errorf("failed to write the diff to %s: %w", sink, err))
+	}
+
+	if exitCode && len(lines) > 0 {
+		return diffChanged
+	}
+	return diffSame
+}
+
+func diffUsageError(command string, err error) int {
+	fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
+	if command == "diff" {
+		fmt.Fprintln(os.Stderr, "Usage: lazyhelm diff [flags] <repo/chart> <version1> <version2>")
+	} else {
+		fmt.Fprintln(os.Stderr, "Usage: lazyhelm diff-release [flags] <release> <revision1> <revision2>")
+	}
+	fmt.Fprintln(os.Stderr, "Run lazyhelm --help for the flags.")
+	return diffTrouble
+}
+
+func diffFailed(err error) int {
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	return diffTrouble
+}
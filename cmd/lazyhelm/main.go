@@ -15,26 +15,41 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"log/slog"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/alessandropitocchi/lazyhelm/internal/artifacthub"
+	"github.com/alessandropitocchi/lazyhelm/internal/config"
+	"github.com/alessandropitocchi/lazyhelm/internal/export"
+	"github.com/alessandropitocchi/lazyhelm/internal/gitops"
 	"github.com/alessandropitocchi/lazyhelm/internal/helm"
 	"github.com/alessandropitocchi/lazyhelm/internal/ui"
-	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/sahilm/fuzzy"
 	"gopkg.in/yaml.v3"
+	"k8s.io/klog/v2"
 )
 
 var (
@@ -93,6 +108,19 @@ var (
 			Background(lipgloss.Color("160")). // Rosso medio
 			Bold(true)
 
+	// The words that changed inside a changed line
+	addedWordStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("231")). // Bianco
+			Background(lipgloss.Color("28")).  // Verde scuro
+			Bold(true).
+			Underline(true)
+
+	removedWordStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("231")). // Bianco
+				Background(lipgloss.Color("88")).  // Rosso scuro
+				Bold(true).
+				Underline(true)
+
 	modifiedStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("0")).   // Nero
 			Background(lipgloss.Color("228")). // Giallo chiaro
@@ -128,20 +156,42 @@ const (
 	stateMainMenu navigationState = iota
 	stateBrowseMenu
 	stateRepoList
+	stateRepoInfo
 	stateChartList
 	stateChartDetail
+	stateChartInfo
+	stateChartReadme
+	stateChartFiles
+	stateChartFile
+	stateChartSchema
+	stateTemplatePreview
 	stateValueViewer
+	stateValidation
 	stateDiffViewer
 	stateHelp
 	stateArtifactHubSearch
 	stateArtifactHubPackageDetail
 	stateArtifactHubVersions
+	stateArtifactHubSecurity
 	stateClusterReleasesMenu
 	stateNamespaceList
+	stateContextList
 	stateReleaseList
 	stateReleaseDetail
 	stateReleaseHistory
 	stateReleaseValues
+	stateReleaseNotes
+	stateReleaseDrift
+	stateReleaseEvents
+	statePodPicker
+	statePodLogs
+	stateReleaseTest
+	stateSettings
+	stateRecent
+	stateFavorites
+	stateActionLog
+	stateHelmfile
+	stateRegistries
 )
 
 type inputMode int
@@ -151,13 +201,75 @@ const (
 	searchMode
 	addRepoMode
 	templatePathMode
+	templateReleaseMode
+	templateNamespaceMode
+	templateKubeVersionMode
 	templateValuesMode
+	templateSetMode
+	templatePostRendererMode
+	templateValidateMode
+	uninstallKeepHistoryMode
+	uninstallWaitMode
+	confirmUninstallMode
 	exportValuesMode
 	saveEditMode
 	confirmRemoveRepoMode
+	confirmDuplicateRepoMode
+	renameRepoMode
+	manifestFormatMode
+	manifestPathMode
+	gotoMode
+	localDiffMode
+	validateValuesMode
+	selectorMode
+	settingMode
+	upgradeChartMode
+	upgradeVersionMode
+	upgradeValuesMode
+	upgradeSetMode
+	dryRunReleaseMode
+	dryRunNamespaceMode
+	helmfilePathMode
+	helmfileEnvMode
+	helmfileActionMode
+	registryHostMode
+	registryUsernameMode
+	registryPasswordMode
+	confirmRegistryLogoutMode
+	exportReposMode
+	importReposPathMode
+	importReposStrategyMode
+	ahFilterMode
+	fixPendingMode
+	confirmFixPendingMode
+	batchActionMode
+	batchExportMode
+	confirmBatchUninstallMode
+	exportDiffMode
+	jumpKeyMode
+	templateShowOnlyMode
+	repoGrepMode
+	releaseGrepMode
+)
+
+// Steps of the add-repo prompt. Everything after the URL is only asked for
+// when the repository needs credentials or TLS settings.
+const (
+	addRepoNameStep = iota
+	addRepoURLStep
+	addRepoAuthStep
+	addRepoUsernameStep
+	addRepoPasswordStep
+	addRepoCAFileStep
+	addRepoCertFileStep
+	addRepoKeyFileStep
+	addRepoInsecureStep
+	addRepoGitRefStep
+	addRepoGitPathStep
 )
 
 type model struct {
+	config       *config.Config
 	helmClient   *helm.Client
 	cache        *helm.Cache
 	chartCache   map[string]chartCacheEntry
@@ -167,6 +279,7 @@ type model struct {
 	mode         inputMode
 
 	repos        []helm.Repository
+	repoUpdating map[string]bool // Repositories whose index is being updated in the background
 	charts       []helm.Chart
 	versions     []helm.ChartVersion
 	values       string
@@ -181,66 +294,249 @@ type model struct {
 	searchMatches      []int    // Line numbers of matches
 	currentMatchIndex  int      // Current match being viewed
 	lastSearchQuery    string   // Last search query
+	searchPattern      *ui.SearchPattern // Last valid search of the text viewers
+	searchErr          error             // Why the search being typed isn't valid
+	searchOptions      ui.SearchOptions  // Case-sensitive and whole-word toggles
 
 	// Horizontal scrolling in values
 	horizontalOffset   int      // Horizontal scroll offset for long lines
 
+	// Outline mode of the values viewer, with foldable blocks
+	valuesOutline      bool
+	valuesBlockEnd     []int        // ui.FoldBlocks of valuesLines
+	valuesFolded       map[int]bool // Lines whose block is collapsed
+	outlineCursor      int          // Line of valuesLines under the cursor
+
+	// Line-number gutter of the values and diff viewers
+	lineNumbers bool
+
+	// Jump-to-key prompt of the values viewers
+	keyPaths           []ui.KeyPath
+	keyMatches         []ui.KeyPath
+
 	// Artifact Hub
 	artifactHubClient  *artifacthub.Client
 	ahPackages         []artifacthub.Package
+	ahQuery            string // Query of the loaded search results
+	ahTotal            int    // Packages matching ahQuery, loaded or not
+	ahLoadingMore      bool
+	ahFilters          artifacthub.SearchOptions
 	ahSelectedPackage  *artifacthub.Package
 	ahPackageList      list.Model
 	ahVersionList      list.Model
 	ahSelectedPkg      int
 	ahSelectedVersion  int
 	ahLoading          bool
+	ahSecurityView     viewport.Model
+	ahSecurityLines    []string
 
 	// Cluster Releases
 	releases           []helm.Release
+	releaseLoadSeq     int
+	loadingMore        bool // Further release pages are still being fetched
 	namespaces         []string
 	selectedRelease    int
 	selectedRevision   int
 	compareRevision    int
+	diffManifests      bool // The revision diff compares manifests rather than values
 	selectedNamespace  string
+	releaseSelector    string // Label selector applied to helm list
+	releaseFilter      string // Fuzzy filter on the release list, re-applied as later pages arrive
+	defaultNamespace   string // Effective namespace from --namespace / HELM_NAMESPACE / kube context
+	namespacePicked    bool   // Release list was opened from the namespace list
 	releaseHistory     []helm.ReleaseRevision
 	releaseValues      string
 	releaseValuesLines []string
+	releaseNotes       string
+	releaseNotesLines  []string // Wrapped notes lines (for search)
+	releaseDriftLines  []string
+	releaseEventsLines []string
+	releaseDetailLines []string // Unscrolled release detail text (for search)
 	releaseStatus      *helm.ReleaseStatus
+	releaseResources   []helm.ReleaseResource
+	resourcesLoading   bool
+	resourcesErr       error
+	repoInfo           *helm.RepositoryInfo
+	chartInfo          *helm.ChartInfo
+	readme             string   // Markdown of the chart README being viewed
+	readmeLines        []string // Rendered README lines (for search)
+	readmeReturn       navigationState // Chart detail or the Artifact Hub package
+	chartFiles         []helm.ChartFile
+	chartFileLines     []string // Lines of the chart file being viewed
+	valuesSchema       []helm.SchemaProperty
+	schemaReturn       navigationState // Chart detail or the Artifact Hub package
 	kubeContext        string
+	kubeContexts       []helm.KubeContext
+	clusterVersion     string // Empty until loaded, or when there's no reachable cluster
+	clusterChecked     bool
+	clusterGen         int // Bumped when the cluster changes, dropping what the previous one still loads
+
+	// Contexts of the loaders started for a screen, cancelled when it's left
+	loadContexts map[navigationState]context.Context
+	loadCancels  map[navigationState]context.CancelFunc
 
 	mainMenu              list.Model
 	browseMenu            list.Model
 	clusterReleasesMenu   list.Model
+	settingsList          list.Model
+	recentList            list.Model
+	favoritesList         list.Model
+	helmfileList          list.Model
+	registryList          list.Model
 	namespaceList         list.Model
+	contextList           list.Model
 	releaseList           list.Model
 	releaseHistoryList    list.Model
 	releaseDetailView     viewport.Model
 	releaseValuesView     viewport.Model
+	releaseNotesView      viewport.Model
+	releaseDriftView      viewport.Model
+	releaseEventsView     viewport.Model
+	actionLogView         viewport.Model
+	releaseTestView       viewport.Model
+	podPickerList         list.Model
+	podLogsView           viewport.Model
 	repoList     list.Model
 	chartList    list.Model
 	versionList  list.Model
 	valuesView   viewport.Model
 	diffView     viewport.Model
+	validationView viewport.Model
+	readmeView   viewport.Model
+	templateView viewport.Model
+	chartFileList list.Model
+	chartFileView viewport.Model
+	schemaList   list.Model
 	searchInput  textinput.Model
 	helpView     help.Model
 	keys         keyMap
 
 	loading      bool
+	loadGen      int // Bumped by startLoading, so a dropped result only ends the loading it started
 	loadingVals  bool
+	spinner      spinner.Model
+	spinning     bool      // A spinner tick is scheduled
+	loadingSince time.Time // When the current operations started
+	backgroundOps int      // Operations started through background() still running
+	actionLog     []actionLogEntry // Operations performed this session, oldest first
+	actionLogFile *actionLogWriter // Appends actionLog to the action_log file
+	hideDeprecated bool
 	diffMode     bool
 	successMsg   string
+	successSeq   int
+	undoRepo     *helm.RemovedRepository // Last removed repository, while its undo toast is shown
 	err          error
 	termWidth    int
 	termHeight   int
+	darkBackground bool // Terminal background detected at startup
 
 	templatePath   string
+	templateRelease     string // Release name, namespace and Kubernetes version of the last template, offered again next time
+	templateNamespace   string
+	templateKubeVersion string
 	templateValues string
+	postRenderer   string // Last post-renderer used for templating, offered again next time
+	templateCheck  bool   // Validate templates against the cluster (--validate)
+	templateReturn      navigationState       // Screen the template preview was opened from
+	templateLines       []string              // Rendered manifest of the template preview
+	templateHighlighted []string              // templateLines with syntax highlighting
+	templateResources   []ui.ManifestResource // Objects of the template preview, in order
+	templateChart       string                // Chart and options of the template preview, to render it again
+	templateOpts        helm.TemplateOptions
+	templateSources     []string // Templates of the chart that render objects, e.g. templates/service.yaml
+	templateSourceMatches []string // Completions of the template being typed for show-only
+	overrides       []string     // key=value overrides of the template or upgrade preview being set up
+	overrideKeys    []ui.KeyPath // Values paths of the chart, to complete overrides
+	overrideMatches []ui.KeyPath // Completions of the override being typed
 	exportPath     string
+	manifestFormat string
+	manifestRef    gitops.ChartRef
+	uninstallRel   helm.Release
+	fixRel         helm.Release
+	markedReleases map[string]bool // Marked for a batch action, by namespace/name
+	fixAction      string // "rollback" or "unlock", for a release stuck in pending-*
+	upgradeRel     helm.Release
+	upgradeOpts    helm.DiffUpgradeOptions
+	upgradeDryRun  bool // The upgrade questions end in a server-side dry run instead of helm diff
+	helmfile        *gitops.Helmfile
+	helmfilePath    string                  // Last helmfile opened, offered again next time
+	helmfileRelease gitops.HelmfileRelease // Release the helmfile action prompt is about
+	registries      []helm.Registry
+	registryStatus  map[string]error // Outcome of authenticating to each registry, by host; missing while checking
+	registryHost    string           // Registry being logged in to or out of
+	registryUser    string
+	importReposPath string // File the repository import prompt is about
+	uninstallOpts  helm.UninstallOptions
+	test           *releaseTest
+	logs           *podLogs
+	logTargets     []podLogTarget // Pod containers offered by the pod picker
 	newRepoName    string
 	newRepoURL     string
+	newRepoOpts    helm.RepositoryOptions
+	renameRepoFrom string
 	addRepoStep    int
+	newGitSource   helm.GitSource // Git source being added, when the URL is a git repository
 	editedContent  string // Content from external editor
 	editTempFile   string // Temp file path for editing
+	editSchemaErrors []string // Schema violations of the edited values
+	lastAction     *repeatableAction
+	editSetting    config.Setting // Setting being edited on the Settings screen
+	recent         []config.RecentItem // Charts and releases viewed recently, most recent first
+	favorites      []config.Favorite   // Starred charts and releases
+	pendingOpen    *config.RecentItem  // Recent or favorite item opened once the screens leading to it load
+	gotoMatches    []gotoTarget
+	pendingG       bool // First g of a gg in a viewer
+	diffReturn     navigationState // Screen esc returns to from the diff viewer, when not the default
+	valuesDiff     *valuesDiff     // The documents of a values diff, nil for other diffs
+	diffBase       *chartVersionRef // Version pinned to be diffed with a version of another chart
+	diffUnified    string          // The diff being viewed as a plain unified diff, for exports
+	releaseReturn  navigationState // Screen esc returns to from release notes or drift
+	repoGrep       *valuesGrep     // Values search narrowing the chart list, nil when none
+	releaseGrep    *valuesGrep     // Values search narrowing the release list, nil when none
+
+	// Tabs, each with its own navigation. tabs holds the state of the other
+	// tabs while they're in the background; the active one is m itself.
+	tabs      []model
+	activeTab int
+}
+
+// valuesGrep is a search of the values of every chart of a repository, or
+// of every release
+type valuesGrep struct {
+	query   string
+	matches map[string]string // Chart name or release key → first hit, e.g. "image.registry: docker.io"
+	failed  int               // Charts or releases whose values couldn't be fetched
+}
+
+// gotoTarget is something the goto prompt can jump to
+type gotoTarget struct {
+	label     string // What the prompt matches against, e.g. "chart bitnami/nginx"
+	repo      int    // Index in repos, for repositories and charts
+	chart     *helm.Chart
+	charts    []helm.Chart // Charts of the target's repository
+	release   int          // Index in releases, -1 if not a release
+	namespace string       // Set for namespaces
+}
+
+// repeatableAction is the last non-destructive action, replayed with '.'
+type repeatableAction struct {
+	label   string
+	prepare func(m *model) // Optional UI setup before the command runs again
+	cmd     tea.Cmd
+}
+
+type repoGrepDoneMsg struct {
+	repo string
+	gen  int // Load generation the grep was started in
+	grep *valuesGrep
+	err  error
+}
+
+type releaseGrepDoneMsg struct {
+	releases []helm.Release // The releases with a match
+	gen      int            // Load generation the grep was started in
+	grep     *valuesGrep
+	err      error
 }
 
 type chartCacheEntry struct {
@@ -268,14 +564,70 @@ type keyMap struct {
 	AddRepo     key.Binding
 	Export      key.Binding
 	Template    key.Binding
+	NextResource key.Binding
+	PrevResource key.Binding
 	Versions    key.Binding
 	Copy        key.Binding
+	CopyPair    key.Binding
+	CopyAltPath key.Binding
+	CopyValue   key.Binding
+	CopySetFlag key.Binding
+	LineNumbers key.Binding
+	SearchCase  key.Binding
+	ValuesGrep  key.Binding
+	NewTab      key.Binding
+	CloseTab    key.Binding
+	NextTab     key.Binding
+	PrevTab     key.Binding
+	GotoTab     key.Binding
+	SearchWord  key.Binding
 	Diff        key.Binding
 	Edit        key.Binding
 	ArtifactHub key.Binding
 	RemoveRepo  key.Binding
+	RenameRepo  key.Binding
+	UndoRemove  key.Binding
 	UpdateRepo  key.Binding
+	RepoInfo    key.Binding
+	Deprecated  key.Binding
 	ClearFilter key.Binding
+	Repeat      key.Binding
+	Manifest    key.Binding
+	Open        key.Binding
+	Goto        key.Binding
+	Validate    key.Binding
+	Selector    key.Binding
+	Uninstall   key.Binding
+	Test        key.Binding
+	Fix         key.Binding
+	Security    key.Binding
+	Filters     key.Binding
+	Sort        key.Binding
+	Publisher   key.Binding
+	JumpKey     key.Binding
+	Outline     key.Binding
+	DiffBase    key.Binding
+	PathDiff    key.Binding
+	Mark        key.Binding
+	Favorite    key.Binding
+	Batch       key.Binding
+	Readme      key.Binding
+	Files       key.Binding
+	Schema      key.Binding
+	Notes       key.Binding
+	Drift       key.Binding
+	Events      key.Binding
+	Refresh     key.Binding
+	Logs        key.Binding
+	Follow      key.Binding
+	ShowOnly    key.Binding
+	Preview     key.Binding
+	DryRun      key.Binding
+	Environment key.Binding
+	Logout      key.Binding
+	ImportRepos key.Binding
+	MoveUp      key.Binding
+	MoveDown    key.Binding
 }
 
 func (k keyMap) ShortHelp() []key.Binding {
@@ -348,6 +700,14 @@ var defaultKeys = keyMap{
 		key.WithKeys("t"),
 		key.WithHelp("t", "template"),
 	),
+	NextResource: key.NewBinding(
+		key.WithKeys("]"),
+		key.WithHelp("]", "next resource"),
+	),
+	PrevResource: key.NewBinding(
+		key.WithKeys("["),
+		key.WithHelp("[", "previous resource"),
+	),
 	Versions: key.NewBinding(
 		key.WithKeys("v"),
 		key.WithHelp("v", "view versions"),
@@ -356,6 +716,58 @@ var defaultKeys = keyMap{
 		key.WithKeys("y"),
 		key.WithHelp("y", "copy yaml path"),
 	),
+	CopyPair: key.NewBinding(
+		key.WithKeys("Y"),
+		key.WithHelp("Y", "copy path with value"),
+	),
+	CopyAltPath: key.NewBinding(
+		key.WithKeys("ctrl+y"),
+		key.WithHelp("ctrl+y", "copy path in the other format"),
+	),
+	CopyValue: key.NewBinding(
+		key.WithKeys("alt+y"),
+		key.WithHelp("alt+y", "copy value or nested block"),
+	),
+	NewTab: key.NewBinding(
+		key.WithKeys("ctrl+t"),
+		key.WithHelp("ctrl+t", "new tab"),
+	),
+	CloseTab: key.NewBinding(
+		key.WithKeys("ctrl+w"),
+		key.WithHelp("ctrl+w", "close tab"),
+	),
+	NextTab: key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "next tab"),
+	),
+	PrevTab: key.NewBinding(
+		key.WithKeys("shift+tab"),
+		key.WithHelp("shift+tab", "previous tab"),
+	),
+	GotoTab: key.NewBinding(
+		key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"),
+		key.WithHelp("1-9", "go to tab"),
+	),
+	ValuesGrep: key.NewBinding(
+		key.WithKeys("A"),
+		key.WithHelp("A", "search values of all charts or releases"),
+	),
+	SearchCase: key.NewBinding(
+		key.WithKeys("alt+c"),
+		key.WithHelp("alt+c", "toggle case-sensitive search"),
+	),
+	SearchWord: key.NewBinding(
+		key.WithKeys("alt+w"),
+		key.WithHelp("alt+w", "toggle whole-word search"),
+	),
+	LineNumbers: key.NewBinding(
+		key.WithKeys("#"),
+		key.WithHelp("#", "toggle line numbers"),
+	),
+	CopySetFlag: key.NewBinding(
+		key.WithKeys("alt+s"),
+		key.WithHelp("alt+s", "copy as --set flag"),
+	),
 	Diff: key.NewBinding(
 		key.WithKeys("d"),
 		key.WithHelp("d", "diff versions"),
@@ -372,34 +784,285 @@ var defaultKeys = keyMap{
 		key.WithKeys("r"),
 		key.WithHelp("r", "remove repository"),
 	),
+	RenameRepo: key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "rename repository"),
+	),
+	Readme: key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "chart README"),
+	),
+	Files: key.NewBinding(
+		key.WithKeys("f"),
+		key.WithHelp("f", "browse chart files"),
+	),
+	Schema: key.NewBinding(
+		key.WithKeys("S"),
+		key.WithHelp("S", "values schema"),
+	),
+	Notes: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "release notes"),
+	),
+	Drift: key.NewBinding(
+		key.WithKeys("D"),
+		key.WithHelp("D", "detect drift"),
+	),
+	Events: key.NewBinding(
+		key.WithKeys("E"),
+		key.WithHelp("E", "release events"),
+	),
+	Refresh: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "refresh resources"),
+	),
+	Logs: key.NewBinding(
+		key.WithKeys("L"),
+		key.WithHelp("L", "pod logs"),
+	),
+	Follow: key.NewBinding(
+		key.WithKeys("f"),
+		key.WithHelp("f", "follow/pause logs"),
+	),
+	ShowOnly: key.NewBinding(
+		key.WithKeys("f"),
+		key.WithHelp("f", "show only one template"),
+	),
+	Preview: key.NewBinding(
+		key.WithKeys("P"),
+		key.WithHelp("P", "preview upgrade"),
+	),
+	DryRun: key.NewBinding(
+		key.WithKeys("I"),
+		key.WithHelp("I", "dry run on the cluster"),
+	),
+	Environment: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "switch environment"),
+	),
+	Logout: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "log out"),
+	),
+	ImportRepos: key.NewBinding(
+		key.WithKeys("I"),
+		key.WithHelp("I", "import repositories"),
+	),
+	MoveUp: key.NewBinding(
+		key.WithKeys("shift+up", "<"),
+		key.WithHelp("shift+↑/<", "move up"),
+	),
+	MoveDown: key.NewBinding(
+		key.WithKeys("shift+down", ">"),
+		key.WithHelp("shift+↓/>", "move down"),
+	),
+	UndoRemove: key.NewBinding(
+		key.WithKeys("U"),
+		key.WithHelp("U", "undo repository removal"),
+	),
 	UpdateRepo: key.NewBinding(
 		key.WithKeys("u"),
 		key.WithHelp("u", "update repository"),
 	),
+	RepoInfo: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "repository info"),
+	),
+	Deprecated: key.NewBinding(
+		key.WithKeys("D"),
+		key.WithHelp("D", "hide/show deprecated charts"),
+	),
 	ClearFilter: key.NewBinding(
 		key.WithKeys("c"),
 		key.WithHelp("c", "clear filter"),
 	),
+	Repeat: key.NewBinding(
+		key.WithKeys("."),
+		key.WithHelp(".", "repeat last action"),
+	),
+	Manifest: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "generate gitops manifest"),
+	),
+	Open: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "open in browser"),
+	),
+	Goto: key.NewBinding(
+		key.WithKeys("g"),
+		key.WithHelp("g", "go to"),
+	),
+	Validate: key.NewBinding(
+		key.WithKeys("V"),
+		key.WithHelp("V", "validate values file"),
+	),
+	Selector: key.NewBinding(
+		key.WithKeys("l"),
+		key.WithHelp("l", "label selector"),
+	),
+	Uninstall: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "uninstall release"),
+	),
+	Test: key.NewBinding(
+		key.WithKeys("T"),
+		key.WithHelp("T", "run helm test"),
+	),
+	Fix: key.NewBinding(
+		key.WithKeys("F"),
+		key.WithHelp("F", "fix stuck release"),
+	),
+	Security: key.NewBinding(
+		key.WithKeys("C"),
+		key.WithHelp("C", "security report (CVEs)"),
+	),
+	Filters: key.NewBinding(
+		key.WithKeys("F"),
+		key.WithHelp("F", "filter search results"),
+	),
+	Sort: key.NewBinding(
+		key.WithKeys("O"),
+		key.WithHelp("O", "cycle sort order"),
+	),
+	Publisher: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "packages of the same publisher"),
+	),
+	DiffBase: key.NewBinding(
+		key.WithKeys("B"),
+		key.WithHelp("B", "diff with a version of another chart"),
+	),
+	JumpKey: key.NewBinding(
+		key.WithKeys("ctrl+g"),
+		key.WithHelp("ctrl+g", "jump to a key"),
+	),
+	Outline: key.NewBinding(
+		key.WithKeys("z"),
+		key.WithHelp("z", "outline with foldable keys"),
+	),
+	PathDiff: key.NewBinding(
+		key.WithKeys("K"),
+		key.WithHelp("K", "diff by YAML path"),
+	),
+	Mark: key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("space", "mark release"),
+	),
+	Favorite: key.NewBinding(
+		key.WithKeys("*"),
+		key.WithHelp("*", "star/unstar"),
+	),
+	Batch: key.NewBinding(
+		key.WithKeys("b"),
+		key.WithHelp("b", "batch action on marked releases"),
+	),
 }
 
 type chartsLoadedMsg struct {
+	repo   string
 	charts []helm.Chart
 	err    error
 }
 
 type valuesLoadedMsg struct {
-	values string
-	err    error
+	chart   string
+	version string // Empty for the latest version
+	values  string
+	err     error
 }
 
 type versionsLoadedMsg struct {
+	chart    string
 	versions []helm.ChartVersion
 	err      error
 }
 
+type versionDiffLoadedMsg struct {
+	label1   string
+	label2   string
+	values1  string
+	values2  string
+	lines    []ui.DiffLine
+	err      error
+}
+
+// revisionDiffLoadedMsg carries the values, or manifests, of two revisions
+// of a release to compare
+type revisionDiffLoadedMsg struct {
+	label1    string
+	label2    string
+	doc1      string
+	doc2      string
+	manifests bool
+	err       error
+}
+
+// releaseOverridesLoadedMsg carries the chart defaults a release's values are
+// compared with to show its overrides
+type releaseOverridesLoadedMsg struct {
+	chart    string
+	label    string
+	defaults string
+	values   string
+	err      error
+}
+
+// valuesDiff is a diff of two values documents, which the diff viewer can
+// show line by line or by YAML path
+type valuesDiff struct {
+	old, new       string
+	label1, label2 string
+	lineDiff       string // Rendered line diff, restored when toggling back
+	byPath         bool
+}
+
+// actionLogEntry is an operation recorded in the session action log
+type actionLogEntry struct {
+	time    time.Time
+	message string
+	failed  bool
+}
+
 type operationDoneMsg struct {
-	success string
-	err     error
+	success    string
+	err        error
+	background bool            // Started through background()
+	origin     navigationState // Screen the operation was started from
+	refresh    bool            // Reload the release list on success
+}
+
+// backgroundDoneMsg wraps any other result of a background() command, e.g. a
+// repeated search, so the operation stops counting as running
+type backgroundDoneMsg struct {
+	msg tea.Msg
+}
+
+// clusterLoadedMsg wraps the result of an inCluster() load with the cluster
+// generation it was started in
+type clusterLoadedMsg struct {
+	gen int
+	msg tea.Msg
+}
+
+// repoRefreshedMsg reports a repository index updated in the background
+// because it was older than repo_refresh
+type repoRefreshedMsg struct {
+	name string
+	err  error
+}
+
+type reposImportedMsg struct {
+	result *helm.RepositoryImport
+	repos  []helm.Repository
+	err    error
+}
+
+// batchUninstalledMsg reports the outcome of uninstalling each marked
+// release; errs holds the error of each failed release
+type batchUninstalledMsg struct {
+	uninstalled []helm.Release
+	failed      []helm.Release
+	errs        []error
 }
 
 type reposReloadedMsg struct {
@@ -410,9 +1073,62 @@ type reposReloadedMsg struct {
 type repoRemovedMsg struct {
 	repos    []helm.Repository
 	repoName string
+	removed  *helm.RemovedRepository
+	err      error
+}
+
+type repoRestoredMsg struct {
+	repos    []helm.Repository
+	repoName string
+	err      error
+}
+
+type repoRenamedMsg struct {
+	repos   []helm.Repository
+	oldName string
+	newName string
+	err     error
+}
+
+type valuesValidatedMsg struct {
+	path   string
+	result *helm.ValuesValidation
+	err    error
+}
+
+type chartInfoLoadedMsg struct {
+	info *helm.ChartInfo
+	err  error
+}
+
+type readmeLoadedMsg struct {
+	readme string
+	err    error
+}
+
+type chartFilesLoadedMsg struct {
+	files []helm.ChartFile
+	err   error
+}
+
+type valuesSchemaLoadedMsg struct {
+	props []helm.SchemaProperty
+	err   error
+}
+
+type editedValuesValidatedMsg struct {
+	content  string
+	filePath string
+	result   *helm.ValuesValidation
 	err      error
 }
 
+type repoInfoLoadedMsg struct {
+	info    *helm.RepositoryInfo
+	success string
+	err     error
+}
+
 type editorFinishedMsg struct {
 	content  string
 	filePath string
@@ -420,8 +1136,12 @@ type editorFinishedMsg struct {
 }
 
 type releasesLoadedMsg struct {
-	releases []helm.Release
-	err      error
+	releases  []helm.Release
+	namespace string
+	selector  string
+	offset    int
+	seq       int // Load the page belongs to; pages of abandoned loads are dropped
+	err       error
 }
 
 type namespacesLoadedMsg struct {
@@ -439,43 +1159,189 @@ type releaseValuesLoadedMsg struct {
 	err    error
 }
 
+type releaseNotesLoadedMsg struct {
+	notes string
+	err   error
+}
+
+type releaseEventsLoadedMsg struct {
+	release   string
+	namespace string
+	events    []helm.ReleaseEvent
+	err       error
+}
+
+type releaseDriftLoadedMsg struct {
+	release   string
+	namespace string
+	drift     *helm.ReleaseDrift
+	err       error
+}
+
+type upgradePreviewMsg struct {
+	release helm.Release
+	opts    helm.DiffUpgradeOptions
+	output  string
+	warning string // Set when the chart's kubeVersion excludes the cluster
+	origin  navigationState
+	err     error
+}
+
+type dryRunMsg struct {
+	release helm.Release
+	opts    helm.DryRunOptions
+	result  *helm.DryRunResult
+	warning string // Set when the chart's kubeVersion excludes the cluster
+	origin  navigationState
+	err     error
+}
+
+type registriesLoadedMsg struct {
+	registries []helm.Registry
+	err        error
+}
+
+type registryCheckedMsg struct {
+	host string
+	err  error
+}
+
+// registryChangedMsg reports a registry login or logout
+type registryChangedMsg struct {
+	message string
+	err     error
+}
+
+type helmfileLoadedMsg struct {
+	helmfile *gitops.Helmfile
+	err      error
+}
+
 type releaseStatusLoadedMsg struct {
 	status *helm.ReleaseStatus
 	err    error
 }
 
+type releaseResourcesLoadedMsg struct {
+	release   string
+	resources []helm.ReleaseResource
+	err       error
+}
+
 type kubeContextLoadedMsg struct {
 	context string
 	err     error
 }
 
+type kubeContextsLoadedMsg struct {
+	contexts []helm.KubeContext
+	err      error
+}
+
+// releaseTest is a helm test run and the output it has printed so far
+type releaseTest struct {
+	release  helm.Release
+	lines    []string
+	running  bool
+	err      error
+	returnTo navigationState
+}
+
+type releaseTestOutputMsg struct {
+	test  *releaseTest
+	line  string
+	lines <-chan string
+	done  <-chan error
+}
+
+type releaseTestDoneMsg struct {
+	test *releaseTest
+	err  error
+}
+
+// podLogTarget is a container of a release pod whose logs can be followed
+type podLogTarget struct {
+	title     string // Pod name, with the container for multi-container pods
+	namespace string
+	pod       string
+	container string
+	status    string
+}
+
+// podLogs is a pod log stream and the lines received so far
+type podLogs struct {
+	target    podLogTarget
+	lines     []string
+	following bool // Keep the view at the end; when paused the view is frozen
+	shown     int  // Lines in the view, behind len(lines) while paused
+	running   bool
+	err       error
+	cancel    context.CancelFunc
+	returnTo  navigationState
+}
+
+type podLogsOutputMsg struct {
+	logs  *podLogs
+	batch []string
+	lines <-chan string
+	done  <-chan error
+}
+
+type podLogsDoneMsg struct {
+	logs *podLogs
+	err  error
+}
+
+type clusterVersionLoadedMsg struct {
+	version string
+	err     error
+}
+
 type artifactHubSearchMsg struct {
 	packages []artifacthub.Package
+	total    int
+	query    string
+	opts     artifacthub.SearchOptions
+	offset   int // 0 for a new search, else a further page of query
 	err      error
 }
 
+// artifactHubRateLimitedMsg reports an Artifact Hub request waiting to be
+// retried
+type artifactHubRateLimitedMsg struct {
+	wait time.Duration
+}
+
 type artifactHubPackageMsg struct {
 	pkg *artifacthub.Package
 	err error
 }
 
-type clearSuccessMsgMsg struct{}
+type artifactHubSecurityMsg struct {
+	vulns []artifacthub.Vulnerability
+	err   error
+}
+
+type clearSuccessMsgMsg struct {
+	seq int
+}
 
 type listItem struct {
 	title       string
 	description string
+	namespace   string // Of a release, whose name is only unique within it
 }
 
 func (i listItem) Title() string       { return i.title }
 func (i listItem) Description() string { return i.description }
 func (i listItem) FilterValue() string { return i.title }
 
-func loadCharts(client *helm.Client, chartCache map[string]chartCacheEntry, repoName string) tea.Cmd {
+func loadCharts(client *helm.Client, chartCache map[string]chartCacheEntry, ttl time.Duration, repoName string) tea.Cmd {
 	return func() tea.Msg {
-		// Check cache first (30 minute TTL)
+		// Check cache first
 		if entry, exists := chartCache[repoName]; exists {
-			if time.Since(entry.timestamp) < 30*time.Minute {
-				return chartsLoadedMsg{charts: entry.charts, err: nil}
+			if time.Since(entry.timestamp) < ttl {
+				return chartsLoadedMsg{repo: repoName, charts: entry.charts, err: nil}
 			}
 		}
 
@@ -486,2406 +1352,8024 @@ func loadCharts(client *helm.Client, chartCache map[string]chartCacheEntry, repo
 				timestamp: time.Now(),
 			}
 		}
-		return chartsLoadedMsg{charts: charts, err: err}
+		return chartsLoadedMsg{repo: repoName, charts: charts, err: err}
 	}
 }
 
 func loadValues(client *helm.Client, cache *helm.Cache, chartName string) tea.Cmd {
 	return func() tea.Msg {
 		if cached, found := cache.Get(chartName, ""); found {
-			return valuesLoadedMsg{values: cached, err: nil}
+			return valuesLoadedMsg{chart: chartName, values: cached, err: nil}
 		}
 
 		values, err := client.GetChartValues(chartName)
 		if err == nil {
 			cache.Set(chartName, "", values)
 		}
-		return valuesLoadedMsg{values: values, err: err}
+		return valuesLoadedMsg{chart: chartName, values: values, err: err}
 	}
 }
 
 func loadValuesByVersion(client *helm.Client, cache *helm.Cache, chartName, version string) tea.Cmd {
 	return func() tea.Msg {
 		if cached, found := cache.Get(chartName, version); found {
-			return valuesLoadedMsg{values: cached, err: nil}
+			return valuesLoadedMsg{chart: chartName, version: version, values: cached, err: nil}
 		}
 
 		values, err := client.GetChartValuesByVersion(chartName, version)
 		if err == nil {
 			cache.Set(chartName, version, values)
 		}
-		return valuesLoadedMsg{values: values, err: err}
+		return valuesLoadedMsg{chart: chartName, version: version, values: values, err: err}
 	}
 }
 
-func loadVersions(client *helm.Client, versionCache map[string]versionCacheEntry, chartName string) tea.Cmd {
-	return func() tea.Msg {
-		// Check cache first (30 minute TTL)
-		if entry, exists := versionCache[chartName]; exists {
-			if time.Since(entry.timestamp) < 30*time.Minute {
-				return versionsLoadedMsg{versions: entry.versions, err: nil}
+// grepWorkers is how many values documents grepValues fetches at once
+const grepWorkers = 8
+
+// grepValues fetches the values of each key, a few at a time, and looks
+// query up in them. It stops with ctx.Err() once ctx is cancelled.
+func grepValues(ctx context.Context, keys []string, fetch func(key string) (string, error), query string) (*valuesGrep, error) {
+	grep := &valuesGrep{query: query, matches: make(map[string]string)}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	queue := make(chan string)
+	for range grepWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range queue {
+				values, err := fetch(key)
+				mu.Lock()
+				if err != nil {
+					grep.failed++
+				} else if hit, ok := ui.FindInValues(values, query); ok {
+					grep.matches[key] = hit
+				}
+				mu.Unlock()
 			}
-		}
+		}()
+	}
 
-		versions, err := client.GetChartVersions(chartName)
-		if err == nil && len(versions) > 0 {
-			versionCache[chartName] = versionCacheEntry{
-				versions:  versions,
-				timestamp: time.Now(),
-			}
+feed:
+	for _, key := range keys {
+		select {
+		case queue <- key:
+		case <-ctx.Done():
+			break feed
 		}
-		return versionsLoadedMsg{versions: versions, err: err}
 	}
-}
-
-func loadReleases(client *helm.Client, namespace string) tea.Cmd {
-	return func() tea.Msg {
-		releases, err := client.ListReleases(namespace)
-		return releasesLoadedMsg{releases: releases, err: err}
+	close(queue)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
+	return grep, nil
 }
 
-func loadNamespaces(client *helm.Client) tea.Cmd {
+// grepRepoValues looks query up in the latest values of every chart of a
+// repository, fetched through the cache
+func grepRepoValues(ctx context.Context, client *helm.Client, cache *helm.Cache, repoName string, charts []helm.Chart, query string, gen int) tea.Cmd {
 	return func() tea.Msg {
-		namespaces, err := client.ListNamespaces()
-		return namespacesLoadedMsg{namespaces: namespaces, err: err}
+		names := make([]string, len(charts))
+		for i, chart := range charts {
+			names[i] = chart.Name
+		}
+		grep, err := grepValues(ctx, names, func(name string) (string, error) {
+			if cached, found := cache.Get(name, ""); found {
+				return cached, nil
+			}
+			values, err := client.GetChartValues(name)
+			if err == nil {
+				cache.Set(name, "", values)
+			}
+			return values, err
+		}, query)
+		return repoGrepDoneMsg{repo: repoName, gen: gen, grep: grep, err: err}
 	}
 }
 
-func loadReleaseHistory(client *helm.Client, releaseName, namespace string) tea.Cmd {
+// grepReleaseValues looks query up in the user-supplied values of every
+// release of a namespace, or of all namespaces when namespace is empty
+func grepReleaseValues(ctx context.Context, client *helm.Client, namespace, query string, gen int) tea.Cmd {
 	return func() tea.Msg {
-		history, err := client.GetReleaseHistory(releaseName, namespace)
-		return releaseHistoryLoadedMsg{history: history, err: err}
+		releases, err := client.ListReleases(ctx, namespace)
+		if err != nil {
+			return releaseGrepDoneMsg{gen: gen, err: err}
+		}
+		keys := make([]string, len(releases))
+		byKey := make(map[string]helm.Release, len(releases))
+		for i, release := range releases {
+			keys[i] = releaseKey(release)
+			byKey[keys[i]] = release
+		}
+		grep, err := grepValues(ctx, keys, func(key string) (string, error) {
+			return client.GetReleaseValues(ctx, byKey[key].Name, byKey[key].Namespace)
+		}, query)
+		if err != nil {
+			return releaseGrepDoneMsg{gen: gen, err: err}
+		}
+		var matched []helm.Release
+		for _, release := range releases {
+			if _, ok := grep.matches[releaseKey(release)]; ok {
+				matched = append(matched, release)
+			}
+		}
+		return releaseGrepDoneMsg{releases: matched, gen: gen, grep: grep}
 	}
 }
 
-func loadReleaseValues(client *helm.Client, releaseName, namespace string) tea.Cmd {
-	return func() tea.Msg {
-		values, err := client.GetReleaseValues(releaseName, namespace)
-		return releaseValuesLoadedMsg{values: values, err: err}
-	}
+// chartVersionRef is a version of a chart of a local repository
+type chartVersionRef struct {
+	chart   string // e.g. bitnami/nginx
+	version string
 }
 
-func loadReleaseStatus(client *helm.Client, releaseName, namespace string) tea.Cmd {
+// loadVersionDiff fetches the values of two chart versions, through the
+// cache, and diffs them. The versions may be of different charts; labels
+// name the versions in the diff.
+func loadVersionDiff(client *helm.Client, cache *helm.Cache, refs [2]chartVersionRef, labels [2]string) tea.Cmd {
 	return func() tea.Msg {
-		status, err := client.GetReleaseStatus(releaseName, namespace)
-		return releaseStatusLoadedMsg{status: status, err: err}
+		values := make([]string, 2)
+		for i, ref := range refs {
+			cached, found := cache.Get(ref.chart, ref.version)
+			if !found {
+				v, err := client.GetChartValuesByVersion(ref.chart, ref.version)
+				if err != nil {
+					return versionDiffLoadedMsg{label1: labels[0], label2: labels[1], err: err}
+				}
+				cached = v
+				cache.Set(ref.chart, ref.version, cached)
+			}
+			values[i] = cached
+		}
+		return versionDiffLoadedMsg{label1: labels[0], label2: labels[1], values1: values[0], values2: values[1], lines: ui.DiffYAML(values[0], values[1])}
 	}
 }
 
-func addRepository(client *helm.Client, name, url string) tea.Cmd {
+// loadRevisionDiff fetches the values, or with manifests the manifests, of
+// two revisions of a release
+func loadRevisionDiff(ctx context.Context, client *helm.Client, release helm.Release, revision1, revision2 int, manifests bool) tea.Cmd {
 	return func() tea.Msg {
-		err := client.AddRepository(name, url)
-		if err != nil {
-			return operationDoneMsg{err: err}
+		get, kind := client.GetReleaseValuesByRevision, ""
+		if manifests {
+			get, kind = client.GetReleaseManifest, " manifest"
 		}
-
-		repos, repoErr := client.ListRepositories()
-		if repoErr != nil {
-			return operationDoneMsg{success: fmt.Sprintf("Repository '%s' added, but failed to reload list", name)}
+		msg := revisionDiffLoadedMsg{
+			label1:    fmt.Sprintf("Revision %d%s", revision1, kind),
+			label2:    fmt.Sprintf("Revision %d%s", revision2, kind),
+			manifests: manifests,
 		}
-
-		return reposReloadedMsg{repos: repos}
+		if msg.doc1, msg.err = get(ctx, release.Name, release.Namespace, revision1); msg.err != nil {
+			return msg
+		}
+		msg.doc2, msg.err = get(ctx, release.Name, release.Namespace, revision2)
+		return msg
 	}
 }
 
-func exportValues(client *helm.Client, chartName, outputFile string) tea.Cmd {
+// loadReleaseOverrides fetches the defaults of the chart version a release
+// runs, to diff values, the release values of revision, against
+func loadReleaseOverrides(ctx context.Context, client *helm.Client, release helm.Release, revision int, values string) tea.Cmd {
 	return func() tea.Msg {
-		err := client.ExportValues(chartName, outputFile)
-		if err != nil {
-			return operationDoneMsg{err: err}
+		label := "release values"
+		if revision > 0 {
+			label = fmt.Sprintf("revision %d values", revision)
 		}
-		return operationDoneMsg{success: fmt.Sprintf("Values exported to %s", outputFile)}
+		defaults, err := client.GetReleaseChartValues(ctx, release.Name, release.Namespace, revision)
+		return releaseOverridesLoadedMsg{chart: release.Chart, label: label, defaults: defaults, values: values, err: err}
 	}
 }
 
-func generateTemplate(client *helm.Client, chartName, valuesFile, outputPath string) tea.Cmd {
+// startReleaseTest runs helm test in the background; its output arrives as
+// releaseTestOutputMsg, one line at a time, followed by releaseTestDoneMsg
+func startReleaseTest(client *helm.Client, test *releaseTest, timeout time.Duration) tea.Cmd {
+	lines := make(chan string, 64)
+	done := make(chan error, 1)
+	go func() {
+		done <- client.TestRelease(test.release.Name, test.release.Namespace, timeout, lines)
+	}()
+	return waitForTestOutput(test, lines, done)
+}
+
+func waitForTestOutput(test *releaseTest, lines <-chan string, done <-chan error) tea.Cmd {
 	return func() tea.Msg {
-		err := client.GenerateTemplate(chartName, valuesFile, outputPath)
-		if err != nil {
-			return operationDoneMsg{err: err}
+		line, ok := <-lines
+		if !ok {
+			return releaseTestDoneMsg{test: test, err: <-done}
 		}
-		return operationDoneMsg{success: fmt.Sprintf("Template generated in %s", outputPath)}
+		return releaseTestOutputMsg{test: test, line: line, lines: lines, done: done}
 	}
 }
 
-func searchArtifactHub(client *artifacthub.Client, query string) tea.Cmd {
+// startPodLogs follows the logs of a pod container in the background; they
+// arrive as podLogsOutputMsg, followed by podLogsDoneMsg when the log ends or
+// the stream is cancelled
+func startPodLogs(client *helm.Client, logs *podLogs) tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	logs.cancel = cancel
+	lines := make(chan string, 256)
+	done := make(chan error, 1)
+	go func() {
+		done <- client.StreamPodLogs(ctx, logs.target.namespace, logs.target.pod, logs.target.container, lines)
+	}()
+	return waitForPodLogs(logs, lines, done)
+}
+
+// waitForPodLogs waits for the next log line and takes every line already
+// buffered with it, so a burst of logs is rendered once
+func waitForPodLogs(logs *podLogs, lines <-chan string, done <-chan error) tea.Cmd {
 	return func() tea.Msg {
-		packages, err := client.SearchPackages(query, 50)
-		if err != nil {
-			return artifactHubSearchMsg{err: err}
+		line, ok := <-lines
+		if !ok {
+			return podLogsDoneMsg{logs: logs, err: <-done}
+		}
+		batch := []string{line}
+		for len(batch) < cap(lines) {
+			select {
+			case line, ok := <-lines:
+				if !ok {
+					return podLogsOutputMsg{logs: logs, batch: batch, lines: lines, done: done}
+				}
+				batch = append(batch, line)
+			default:
+				return podLogsOutputMsg{logs: logs, batch: batch, lines: lines, done: done}
+			}
 		}
-		return artifactHubSearchMsg{packages: packages}
+		return podLogsOutputMsg{logs: logs, batch: batch, lines: lines, done: done}
 	}
 }
 
-func loadArtifactHubPackage(client *artifacthub.Client, repoName, packageName string) tea.Cmd {
+func loadClusterVersion(ctx context.Context, client *helm.Client) tea.Cmd {
 	return func() tea.Msg {
-		pkg, err := client.GetPackageDetails(repoName, packageName)
-		if err != nil {
-			return artifactHubPackageMsg{err: err}
-		}
-		return artifactHubPackageMsg{pkg: pkg}
+		version, err := client.GetClusterVersion(ctx)
+		return clusterVersionLoadedMsg{version: version, err: err}
 	}
 }
 
-func clearSuccessMsgAfter(d time.Duration) tea.Cmd {
-	return tea.Tick(d, func(t time.Time) tea.Msg {
-		return clearSuccessMsgMsg{}
-	})
+func loadVersions(client *helm.Client, versionCache map[string]versionCacheEntry, ttl time.Duration, chartName string) tea.Cmd {
+	return func() tea.Msg {
+		// Check cache first
+		if entry, exists := versionCache[chartName]; exists {
+			if time.Since(entry.timestamp) < ttl {
+				return versionsLoadedMsg{chart: chartName, versions: entry.versions, err: nil}
+			}
+		}
+
+		versions, err := client.GetChartVersions(chartName)
+		if err == nil && len(versions) > 0 {
+			versionCache[chartName] = versionCacheEntry{
+				versions:  versions,
+				timestamp: time.Now(),
+			}
+		}
+		return versionsLoadedMsg{chart: chartName, versions: versions, err: err}
+	}
 }
 
-// Helper to set success message and auto-clear after 3 seconds
-func (m *model) setSuccessMsg(msg string) tea.Cmd {
-	m.successMsg = msg
-	return clearSuccessMsgAfter(3 * time.Second)
+func loadChartInfo(client *helm.Client, chartName, version string) tea.Cmd {
+	return func() tea.Msg {
+		info, err := client.GetChartInfo(chartName, version)
+		return chartInfoLoadedMsg{info: info, err: err}
+	}
 }
 
-func initialModel() model {
-	client := helm.NewClient()
-	cache := helm.NewCache(30 * time.Minute)
-	repos, err := client.ListRepositories()
+func loadReadme(client *helm.Client, chartName, version string) tea.Cmd {
+	return func() tea.Msg {
+		readme, err := client.GetChartReadme(chartName, version)
+		return readmeLoadedMsg{readme: readme, err: err}
+	}
+}
 
-	repoItems := make([]list.Item, len(repos))
-	for i, repo := range repos {
-		repoItems[i] = listItem{
-			title:       repo.Name,
-			description: repo.URL,
+func loadArtifactHubSecurity(client *artifacthub.Client, pkg *artifacthub.Package) tea.Cmd {
+	return func() tea.Msg {
+		vulns, err := client.GetSecurityReport(pkg.PackageID, pkg.Version)
+		return artifactHubSecurityMsg{vulns: vulns, err: err}
+	}
+}
+
+// loadArtifactHubSchema reads the values schema of an Artifact Hub package,
+// fetching it when the package details don't include it
+func loadArtifactHubSchema(client *artifacthub.Client, pkg *artifacthub.Package) tea.Cmd {
+	return func() tea.Msg {
+		var data []byte
+		var err error
+		if pkg.ValuesSchema != nil {
+			data, err = json.Marshal(pkg.ValuesSchema)
+		} else {
+			data, err = client.GetValuesSchema(pkg.PackageID, pkg.Version)
+		}
+		if err != nil || len(data) == 0 {
+			return valuesSchemaLoadedMsg{err: err}
 		}
+		props, err := helm.ParseValuesSchema(data)
+		return valuesSchemaLoadedMsg{props: props, err: err}
 	}
+}
 
-	// Create custom delegate with fzf-like colors (background for selected items)
-	delegate := list.NewDefaultDelegate()
-	delegate.Styles.SelectedTitle = delegate.Styles.SelectedTitle.
-		Foreground(lipgloss.Color("0")).    // Nero/Bianco (adaptive)
-		Background(lipgloss.Color("141")).  // Violet - stile fzf
-		Bold(true)
-	delegate.Styles.SelectedDesc = delegate.Styles.SelectedDesc.
-		Foreground(lipgloss.Color("0")).    // Nero/Bianco
-		Background(lipgloss.Color("141"))   // Violet
-	delegate.Styles.NormalTitle = delegate.Styles.NormalTitle.
-		Foreground(lipgloss.AdaptiveColor{Light: "235", Dark: "255"})   // Grigio scuro su chiaro, bianco su scuro
-	delegate.Styles.NormalDesc = delegate.Styles.NormalDesc.
-		Foreground(lipgloss.AdaptiveColor{Light: "240", Dark: "250"})   // Grigio medio
+func loadChartFiles(client *helm.Client, chartName, version string) tea.Cmd {
+	return func() tea.Msg {
+		files, err := client.GetChartFiles(chartName, version)
+		return chartFilesLoadedMsg{files: files, err: err}
+	}
+}
 
-	repoList := list.New(repoItems, delegate, 0, 0)
-	repoList.Title = "Repositories"
-	repoList.SetShowStatusBar(false)
-	repoList.SetFilteringEnabled(true)
-	repoList.Styles.Title = titleStyle
-	repoList.Styles.FilterPrompt = searchInputStyle
-	repoList.Styles.FilterCursor = lipgloss.NewStyle().Foreground(lipgloss.Color("141"))
+func loadValuesSchema(client *helm.Client, chartName, version string) tea.Cmd {
+	return func() tea.Msg {
+		props, err := client.GetValuesSchema(chartName, version)
+		return valuesSchemaLoadedMsg{props: props, err: err}
+	}
+}
 
-	chartDelegate := list.NewDefaultDelegate()
-	chartDelegate.Styles = delegate.Styles
-	chartList := list.New([]list.Item{}, chartDelegate, 0, 0)
-	chartList.Title = "Charts"
-	chartList.SetShowStatusBar(false)
-	chartList.SetFilteringEnabled(true)
-	chartList.Styles.Title = titleStyle
-	chartList.Styles.FilterPrompt = searchInputStyle
-	chartList.Styles.FilterCursor = lipgloss.NewStyle().Foreground(lipgloss.Color("141"))
+// validateEditedValues checks values edited in the external editor against
+// the chart schema before they're saved
+func validateEditedValues(client *helm.Client, chartName, version string, edited editorFinishedMsg) tea.Cmd {
+	return func() tea.Msg {
+		result, err := client.ValidateValues(chartName, version, []byte(edited.content))
+		return editedValuesValidatedMsg{content: edited.content, filePath: edited.filePath, result: result, err: err}
+	}
+}
 
-	versionDelegate := list.NewDefaultDelegate()
-	versionDelegate.Styles = delegate.Styles
-	versionList := list.New([]list.Item{}, versionDelegate, 0, 0)
-	versionList.Title = "Versions"
-	versionList.SetShowStatusBar(false)
-	versionList.SetFilteringEnabled(true)
-	versionList.Styles.Title = titleStyle
-	versionList.Styles.FilterPrompt = searchInputStyle
-	versionList.Styles.FilterCursor = lipgloss.NewStyle().Foreground(lipgloss.Color("141"))
+func loadRepoInfo(client *helm.Client, repoName string) tea.Cmd {
+	return func() tea.Msg {
+		info, err := client.GetRepositoryInfo(repoName)
+		return repoInfoLoadedMsg{info: info, err: err}
+	}
+}
 
-	valuesView := viewport.New(0, 0)
-	diffView := viewport.New(0, 0)
+// releasePageSize is how many releases are fetched per helm list call, so
+// clusters with thousands of releases show the first ones right away
+const releasePageSize = 200
 
-	searchInput := textinput.New()
-	searchInput.Placeholder = "Search..."
+func loadReleasePage(ctx context.Context, client *helm.Client, namespace, selector string, offset, seq int) tea.Cmd {
+	return func() tea.Msg {
+		releases, err := client.ListReleasesPage(ctx, namespace, selector, offset, releasePageSize)
+		return releasesLoadedMsg{releases: releases, namespace: namespace, selector: selector, offset: offset, seq: seq, err: err}
+	}
+}
 
-	helpView := help.New()
+// loadReleases starts loading the release list page by page, dropping any
+// load still in flight
+func (m *model) loadReleases(namespace string) tea.Cmd {
+	m.releaseLoadSeq++
+	m.releases = nil
+	m.releaseGrep = nil
+	m.markedReleases = nil
+	m.releaseFilter = ""
+	m.loadingMore = true
+	return loadReleasePage(m.loadContext(stateReleaseList), m.helmClient, namespace, m.releaseSelector, 0, m.releaseLoadSeq)
+}
 
-	// Artifact Hub lists
-	ahPackageDelegate := list.NewDefaultDelegate()
-	ahPackageDelegate.Styles = delegate.Styles
-	ahPackageList := list.New([]list.Item{}, ahPackageDelegate, 0, 0)
-	ahPackageList.Title = "Artifact Hub"
-	ahPackageList.SetShowStatusBar(false)
-	ahPackageList.SetFilteringEnabled(true)
-	ahPackageList.Styles.Title = titleStyle
-	ahPackageList.Styles.FilterPrompt = searchInputStyle
-	ahPackageList.Styles.FilterCursor = lipgloss.NewStyle().Foreground(lipgloss.Color("141"))
+func loadNamespaces(ctx context.Context, client *helm.Client) tea.Cmd {
+	return func() tea.Msg {
+		namespaces, err := client.ListNamespaces(ctx)
+		return namespacesLoadedMsg{namespaces: namespaces, err: err}
+	}
+}
 
-	ahVersionDelegate := list.NewDefaultDelegate()
-	ahVersionDelegate.Styles = delegate.Styles
-	ahVersionList := list.New([]list.Item{}, ahVersionDelegate, 0, 0)
-	ahVersionList.Title = "Versions"
-	ahVersionList.SetShowStatusBar(false)
-	ahVersionList.SetFilteringEnabled(true)
-	ahVersionList.Styles.Title = titleStyle
-	ahVersionList.Styles.FilterPrompt = searchInputStyle
-	ahVersionList.Styles.FilterCursor = lipgloss.NewStyle().Foreground(lipgloss.Color("141"))
+func loadKubeContext(client *helm.Client) tea.Cmd {
+	return func() tea.Msg {
+		ctx, err := client.GetCurrentContext()
+		if err != nil {
+			return kubeContextLoadedMsg{err: err}
+		}
+		return kubeContextLoadedMsg{context: ctx}
+	}
+}
 
-	// Main Menu
-	menuItems := []list.Item{
-		listItem{title: "Browse Repositories", description: "Browse Helm repositories and charts"},
-		listItem{title: "Cluster Releases", description: "View deployed Helm releases"},
-		listItem{title: "Settings", description: "Configure LazyHelm settings (Coming Soon)"},
+func loadKubeContexts(client *helm.Client) tea.Cmd {
+	return func() tea.Msg {
+		contexts, err := client.ListContexts()
+		return kubeContextsLoadedMsg{contexts: contexts, err: err}
 	}
-	mainMenuDelegate := list.NewDefaultDelegate()
-	mainMenuDelegate.Styles = delegate.Styles
-	mainMenu := list.New(menuItems, mainMenuDelegate, 0, 0)
-	mainMenu.Title = "LazyHelm"
-	mainMenu.SetShowStatusBar(false)
-	mainMenu.SetFilteringEnabled(false)
-	mainMenu.Styles.Title = titleStyle
+}
 
-	// Browse Menu (submenu for Browse Repositories)
-	browseMenuItems := []list.Item{
-		listItem{title: "Local Repositories", description: "Browse your configured Helm repositories"},
-		listItem{title: "Search Artifact Hub", description: "Search charts on Artifact Hub"},
+func loadReleaseHistory(ctx context.Context, client *helm.Client, releaseName, namespace string) tea.Cmd {
+	return func() tea.Msg {
+		history, err := client.GetReleaseHistory(ctx, releaseName, namespace)
+		return releaseHistoryLoadedMsg{history: history, err: err}
 	}
-	browseMenuDelegate := list.NewDefaultDelegate()
-	browseMenuDelegate.Styles = delegate.Styles
-	browseMenu := list.New(browseMenuItems, browseMenuDelegate, 0, 0)
-	browseMenu.Title = "Browse Repositories"
-	browseMenu.SetShowStatusBar(false)
-	browseMenu.SetFilteringEnabled(false)
-	browseMenu.Styles.Title = titleStyle
+}
 
-	// Cluster Releases Menu
-	clusterReleasesMenuItems := []list.Item{
-		listItem{title: "All Namespaces", description: "View releases from all namespaces"},
-		listItem{title: "Select Namespace", description: "Choose a specific namespace"},
+func loadReleaseValues(ctx context.Context, client *helm.Client, releaseName, namespace string) tea.Cmd {
+	return func() tea.Msg {
+		values, err := client.GetReleaseValues(ctx, releaseName, namespace)
+		return releaseValuesLoadedMsg{values: values, err: err}
 	}
-	clusterReleasesMenuDelegate := list.NewDefaultDelegate()
-	clusterReleasesMenuDelegate.Styles = delegate.Styles
-	clusterReleasesMenu := list.New(clusterReleasesMenuItems, clusterReleasesMenuDelegate, 0, 0)
-	clusterReleasesMenu.Title = "Cluster Releases"
-	clusterReleasesMenu.SetShowStatusBar(false)
-	clusterReleasesMenu.SetFilteringEnabled(false)
-	clusterReleasesMenu.Styles.Title = titleStyle
+}
 
-	// Namespace List
-	namespaceDelegate := list.NewDefaultDelegate()
-	namespaceDelegate.Styles = delegate.Styles
-	namespaceList := list.New([]list.Item{}, namespaceDelegate, 0, 0)
-	namespaceList.Title = "Namespaces"
-	namespaceList.SetShowStatusBar(false)
-	namespaceList.SetFilteringEnabled(true)
-	namespaceList.Styles.Title = titleStyle
-	namespaceList.Styles.FilterPrompt = searchInputStyle
-	namespaceList.Styles.FilterCursor = lipgloss.NewStyle().Foreground(lipgloss.Color("141"))
+func loadReleaseNotes(ctx context.Context, client *helm.Client, releaseName, namespace string) tea.Cmd {
+	return func() tea.Msg {
+		notes, err := client.GetReleaseNotes(ctx, releaseName, namespace)
+		return releaseNotesLoadedMsg{notes: notes, err: err}
+	}
+}
 
-	// Release List
-	releaseDelegate := list.NewDefaultDelegate()
-	releaseDelegate.Styles = delegate.Styles
-	releaseList := list.New([]list.Item{}, releaseDelegate, 0, 0)
-	releaseList.Title = "Releases"
-	releaseList.SetShowStatusBar(false)
-	releaseList.SetFilteringEnabled(true)
-	releaseList.Styles.Title = titleStyle
-	releaseList.Styles.FilterPrompt = searchInputStyle
-	releaseList.Styles.FilterCursor = lipgloss.NewStyle().Foreground(lipgloss.Color("141"))
+func loadReleaseEvents(ctx context.Context, client *helm.Client, releaseName, namespace string) tea.Cmd {
+	return func() tea.Msg {
+		events, err := client.GetReleaseEvents(ctx, releaseName, namespace)
+		return releaseEventsLoadedMsg{release: releaseName, namespace: namespace, events: events, err: err}
+	}
+}
 
-	// Release History List
-	releaseHistoryDelegate := list.NewDefaultDelegate()
-	releaseHistoryDelegate.Styles = delegate.Styles
-	releaseHistoryList := list.New([]list.Item{}, releaseHistoryDelegate, 0, 0)
-	releaseHistoryList.Title = "Release History"
-	releaseHistoryList.SetShowStatusBar(false)
-	releaseHistoryList.SetFilteringEnabled(false)
-	releaseHistoryList.Styles.Title = titleStyle
+func loadReleaseDrift(ctx context.Context, client *helm.Client, releaseName, namespace string) tea.Cmd {
+	return func() tea.Msg {
+		drift, err := client.GetReleaseDrift(ctx, releaseName, namespace)
+		return releaseDriftLoadedMsg{release: releaseName, namespace: namespace, drift: drift, err: err}
+	}
+}
 
-	// Release Detail View
-	releaseDetailView := viewport.New(0, 0)
+// previewUpgrade runs helm diff upgrade for a release; origin is the screen
+// the preview was asked from
+func previewUpgrade(ctx context.Context, client *helm.Client, release helm.Release, opts helm.DiffUpgradeOptions, clusterVersion string, origin navigationState) tea.Cmd {
+	return func() tea.Msg {
+		output, err := client.DiffUpgrade(ctx, release.Name, release.Namespace, opts)
+		warning := kubeVersionWarning(ctx, client, opts.Chart, opts.Version, clusterVersion)
+		return upgradePreviewMsg{release: release, opts: opts, output: output, warning: warning, origin: origin, err: err}
+	}
+}
 
-	// Release Values View
-	releaseValuesView := viewport.New(0, 0)
+// dryRunRelease installs or upgrades a release with --dry-run=server; origin
+// is the screen the result is shown over
+func dryRunRelease(ctx context.Context, client *helm.Client, release helm.Release, opts helm.DryRunOptions, clusterVersion string, origin navigationState) tea.Cmd {
+	return func() tea.Msg {
+		result, err := client.DryRun(ctx, release.Name, release.Namespace, opts)
+		warning := kubeVersionWarning(ctx, client, opts.Chart, opts.Version, clusterVersion)
+		return dryRunMsg{release: release, opts: opts, result: result, warning: warning, origin: origin, err: err}
+	}
+}
 
-	return model{
-		helmClient:        client,
-		cache:             cache,
-		chartCache:        make(map[string]chartCacheEntry),
-		versionCache:      make(map[string]versionCacheEntry),
-		state:             stateMainMenu,
-		mode:              normalMode,
-		repos:             repos,
-		compareRevision:   -1,
-		artifactHubClient:     artifacthub.NewClient(),
-		ahPackageList:         ahPackageList,
-		ahVersionList:         ahVersionList,
-		mainMenu:              mainMenu,
-		browseMenu:            browseMenu,
-		clusterReleasesMenu:   clusterReleasesMenu,
-		namespaceList:         namespaceList,
-		releaseList:           releaseList,
-		releaseHistoryList:    releaseHistoryList,
-		releaseDetailView:     releaseDetailView,
-		releaseValuesView:     releaseValuesView,
-		repoList:              repoList,
-		chartList:         chartList,
-		versionList:       versionList,
-		valuesView:        valuesView,
-		diffView:          diffView,
-		searchInput:       searchInput,
-		helpView:          helpView,
-		keys:              defaultKeys,
-		err:               err,
+// kubeVersionWarning runs the kubeVersion check of the version list against
+// the chart an upgrade or dry run installs, asking the cluster for its
+// version when it isn't known yet. It's empty when the chart fits the
+// cluster or either can't be read.
+func kubeVersionWarning(ctx context.Context, client *helm.Client, chartName, version, clusterVersion string) string {
+	constraint, err := client.ChartKubeVersion(chartName, version)
+	if err != nil || constraint == "" {
+		return ""
+	}
+	if clusterVersion == "" {
+		if clusterVersion, err = client.GetClusterVersion(ctx); err != nil {
+			return ""
+		}
+	}
+	if helm.KubeVersionCompatible(constraint, clusterVersion) {
+		return ""
 	}
+	return fmt.Sprintf("⚠ %s needs Kubernetes %s, cluster is %s", chartName, constraint, clusterVersion)
 }
 
-func (m model) Init() tea.Cmd {
-	return nil
+func loadRegistries(client *helm.Client) tea.Cmd {
+	return func() tea.Msg {
+		registries, err := client.ListRegistries()
+		return registriesLoadedMsg{registries: registries, err: err}
+	}
 }
 
-func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	var cmd tea.Cmd
-	var cmds []tea.Cmd
+// registryCheckTimeout bounds how long a registry may take to authenticate
+const registryCheckTimeout = 15 * time.Second
 
-	switch msg := msg.(type) {
-	case tea.WindowSizeMsg:
-		m.termWidth = msg.Width
-		m.termHeight = msg.Height
+func checkRegistry(ctx context.Context, client *helm.Client, host string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, registryCheckTimeout)
+		defer cancel()
+		return registryCheckedMsg{host: host, err: client.CheckRegistry(ctx, host)}
+	}
+}
 
-		h := msg.Height - 10
-		w := msg.Width - 4
-
-		m.mainMenu.SetSize(w/2, h)
-		m.browseMenu.SetSize(w/2, h)
-		m.repoList.SetSize(w/3, h)
-		m.chartList.SetSize(w/2, h)
-		m.versionList.SetSize(w/3, h)
+func loginRegistry(client *helm.Client, host, username, password string) tea.Cmd {
+	return func() tea.Msg {
+		if err := client.RegistryLogin(host, username, password); err != nil {
+			return registryChangedMsg{err: err}
+		}
+		return registryChangedMsg{message: fmt.Sprintf("Logged in to %s as %s", host, username)}
+	}
+}
 
-		// Artifact Hub lists
-		m.ahPackageList.SetSize(w-4, h)
-		m.ahVersionList.SetSize(w/3, h)
+func logoutRegistry(client *helm.Client, host string) tea.Cmd {
+	return func() tea.Msg {
+		if err := client.RegistryLogout(host); err != nil {
+			return registryChangedMsg{err: err}
+		}
+		return registryChangedMsg{message: "Logged out of " + host}
+	}
+}
 
-		// Cluster Releases lists
-		m.clusterReleasesMenu.SetSize(w/2, h)
-		m.namespaceList.SetSize(w/3, h)
-		m.releaseList.SetSize(w-4, h)
-		m.releaseHistoryList.SetSize(w/3, h)
+func loadHelmfile(path, environment string) tea.Cmd {
+	return func() tea.Msg {
+		helmfile, err := gitops.LoadHelmfile(path, environment)
+		return helmfileLoadedMsg{helmfile: helmfile, err: err}
+	}
+}
 
-		// Values view takes full screen
-		m.valuesView.Width = msg.Width - 6  // Full width minus border padding
-		m.valuesView.Height = msg.Height - 8 // Full height minus header/footer
+func loadReleaseStatus(ctx context.Context, client *helm.Client, releaseName, namespace string) tea.Cmd {
+	return func() tea.Msg {
+		status, err := client.GetReleaseStatus(ctx, releaseName, namespace)
+		return releaseStatusLoadedMsg{status: status, err: err}
+	}
+}
 
-		m.diffView.Width = msg.Width - 6
-		m.diffView.Height = msg.Height - 8
+func loadReleaseResources(ctx context.Context, client *helm.Client, releaseName, namespace string) tea.Cmd {
+	return func() tea.Msg {
+		resources, err := client.GetReleaseResources(ctx, releaseName, namespace)
+		return releaseResourcesLoadedMsg{release: releaseName, resources: resources, err: err}
+	}
+}
 
-		m.releaseDetailView.Width = msg.Width - 6
-		m.releaseDetailView.Height = msg.Height - 8
+func refreshRepo(client *helm.Client, name string) tea.Cmd {
+	return func() tea.Msg {
+		return repoRefreshedMsg{name: name, err: client.UpdateRepository(name)}
+	}
+}
 
-		m.releaseValuesView.Width = msg.Width - 6
-		m.releaseValuesView.Height = msg.Height - 8
+func importRepositories(client *helm.Client, path string, replace bool) tea.Cmd {
+	return func() tea.Msg {
+		result, err := client.ImportRepositories(path, replace)
+		repos, repoErr := client.ListRepositories()
+		if err == nil {
+			err = repoErr
+		}
+		return reposImportedMsg{result: result, repos: repos, err: err}
+	}
+}
 
-		return m, nil
+// addGitSource clones a git repository and adds it as a chart source,
+// which can take a while for big repositories
+func addGitSource(client *helm.Client, src helm.GitSource) tea.Cmd {
+	return func() tea.Msg {
+		if err := client.AddGitSource(src); err != nil {
+			return reposReloadedMsg{err: err}
+		}
+		repos, err := client.ListRepositories()
+		return reposReloadedMsg{repos: repos, err: err}
+	}
+}
 
-	case tea.KeyMsg:
-		if m.state == stateHelp {
-			if msg.String() == "?" || msg.String() == "esc" || msg.String() == "q" {
-				m.state = m.previousState
-			}
-			return m, nil
+func addRepository(client *helm.Client, name, url string, opts helm.RepositoryOptions) tea.Cmd {
+	return func() tea.Msg {
+		err := client.AddRepository(name, url, opts)
+		if err != nil {
+			return operationDoneMsg{err: err}
 		}
 
-		if m.mode != normalMode {
-			return m.handleInputMode(msg)
+		repos, repoErr := client.ListRepositories()
+		if repoErr != nil {
+			return operationDoneMsg{success: fmt.Sprintf("Repository '%s' added, but failed to reload list", name)}
 		}
 
-		switch {
-		case key.Matches(msg, m.keys.Quit):
-			return m, tea.Quit
+		return reposReloadedMsg{repos: repos}
+	}
+}
 
-		case key.Matches(msg, m.keys.Help):
-			m.previousState = m.state
-			m.state = stateHelp
-			return m, nil
+func exportValues(client *helm.Client, chartName string, sink export.Sink) tea.Cmd {
+	return func() tea.Msg {
+		values, err := client.GetChartValues(chartName)
+		if err != nil {
+			return operationDoneMsg{err: err}
+		}
+		if err := sink.Write([]byte(values)); err != nil {
+			return operationDoneMsg{err: err}
+		}
+		return operationDoneMsg{success: fmt.Sprintf("Values exported to %s", sink)}
+	}
+}
 
-		case key.Matches(msg, m.keys.Back):
-			return m.handleBack()
+// exportSink resolves the target typed at an export prompt: a file path,
+// @clipboard, or |command to pipe the content to
+func (m model) exportSink(target string) (export.Sink, error) {
+	target = expandHome(target)
+	return export.Parse(target, export.Options{
+		ClipboardMode:  m.config.Clipboard,
+		DefaultCommand: m.config.ExportCommand,
+	})
+}
 
-		case key.Matches(msg, m.keys.Enter):
-			return m.handleEnter()
+// background wraps a long-running operation so that, if the user has moved to
+// another screen by the time it finishes, they get a bell or desktop
+// notification on top of the usual toast. It shows in the footer while it
+// runs.
+func (m *model) background(cmd tea.Cmd) tea.Cmd {
+	m.backgroundOps++
+	origin := m.state
+	return func() tea.Msg {
+		msg := cmd()
+		if done, ok := msg.(operationDoneMsg); ok {
+			done.background = true
+			done.origin = origin
+			return done
+		}
+		return backgroundDoneMsg{msg: msg}
+	}
+}
 
-		case key.Matches(msg, m.keys.Search):
-			return m.handleSearch()
+// inCluster tags a load of cluster data with the current cluster, so its
+// result is dropped if the kube context changes before it arrives
+func (m model) inCluster(cmd tea.Cmd) tea.Cmd {
+	gen := m.clusterGen
+	return func() tea.Msg {
+		return clusterLoadedMsg{gen: gen, msg: cmd()}
+	}
+}
 
-		case key.Matches(msg, m.keys.AddRepo):
-			if m.state == stateRepoList {
-				m.mode = addRepoMode
-				m.addRepoStep = 0
-				m.searchInput.Reset()
-				m.searchInput.Placeholder = "Repository name..."
-				m.searchInput.Focus()
-			}
-			if (m.state == stateArtifactHubPackageDetail || m.state == stateArtifactHubVersions) && m.ahSelectedPackage != nil {
-				// Add repo from Artifact Hub - only ask for name, URL is auto-filled
-				m.mode = addRepoMode
-				m.addRepoStep = 0
-				m.newRepoURL = m.ahSelectedPackage.Repository.URL // Pre-fill URL
-				m.searchInput.Reset()
-				m.searchInput.Placeholder = fmt.Sprintf("Repository name (default: %s)...", m.ahSelectedPackage.Repository.Name)
-				m.searchInput.Focus()
-			}
-			return m, nil
+func (m model) notify(done operationDoneMsg) tea.Cmd {
+	message := done.success
+	if done.err != nil {
+		message = "Failed: " + done.err.Error()
+	}
+	mode := m.config.Notify
+	return func() tea.Msg {
+		ui.Notify("lazyhelm", message, mode)
+		return nil
+	}
+}
 
-		case key.Matches(msg, m.keys.RemoveRepo):
-			if m.state == stateRepoList && len(m.repos) > 0 {
-				// Enter confirmation mode - use selected item to handle filtered lists
-				selectedItem := m.repoList.SelectedItem()
-				if selectedItem != nil {
-					item := selectedItem.(listItem)
-					m.mode = confirmRemoveRepoMode
-					m.searchInput.Reset()
-					m.searchInput.Placeholder = fmt.Sprintf("Remove '%s'? (y/n)", item.title)
-					m.searchInput.Focus()
-				}
-			}
-			return m, nil
+// renderTemplate renders a chart to a non-file sink, e.g. the clipboard or
+// kubectl apply -f -
+func renderTemplate(client *helm.Client, chartName string, opts helm.TemplateOptions, sink export.Sink) tea.Cmd {
+	return func() tea.Msg {
+		manifests, err := client.RenderTemplate(chartName, opts)
+		if err != nil {
+			return operationDoneMsg{err: err}
+		}
+		if err := sink.Write([]byte(manifests)); err != nil {
+			return operationDoneMsg{err: err}
+		}
+		return operationDoneMsg{success: fmt.Sprintf("Template sent to %s", sink)}
+	}
+}
 
-		case key.Matches(msg, m.keys.UpdateRepo):
-			if m.state == stateRepoList && len(m.repos) > 0 {
-				selectedItem := m.repoList.SelectedItem()
-				if selectedItem != nil {
-					item := selectedItem.(listItem)
-					repoName := item.title
-					return m, func() tea.Msg {
-						err := m.helmClient.UpdateRepository(repoName)
-						if err != nil {
-							return operationDoneMsg{err: err}
-						}
-						return operationDoneMsg{success: fmt.Sprintf("Repository '%s' updated successfully", repoName)}
-					}
-				}
-			}
-			return m, nil
+// overrideKeysMsg carries the values paths of the chart being templated or
+// upgraded to
+type overrideKeysMsg struct {
+	paths []ui.KeyPath
+}
 
-		case key.Matches(msg, m.keys.Export):
-			if m.state == stateChartDetail || m.state == stateValueViewer || m.state == stateReleaseValues {
-				m.mode = exportValuesMode
-				m.searchInput.Reset()
-				m.searchInput.Placeholder = "./values.yaml"
-				m.searchInput.Focus()
+// loadOverrideKeys lists the values paths of a chart version to complete
+// overrides with
+func loadOverrideKeys(client *helm.Client, cache *helm.Cache, chartName, version string) tea.Cmd {
+	return func() tea.Msg {
+		values, found := cache.Get(chartName, version)
+		if !found {
+			var err error
+			if values, err = client.GetChartValuesByVersion(chartName, version); err != nil {
+				return overrideKeysMsg{} // Overrides can still be typed, just not completed
 			}
-			return m, nil
+			cache.Set(chartName, version, values)
+		}
+		return overrideKeysMsg{paths: ui.KeyPaths(values)}
+	}
+}
 
-		case key.Matches(msg, m.keys.Template):
-			if m.state == stateChartDetail || m.state == stateValueViewer {
-				m.mode = templatePathMode
-				m.searchInput.Reset()
-				m.searchInput.Placeholder = "./output/"
-				m.searchInput.Focus()
-			}
-			return m, nil
+// templatePreviewTarget is the template output target that shows the
+// rendered manifest in the TUI instead of writing it anywhere
+const templatePreviewTarget = "@preview"
 
-		case key.Matches(msg, m.keys.ArtifactHub):
-			if m.state == stateRepoList {
-				m.mode = searchMode
-				m.searchInput.Reset()
-				m.searchInput.Placeholder = "Search Artifact Hub..."
-				m.searchInput.Focus()
-				m.state = stateArtifactHubSearch
-			}
-			return m, nil
+type templatePreviewMsg struct {
+	opts     helm.TemplateOptions
+	manifest string
+	err      error
+}
 
-		case key.Matches(msg, m.keys.ClearFilter):
-			// Clear filters and restore full lists
-			var clearCmd tea.Cmd
-			switch m.state {
-			case stateRepoList:
-				items := make([]list.Item, len(m.repos))
-				for i, repo := range m.repos {
-					items[i] = listItem{
-						title:       repo.Name,
-						description: repo.URL,
-					}
-				}
-				m.repoList.SetItems(items)
-				clearCmd = m.setSuccessMsg("Filter cleared")
+// previewTemplate renders a chart in memory for the template preview
+func previewTemplate(client *helm.Client, chartName string, opts helm.TemplateOptions) tea.Cmd {
+	return func() tea.Msg {
+		manifest, err := client.RenderTemplate(chartName, opts)
+		return templatePreviewMsg{opts: opts, manifest: manifest, err: err}
+	}
+}
 
-			case stateChartList:
-				items := make([]list.Item, len(m.charts))
-				for i, chart := range m.charts {
-					name := chart.Name
-					if m.selectedRepo < len(m.repos) {
-						name = strings.TrimPrefix(name, m.repos[m.selectedRepo].Name+"/")
-					}
-					items[i] = listItem{
-						title:       name,
-						description: chart.Description,
-					}
-				}
-				m.chartList.SetItems(items)
-				clearCmd = m.setSuccessMsg("Filter cleared")
+func generateTemplate(client *helm.Client, chartName string, opts helm.TemplateOptions) tea.Cmd {
+	return func() tea.Msg {
+		err := client.GenerateTemplate(chartName, opts)
+		if err != nil {
+			return operationDoneMsg{err: err}
+		}
+		var notes []string
+		if opts.PostRenderer != "" {
+			notes = append(notes, "post-rendered")
+		}
+		if opts.Validate {
+			notes = append(notes, "validated against cluster")
+		}
+		if len(notes) > 0 {
+			return operationDoneMsg{success: fmt.Sprintf("Template generated in %s (%s)", opts.OutputDir, strings.Join(notes, ", "))}
+		}
+		return operationDoneMsg{success: fmt.Sprintf("Template generated in %s", opts.OutputDir)}
+	}
+}
 
-			case stateChartDetail:
-				items := make([]list.Item, len(m.versions))
-				for i, ver := range m.versions {
-					desc := ""
-					if ver.AppVersion != "" {
-						desc = "App: " + ver.AppVersion
-					}
-					items[i] = listItem{
-						title:       "v" + ver.Version,
-						description: desc,
-					}
-				}
-				m.versionList.SetItems(items)
-				clearCmd = m.setSuccessMsg("Filter cleared")
+func generateManifest(client *helm.Client, format string, ref gitops.ChartRef, outputPath string) tea.Cmd {
+	return func() tea.Msg {
+		values, err := client.GetChartValuesByVersion(ref.RepoName+"/"+ref.Chart, ref.Version)
+		if err != nil {
+			return operationDoneMsg{err: err}
+		}
+		ref.Values = values
 
-			case stateArtifactHubSearch:
-				items := make([]list.Item, len(m.ahPackages))
-				for i, pkg := range m.ahPackages {
-					badges := pkg.GetBadges()
-					stars := fmt.Sprintf("⭐%d", pkg.Stars)
-					security := pkg.SecurityReport.GetSecurityBadge()
-
-					desc := fmt.Sprintf("%s | %s %s | %s", pkg.Repository.DisplayName, stars, badges, security)
-					items[i] = listItem{
-						title:       pkg.Name,
-						description: desc,
-					}
-				}
-				m.ahPackageList.SetItems(items)
-				clearCmd = m.setSuccessMsg("Filter cleared")
+		manifest, err := gitops.Generate(format, ref)
+		if err != nil {
+			return operationDoneMsg{err: err}
+		}
+		if err := os.WriteFile(outputPath, []byte(manifest), 0644); err != nil {
+			return operationDoneMsg{err: err}
+		}
+		return operationDoneMsg{success: fmt.Sprintf("Manifest for %s v%s written to %s", ref.Chart, ref.Version, outputPath)}
+	}
+}
 
-			case stateReleaseList:
-				items := make([]list.Item, len(m.releases))
-				for i, release := range m.releases {
-					desc := fmt.Sprintf("%s | %s | %s", release.Namespace, release.Chart, release.Status)
-					items[i] = listItem{
-						title:       release.Name,
-						description: desc,
-					}
-				}
-				m.releaseList.SetItems(items)
-				clearCmd = m.setSuccessMsg("Filter cleared")
-			}
-			return m, clearCmd
+func openURL(url string) tea.Cmd {
+	return func() tea.Msg {
+		if err := ui.OpenURL(url); err != nil {
+			return operationDoneMsg{err: err}
+		}
+		return operationDoneMsg{success: "Opened " + url}
+	}
+}
 
-		case key.Matches(msg, m.keys.Versions):
-			if m.state == stateChartList && len(m.charts) > 0 {
-				m.state = stateChartDetail
-				m.loading = true
-				idx := m.chartList.Index()
-				if idx < len(m.charts) {
-					return m, loadVersions(m.helmClient, m.versionCache, m.charts[idx].Name)
-				}
+// openChartHome opens the chart's home page, or its Artifact Hub search
+// results when the index doesn't list one
+func openChartHome(client *helm.Client, chartName, version string) tea.Cmd {
+	return func() tea.Msg {
+		url, err := client.ChartHomeURL(chartName, version)
+		if err != nil {
+			shortName := chartName
+			if idx := strings.LastIndex(chartName, "/"); idx >= 0 {
+				shortName = chartName[idx+1:]
 			}
-			if m.state == stateArtifactHubPackageDetail && m.ahSelectedPackage != nil {
-				m.state = stateArtifactHubVersions
+			url = artifacthub.SearchURL(shortName)
+		}
+		return openURL(url)()
+	}
+}
+
+// artifactHubPageSize is how many search results are loaded at a time
+const artifactHubPageSize = 50
+
+func searchArtifactHub(client *artifacthub.Client, query string, opts artifacthub.SearchOptions, offset int) tea.Cmd {
+	return func() tea.Msg {
+		page, err := client.SearchPackages(query, opts, artifactHubPageSize, offset)
+		if err != nil {
+			return artifactHubSearchMsg{query: query, opts: opts, offset: offset, err: err}
+		}
+		return artifactHubSearchMsg{packages: page.Packages, total: page.Total, query: query, opts: opts, offset: offset}
+	}
+}
+
+// waitForRateLimit waits for the next rate limited Artifact Hub request
+func waitForRateLimit(client *artifacthub.Client) tea.Cmd {
+	return func() tea.Msg {
+		return artifactHubRateLimitedMsg{wait: <-client.RateLimited()}
+	}
+}
+
+func loadArtifactHubPackage(client *artifacthub.Client, repoName, packageName string) tea.Cmd {
+	return func() tea.Msg {
+		pkg, err := client.GetPackageDetails(repoName, packageName)
+		if err != nil {
+			return artifactHubPackageMsg{err: err}
+		}
+		return artifactHubPackageMsg{pkg: pkg}
+	}
+}
+
+// copyToClipboard copies text using the clipboard mode from the config
+func (m model) copyToClipboard(text string) error {
+	return ui.CopyToClipboard(text, m.config.Clipboard)
+}
+
+func clearSuccessMsgAfter(d time.Duration, seq int) tea.Cmd {
+	return tea.Tick(d, func(t time.Time) tea.Msg {
+		return clearSuccessMsgMsg{seq: seq}
+	})
+}
+
+// Helper to set success message and auto-clear after 3 seconds
+func (m *model) setSuccessMsg(msg string) tea.Cmd {
+	return m.setSuccessMsgFor(msg, 3*time.Second)
+}
+
+// loadContext returns the context of the loaders whose result belongs to
+// state, cancelled when state is left so a slow load doesn't outlive its
+// screen
+func (m *model) loadContext(state navigationState) context.Context {
+	if ctx, ok := m.loadContexts[state]; ok {
+		return ctx
+	}
+	if m.loadContexts == nil {
+		m.loadContexts = make(map[navigationState]context.Context)
+		m.loadCancels = make(map[navigationState]context.CancelFunc)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.loadContexts[state] = ctx
+	m.loadCancels[state] = cancel
+	return ctx
+}
+
+// startLoading shows the spinner for a new load and returns its generation
+func (m *model) startLoading() int {
+	m.loading = true
+	m.loadGen++
+	return m.loadGen
+}
+
+// cancelLoads cancels the loaders started for state
+func (m *model) cancelLoads(state navigationState) {
+	if cancel, ok := m.loadCancels[state]; ok {
+		cancel()
+		delete(m.loadContexts, state)
+		delete(m.loadCancels, state)
+	}
+}
+
+// cancelAllLoads cancels the loaders of every screen but keep, for jumps and
+// tab switches that leave the screens behind
+func (m *model) cancelAllLoads(keep ...navigationState) {
+	for state := range m.loadCancels {
+		if !slices.Contains(keep, state) {
+			m.cancelLoads(state)
+		}
+	}
+}
+
+// setSuccessMsgFor shows a success message for a custom duration. Each message
+// gets a sequence number so an older timer can't clear a newer message.
+func (m *model) setSuccessMsgFor(msg string, d time.Duration) tea.Cmd {
+	m.successMsg = msg
+	m.successSeq++
+	return clearSuccessMsgAfter(d, m.successSeq)
+}
+
+// newClient creates the Helm client for the command line flags and the
+// config file
+func newClient(opts options, cfg *config.Config) (*helm.Client, error) {
+	var err error
+	client := helm.NewClient()
+	if opts.repositoryConfig != "" {
+		client.SetRepositoryConfig(opts.repositoryConfig)
+	}
+	if opts.kubeContext != "" {
+		client.SetContext(opts.kubeContext)
+	}
+
+	// --namespace wins over the config file, which wins over HELM_NAMESPACE
+	if opts.namespace != "" {
+		client.SetNamespace(opts.namespace)
+	} else if cfg.Namespace != "" {
+		client.SetNamespace(cfg.Namespace)
+	}
+
+	// --helm-driver wins over the config file, which wins over HELM_DRIVER
+	driver := cfg.HelmDriver
+	if opts.helmDriver != "" {
+		driver = opts.helmDriver
+	}
+	if driver != "" {
+		err = client.SetDriver(driver)
+	}
+
+	// --kubeconfig wins over the config file, which wins over KUBECONFIG
+	kubeConfig := cfg.KubeConfig
+	if opts.kubeConfig != "" {
+		kubeConfig = opts.kubeConfig
+	}
+	if kubeConfig != "" {
+		if kubeConfigErr := client.SetKubeConfig(expandHomeList(kubeConfig)); kubeConfigErr != nil && err == nil {
+			err = kubeConfigErr
+		}
+	}
+	return client, err
+}
+
+func initialModel(opts options) model {
+	cfg, cfgErr := config.Load()
+	client, clientErr := newClient(opts, cfg)
+	repos, err := client.ListRepositories()
+	if err == nil {
+		err = cfgErr
+	}
+	if err == nil {
+		err = clientErr
+	}
+	cache := helm.NewCache(cfg.CacheDuration())
+	// A broken history or favorites file only empties its screen
+	recent, _ := config.LoadRecent()
+	favorites, _ := config.LoadFavorites()
+
+	defaultNamespace := client.Namespace()
+
+	// The config file wins over ARTIFACTHUB_API_KEY_ID/SECRET
+	artifactHubClient := artifacthub.NewClient()
+	if cfg.ArtifactHubAPIKeyID != "" && cfg.ArtifactHubAPIKeySecret != "" {
+		artifactHubClient.SetAPIKey(cfg.ArtifactHubAPIKeyID, cfg.ArtifactHubAPIKeySecret)
+	}
+
+	// Detected before a theme overrides it, for switching back to auto
+	darkBackground := lipgloss.HasDarkBackground()
+	applyTheme(cfg.Theme, darkBackground)
+
+	repos = orderRepos(repos, cfg)
+
+	// Indexes older than repo_refresh are updated once the UI is up
+	repoUpdating := make(map[string]bool)
+	if refreshAge := cfg.RepoRefreshAge(); refreshAge > 0 {
+		for _, repo := range repos {
+			if time.Since(repo.IndexUpdated) > refreshAge {
+				repoUpdating[repo.Name] = true
+			}
+		}
+	}
+	repoItems := repoListItems(repos, repoUpdating, cfg)
+
+	// Create custom delegate with fzf-like colors (background for selected items)
+	delegate := list.NewDefaultDelegate()
+	delegate.Styles.SelectedTitle = delegate.Styles.SelectedTitle.
+		Foreground(lipgloss.Color("0")).    // Nero/Bianco (adaptive)
+		Background(lipgloss.Color("141")).  // Violet - stile fzf
+		Bold(true)
+	delegate.Styles.SelectedDesc = delegate.Styles.SelectedDesc.
+		Foreground(lipgloss.Color("0")).    // Nero/Bianco
+		Background(lipgloss.Color("141"))   // Violet
+	delegate.Styles.NormalTitle = delegate.Styles.NormalTitle.
+		Foreground(lipgloss.AdaptiveColor{Light: "235", Dark: "255"})   // Grigio scuro su chiaro, bianco su scuro
+	delegate.Styles.NormalDesc = delegate.Styles.NormalDesc.
+		Foreground(lipgloss.AdaptiveColor{Light: "240", Dark: "250"})   // Grigio medio
+
+	repoList := list.New(repoItems, delegate, 0, 0)
+	repoList.Title = "Repositories"
+	repoList.SetShowStatusBar(false)
+	repoList.SetFilteringEnabled(true)
+	repoList.Styles.Title = titleStyle
+	repoList.Styles.FilterPrompt = searchInputStyle
+	repoList.Styles.FilterCursor = lipgloss.NewStyle().Foreground(lipgloss.Color("141"))
+
+	chartDelegate := list.NewDefaultDelegate()
+	chartDelegate.Styles = delegate.Styles
+	chartList := list.New([]list.Item{}, chartDelegate, 0, 0)
+	chartList.Title = "Charts"
+	chartList.SetShowStatusBar(false)
+	chartList.SetFilteringEnabled(true)
+	chartList.Styles.Title = titleStyle
+	chartList.Styles.FilterPrompt = searchInputStyle
+	chartList.Styles.FilterCursor = lipgloss.NewStyle().Foreground(lipgloss.Color("141"))
+
+	versionDelegate := list.NewDefaultDelegate()
+	versionDelegate.Styles = delegate.Styles
+	versionList := list.New([]list.Item{}, versionDelegate, 0, 0)
+	versionList.Title = "Versions"
+	versionList.SetShowStatusBar(false)
+	versionList.SetFilteringEnabled(true)
+	versionList.Styles.Title = titleStyle
+	versionList.Styles.FilterPrompt = searchInputStyle
+	versionList.Styles.FilterCursor = lipgloss.NewStyle().Foreground(lipgloss.Color("141"))
+
+	valuesView := viewport.New(0, 0)
+	diffView := viewport.New(0, 0)
+
+	searchInput := textinput.New()
+	searchInput.Placeholder = "Search..."
+
+	helpView := help.New()
+
+	// Artifact Hub lists
+	ahPackageDelegate := list.NewDefaultDelegate()
+	ahPackageDelegate.Styles = delegate.Styles
+	ahPackageList := list.New([]list.Item{}, ahPackageDelegate, 0, 0)
+	ahPackageList.Title = "Artifact Hub"
+	ahPackageList.SetShowStatusBar(false)
+	ahPackageList.SetFilteringEnabled(true)
+	ahPackageList.Styles.Title = titleStyle
+	ahPackageList.Styles.FilterPrompt = searchInputStyle
+	ahPackageList.Styles.FilterCursor = lipgloss.NewStyle().Foreground(lipgloss.Color("141"))
+
+	ahVersionDelegate := list.NewDefaultDelegate()
+	ahVersionDelegate.Styles = delegate.Styles
+	ahVersionList := list.New([]list.Item{}, ahVersionDelegate, 0, 0)
+	ahVersionList.Title = "Versions"
+	ahVersionList.SetShowStatusBar(false)
+	ahVersionList.SetFilteringEnabled(true)
+	ahVersionList.Styles.Title = titleStyle
+	ahVersionList.Styles.FilterPrompt = searchInputStyle
+	ahVersionList.Styles.FilterCursor = lipgloss.NewStyle().Foreground(lipgloss.Color("141"))
+
+	podPickerDelegate := list.NewDefaultDelegate()
+	podPickerDelegate.Styles = delegate.Styles
+	podPickerList := list.New([]list.Item{}, podPickerDelegate, 0, 0)
+	podPickerList.Title = "Pods"
+	podPickerList.SetShowStatusBar(false)
+	podPickerList.SetFilteringEnabled(false)
+	podPickerList.Styles.Title = titleStyle
+
+	recentDelegate := list.NewDefaultDelegate()
+	recentDelegate.Styles = delegate.Styles
+	recentList := list.New([]list.Item{}, recentDelegate, 0, 0)
+	recentList.Title = "Recently Viewed"
+	recentList.SetShowStatusBar(false)
+	recentList.SetFilteringEnabled(false)
+	recentList.Styles.Title = titleStyle
+
+	favoritesDelegate := list.NewDefaultDelegate()
+	favoritesDelegate.Styles = delegate.Styles
+	favoritesList := list.New([]list.Item{}, favoritesDelegate, 0, 0)
+	favoritesList.Title = "Favorites"
+	favoritesList.SetShowStatusBar(false)
+	favoritesList.SetFilteringEnabled(false)
+	favoritesList.Styles.Title = titleStyle
+
+	helmfileDelegate := list.NewDefaultDelegate()
+	helmfileDelegate.Styles = delegate.Styles
+	helmfileList := list.New([]list.Item{}, helmfileDelegate, 0, 0)
+	helmfileList.Title = "Helmfile Releases"
+	helmfileList.SetShowStatusBar(false)
+	helmfileList.SetFilteringEnabled(false)
+	helmfileList.Styles.Title = titleStyle
+
+	registryDelegate := list.NewDefaultDelegate()
+	registryDelegate.Styles = delegate.Styles
+	registryList := list.New([]list.Item{}, registryDelegate, 0, 0)
+	registryList.Title = "OCI Registries"
+	registryList.SetShowStatusBar(false)
+	registryList.SetFilteringEnabled(false)
+	registryList.Styles.Title = titleStyle
+
+	chartFileDelegate := list.NewDefaultDelegate()
+	chartFileDelegate.Styles = delegate.Styles
+	chartFileList := list.New([]list.Item{}, chartFileDelegate, 0, 0)
+	chartFileList.Title = "Chart Files"
+	chartFileList.SetShowStatusBar(false)
+	chartFileList.SetFilteringEnabled(true)
+	chartFileList.Styles.Title = titleStyle
+	chartFileList.Styles.FilterPrompt = searchInputStyle
+	chartFileList.Styles.FilterCursor = lipgloss.NewStyle().Foreground(lipgloss.Color("141"))
+
+	schemaDelegate := list.NewDefaultDelegate()
+	schemaDelegate.Styles = delegate.Styles
+	schemaList := list.New([]list.Item{}, schemaDelegate, 0, 0)
+	schemaList.Title = "Values Schema"
+	schemaList.SetShowStatusBar(false)
+	schemaList.SetFilteringEnabled(true)
+	schemaList.Styles.Title = titleStyle
+	schemaList.Styles.FilterPrompt = searchInputStyle
+	schemaList.Styles.FilterCursor = lipgloss.NewStyle().Foreground(lipgloss.Color("141"))
+
+	// Main Menu
+	menuItems := []list.Item{
+		listItem{title: "Browse Repositories", description: "Browse Helm repositories and charts"},
+		listItem{title: "Cluster Releases", description: "View deployed Helm releases"},
+		listItem{title: "Favorites", description: "Starred charts and releases"},
+		listItem{title: "Recent", description: "Jump back to charts and releases viewed recently"},
+		listItem{title: "Action Log", description: "Operations performed this session and their outcome"},
+		listItem{title: "Helmfile", description: "Browse the releases of a helmfile per environment"},
+		listItem{title: "Settings", description: "Configure LazyHelm settings"},
+	}
+	mainMenuDelegate := list.NewDefaultDelegate()
+	mainMenuDelegate.Styles = delegate.Styles
+	mainMenu := list.New(menuItems, mainMenuDelegate, 0, 0)
+	mainMenu.Title = "LazyHelm"
+	mainMenu.SetShowStatusBar(false)
+	mainMenu.SetFilteringEnabled(false)
+	mainMenu.Styles.Title = titleStyle
+
+	// Browse Menu (submenu for Browse Repositories)
+	browseMenuItems := []list.Item{
+		listItem{title: "Local Repositories", description: "Browse your configured Helm repositories"},
+		listItem{title: "Search Artifact Hub", description: "Search charts on Artifact Hub"},
+		listItem{title: "OCI Registries", description: "Log in to and out of OCI registries"},
+	}
+	browseMenuDelegate := list.NewDefaultDelegate()
+	browseMenuDelegate.Styles = delegate.Styles
+	browseMenu := list.New(browseMenuItems, browseMenuDelegate, 0, 0)
+	browseMenu.Title = "Browse Repositories"
+	browseMenu.SetShowStatusBar(false)
+	browseMenu.SetFilteringEnabled(false)
+	browseMenu.Styles.Title = titleStyle
+
+	// Cluster Releases Menu
+	clusterReleasesMenuItems := []list.Item{
+		listItem{title: "Current Namespace", description: fmt.Sprintf("View releases in '%s'", defaultNamespace)},
+		listItem{title: "All Namespaces", description: "View releases from all namespaces"},
+		listItem{title: "Select Namespace", description: "Choose a specific namespace"},
+		listItem{title: "Switch Context", description: "Inspect releases in another kube context"},
+	}
+	clusterReleasesMenuDelegate := list.NewDefaultDelegate()
+	clusterReleasesMenuDelegate.Styles = delegate.Styles
+	clusterReleasesMenu := list.New(clusterReleasesMenuItems, clusterReleasesMenuDelegate, 0, 0)
+	clusterReleasesMenu.Title = "Cluster Releases"
+	clusterReleasesMenu.SetShowStatusBar(false)
+	clusterReleasesMenu.SetFilteringEnabled(false)
+	clusterReleasesMenu.Styles.Title = titleStyle
+
+	// Namespace List
+	namespaceDelegate := list.NewDefaultDelegate()
+	namespaceDelegate.Styles = delegate.Styles
+	namespaceList := list.New([]list.Item{}, namespaceDelegate, 0, 0)
+	namespaceList.Title = "Namespaces"
+	namespaceList.SetShowStatusBar(false)
+	namespaceList.SetFilteringEnabled(true)
+	namespaceList.Styles.Title = titleStyle
+	namespaceList.Styles.FilterPrompt = searchInputStyle
+	namespaceList.Styles.FilterCursor = lipgloss.NewStyle().Foreground(lipgloss.Color("141"))
+
+	// Settings
+	settingsDelegate := list.NewDefaultDelegate()
+	settingsDelegate.Styles = delegate.Styles
+	settingsList := list.New([]list.Item{}, settingsDelegate, 0, 0)
+	settingsList.Title = "Settings"
+	settingsList.SetShowStatusBar(false)
+	settingsList.SetFilteringEnabled(false)
+	settingsList.Styles.Title = titleStyle
+
+	// Kube Context List
+	contextDelegate := list.NewDefaultDelegate()
+	contextDelegate.Styles = delegate.Styles
+	contextList := list.New([]list.Item{}, contextDelegate, 0, 0)
+	contextList.Title = "Kube Contexts"
+	contextList.SetShowStatusBar(false)
+	contextList.SetFilteringEnabled(true)
+	contextList.Styles.Title = titleStyle
+	contextList.Styles.FilterPrompt = searchInputStyle
+	contextList.Styles.FilterCursor = lipgloss.NewStyle().Foreground(lipgloss.Color("141"))
+
+	// Release List
+	releaseDelegate := list.NewDefaultDelegate()
+	releaseDelegate.Styles = delegate.Styles
+	releaseList := list.New([]list.Item{}, releaseDelegate, 0, 0)
+	releaseList.Title = "Releases"
+	releaseList.SetShowStatusBar(false)
+	releaseList.SetFilteringEnabled(true)
+	releaseList.Styles.Title = titleStyle
+	releaseList.Styles.FilterPrompt = searchInputStyle
+	releaseList.Styles.FilterCursor = lipgloss.NewStyle().Foreground(lipgloss.Color("141"))
+
+	// Release History List
+	releaseHistoryDelegate := list.NewDefaultDelegate()
+	releaseHistoryDelegate.Styles = delegate.Styles
+	releaseHistoryList := list.New([]list.Item{}, releaseHistoryDelegate, 0, 0)
+	releaseHistoryList.Title = "Release History"
+	releaseHistoryList.SetShowStatusBar(false)
+	releaseHistoryList.SetFilteringEnabled(false)
+	releaseHistoryList.Styles.Title = titleStyle
+
+	// Release Detail View
+	releaseDetailView := viewport.New(0, 0)
+
+	// Release Values View
+	releaseValuesView := viewport.New(0, 0)
+
+	return model{
+		config:            cfg,
+		actionLogFile:     newActionLogWriter(),
+		repoUpdating:      repoUpdating,
+		recent:            recent,
+		favorites:         favorites,
+		helmClient:        client,
+		cache:             cache,
+		chartCache:        make(map[string]chartCacheEntry),
+		versionCache:      make(map[string]versionCacheEntry),
+		state:             stateMainMenu,
+		mode:              normalMode,
+		repos:             repos,
+		compareRevision:   -1,
+		defaultNamespace:  defaultNamespace,
+		darkBackground:    darkBackground,
+		artifactHubClient:     artifactHubClient,
+		ahSecurityView:        viewport.New(0, 0),
+		ahPackageList:         ahPackageList,
+		ahVersionList:         ahVersionList,
+		mainMenu:              mainMenu,
+		browseMenu:            browseMenu,
+		clusterReleasesMenu:   clusterReleasesMenu,
+		settingsList:          settingsList,
+		recentList:            recentList,
+		favoritesList:         favoritesList,
+		helmfileList:          helmfileList,
+		registryList:          registryList,
+		namespaceList:         namespaceList,
+		contextList:           contextList,
+		releaseList:           releaseList,
+		releaseHistoryList:    releaseHistoryList,
+		releaseDetailView:     releaseDetailView,
+		releaseValuesView:     releaseValuesView,
+		releaseNotesView:      viewport.New(0, 0),
+		releaseDriftView:      viewport.New(0, 0),
+		releaseEventsView:     viewport.New(0, 0),
+		actionLogView:         viewport.New(0, 0),
+		releaseTestView:       viewport.New(0, 0),
+		podPickerList:         podPickerList,
+		podLogsView:           viewport.New(0, 0),
+		repoList:              repoList,
+		chartList:         chartList,
+		versionList:       versionList,
+		valuesView:        valuesView,
+		diffView:          diffView,
+		validationView:    viewport.New(0, 0),
+		readmeView:        viewport.New(0, 0),
+		templateView:      viewport.New(0, 0),
+		chartFileList:     chartFileList,
+		chartFileView:     viewport.New(0, 0),
+		schemaList:        schemaList,
+		searchInput:       searchInput,
+		helpView:          helpView,
+		spinner:           spinner.New(spinner.WithSpinner(spinner.Dot), spinner.WithStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("141")))),
+		keys:              defaultKeys,
+		err:               err,
+	}
+}
+
+func (m model) Init() tea.Cmd {
+	cmds := []tea.Cmd{waitForRateLimit(m.artifactHubClient)}
+	for name := range m.repoUpdating {
+		cmds = append(cmds, refreshRepo(m.helmClient, name))
+	}
+	return tea.Batch(cmds...)
+}
+
+// busy reports whether anything is loading, which keeps the spinner going
+func (m model) busy() bool {
+	return m.loading || m.loadingVals || m.ahLoading || m.ahLoadingMore || m.resourcesLoading || m.backgroundOps > 0
+}
+
+// loadingView is the spinner followed by text and, after a second, the
+// time spent so far
+func (m model) loadingView(text string) string {
+	view := m.spinner.View() + " " + text
+	if elapsed := time.Since(m.loadingSince); elapsed >= time.Second {
+		view += fmt.Sprintf(" (%s)", elapsed.Truncate(time.Second))
+	}
+	return view
+}
+
+// Update starts the spinner whenever an operation starts; its ticks stop
+// once nothing is loading
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	wasBusy := m.busy()
+	updated, cmd := m.update(msg)
+	m = updated.(model)
+	if m.busy() {
+		if !wasBusy {
+			m.loadingSince = time.Now()
+		}
+		if !m.spinning {
+			m.spinning = true
+			cmd = tea.Batch(cmd, m.spinner.Tick)
+		}
+	}
+	return m, cmd
+}
+
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var cmds []tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.termWidth = msg.Width
+		m.termHeight = msg.Height
+
+		h := msg.Height - 10
+		w := msg.Width - 4
+
+		m.mainMenu.SetSize(w/2, h)
+		m.browseMenu.SetSize(w/2, h)
+		m.repoList.SetSize(w/3, h)
+		m.chartList.SetSize(w/2, h)
+		m.versionList.SetSize(w/3, h)
+
+		// Artifact Hub lists
+		m.ahPackageList.SetSize(w-4, h)
+		m.ahVersionList.SetSize(w/3, h)
+		m.ahSecurityView.Width = msg.Width - 6
+		m.ahSecurityView.Height = msg.Height - 8
+
+		// Cluster Releases lists
+		m.clusterReleasesMenu.SetSize(w/2, h)
+		m.settingsList.SetSize(w/2, h)
+		m.recentList.SetSize(w-4, h)
+		m.favoritesList.SetSize(w-4, h)
+		m.helmfileList.SetSize(w-4, h)
+		m.registryList.SetSize(w-4, h)
+		m.namespaceList.SetSize(w/3, h)
+		m.contextList.SetSize(w/2, h)
+		m.releaseList.SetSize(w-4, h)
+		m.releaseHistoryList.SetSize(w/3, h)
+		m.podPickerList.SetSize(w-4, h)
+
+		// Values view takes full screen
+		m.valuesView.Width = msg.Width - 6  // Full width minus border padding
+		m.valuesView.Height = msg.Height - 8 // Full height minus header/footer
+
+		m.diffView.Width = msg.Width - 6
+		m.diffView.Height = msg.Height - 8
+
+		m.validationView.Width = msg.Width - 6
+		m.validationView.Height = msg.Height - 8
+
+		m.readmeView.Width = msg.Width - 6
+		m.readmeView.Height = msg.Height - 8
+		m.templateView.Width = msg.Width - 6
+		m.templateView.Height = msg.Height - 10 // Room for the resource header
+
+		m.chartFileList.SetSize(w/2, h)
+		m.schemaList.SetSize(w-4, h)
+		m.chartFileView.Width = msg.Width - 6
+		m.chartFileView.Height = msg.Height - 8
+		if m.readme != "" {
+			// Markdown is wrapped to the viewport width
+			m.renderReadme()
+		}
+
+		m.releaseDetailView.Width = msg.Width - 6
+		m.releaseDetailView.Height = msg.Height - 8
+
+		m.releaseValuesView.Width = msg.Width - 6
+		m.releaseValuesView.Height = msg.Height - 8
+
+		m.releaseNotesView.Width = msg.Width - 6
+		m.releaseNotesView.Height = msg.Height - 8
+
+		m.releaseDriftView.Width = msg.Width - 6
+		m.releaseDriftView.Height = msg.Height - 8
+
+		m.releaseEventsView.Width = msg.Width - 6
+		m.releaseEventsView.Height = msg.Height - 8
+		m.actionLogView.Width = msg.Width - 6
+		m.actionLogView.Height = msg.Height - 8
+		if m.releaseNotes != "" {
+			// Notes are wrapped to the viewport width
+			m.wrapReleaseNotes()
+		}
+
+		m.releaseTestView.Width = msg.Width - 6
+		m.releaseTestView.Height = msg.Height - 10
+
+		m.podLogsView.Width = msg.Width - 6
+		m.podLogsView.Height = msg.Height - 10
+		if m.logs != nil {
+			m.updatePodLogsView()
+		}
+
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.state == stateHelp {
+			if msg.String() == "?" || msg.String() == "esc" || msg.String() == "q" {
+				m.state = m.previousState
+			}
+			return m, nil
+		}
+
+		if m.mode != normalMode {
+			return m.handleInputMode(msg)
+		}
+
+		if view, lines := m.activeViewer(); view != nil {
+			if handled := m.handleViewerKeys(msg, view, lines); handled {
+				return m, nil
+			}
+		}
+
+		switch {
+		case key.Matches(msg, m.keys.Quit):
+			return m, tea.Quit
+
+		case key.Matches(msg, m.keys.Help):
+			m.previousState = m.state
+			m.state = stateHelp
+			return m, nil
+
+		case key.Matches(msg, m.keys.Back):
+			return m.handleBack()
+
+		case key.Matches(msg, m.keys.Repeat):
+			if m.lastAction == nil {
+				return m, m.setSuccessMsg("Nothing to repeat yet")
+			}
+			if m.lastAction.prepare != nil {
+				m.lastAction.prepare(&m)
+			}
+			return m, tea.Batch(m.setSuccessMsg("Repeating: "+m.lastAction.label), m.background(m.lastAction.cmd))
+
+		case key.Matches(msg, m.keys.NewTab):
+			return m.openTab()
+
+		case key.Matches(msg, m.keys.CloseTab):
+			return m.closeTab()
+
+		case key.Matches(msg, m.keys.NextTab) && len(m.tabs) > 1:
+			return m.switchTab((m.activeTab + 1) % len(m.tabs))
+
+		case key.Matches(msg, m.keys.PrevTab) && len(m.tabs) > 1:
+			return m.switchTab((m.activeTab + len(m.tabs) - 1) % len(m.tabs))
+
+		case key.Matches(msg, m.keys.GotoTab) && len(m.tabs) > 1:
+			return m.switchTab(int(msg.String()[0] - '1'))
+
+		case key.Matches(msg, m.keys.Goto) && m.state != stateValueViewer && m.state != stateReleaseValues && m.state != stateDiffViewer:
+			m.successMsg = ""
+			m.mode = gotoMode
+			m.gotoMatches = nil
+			m.searchInput.Reset()
+			m.searchInput.Placeholder = "repo, chart, release or namespace..."
+			m.searchInput.Focus()
+			return m, nil
+
+		case key.Matches(msg, m.keys.JumpKey) && (m.state == stateValueViewer || m.state == stateReleaseValues):
+			values := m.values
+			if m.state == stateReleaseValues {
+				values = m.releaseValues
+			}
+			m.keyPaths = ui.KeyPaths(values)
+			if len(m.keyPaths) == 0 {
+				return m, nil
+			}
+			if m.valuesOutline {
+				m.valuesOutline = false
+				m.updateValuesViewWithSearch()
+			}
+			m.successMsg = ""
+			m.mode = jumpKeyMode
+			m.keyMatches = nil
+			m.searchInput.Reset()
+			m.searchInput.Placeholder = "YAML path, e.g. img.pullpol..."
+			m.searchInput.Focus()
+			return m, nil
+
+		case key.Matches(msg, m.keys.Outline) && m.state == stateValueViewer && m.values != "":
+			m.valuesOutline = !m.valuesOutline
+			m.searchMatches = []int{}
+			m.lastSearchQuery = ""
+			if m.valuesOutline {
+				// Start with every top-level key collapsed
+				m.valuesBlockEnd = ui.FoldBlocks(m.valuesLines)
+				m.valuesFolded = make(map[int]bool)
+				for i, line := range m.valuesLines {
+					if m.valuesBlockEnd[i] > i+1 && !strings.HasPrefix(line, " ") {
+						m.valuesFolded[i] = true
+					}
+				}
+				m.outlineCursor = 0
+				m.valuesView.GotoTop()
+			}
+			m.updateValuesViewWithSearch()
+			return m, nil
+
+		case m.state == stateValueViewer && m.valuesOutline && (key.Matches(msg, m.keys.Up) || key.Matches(msg, m.keys.Down)):
+			visible := m.outlineLines()
+			for i, line := range visible {
+				if line != m.outlineCursor {
+					continue
+				}
+				if key.Matches(msg, m.keys.Up) && i > 0 {
+					i--
+				} else if key.Matches(msg, m.keys.Down) && i < len(visible)-1 {
+					i++
+				}
+				m.outlineCursor = visible[i]
+				// Keep the cursor on screen
+				if i < m.valuesView.YOffset {
+					m.valuesView.SetYOffset(i)
+				} else if i >= m.valuesView.YOffset+m.valuesView.Height {
+					m.valuesView.SetYOffset(i - m.valuesView.Height + 1)
+				}
+				break
+			}
+			m.updateValuesViewWithSearch()
+			return m, nil
+
+		case m.state == stateValueViewer && m.valuesOutline && (key.Matches(msg, m.keys.Enter) || msg.String() == " "):
+			if m.valuesBlockEnd[m.outlineCursor] > m.outlineCursor+1 {
+				m.valuesFolded[m.outlineCursor] = !m.valuesFolded[m.outlineCursor]
+				m.updateValuesViewWithSearch()
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.Enter):
+			return m.handleEnter()
+
+		case key.Matches(msg, m.keys.Search):
+			return m.handleSearch()
+
+		case key.Matches(msg, m.keys.Favorite) && m.state == stateRepoList:
+			item, ok := m.repoList.SelectedItem().(listItem)
+			if !ok {
+				return m, nil
+			}
+			updated := *m.config
+			message := fmt.Sprintf("Unpinned '%s'", item.title)
+			if updated.TogglePinnedRepo(item.title) {
+				message = fmt.Sprintf("Pinned '%s' to the top", item.title)
+			}
+			return m, m.applyRepoLayout(updated, item.title, message)
+
+		case (key.Matches(msg, m.keys.MoveUp) || key.Matches(msg, m.keys.MoveDown)) && m.state == stateRepoList:
+			item, ok := m.repoList.SelectedItem().(listItem)
+			if !ok {
+				return m, nil
+			}
+			if len(m.repoList.Items()) != len(m.repos) {
+				return m, m.setSuccessMsg("Clear the filter to reorder repositories")
+			}
+			names := make([]string, len(m.repos))
+			for i, repo := range m.repos {
+				names[i] = repo.Name
+			}
+			delta := 1
+			if key.Matches(msg, m.keys.MoveUp) {
+				delta = -1
+			}
+			updated := *m.config
+			if !updated.MoveRepo(names, item.title, delta) {
+				return m, nil
+			}
+			return m, m.applyRepoLayout(updated, item.title, fmt.Sprintf("Moved '%s'", item.title))
+
+		case key.Matches(msg, m.keys.ImportRepos) && m.state == stateRepoList:
+			m.mode = importReposPathMode
+			m.searchInput.Reset()
+			m.searchInput.Placeholder = "./repositories.yaml"
+			m.searchInput.Focus()
+			return m, nil
+
+		case key.Matches(msg, m.keys.AddRepo) && m.state == stateRegistries:
+			m.mode = registryHostMode
+			m.searchInput.Reset()
+			m.searchInput.Placeholder = "ghcr.io"
+			if registry, ok := m.currentRegistry(); ok {
+				m.searchInput.Placeholder = registry.Host
+			}
+			m.searchInput.Focus()
+			return m, nil
+
+		case key.Matches(msg, m.keys.Logout) && m.state == stateRegistries:
+			registry, ok := m.currentRegistry()
+			if !ok {
+				return m, nil
+			}
+			if registry.Source == helm.RegistrySourceDocker {
+				return m, m.setSuccessMsg(fmt.Sprintf("%s is logged in through the Docker config: run docker logout %s", registry.Host, registry.Host))
+			}
+			m.registryHost = registry.Host
+			m.mode = confirmRegistryLogoutMode
+			m.searchInput.Reset()
+			m.searchInput.Placeholder = fmt.Sprintf("Log out of %s? (y/n)", registry.Host)
+			m.searchInput.Focus()
+			return m, nil
+
+		case key.Matches(msg, m.keys.Refresh) && m.state == stateRegistries:
+			m.startLoading()
+			return m, loadRegistries(m.helmClient)
+
+		case key.Matches(msg, m.keys.AddRepo):
+			if m.state == stateRepoList {
+				m.mode = addRepoMode
+				m.addRepoStep = addRepoNameStep
+				m.newRepoOpts = helm.RepositoryOptions{}
+				m.searchInput.Reset()
+				m.searchInput.Placeholder = "Repository name..."
+				m.searchInput.Focus()
+			}
+			if (m.state == stateArtifactHubPackageDetail || m.state == stateArtifactHubVersions) && m.ahSelectedPackage != nil {
+				// Add repo from Artifact Hub - only ask for name, URL is auto-filled
+				m.mode = addRepoMode
+				m.addRepoStep = addRepoNameStep
+				m.newRepoURL = m.ahSelectedPackage.Repository.URL // Pre-fill URL
+				m.newRepoOpts = helm.RepositoryOptions{}
+				m.searchInput.Reset()
+				m.searchInput.Placeholder = fmt.Sprintf("Repository name (default: %s)...", m.ahSelectedPackage.Repository.Name)
+				m.searchInput.Focus()
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.Environment) && m.state == stateHelmfile:
+			m.mode = helmfileEnvMode
+			m.searchInput.Reset()
+			m.searchInput.Placeholder = m.helmfile.Environment
+			m.searchInput.Focus()
+			return m, nil
+
+		case key.Matches(msg, m.keys.Refresh) && m.state == stateHelmfile:
+			return m, loadHelmfile(m.helmfile.Path, m.helmfile.Environment)
+
+		case key.Matches(msg, m.keys.Refresh) && m.state == stateReleaseDetail:
+			if m.selectedRelease >= len(m.releases) || m.resourcesLoading {
+				return m, nil
+			}
+			release := m.releases[m.selectedRelease]
+			m.resourcesLoading = true
+			m.updateReleaseDetailView()
+			return m, m.inCluster(loadReleaseResources(m.loadContext(stateReleaseDetail), m.helmClient, release.Name, release.Namespace))
+
+		case key.Matches(msg, m.keys.RemoveRepo):
+			if m.state == stateRepoList && len(m.repos) > 0 {
+				// Enter confirmation mode - use selected item to handle filtered lists
+				selectedItem := m.repoList.SelectedItem()
+				if selectedItem != nil {
+					item := selectedItem.(listItem)
+					m.mode = confirmRemoveRepoMode
+					m.searchInput.Reset()
+					m.searchInput.Placeholder = fmt.Sprintf("Remove '%s'? (y/n)", item.title)
+					m.searchInput.Focus()
+				}
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.UndoRemove):
+			if m.undoRepo != nil {
+				removed := m.undoRepo
+				m.undoRepo = nil
+				m.successMsg = fmt.Sprintf("Restoring '%s'...", removed.Name())
+				return m, func() tea.Msg {
+					if err := m.helmClient.RestoreRepository(removed); err != nil {
+						return repoRestoredMsg{err: err}
+					}
+					repos, err := m.helmClient.ListRepositories()
+					return repoRestoredMsg{repos: repos, repoName: removed.Name(), err: err}
+				}
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.Security) && m.state == stateArtifactHubPackageDetail:
+			if m.ahSelectedPackage == nil {
+				return m, nil
+			}
+			m.state = stateArtifactHubSecurity
+			m.ahSecurityLines = nil
+			m.ahLoading = true
+			return m, loadArtifactHubSecurity(m.artifactHubClient, m.ahSelectedPackage)
+
+		case key.Matches(msg, m.keys.Readme) && m.state == stateArtifactHubPackageDetail:
+			if m.ahSelectedPackage == nil {
+				return m, nil
+			}
+			if strings.TrimSpace(m.ahSelectedPackage.Readme) == "" {
+				return m, m.setSuccessMsg("This package has no README on Artifact Hub")
+			}
+			m.state = stateChartReadme
+			m.readmeReturn = stateArtifactHubPackageDetail
+			m.readme = m.ahSelectedPackage.Readme
+			m.searchMatches = []int{}
+			m.lastSearchQuery = ""
+			m.renderReadme()
+			m.readmeView.GotoTop()
+			return m, nil
+
+		case key.Matches(msg, m.keys.Readme) && m.state == stateChartDetail:
+			if m.diffMode || m.selectedChart >= len(m.charts) {
+				return m, nil
+			}
+			selectedItem := m.versionList.SelectedItem()
+			if selectedItem == nil {
+				return m, nil
+			}
+			for i, ver := range m.versions {
+				if "v"+ver.Version == selectedItem.(listItem).title {
+					m.selectedVersion = i
+					m.state = stateChartReadme
+					m.readmeReturn = stateChartDetail
+					m.readme = ""
+					m.readmeLines = nil
+					m.startLoading()
+					return m, loadReadme(m.helmClient, m.charts[m.selectedChart].Name, ver.Version)
+				}
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.Logs) && m.state == stateReleaseDetail:
+			if m.resourcesLoading && len(m.releaseResources) == 0 {
+				return m, m.setSuccessMsg("Release resources are still loading")
+			}
+			m.logTargets = nil
+			for _, res := range m.releaseResources {
+				if res.Kind != "Pod" || res.Status == "missing" {
+					continue
+				}
+				for _, container := range res.Containers {
+					title := res.Name
+					if len(res.Containers) > 1 {
+						title = res.Name + "/" + container
+					}
+					m.logTargets = append(m.logTargets, podLogTarget{
+						title:     title,
+						namespace: res.Namespace,
+						pod:       res.Name,
+						container: container,
+						status:    res.Status,
+					})
+				}
+			}
+			switch len(m.logTargets) {
+			case 0:
+				return m, m.setSuccessMsg("No pods found for this release")
+			case 1:
+				return m.followPodLogs(m.logTargets[0])
+			}
+			items := make([]list.Item, len(m.logTargets))
+			for i, target := range m.logTargets {
+				items[i] = listItem{title: target.title, description: target.status}
+			}
+			m.podPickerList.SetItems(items)
+			m.podPickerList.Select(0)
+			m.state = statePodPicker
+			return m, nil
+
+		case key.Matches(msg, m.keys.Follow) && m.state == statePodLogs:
+			m.logs.following = !m.logs.following
+			if m.logs.following {
+				m.searchMatches = []int{}
+				m.lastSearchQuery = ""
+			}
+			m.updatePodLogsView()
+			return m, nil
+
+		case key.Matches(msg, m.keys.ShowOnly) && m.state == stateTemplatePreview:
+			if m.loading {
+				return m, nil
+			}
+			m.mode = templateShowOnlyMode
+			m.templateSourceMatches = nil
+			m.searchInput.Reset()
+			m.searchInput.Placeholder = "templates/deployment.yaml (empty for all)..."
+			m.searchInput.SetValue(strings.Join(m.templateOpts.ShowOnly, ","))
+			m.searchInput.Focus()
+			return m, nil
+
+		case key.Matches(msg, m.keys.Files):
+			if m.state != stateChartDetail || m.diffMode || m.selectedChart >= len(m.charts) {
+				return m, nil
+			}
+			selectedItem := m.versionList.SelectedItem()
+			if selectedItem == nil {
+				return m, nil
+			}
+			for i, ver := range m.versions {
+				if "v"+ver.Version == selectedItem.(listItem).title {
+					m.selectedVersion = i
+					m.state = stateChartFiles
+					m.chartFiles = nil
+					m.chartFileList.SetItems([]list.Item{})
+					m.startLoading()
+					return m, loadChartFiles(m.helmClient, m.charts[m.selectedChart].Name, ver.Version)
+				}
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.Schema) && m.state == stateArtifactHubPackageDetail:
+			if m.ahSelectedPackage == nil {
+				return m, nil
+			}
+			m.state = stateChartSchema
+			m.schemaReturn = stateArtifactHubPackageDetail
+			m.valuesSchema = nil
+			m.schemaList.SetItems([]list.Item{})
+			m.startLoading()
+			return m, loadArtifactHubSchema(m.artifactHubClient, m.ahSelectedPackage)
+
+		case key.Matches(msg, m.keys.Schema):
+			if m.state != stateChartDetail || m.diffMode || m.selectedChart >= len(m.charts) {
+				return m, nil
+			}
+			selectedItem := m.versionList.SelectedItem()
+			if selectedItem == nil {
+				return m, nil
+			}
+			for i, ver := range m.versions {
+				if "v"+ver.Version == selectedItem.(listItem).title {
+					m.selectedVersion = i
+					m.state = stateChartSchema
+					m.schemaReturn = stateChartDetail
+					m.valuesSchema = nil
+					m.schemaList.SetItems([]list.Item{})
+					m.startLoading()
+					return m, loadValuesSchema(m.helmClient, m.charts[m.selectedChart].Name, ver.Version)
+				}
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.RenameRepo):
+			if m.state == stateRepoList && len(m.repos) > 0 {
+				selectedItem := m.repoList.SelectedItem()
+				if selectedItem != nil {
+					item := selectedItem.(listItem)
+					m.renameRepoFrom = item.title
+					m.mode = renameRepoMode
+					m.searchInput.Reset()
+					m.searchInput.Placeholder = fmt.Sprintf("New name for '%s'...", item.title)
+					m.searchInput.Focus()
+				}
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.RepoInfo):
+			if m.state == stateRepoList && len(m.repos) > 0 {
+				selectedItem := m.repoList.SelectedItem()
+				if selectedItem != nil {
+					item := selectedItem.(listItem)
+					for i, repo := range m.repos {
+						if repo.Name == item.title {
+							m.selectedRepo = i
+							break
+						}
+					}
+					m.state = stateRepoInfo
+					m.repoInfo = nil
+					m.startLoading()
+					return m, loadRepoInfo(m.helmClient, item.title)
+				}
+			}
+			if m.state == stateChartDetail && !m.diffMode && m.selectedChart < len(m.charts) {
+				selectedItem := m.versionList.SelectedItem()
+				if selectedItem == nil {
+					return m, nil
+				}
+				for i, ver := range m.versions {
+					if "v"+ver.Version == selectedItem.(listItem).title {
+						m.selectedVersion = i
+						m.state = stateChartInfo
+						m.chartInfo = nil
+						m.startLoading()
+						return m, loadChartInfo(m.helmClient, m.charts[m.selectedChart].Name, ver.Version)
+					}
+				}
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.UpdateRepo):
+			if m.state == stateRepoInfo && m.repoInfo != nil {
+				repoName := m.repoInfo.Name
+				m.startLoading()
+				return m, func() tea.Msg {
+					if err := m.helmClient.UpdateRepository(repoName); err != nil {
+						return repoInfoLoadedMsg{err: err}
+					}
+					info, err := m.helmClient.GetRepositoryInfo(repoName)
+					return repoInfoLoadedMsg{info: info, success: fmt.Sprintf("Repository '%s' updated successfully", repoName), err: err}
+				}
+			}
+			if m.state == stateRepoList && len(m.repos) > 0 {
+				selectedItem := m.repoList.SelectedItem()
+				if selectedItem != nil {
+					item := selectedItem.(listItem)
+					repoName := item.title
+					updateCmd := func() tea.Msg {
+						err := m.helmClient.UpdateRepository(repoName)
+						if err != nil {
+							return operationDoneMsg{err: err}
+						}
+						return operationDoneMsg{success: fmt.Sprintf("Repository '%s' updated successfully", repoName)}
+					}
+					m.lastAction = &repeatableAction{label: fmt.Sprintf("update repository '%s'", repoName), cmd: updateCmd}
+					return m, m.background(updateCmd)
+				}
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.Export):
+			if m.state == stateChartDetail || m.state == stateValueViewer || m.state == stateReleaseValues {
+				m.mode = exportValuesMode
+				m.searchInput.Reset()
+				m.searchInput.Placeholder = m.config.ExportPath
+				m.searchInput.Focus()
+			}
+			if m.state == stateRepoList {
+				m.mode = exportReposMode
+				m.searchInput.Reset()
+				m.searchInput.Placeholder = "./repositories.yaml"
+				m.searchInput.Focus()
+			}
+			if m.state == stateDiffViewer && m.diffLines != nil {
+				m.mode = exportDiffMode
+				m.searchInput.Reset()
+				m.searchInput.Placeholder = "./changes.diff"
+				m.searchInput.Focus()
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.Manifest):
+			if m.state == stateReleaseHistory && !m.diffMode && len(m.releaseHistory) > 1 {
+				m.diffMode = true
+				m.diffManifests = true
+				m.compareRevision = m.releaseHistoryList.Index()
+				return m, nil
+			}
+			if (m.state == stateChartDetail && !m.diffMode) || m.state == stateValueViewer {
+				ref, ok := m.selectedChartRef()
+				if !ok {
+					return m, nil
+				}
+				m.manifestRef = ref
+				m.mode = manifestFormatMode
+				m.searchInput.Reset()
+				m.searchInput.Placeholder = gitops.Formats[0]
+				m.searchInput.Focus()
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.Notes) && (m.state == stateReleaseList || m.state == stateReleaseDetail):
+			release, ok := m.currentRelease()
+			if !ok {
+				return m, nil
+			}
+			for i, r := range m.releases {
+				if r == release {
+					m.selectedRelease = i
+				}
+			}
+			m.releaseReturn = m.state
+			m.state = stateReleaseNotes
+			m.releaseNotes = ""
+			m.releaseNotesLines = nil
+			m.searchMatches = []int{}
+			m.lastSearchQuery = ""
+			m.startLoading()
+			return m, m.inCluster(loadReleaseNotes(m.loadContext(stateReleaseNotes), m.helmClient, release.Name, release.Namespace))
+
+		case key.Matches(msg, m.keys.Drift) && (m.state == stateReleaseList || m.state == stateReleaseDetail):
+			release, ok := m.currentRelease()
+			if !ok {
+				return m, nil
+			}
+			for i, r := range m.releases {
+				if r == release {
+					m.selectedRelease = i
+				}
+			}
+			m.releaseReturn = m.state
+			m.state = stateReleaseDrift
+			m.releaseDriftLines = nil
+			m.startLoading()
+			return m, m.inCluster(loadReleaseDrift(m.loadContext(stateReleaseDrift), m.helmClient, release.Name, release.Namespace))
+
+		case key.Matches(msg, m.keys.Events) && (m.state == stateReleaseList || m.state == stateReleaseDetail):
+			release, ok := m.currentRelease()
+			if !ok {
+				return m, nil
+			}
+			for i, r := range m.releases {
+				if r == release {
+					m.selectedRelease = i
+				}
+			}
+			m.releaseReturn = m.state
+			m.state = stateReleaseEvents
+			m.releaseEventsLines = nil
+			m.startLoading()
+			return m, m.inCluster(loadReleaseEvents(m.loadContext(stateReleaseEvents), m.helmClient, release.Name, release.Namespace))
+
+		case key.Matches(msg, m.keys.Open):
+			if m.state == stateChartInfo && m.chartInfo != nil && m.chartInfo.Home != "" {
+				return m, openURL(m.chartInfo.Home)
+			}
+			if m.state == stateChartDetail && !m.diffMode && m.selectedChart < len(m.charts) {
+				var version string
+				if selectedItem := m.versionList.SelectedItem(); selectedItem != nil {
+					version = strings.TrimPrefix(selectedItem.(listItem).title, "v")
+				}
+				return m, openChartHome(m.helmClient, m.charts[m.selectedChart].Name, version)
+			}
+			if (m.state == stateArtifactHubPackageDetail || m.state == stateArtifactHubVersions) && m.ahSelectedPackage != nil {
+				pkg := m.ahSelectedPackage
+				return m, openURL(artifacthub.PackageURL(pkg.Repository.Name, pkg.Name))
+			}
+			return m, nil
+
+		case (key.Matches(msg, m.keys.NextResource) || key.Matches(msg, m.keys.PrevResource)) && m.state == stateTemplatePreview:
+			if res, ok := m.adjacentTemplateResource(key.Matches(msg, m.keys.NextResource)); ok {
+				m.templateView.SetYOffset(res.Line)
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.Template):
+			if m.state == stateChartDetail || m.state == stateValueViewer {
+				m.mode = templatePathMode
+				m.searchInput.Reset()
+				m.searchInput.Placeholder = m.config.TemplatePath
+				m.searchInput.Focus()
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.ArtifactHub):
+			if m.state == stateRepoList {
+				m.mode = searchMode
+				m.searchInput.Reset()
+				m.searchInput.Placeholder = "Search Artifact Hub..."
+				m.searchInput.Focus()
+				m.state = stateArtifactHubSearch
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.ClearFilter):
+			// Clear filters and restore full lists
+			var clearCmd tea.Cmd
+			switch m.state {
+			case stateRepoList:
+				m.repoList.SetItems(m.repoItems(m.repos))
+				clearCmd = m.setSuccessMsg("Filter cleared")
+
+			case stateChartList:
+				m.repoGrep = nil
+				m.chartList.SetItems(m.chartListItems(m.charts))
+				clearCmd = m.setSuccessMsg("Filter cleared")
+
+			case stateChartDetail:
+				m.versionList.SetItems(m.versionListItems(m.versions))
+				clearCmd = m.setSuccessMsg("Filter cleared")
+
+			case stateArtifactHubSearch:
+				// The search filters, e.g. the publisher set by p, stick
+				// across searches until cleared
+				if m.ahFilters.String() != "" && !m.ahLoading {
+					m.ahFilters = artifacthub.SearchOptions{Sort: m.ahFilters.Sort}
+					m.ahLoading = true
+					return m, tea.Batch(searchArtifactHub(m.artifactHubClient, m.ahQuery, m.ahFilters, 0), m.setSuccessMsg("Search filters cleared"))
+				}
+				m.ahPackageList.SetItems(ahPackageItems(m.ahPackages))
+				clearCmd = m.setSuccessMsg("Filter cleared")
+
+			case stateReleaseList:
+				if m.releaseGrep != nil {
+					m.startLoading()
+					return m, tea.Batch(m.loadReleases(m.selectedNamespace), m.setSuccessMsg("Values search cleared"))
+				}
+				if m.releaseSelector != "" {
+					m.releaseSelector = ""
+					m.startLoading()
+					return m, tea.Batch(m.loadReleases(m.selectedNamespace), m.setSuccessMsg("Label selector cleared"))
+				}
+				m.releaseFilter = ""
+				m.releaseList.SetItems(m.releaseListItems(m.releases))
+				clearCmd = m.setSuccessMsg("Filter cleared")
+			}
+			return m, clearCmd
+
+		case key.Matches(msg, m.keys.Deprecated):
+			if m.state == stateChartList {
+				m.hideDeprecated = !m.hideDeprecated
+				m.chartList.SetItems(m.chartListItems(m.charts))
+				if m.hideDeprecated {
+					return m, m.setSuccessMsg("Hiding deprecated charts")
+				}
+				return m, m.setSuccessMsg("Showing deprecated charts")
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.Versions):
+			if m.state == stateChartList && len(m.charts) > 0 {
+				return m.handleEnter()
+			}
+			if m.state == stateArtifactHubPackageDetail && m.ahSelectedPackage != nil {
+				m.state = stateArtifactHubVersions
+			}
+			if m.state == stateReleaseDetail && m.selectedRelease < len(m.releases) {
+				release := m.releases[m.selectedRelease]
+				m.state = stateReleaseValues
+				m.loadingVals = true
+				return m, m.inCluster(loadReleaseValues(m.loadContext(stateReleaseValues), m.helmClient, release.Name, release.Namespace))
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.Copy):
+			if m.state == stateReleaseNotes && m.releaseNotes != "" {
+				if err := m.copyToClipboard(m.releaseNotes); err != nil {
+					return m, m.setSuccessMsg("Failed to copy to clipboard")
+				}
+				return m, m.setSuccessMsg("Copied release notes")
+			}
+			if m.state == stateChartDetail && !m.diffMode && m.selectedChart < len(m.charts) {
+				selectedItem := m.versionList.SelectedItem()
+				if selectedItem == nil {
+					return m, nil
+				}
+				version := strings.TrimPrefix(selectedItem.(listItem).title, "v")
+				snippet := m.installSnippet(m.charts[m.selectedChart].Name, version)
+				if err := m.copyToClipboard(snippet); err != nil {
+					return m, m.setSuccessMsg("Failed to copy to clipboard")
+				}
+				return m, m.setSuccessMsg("Copied: " + snippet)
+			}
+			if lines, lineNum, ok := m.valuesCursor(); ok {
+				yamlPath := m.yamlPath(lines, lineNum, false)
+				var copyCmd tea.Cmd
+				if yamlPath != "" {
+					err := m.copyToClipboard(yamlPath)
+					if err != nil {
+						copyCmd = m.setSuccessMsg("Failed to copy to clipboard")
+					} else {
+						copyCmd = m.setSuccessMsg("Copied: " + yamlPath)
+					}
+				} else {
+					copyCmd = m.setSuccessMsg("No YAML path found for current line")
+				}
+				return m, copyCmd
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.CopyAltPath):
+			if lines, lineNum, ok := m.valuesCursor(); ok {
+				yamlPath := m.yamlPath(lines, lineNum, true)
+				if yamlPath == "" {
+					return m, m.setSuccessMsg("No YAML path found for current line")
+				}
+				if err := m.copyToClipboard(yamlPath); err != nil {
+					return m, m.setSuccessMsg("Failed to copy to clipboard")
+				}
+				return m, m.setSuccessMsg("Copied: " + yamlPath)
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.CopyPair):
+			if lines, lineNum, ok := m.valuesCursor(); ok {
+				yamlPath := m.yamlPath(lines, lineNum, false)
+				if yamlPath == "" {
+					return m, m.setSuccessMsg("No YAML path found for current line")
+				}
+				value := ui.GetYAMLValue(lines[lineNum])
+				if value == "" {
+					return m, m.setSuccessMsg("No scalar value on current line")
+				}
+				text := yamlPath + ": " + value
+				if err := m.copyToClipboard(text); err != nil {
+					return m, m.setSuccessMsg("Failed to copy to clipboard")
+				}
+				return m, m.setSuccessMsg("Copied: " + text)
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.CopyValue):
+			if lines, lineNum, ok := m.valuesCursor(); ok {
+				text := ui.GetYAMLBlock(lines, lineNum)
+				status := fmt.Sprintf("Copied block (%d lines)", strings.Count(text, "\n")+1)
+				if text == "" {
+					text = ui.GetYAMLValue(lines[lineNum])
+					status = "Copied: " + text
+				}
+				if text == "" {
+					return m, m.setSuccessMsg("No value on current line")
+				}
+				if err := m.copyToClipboard(text); err != nil {
+					return m, m.setSuccessMsg("Failed to copy to clipboard")
+				}
+				return m, m.setSuccessMsg(status)
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.ValuesGrep) && m.state == stateReleaseList:
+			if m.loading {
+				return m, nil
+			}
+			m.successMsg = ""
+			m.mode = releaseGrepMode
+			m.searchInput.Reset()
+			m.searchInput.Placeholder = "YAML path or text, e.g. resources.limits (-A: all namespaces)"
+			m.searchInput.Focus()
+			return m, nil
+
+		case key.Matches(msg, m.keys.ValuesGrep) && m.state == stateChartList:
+			if m.loading || len(m.charts) == 0 {
+				return m, nil
+			}
+			m.successMsg = ""
+			m.mode = repoGrepMode
+			m.searchInput.Reset()
+			m.searchInput.Placeholder = "YAML path or text, e.g. image.registry"
+			m.searchInput.Focus()
+			return m, nil
+
+		case (key.Matches(msg, m.keys.SearchCase) || key.Matches(msg, m.keys.SearchWord)) && m.searchesLines():
+			m.toggleSearchOption(msg)
+			if m.lastSearchQuery != "" {
+				m = m.searchAgain(m.lastSearchQuery)
+			}
+			return m, m.setSuccessMsg("Search: " + m.searchOptionsLabel())
+
+		case key.Matches(msg, m.keys.LineNumbers) && (m.state == stateValueViewer || m.state == stateReleaseValues || m.state == stateDiffViewer):
+			m.lineNumbers = !m.lineNumbers
+			switch m.state {
+			case stateValueViewer:
+				m.updateValuesViewWithSearch()
+			case stateReleaseValues:
+				m.updateReleaseValuesViewWithSearch()
+			default:
+				m.updateDiffViewWithSearch()
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.CopySetFlag):
+			if lines, lineNum, ok := m.valuesCursor(); ok {
+				flag := ui.GetYAMLSetFlag(lines, lineNum)
+				if flag == "" {
+					return m, m.setSuccessMsg("No scalar value on current line")
+				}
+				if err := m.copyToClipboard(flag); err != nil {
+					return m, m.setSuccessMsg("Failed to copy to clipboard")
+				}
+				return m, m.setSuccessMsg("Copied: " + flag)
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.Test):
+			if release, ok := m.currentRelease(); ok {
+				for i, r := range m.releases {
+					if r == release {
+						m.selectedRelease = i
+					}
+				}
+				m.test = &releaseTest{release: release, running: true, returnTo: m.state}
+				m.state = stateReleaseTest
+				m.releaseTestView.SetContent("")
+				return m, startReleaseTest(m.helmClient, m.test, m.config.OperationTimeout())
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.Preview):
+			if release, ok := m.currentRelease(); ok {
+				if !m.helmClient.HasDiffPlugin() {
+					return m, m.setSuccessMsg("Upgrade preview needs the helm-diff plugin: helm plugin install https://github.com/databus23/helm-diff")
+				}
+				m.upgradeRel = release
+				m.upgradeOpts = helm.DiffUpgradeOptions{}
+				m.upgradeDryRun = false
+				m.mode = upgradeChartMode
+				m.searchInput.Reset()
+				m.searchInput.Placeholder = m.guessChartRef(release)
+				m.searchInput.Focus()
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.DryRun):
+			// Same questions as P for a release, install ones for a chart
+			if release, ok := m.currentRelease(); ok {
+				m.upgradeRel = release
+				m.upgradeOpts = helm.DiffUpgradeOptions{}
+				m.upgradeDryRun = true
+				m.mode = upgradeChartMode
+				m.searchInput.Reset()
+				m.searchInput.Placeholder = m.guessChartRef(release)
+				m.searchInput.Focus()
+				return m, nil
+			}
+			if m.selectedChart >= len(m.charts) || (m.state != stateChartDetail && m.state != stateValueViewer) || m.diffMode {
+				return m, nil
+			}
+			chartName := m.charts[m.selectedChart].Name
+			m.upgradeOpts = helm.DiffUpgradeOptions{Chart: chartName}
+			if m.state == stateValueViewer && m.selectedVersion < len(m.versions) {
+				m.upgradeOpts.Version = m.versions[m.selectedVersion].Version
+			} else if selectedItem := m.versionList.SelectedItem(); selectedItem != nil {
+				m.upgradeOpts.Version = strings.TrimPrefix(selectedItem.(listItem).title, "v")
+			}
+			m.upgradeRel = helm.Release{}
+			m.upgradeDryRun = true
+			m.mode = dryRunReleaseMode
+			m.searchInput.Reset()
+			m.searchInput.Placeholder = chartName[strings.LastIndex(chartName, "/")+1:]
+			m.searchInput.Focus()
+			return m, nil
+
+		case key.Matches(msg, m.keys.Uninstall):
+			if release, ok := m.currentRelease(); ok {
+				m.uninstallRel = release
+				m.uninstallOpts = helm.UninstallOptions{}
+				m.mode = uninstallKeepHistoryMode
+				m.searchInput.Reset()
+				m.searchInput.Placeholder = "Keep release history (--keep-history)? (y/N)"
+				m.searchInput.Focus()
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.Publisher) && m.state == stateArtifactHubPackageDetail:
+			if m.ahSelectedPackage == nil {
+				return m, nil
+			}
+			// Everything from the organization, or from the repository of
+			// packages published by a user
+			repo := m.ahSelectedPackage.Repository
+			m.ahFilters = artifacthub.SearchOptions{Sort: m.ahFilters.Sort}
+			if repo.OrganizationName != "" {
+				m.ahFilters.Org = repo.OrganizationName
+			} else {
+				m.ahFilters.Repo = repo.Name
+			}
+			m.state = stateArtifactHubSearch
+			m.ahSelectedPackage = nil
+			m.ahVersionList.SetItems([]list.Item{})
+			m.ahLoading = true
+			return m, searchArtifactHub(m.artifactHubClient, "", m.ahFilters, 0)
+
+		case key.Matches(msg, m.keys.DiffBase) && m.state == stateChartDetail:
+			selectedItem := m.versionList.SelectedItem()
+			if selectedItem == nil || m.selectedChart >= len(m.charts) {
+				return m, nil
+			}
+			ref := chartVersionRef{chart: m.charts[m.selectedChart].Name, version: strings.TrimPrefix(selectedItem.(listItem).title, "v")}
+			switch {
+			case m.diffBase == nil:
+				m.diffBase = &ref
+				return m, m.setSuccessMsgFor(fmt.Sprintf("Pinned %s v%s: press B on a version of another chart to compare", ref.chart, ref.version), 5*time.Second)
+			case *m.diffBase == ref:
+				m.diffBase = nil
+				return m, m.setSuccessMsg("Unpinned " + ref.chart + " v" + ref.version)
+			}
+
+			base := *m.diffBase
+			m.diffBase = nil
+			m.diffLines = nil
+			m.diffView.SetContent("")
+			m.state = stateDiffViewer
+			m.diffMode = false
+			m.startLoading()
+			labels := [2]string{base.chart + " v" + base.version, ref.chart + " v" + ref.version}
+			return m, loadVersionDiff(m.helmClient, m.cache, [2]chartVersionRef{base, ref}, labels)
+
+		case key.Matches(msg, m.keys.PathDiff) && m.state == stateDiffViewer:
+			if m.valuesDiff == nil {
+				return m, m.setSuccessMsg("Only values diffs can be compared by YAML path")
+			}
+			diffContent := m.valuesDiff.lineDiff
+			if !m.valuesDiff.byPath {
+				changes, err := ui.DiffPaths(m.valuesDiff.old, m.valuesDiff.new)
+				if err != nil {
+					return m, m.setSuccessMsg(err.Error())
+				}
+				diffContent = renderPathDiff(changes, m.valuesDiff.label1, m.valuesDiff.label2)
+			}
+			m.valuesDiff.byPath = !m.valuesDiff.byPath
+			m.setDiffContent(diffContent)
+			m.diffView.GotoTop()
+			m.searchMatches = []int{}
+			m.lastSearchQuery = ""
+			return m, nil
+
+		case key.Matches(msg, m.keys.Filters) && m.state == stateArtifactHubSearch:
+			if m.ahLoading {
+				return m, nil
+			}
+			m.mode = ahFilterMode
+			m.searchInput.Reset()
+			m.searchInput.Placeholder = "verified official signed license=<SPDX> org=<name> repo=<name>"
+			m.searchInput.SetValue(m.ahFilters.String())
+			m.searchInput.Focus()
+			return m, nil
+
+		case key.Matches(msg, m.keys.Sort) && m.state == stateArtifactHubSearch:
+			if m.ahLoading {
+				return m, nil
+			}
+			m.ahFilters.Sort = artifacthub.NextSort(m.ahFilters.Sort)
+			m.ahLoading = true
+			return m, searchArtifactHub(m.artifactHubClient, m.ahQuery, m.ahFilters, 0)
+
+		case key.Matches(msg, m.keys.Fix):
+			if release, ok := m.currentRelease(); ok {
+				if !helm.IsPendingStatus(release.Status) {
+					return m, m.setSuccessMsg(fmt.Sprintf("'%s' is %s, not stuck in a pending state", release.Name, release.Status))
+				}
+				m.fixRel = release
+				m.mode = fixPendingMode
+				m.searchInput.Reset()
+				m.searchInput.Placeholder = fmt.Sprintf("'%s' is stuck in %s: (r)ollback, (d)elete the pending revision lock, (u)ninstall?", release.Name, release.Status)
+				m.searchInput.Focus()
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.Favorite) && (m.state == stateChartList || m.state == stateChartDetail || m.state == stateReleaseList || m.state == stateReleaseDetail || m.state == stateFavorites):
+			return m.toggleFavorite()
+
+		case key.Matches(msg, m.keys.Mark) && m.state == stateReleaseList:
+			release, ok := m.currentRelease()
+			if !ok {
+				return m, nil
+			}
+			if m.markedReleases == nil {
+				m.markedReleases = make(map[string]bool)
+			}
+			if m.markedReleases[releaseKey(release)] {
+				delete(m.markedReleases, releaseKey(release))
+			} else {
+				m.markedReleases[releaseKey(release)] = true
+			}
+			index := m.releaseList.Index()
+			m.releaseList.SetItem(index, listItem{title: release.Name, description: m.releaseDescription(release), namespace: release.Namespace})
+			m.releaseList.CursorDown()
+			return m, nil
+
+		case key.Matches(msg, m.keys.Batch) && m.state == stateReleaseList:
+			marked := m.marked()
+			if len(marked) == 0 {
+				return m, m.setSuccessMsg("Mark releases with space first")
+			}
+			m.mode = batchActionMode
+			m.searchInput.Reset()
+			m.searchInput.Placeholder = fmt.Sprintf("%d releases marked: (e)xport values, (u)pdate their repositories, (x) uninstall?", len(marked))
+			m.searchInput.Focus()
+			return m, nil
+
+		case key.Matches(msg, m.keys.Selector):
+			if m.state == stateReleaseList {
+				m.mode = selectorMode
+				m.searchInput.Reset()
+				m.searchInput.SetValue(m.releaseSelector)
+				m.searchInput.Placeholder = "team=payments,tier!=dev"
+				m.searchInput.Focus()
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.Validate):
+			if m.state == stateValueViewer && m.selectedChart < len(m.charts) && m.selectedVersion < len(m.versions) {
+				m.mode = validateValuesMode
+				m.searchInput.Reset()
+				m.searchInput.Placeholder = "./values.yaml"
+				m.searchInput.Focus()
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.Diff):
+			if m.state == stateValueViewer && m.values != "" {
+				m.mode = localDiffMode
+				m.searchInput.Reset()
+				m.searchInput.Placeholder = "./values.yaml"
+				m.searchInput.Focus()
+				return m, nil
+			}
+			if m.state == stateReleaseValues && !m.loadingVals && m.selectedRelease < len(m.releases) {
+				return m.diffReleaseOverrides()
+			}
+			if m.state == stateChartDetail && len(m.versions) > 1 {
+				m.diffMode = true
+				m.compareVersion = m.versionList.Index()
+			} else if m.state == stateReleaseHistory && len(m.releaseHistory) > 1 {
+				m.diffMode = true
+				m.diffManifests = false
+				m.compareRevision = m.releaseHistoryList.Index()
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.Edit):
+			if m.state == stateValueViewer {
+				if m.values == "" {
+					return m, m.setSuccessMsg("No values to edit")
+				}
+				// Show which editor will be used
+				editor := m.editorCommand()
+				editorCmd := m.setSuccessMsg(fmt.Sprintf("Opening %s...", editor))
+				return m, tea.Batch(editorCmd, openEditorCmd(editor, m.values))
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.NextMatch):
+			if (m.state == stateValueViewer || m.state == stateDiffViewer || m.state == stateReleaseValues || m.state == stateReleaseDetail || m.state == stateChartReadme || m.state == stateReleaseNotes || m.state == statePodLogs || m.state == stateTemplatePreview) && len(m.searchMatches) > 0 {
+				m.currentMatchIndex = (m.currentMatchIndex + 1) % len(m.searchMatches)
+				if m.state == stateValueViewer {
+					m.updateValuesViewWithSearch()
+				} else if m.state == stateDiffViewer {
+					m.updateDiffViewWithSearch()
+				} else if m.state == stateReleaseValues {
+					m.updateReleaseValuesViewWithSearch()
+				} else if m.state == stateReleaseDetail {
+					m.updateReleaseDetailView()
+				} else if m.state == stateChartReadme {
+					m.updateReadmeViewWithSearch()
+				} else if m.state == stateReleaseNotes {
+					m.updateReleaseNotesViewWithSearch()
+				} else if m.state == statePodLogs {
+					m.updatePodLogsView()
+				} else if m.state == stateTemplatePreview {
+					m.updateTemplateViewWithSearch()
+				}
+				return m.jumpToMatch(), nil
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.PrevMatch):
+			if (m.state == stateValueViewer || m.state == stateDiffViewer || m.state == stateReleaseValues || m.state == stateReleaseDetail || m.state == stateChartReadme || m.state == stateReleaseNotes || m.state == statePodLogs || m.state == stateTemplatePreview) && len(m.searchMatches) > 0 {
+				m.currentMatchIndex = (m.currentMatchIndex - 1 + len(m.searchMatches)) % len(m.searchMatches)
+				if m.state == stateValueViewer {
+					m.updateValuesViewWithSearch()
+				} else if m.state == stateDiffViewer {
+					m.updateDiffViewWithSearch()
+				} else if m.state == stateReleaseValues {
+					m.updateReleaseValuesViewWithSearch()
+				} else if m.state == stateReleaseDetail {
+					m.updateReleaseDetailView()
+				} else if m.state == stateChartReadme {
+					m.updateReadmeViewWithSearch()
+				} else if m.state == stateReleaseNotes {
+					m.updateReleaseNotesViewWithSearch()
+				} else if m.state == statePodLogs {
+					m.updatePodLogsView()
+				} else if m.state == stateTemplatePreview {
+					m.updateTemplateViewWithSearch()
+				}
+				return m.jumpToMatch(), nil
+			}
+			return m, nil
+
+		case msg.String() == "h":
+			// 'h' for history in release detail view
+			if m.state == stateReleaseDetail && m.selectedRelease < len(m.releases) {
+				m.state = stateReleaseHistory
+				return m, nil
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.Left):
+			// Horizontal scroll left (arrow key only)
+			if m.state == stateValueViewer {
+				if m.horizontalOffset > 0 {
+					m.horizontalOffset -= 5
+					if m.horizontalOffset < 0 {
+						m.horizontalOffset = 0
+					}
+					m.updateValuesViewWithSearch()
+				}
+			} else if m.state == stateReleaseValues {
+				if m.horizontalOffset > 0 {
+					m.horizontalOffset -= 5
+					if m.horizontalOffset < 0 {
+						m.horizontalOffset = 0
+					}
+					m.updateReleaseValuesViewWithSearch()
+				}
+			} else if m.state == stateReleaseDetail {
+				if m.horizontalOffset > 0 {
+					m.horizontalOffset -= 5
+					if m.horizontalOffset < 0 {
+						m.horizontalOffset = 0
+					}
+					m.updateReleaseDetailView()
+				}
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.Right):
+			// Horizontal scroll right (arrow key only)
+			if m.state == stateValueViewer {
+				m.horizontalOffset += 5
+				m.updateValuesViewWithSearch()
+			} else if m.state == stateReleaseValues {
+				m.horizontalOffset += 5
+				m.updateReleaseValuesViewWithSearch()
+			} else if m.state == stateReleaseDetail {
+				m.horizontalOffset += 5
+				m.updateReleaseDetailView()
+			}
+			return m, nil
+		}
+
+	case chartsLoadedMsg:
+		if m.state != stateChartList {
+			m.loading = false
+			return m, nil
+		}
+		// Another repository was opened since: its load is still running
+		if m.selectedRepo >= len(m.repos) || m.repos[m.selectedRepo].Name != msg.repo {
+			return m, nil
+		}
+		m.loading = false
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+
+		m.charts = msg.charts
+		m.repoGrep = nil
+		m.chartList.SetItems(m.chartListItems(msg.charts))
+		if p := m.pendingOpen; p != nil && p.Kind == config.KindChart {
+			for i := range m.charts {
+				if m.charts[i].Name == p.Name {
+					return m.gotoTarget(gotoTarget{repo: m.selectedRepo, chart: &m.charts[i], charts: m.charts, release: -1})
+				}
+			}
+			m.pendingOpen = nil
+			return m, m.setSuccessMsg(fmt.Sprintf("%s isn't in the repository anymore", p.Name))
+		}
+		return m, nil
+
+	case repoGrepDoneMsg:
+		// Dropped when the chart list was left, which ends the grep's loading
+		// too unless another load has started since
+		if errors.Is(msg.err, context.Canceled) || m.state != stateChartList || m.selectedRepo >= len(m.repos) || m.repos[m.selectedRepo].Name != msg.repo {
+			if msg.gen == m.loadGen {
+				m.loading = false
+			}
+			return m, nil
+		}
+		m.loading = false
+		m.repoGrep = msg.grep
+		m.chartList.SetItems(m.chartListItems(m.charts))
+		m.chartList.ResetSelected()
+		status := fmt.Sprintf("%d of %d charts match '%s'", len(msg.grep.matches), len(m.charts), msg.grep.query)
+		if msg.grep.failed > 0 {
+			status += fmt.Sprintf(" (%d charts couldn't be fetched)", msg.grep.failed)
+		}
+		return m, m.setSuccessMsg(status)
+
+	case releaseGrepDoneMsg:
+		// Dropped when the release list was left, which ends the grep's
+		// loading too unless another load has started since
+		if errors.Is(msg.err, context.Canceled) || m.state != stateReleaseList {
+			if msg.gen == m.loadGen {
+				m.loading = false
+			}
+			return m, nil
+		}
+		m.loading = false
+		if msg.err != nil {
+			return m, m.setSuccessMsg(msg.err.Error())
+		}
+		m.releases = msg.releases
+		m.markedReleases = nil
+		m.releaseGrep = msg.grep
+		m.releaseFilter = ""
+		m.releaseList.SetItems(m.releaseListItems(m.releases))
+		m.releaseList.ResetSelected()
+		status := fmt.Sprintf("%d release(s) set '%s'", len(msg.releases), msg.grep.query)
+		if msg.grep.failed > 0 {
+			status += fmt.Sprintf(" (%d releases couldn't be read)", msg.grep.failed)
+		}
+		return m, m.setSuccessMsg(status)
+
+	case versionsLoadedMsg:
+		if m.state != stateChartDetail {
+			m.loading = false
+			return m, nil
+		}
+		// Another chart was opened since: its load is still running
+		if m.selectedChart >= len(m.charts) || m.charts[m.selectedChart].Name != msg.chart {
+			return m, nil
+		}
+		m.loading = false
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+
+		m.versions = msg.versions
+		m.versionList.SetItems(m.versionListItems(msg.versions))
+		var cmds []tea.Cmd
+		if !m.clusterChecked {
+			m.clusterChecked = true
+			cmds = append(cmds, m.inCluster(loadClusterVersion(m.loadContext(stateChartDetail), m.helmClient)))
+		}
+		if p := m.pendingOpen; p != nil && p.Kind == config.KindChart {
+			m.pendingOpen = nil
+			if p.Version == "" {
+				// A favorite chart opens on its version list
+				return m, tea.Batch(cmds...)
+			}
+			found := false
+			for i, item := range m.versionList.Items() {
+				if item.(listItem).title == "v"+p.Version {
+					m.versionList.Select(i)
+					found = true
+					break
+				}
+			}
+			if !found {
+				cmds = append(cmds, m.setSuccessMsg(fmt.Sprintf("Version %s of %s isn't listed anymore", p.Version, p.Name)))
+				return m, tea.Batch(cmds...)
+			}
+			updated, cmd := m.handleEnter()
+			return updated, tea.Batch(append(cmds, cmd)...)
+		}
+		return m, tea.Batch(cmds...)
+
+	case valuesLoadedMsg:
+		if m.state != stateValueViewer {
+			m.loadingVals = false
+			return m, nil
+		}
+		// Another version was opened since: its load is still running
+		if !m.showsChartVersion(msg.chart, msg.version) {
+			return m, nil
+		}
+		m.loadingVals = false
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+
+		m.values = msg.values
+		m.valuesLines = strings.Split(msg.values, "\n")
+		m.valuesOutline = false
+		highlighted := ui.HighlightYAMLContent(msg.values)
+		m.valuesView.SetContent(highlighted)
+		m.updateValuesViewWithSearch()
+		return m, nil
+
+	case spinner.TickMsg:
+		if !m.busy() {
+			m.spinning = false
+			return m, nil
+		}
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case backgroundDoneMsg:
+		m.backgroundOps--
+		if msg.msg == nil {
+			return m, nil
+		}
+		return m.Update(msg.msg)
+
+	case clusterLoadedMsg:
+		// Loaded from the previous kube context
+		if msg.gen != m.clusterGen || msg.msg == nil {
+			return m, nil
+		}
+		return m.Update(msg.msg)
+
+	case operationDoneMsg:
+		if msg.background {
+			m.backgroundOps--
+		}
+		if msg.err != nil {
+			m.logAction(msg.err.Error(), true)
+		} else {
+			m.logAction(msg.success, false)
+		}
+		var notifyCmd tea.Cmd
+		if msg.background && msg.origin != m.state {
+			notifyCmd = m.notify(msg)
+		}
+		if msg.err != nil {
+			m.err = msg.err
+			return m, notifyCmd
+		} else if msg.refresh && (m.state == stateReleaseList || m.state == stateReleaseDetail) {
+			m.state = stateReleaseList
+			m.startLoading()
+			return m, tea.Batch(m.setSuccessMsg(msg.success), notifyCmd, m.loadReleases(m.selectedNamespace))
+		} else {
+			return m, tea.Batch(m.setSuccessMsg(msg.success), notifyCmd)
+		}
+
+
+	case repoRefreshedMsg:
+		delete(m.repoUpdating, msg.name)
+		if msg.err != nil {
+			m.logAction(fmt.Sprintf("Background update of repository '%s' failed: %v", msg.name, msg.err), true)
+		} else {
+			delete(m.chartCache, msg.name)
+		}
+		for i := range m.repos {
+			if m.repos[i].Name != msg.name {
+				continue
+			}
+			if msg.err == nil {
+				m.repos[i].IndexUpdated = time.Now()
+			}
+			// Only the badge changes, so a filtered list stays filtered
+			items := m.repoList.Items()
+			for j, item := range items {
+				if item.(listItem).title == msg.name {
+					items[j] = m.repoItems(m.repos[i : i+1])[0]
+				}
+			}
+			m.repoList.SetItems(items)
+		}
+		return m, nil
+
+	case reposImportedMsg:
+		m.loading = false
+		if msg.repos != nil {
+			m.repos = orderRepos(msg.repos, m.config)
+			m.repoList.SetItems(m.repoItems(m.repos))
+		}
+		var summary string
+		if msg.result != nil {
+			for _, name := range slices.Concat(msg.result.Added, msg.result.Updated, msg.result.Removed) {
+				delete(m.chartCache, name)
+			}
+			summary = fmt.Sprintf("Imported repositories: %d added, %d updated, %d removed, %d unchanged",
+				len(msg.result.Added), len(msg.result.Updated), len(msg.result.Removed), len(msg.result.Skipped))
+		}
+		if msg.err != nil {
+			if summary != "" {
+				summary += "; "
+			}
+			summary += msg.err.Error()
+		}
+		m.logAction(summary, msg.err != nil)
+		return m, m.setSuccessMsgFor(summary, 10*time.Second)
+
+	case batchUninstalledMsg:
+		for _, release := range msg.uninstalled {
+			m.logAction(fmt.Sprintf("Release '%s' uninstalled from '%s'", release.Name, release.Namespace), false)
+		}
+		var failed []string
+		for i, release := range msg.failed {
+			m.logAction(msg.errs[i].Error(), true)
+			failed = append(failed, release.Name)
+		}
+		summary := fmt.Sprintf("%d of %d releases uninstalled", len(msg.uninstalled), len(msg.uninstalled)+len(msg.failed))
+		if len(failed) > 0 {
+			summary += fmt.Sprintf("; failed: %s (see the action log)", strings.Join(failed, ", "))
+		}
+		if m.state != stateReleaseList {
+			return m, m.setSuccessMsgFor(summary, 10*time.Second)
+		}
+		m.startLoading()
+		return m, tea.Batch(m.setSuccessMsgFor(summary, 10*time.Second), m.loadReleases(m.selectedNamespace))
+
+	case reposReloadedMsg:
+		m.loading = false
+		if msg.err == nil {
+			m.repos = orderRepos(msg.repos, m.config)
+			m.repoList.SetItems(m.repoItems(m.repos))
+			m.mode = normalMode
+			m.logAction(fmt.Sprintf("Repository '%s' added", m.newRepoName), false)
+			return m, m.setSuccessMsg(fmt.Sprintf("Repository '%s' added successfully", m.newRepoName))
+		}
+		m.logAction(msg.err.Error(), true)
+		return m, m.setSuccessMsgFor(msg.err.Error(), 10*time.Second)
+
+	case repoRemovedMsg:
+		if msg.err == nil {
+			m.repos = orderRepos(msg.repos, m.config)
+			m.repoList.SetItems(m.repoItems(m.repos))
+			m.mode = normalMode
+			m.logAction(fmt.Sprintf("Repository '%s' removed", msg.repoName), false)
+			if msg.removed != nil {
+				m.undoRepo = msg.removed
+				return m, m.setSuccessMsgFor(fmt.Sprintf("Repository '%s' removed - press U to undo", msg.repoName), 10*time.Second)
+			}
+			return m, m.setSuccessMsg(fmt.Sprintf("Repository '%s' removed successfully", msg.repoName))
+		}
+		return m, nil
+
+	case repoRestoredMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.repos = orderRepos(msg.repos, m.config)
+		m.repoList.SetItems(m.repoItems(m.repos))
+		m.logAction(fmt.Sprintf("Repository '%s' restored", msg.repoName), false)
+		return m, m.setSuccessMsg(fmt.Sprintf("Repository '%s' restored", msg.repoName))
+
+	case repoRenamedMsg:
+		if msg.err == nil {
+			updated := *m.config
+			updated.RenameRepo(msg.oldName, msg.newName)
+			m.config = &updated
+			if err := updated.Save(); err != nil {
+				m.logAction(fmt.Sprintf("Failed to keep the pin and place of repository '%s': %v", msg.newName, err), true)
+			}
+			m.repos = orderRepos(msg.repos, m.config)
+			m.repoList.SetItems(m.repoItems(m.repos))
+			delete(m.chartCache, msg.oldName)
+			m.logAction(fmt.Sprintf("Repository '%s' renamed to '%s'", msg.oldName, msg.newName), false)
+			return m, m.setSuccessMsg(fmt.Sprintf("Repository '%s' renamed to '%s'", msg.oldName, msg.newName))
+		}
+		return m, nil
+
+	case valuesValidatedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.state = stateValueViewer
+			return m, m.setSuccessMsg(fmt.Sprintf("Validation failed: %v", msg.err))
+		}
+		m.validationView.SetContent(m.renderValidationReport(msg.path, msg.result))
+		m.validationView.GotoTop()
+		return m, nil
+
+	case overrideKeysMsg:
+		if m.mode == templateSetMode || m.mode == upgradeSetMode {
+			m.overrideKeys = msg.paths
+			m.updateOverrideMatches()
+		}
+		return m, nil
+
+	case templatePreviewMsg:
+		m.loading = false
+		if m.state != stateTemplatePreview {
+			return m, nil
+		}
+		if msg.err != nil {
+			// A failed show-only keeps what was shown before
+			if m.templateLines == nil {
+				m.state = m.templateReturn
+			}
+			return m, m.setSuccessMsg(fmt.Sprintf("Template failed: %v", msg.err))
+		}
+		m.templateOpts = msg.opts
+		m.searchMatches = []int{}
+		m.lastSearchQuery = ""
+		m.templateLines = strings.Split(strings.TrimSuffix(msg.manifest, "\n"), "\n")
+		m.templateHighlighted = make([]string, len(m.templateLines))
+		for i, line := range m.templateLines {
+			m.templateHighlighted[i] = ui.HighlightYAML(line)
+		}
+		m.templateResources = ui.ManifestResources(msg.manifest)
+		if len(msg.opts.ShowOnly) == 0 {
+			m.templateSources = nil
+			for _, res := range m.templateResources {
+				// Sources start with the chart name: mychart/templates/service.yaml
+				if _, path, ok := strings.Cut(res.Source, "/"); ok && !slices.Contains(m.templateSources, path) {
+					m.templateSources = append(m.templateSources, path)
+				}
+			}
+		}
+		m.updateTemplateViewWithSearch()
+		m.templateView.GotoTop()
+		return m, nil
+
+	case readmeLoadedMsg:
+		m.loading = false
+		if m.state != stateChartReadme {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.state = stateChartDetail
+			return m, m.setSuccessMsg(msg.err.Error())
+		}
+		m.readme = msg.readme
+		m.renderReadme()
+		m.readmeView.GotoTop()
+		return m, nil
+
+	case chartFilesLoadedMsg:
+		m.loading = false
+		if m.state != stateChartFiles {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.state = stateChartDetail
+			return m, m.setSuccessMsg(msg.err.Error())
+		}
+		m.chartFiles = msg.files
+		m.chartFileList.SetItems(chartFileItems(msg.files))
+		return m, nil
+
+	case valuesSchemaLoadedMsg:
+		m.loading = false
+		if m.state != stateChartSchema {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.state = m.schemaReturn
+			return m, m.setSuccessMsg(msg.err.Error())
+		}
+		m.valuesSchema = msg.props
+		m.schemaList.SetItems(schemaItems(msg.props))
+		return m, nil
+
+	case editedValuesValidatedMsg:
+		m.editedContent = msg.content
+		m.editTempFile = msg.filePath
+		m.editSchemaErrors = nil
+		var cmd tea.Cmd
+		switch {
+		case msg.err != nil:
+			cmd = m.setSuccessMsg(fmt.Sprintf("Schema check skipped: %v", msg.err))
+		case len(msg.result.Errors) > 0:
+			m.editSchemaErrors = msg.result.Errors
+			cmd = m.setSuccessMsg(fmt.Sprintf("⚠ Values don't match the chart schema: %s", msg.result.Errors[0]))
+		}
+		m.mode = saveEditMode
+		m.searchInput.Reset()
+		m.searchInput.Placeholder = "./custom-values.yaml"
+		m.searchInput.Focus()
+		return m, cmd
+
+	case chartInfoLoadedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.chartInfo = msg.info
+		return m, nil
+
+	case repoInfoLoadedMsg:
+		m.loading = false
+		if msg.err != nil {
+			// Nothing to show when opening the screen failed
+			if m.state == stateRepoInfo && m.repoInfo == nil {
+				m.state = stateRepoList
+			}
+			return m, m.setSuccessMsg(msg.err.Error())
+		}
+		m.repoInfo = msg.info
+		if msg.success != "" {
+			return m, m.setSuccessMsg(msg.success)
+		}
+		return m, nil
+
+	case editorFinishedMsg:
+		if msg.err != nil {
+			return m, m.setSuccessMsg(fmt.Sprintf("Editor error: %v", msg.err))
+		}
+
+		// Validate YAML
+		var yamlData interface{}
+		if err := yaml.Unmarshal([]byte(msg.content), &yamlData); err != nil {
+			// Clean up temp file
+			if msg.filePath != "" {
+				os.Remove(msg.filePath)
+			}
+			return m, m.setSuccessMsg(fmt.Sprintf("Invalid YAML: %v", err))
+		}
+
+		// Chart values are also checked against the chart's values.schema.json
+		if m.state == stateValueViewer && m.selectedChart < len(m.charts) && m.selectedVersion < len(m.versions) {
+			chartName := m.charts[m.selectedChart].Name
+			version := m.versions[m.selectedVersion].Version
+			return m, tea.Batch(m.setSuccessMsg("Checking values against the chart schema..."), validateEditedValues(m.helmClient, chartName, version, msg))
+		}
+
+		// Save edited content and temp file path, then ask where to save
+		m.editedContent = msg.content
+		m.editTempFile = msg.filePath
+		m.mode = saveEditMode
+		m.searchInput.Reset()
+		m.searchInput.Placeholder = "./custom-values.yaml"
+		m.searchInput.Focus()
+		return m, nil
+
+	case artifactHubRateLimitedMsg:
+		status := fmt.Sprintf("Artifact Hub rate limited, retrying in %s...", msg.wait.Round(time.Second))
+		return m, tea.Batch(m.setSuccessMsgFor(status, msg.wait), waitForRateLimit(m.artifactHubClient))
+
+	case artifactHubSearchMsg:
+		if msg.offset > 0 {
+			m.ahLoadingMore = false
+			// A further page of a search that was replaced since
+			if msg.query != m.ahQuery || msg.opts != m.ahFilters {
+				return m, nil
+			}
+			if msg.err != nil {
+				return m, m.setSuccessMsg(msg.err.Error())
+			}
+			m.ahPackages = append(m.ahPackages, msg.packages...)
+			m.ahTotal = msg.total
+			m.ahPackageList.SetItems(ahPackageItems(m.ahPackages))
+			m.updateArtifactHubTitle()
+			return m, nil
+		}
+
+		m.ahLoading = false
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+
+		m.ahPackages = msg.packages
+		m.ahQuery = msg.query
+		m.ahTotal = msg.total
+		m.ahLoadingMore = false
+		m.ahPackageList.SetItems(ahPackageItems(msg.packages))
+		m.ahPackageList.Select(0)
+		m.updateArtifactHubTitle()
+		return m, nil
+
+	case artifactHubSecurityMsg:
+		m.ahLoading = false
+		if m.state != stateArtifactHubSecurity {
+			return m, nil
+		}
+		if msg.err != nil && !errors.Is(msg.err, artifacthub.ErrNoSecurityReport) {
+			m.state = stateArtifactHubPackageDetail
+			return m, m.setSuccessMsg(msg.err.Error())
+		}
+		report := renderSecurityReport(m.ahSelectedPackage, msg.vulns, msg.err == nil)
+		m.ahSecurityLines = strings.Split(report, "\n")
+		m.ahSecurityView.SetContent(report)
+		m.ahSecurityView.GotoTop()
+		return m, nil
+
+	case artifactHubPackageMsg:
+		m.ahLoading = false
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+
+		m.ahSelectedPackage = msg.pkg
+
+		// Populate version list
+		if len(msg.pkg.AvailableVersions) > 0 {
+			items := make([]list.Item, len(msg.pkg.AvailableVersions))
+			for i, ver := range msg.pkg.AvailableVersions {
+				desc := ""
+				if ver.ContainsSecurityUpdates {
+					desc = "🛡️ Security update"
+				}
+				if ver.Prerelease {
+					desc += " [Pre-release]"
+				}
+				items[i] = listItem{
+					title:       "v" + ver.Version,
+					description: desc,
+				}
+			}
+			m.ahVersionList.SetItems(items)
+		}
+		return m, nil
+
+	case clearSuccessMsgMsg:
+		if msg.seq != m.successSeq {
+			return m, nil
+		}
+		m.successMsg = ""
+		m.undoRepo = nil
+		return m, nil
+
+	case releasesLoadedMsg:
+		if msg.seq != m.releaseLoadSeq {
+			return m, nil
+		}
+		m.loading = false
+		// The release list was left while its pages were loading
+		if errors.Is(msg.err, context.Canceled) {
+			m.loadingMore = false
+			return m, nil
+		}
+		if msg.err != nil {
+			m.loadingMore = false
+			m.err = msg.err
+			return m, nil
+		}
+
+		m.releases = append(m.releases, msg.releases...)
+		// Keep an active search filter, matching the new releases too
+		m.releaseList.SetItems(m.filteredReleaseItems())
+		var openCmd tea.Cmd
+		if p := m.pendingOpen; p != nil && p.Kind == config.KindRelease {
+			for i := len(m.releases) - len(msg.releases); i < len(m.releases); i++ {
+				if m.releases[i].Name == p.Name && m.releases[i].Namespace == p.Namespace {
+					m.pendingOpen = nil
+					updated, cmd := m.gotoTarget(gotoTarget{release: i})
+					m, openCmd = updated.(model), cmd
+					break
+				}
+			}
+			if m.pendingOpen != nil && len(msg.releases) < releasePageSize {
+				m.pendingOpen = nil
+				openCmd = m.setSuccessMsg(fmt.Sprintf("Release %s isn't in namespace %s anymore", p.Name, p.Namespace))
+			}
+		}
+		if len(msg.releases) < releasePageSize {
+			m.loadingMore = false
+			return m, openCmd
+		}
+		return m, tea.Batch(openCmd, loadReleasePage(m.loadContext(stateReleaseList), m.helmClient, msg.namespace, msg.selector, msg.offset+len(msg.releases), msg.seq))
+
+	case namespacesLoadedMsg:
+		m.loading = false
+		// Cancelled by leaving the screen
+		if errors.Is(msg.err, context.Canceled) {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+
+		m.namespaces = msg.namespaces
+		items := make([]list.Item, len(msg.namespaces))
+		for i, ns := range msg.namespaces {
+			items[i] = listItem{
+				title:       ns,
+				description: "Kubernetes namespace",
+			}
+		}
+		m.namespaceList.SetItems(items)
+		return m, nil
+
+	case releaseHistoryLoadedMsg:
+		m.loading = false
+		// Cancelled by leaving the screen
+		if errors.Is(msg.err, context.Canceled) {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+
+		m.releaseHistory = msg.history
+		items := make([]list.Item, len(msg.history))
+		for i, rev := range msg.history {
+			desc := fmt.Sprintf("%s | %s | %s", rev.Status, rev.Chart, rev.Updated)
+			items[i] = listItem{
+				title:       fmt.Sprintf("Revision %d", rev.Revision),
+				description: desc,
+			}
+		}
+		m.releaseHistoryList.SetItems(items)
+
+		// Update detail view if we're showing it
+		if m.state == stateReleaseDetail {
+			m.updateReleaseDetailView()
+		}
+		return m, nil
+
+	case releaseValuesLoadedMsg:
+		m.loadingVals = false
+		// Cancelled by leaving the screen
+		if errors.Is(msg.err, context.Canceled) {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+
+		m.releaseValues = msg.values
+		m.releaseValuesLines = strings.Split(msg.values, "\n")
+		highlighted := ui.HighlightYAMLContent(msg.values)
+		m.releaseValuesView.SetContent(highlighted)
+		return m, nil
+
+	case releaseNotesLoadedMsg:
+		m.loading = false
+		if m.state != stateReleaseNotes {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.state = m.releaseReturn
+			return m, m.setSuccessMsg(msg.err.Error())
+		}
+		if strings.TrimSpace(msg.notes) == "" {
+			m.state = m.releaseReturn
+			return m, m.setSuccessMsg("This release has no notes")
+		}
+		m.releaseNotes = msg.notes
+		m.wrapReleaseNotes()
+		m.releaseNotesView.GotoTop()
+		return m, nil
+
+	case releaseDriftLoadedMsg:
+		if m.state != stateReleaseDrift {
+			m.loading = false
+			return m, nil
+		}
+		// The screen was left and reopened: a newer load is running
+		if errors.Is(msg.err, context.Canceled) || !m.showsRelease(msg.release, msg.namespace) {
+			return m, nil
+		}
+		m.loading = false
+		if msg.err != nil {
+			m.state = m.releaseReturn
+			return m, m.setSuccessMsg(msg.err.Error())
+		}
+		report := renderDriftReport(msg.drift)
+		m.releaseDriftLines = strings.Split(report, "\n")
+		m.releaseDriftView.SetContent(report)
+		m.releaseDriftView.GotoTop()
+		return m, nil
+
+	case releaseEventsLoadedMsg:
+		if m.state != stateReleaseEvents {
+			m.loading = false
+			return m, nil
+		}
+		// The screen was left and reopened: a newer load is running
+		if errors.Is(msg.err, context.Canceled) || !m.showsRelease(msg.release, msg.namespace) {
+			return m, nil
+		}
+		m.loading = false
+		if msg.err != nil {
+			m.state = m.releaseReturn
+			return m, m.setSuccessMsg(msg.err.Error())
+		}
+		report := renderEventsReport(msg.events)
+		m.releaseEventsLines = strings.Split(report, "\n")
+		m.releaseEventsView.SetContent(report)
+		m.releaseEventsView.GotoTop()
+		return m, nil
+
+	case versionDiffLoadedMsg:
+		m.loading = false
+		// Left the diff viewer while the values were loading
+		if m.state != stateDiffViewer || m.diffLines != nil {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.state = stateChartDetail
+			return m, m.setSuccessMsg(fmt.Sprintf("Failed to compare %s and %s: %v", msg.label1, msg.label2, msg.err))
+		}
+		diffContent := renderDiffContent(msg.lines, msg.label1, msg.label2)
+		m.valuesDiff = &valuesDiff{old: msg.values1, new: msg.values2, label1: msg.label1, label2: msg.label2, lineDiff: diffContent}
+		m.diffUnified = ui.UnifiedDiff(msg.values1, msg.values2, msg.label1, msg.label2)
+
+		// Save diff lines for search functionality
+		m.setDiffContent(diffContent)
+		m.diffView.GotoTop()
+		return m, nil
+
+	case revisionDiffLoadedMsg:
+		m.loading = false
+		// Left the diff viewer while the revisions were loading
+		if m.state != stateDiffViewer || m.diffLines != nil {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.state = stateReleaseHistory
+			m.compareRevision = -1
+			return m, m.setSuccessMsg(fmt.Sprintf("Failed to compare %s and %s: %v", msg.label1, msg.label2, msg.err))
+		}
+		m.diffUnified = ui.UnifiedDiff(msg.doc1, msg.doc2, msg.label1, msg.label2)
+		if msg.manifests {
+			m.valuesDiff = nil
+			m.setDiffContent(renderDiffContent(ui.DiffManifests(msg.doc1, msg.doc2), msg.label1, msg.label2))
+		} else {
+			diffContent := renderDiffContent(ui.DiffYAML(msg.doc1, msg.doc2), msg.label1, msg.label2)
+			m.valuesDiff = &valuesDiff{old: msg.doc1, new: msg.doc2, label1: msg.label1, label2: msg.label2, lineDiff: diffContent}
+			m.setDiffContent(diffContent)
+		}
+		m.diffView.GotoTop()
+		return m, nil
+
+	case releaseOverridesLoadedMsg:
+		m.loading = false
+		// Left the diff viewer while the chart defaults were loading
+		if m.state != stateDiffViewer || m.diffLines != nil {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.state = stateReleaseValues
+			m.diffReturn = stateMainMenu
+			return m, m.setSuccessMsg(msg.err.Error())
+		}
+		return m.showReleaseOverrides(msg)
+
+	case registriesLoadedMsg:
+		m.loading = false
+		if m.state != stateRegistries {
+			return m, nil
+		}
+		if msg.err != nil {
+			return m, m.setSuccessMsg(msg.err.Error())
+		}
+		m.registries = msg.registries
+		m.registryStatus = make(map[string]error)
+		m.registryList.SetItems(m.registryItems())
+		var cmds []tea.Cmd
+		for _, registry := range m.registries {
+			cmds = append(cmds, checkRegistry(m.loadContext(stateRegistries), m.helmClient, registry.Host))
+		}
+		return m, tea.Batch(cmds...)
+
+	case registryCheckedMsg:
+		if m.registryStatus == nil {
+			return m, nil
+		}
+		m.registryStatus[msg.host] = msg.err
+		m.registryList.SetItems(m.registryItems())
+		return m, nil
+
+	case registryChangedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.logAction(msg.err.Error(), true)
+			return m, m.setSuccessMsg(msg.err.Error())
+		}
+		m.logAction(msg.message, false)
+		if m.state == stateRegistries {
+			m.startLoading()
+			return m, tea.Batch(m.setSuccessMsg(msg.message), loadRegistries(m.helmClient))
+		}
+		return m, m.setSuccessMsg(msg.message)
+
+	case helmfileLoadedMsg:
+		if msg.err != nil {
+			return m, m.setSuccessMsg(msg.err.Error())
+		}
+		if m.state != stateHelmfile && m.state != stateMainMenu {
+			return m, nil
+		}
+		m.helmfile = msg.helmfile
+		m.helmfilePath = msg.helmfile.Path
+		m.state = stateHelmfile
+		index := m.helmfileList.Index()
+		m.helmfileList.SetItems(m.helmfileItems())
+		if index < len(m.helmfile.Releases) {
+			m.helmfileList.Select(index)
+		}
+		return m, nil
+
+	case dryRunMsg:
+		// Cancelled by leaving the screen
+		if errors.Is(msg.err, context.Canceled) {
+			return m, nil
+		}
+		if msg.err != nil {
+			return m, m.setSuccessMsg(msg.err.Error())
+		}
+		if m.state != msg.origin || m.mode != normalMode {
+			return m, m.setSuccessMsg(fmt.Sprintf("Dry run of '%s' dropped: the screen was left", msg.release.Name))
+		}
+		content := renderDryRun(msg.release, msg.opts, msg.result, msg.warning)
+		m.setDiffContent(content)
+		m.diffView.GotoTop()
+		m.state = stateDiffViewer
+		m.diffReturn = msg.origin
+		m.valuesDiff = nil
+		m.diffUnified = ansi.Strip(content)
+		m.searchMatches = []int{}
+		m.lastSearchQuery = ""
+		return m, nil
+
+	case upgradePreviewMsg:
+		// Cancelled by leaving the release screen
+		if errors.Is(msg.err, context.Canceled) {
+			return m, nil
+		}
+		if msg.err != nil {
+			return m, m.setSuccessMsg(msg.err.Error())
+		}
+		if m.state != msg.origin || m.mode != normalMode {
+			return m, m.setSuccessMsg(fmt.Sprintf("Upgrade preview of '%s' dropped: the release screen was left", msg.release.Name))
+		}
+		target := msg.opts.Chart
+		if msg.opts.Version != "" {
+			target += " " + msg.opts.Version
+		}
+		content := fmt.Sprintf("Upgrade preview: %s → %s (helm diff upgrade)\n\n", msg.release.Name, target)
+		if msg.warning != "" {
+			content += errorStyle.Render(" "+msg.warning+" ") + "\n\n"
+		}
+		if strings.TrimSpace(msg.output) == "" {
+			content += infoStyle.Render(" ✓ The upgrade changes nothing ") + "\n"
+		} else {
+			content += msg.output
+		}
+		m.setDiffContent(content)
+		m.diffView.GotoTop()
+		m.state = stateDiffViewer
+		m.diffReturn = msg.origin
+		m.valuesDiff = nil
+		m.diffUnified = ansi.Strip(msg.output)
+		m.searchMatches = []int{}
+		m.lastSearchQuery = ""
+		return m, nil
+
+	case releaseStatusLoadedMsg:
+		m.loading = false
+		// Cancelled by leaving the screen
+		if errors.Is(msg.err, context.Canceled) {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+
+		m.releaseStatus = msg.status
+
+		// Update detail view if we're showing it
+		if m.state == stateReleaseDetail {
+			m.updateReleaseDetailView()
+		}
+		return m, nil
+
+	case releaseResourcesLoadedMsg:
+		// Ignore results for a release that is no longer shown
+		if errors.Is(msg.err, context.Canceled) || m.selectedRelease >= len(m.releases) || m.releases[m.selectedRelease].Name != msg.release {
+			return m, nil
+		}
+		m.resourcesLoading = false
+		// Not fatal: the cluster may deny listing some kinds
+		m.resourcesErr = msg.err
+		m.releaseResources = msg.resources
+		if m.state == stateReleaseDetail {
+			m.updateReleaseDetailView()
+		}
+		return m, nil
+
+	case kubeContextLoadedMsg:
+		if msg.err != nil {
+			// Context error is not fatal, just don't show it
+			m.kubeContext = "unknown"
+		} else {
+			m.kubeContext = msg.context
+		}
+		return m, nil
+
+	case kubeContextsLoadedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+
+		m.kubeContexts = msg.contexts
+		items := make([]list.Item, len(msg.contexts))
+		for i, ctx := range msg.contexts {
+			desc := "cluster " + ctx.Cluster
+			if ctx.Namespace != "" {
+				desc += " | ns " + ctx.Namespace
+			}
+			if ctx.Name == m.kubeContext {
+				desc = "● in use | " + desc
+			}
+			items[i] = listItem{title: ctx.Name, description: desc}
+		}
+		m.contextList.SetItems(items)
+		return m, nil
+
+	case releaseTestOutputMsg:
+		msg.test.lines = append(msg.test.lines, msg.line)
+		if msg.test == m.test {
+			atBottom := m.releaseTestView.AtBottom()
+			m.releaseTestView.SetContent(strings.Join(m.test.lines, "\n"))
+			if atBottom {
+				m.releaseTestView.GotoBottom()
+			}
+		}
+		return m, waitForTestOutput(msg.test, msg.lines, msg.done)
+
+	case podLogsOutputMsg:
+		msg.logs.lines = append(msg.logs.lines, msg.batch...)
+		if msg.logs == m.logs {
+			m.updatePodLogsView()
+		}
+		return m, waitForPodLogs(msg.logs, msg.lines, msg.done)
+
+	case podLogsDoneMsg:
+		msg.logs.running = false
+		msg.logs.err = msg.err
+		if msg.logs == m.logs {
+			m.updatePodLogsView()
+		}
+		return m, nil
+
+	case releaseTestDoneMsg:
+		msg.test.running = false
+		msg.test.err = msg.err
+		if msg.err != nil {
+			m.logAction(fmt.Sprintf("Tests for '%s' failed: %v", msg.test.release.Name, msg.err), true)
+		} else {
+			m.logAction(fmt.Sprintf("Tests for '%s' passed", msg.test.release.Name), false)
+		}
+		if msg.test != m.test || m.state == stateReleaseTest {
+			return m, nil
+		}
+		// The user left the test screen; tell them how it went
+		done := operationDoneMsg{success: fmt.Sprintf("Tests for '%s' passed", msg.test.release.Name)}
+		if msg.err != nil {
+			done = operationDoneMsg{success: fmt.Sprintf("Tests for '%s' failed", msg.test.release.Name)}
+		}
+		return m, tea.Batch(m.setSuccessMsg(done.success), m.notify(done))
+
+	case clusterVersionLoadedMsg:
+		// Asked again by the next version list
+		if errors.Is(msg.err, context.Canceled) {
+			m.clusterChecked = false
+			return m, nil
+		}
+		// Without a cluster there's nothing to check kubeVersion against
+		if msg.err == nil {
+			m.clusterVersion = msg.version
+			if m.mode != searchMode {
+				m.versionList.SetItems(m.versionListItems(m.versions))
+			}
+		}
+		return m, nil
+	}
+
+	switch m.state {
+	case stateMainMenu:
+		m.mainMenu, cmd = m.mainMenu.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateBrowseMenu:
+		m.browseMenu, cmd = m.browseMenu.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateRepoList:
+		m.repoList, cmd = m.repoList.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateChartList:
+		m.chartList, cmd = m.chartList.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateChartDetail:
+		m.versionList, cmd = m.versionList.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateValueViewer:
+		m.valuesView, cmd = m.valuesView.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateDiffViewer:
+		m.diffView, cmd = m.diffView.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateValidation:
+		m.validationView, cmd = m.validationView.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateChartReadme:
+		m.readmeView, cmd = m.readmeView.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateTemplatePreview:
+		m.templateView, cmd = m.templateView.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateChartFiles:
+		m.chartFileList, cmd = m.chartFileList.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateChartFile:
+		m.chartFileView, cmd = m.chartFileView.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateChartSchema:
+		m.schemaList, cmd = m.schemaList.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateArtifactHubSearch:
+		m.ahPackageList, cmd = m.ahPackageList.Update(msg)
+		cmds = append(cmds, cmd)
+		// Scrolled to the last result (not of a filtered list): load the next page
+		if !m.ahLoading && !m.ahLoadingMore && len(m.ahPackages) < m.ahTotal &&
+			len(m.ahPackageList.Items()) == len(m.ahPackages) && m.ahPackageList.Index() == len(m.ahPackages)-1 {
+			m.ahLoadingMore = true
+			m.updateArtifactHubTitle()
+			cmds = append(cmds, searchArtifactHub(m.artifactHubClient, m.ahQuery, m.ahFilters, len(m.ahPackages)))
+		}
+	case stateArtifactHubPackageDetail:
+		m.ahVersionList, cmd = m.ahVersionList.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateArtifactHubVersions:
+		m.ahVersionList, cmd = m.ahVersionList.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateArtifactHubSecurity:
+		m.ahSecurityView, cmd = m.ahSecurityView.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateClusterReleasesMenu:
+		m.clusterReleasesMenu, cmd = m.clusterReleasesMenu.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateNamespaceList:
+		m.namespaceList, cmd = m.namespaceList.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateContextList:
+		m.contextList, cmd = m.contextList.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateSettings:
+		m.settingsList, cmd = m.settingsList.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateRecent:
+		m.recentList, cmd = m.recentList.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateFavorites:
+		m.favoritesList, cmd = m.favoritesList.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateActionLog:
+		m.actionLogView, cmd = m.actionLogView.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateHelmfile:
+		m.helmfileList, cmd = m.helmfileList.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateRegistries:
+		m.registryList, cmd = m.registryList.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateReleaseList:
+		m.releaseList, cmd = m.releaseList.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateReleaseDetail:
+		m.releaseDetailView, cmd = m.releaseDetailView.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateReleaseHistory:
+		m.releaseHistoryList, cmd = m.releaseHistoryList.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateReleaseValues:
+		m.releaseValuesView, cmd = m.releaseValuesView.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateReleaseNotes:
+		m.releaseNotesView, cmd = m.releaseNotesView.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateReleaseDrift:
+		m.releaseDriftView, cmd = m.releaseDriftView.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateReleaseEvents:
+		m.releaseEventsView, cmd = m.releaseEventsView.Update(msg)
+		cmds = append(cmds, cmd)
+	case stateReleaseTest:
+		m.releaseTestView, cmd = m.releaseTestView.Update(msg)
+		cmds = append(cmds, cmd)
+	case statePodPicker:
+		m.podPickerList, cmd = m.podPickerList.Update(msg)
+		cmds = append(cmds, cmd)
+	case statePodLogs:
+		m.podLogsView, cmd = m.podLogsView.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+
+	return m, tea.Batch(cmds...)
+}
+
+// switchContext points the helm client at another kube context and resets
+// everything that was loaded from the previous cluster
+func (m model) switchContext(name string) (tea.Model, tea.Cmd) {
+	m.helmClient.SetContext(name)
+	m.kubeContext = name
+	m.resetCluster()
+	m.state = stateClusterReleasesMenu
+	return m, m.setSuccessMsg(fmt.Sprintf("Switched to context '%s'", name))
+}
+
+// resetCluster drops everything loaded from the previous cluster after the
+// kube context or kubeconfig changed
+func (m *model) resetCluster() {
+	m.defaultNamespace = m.helmClient.Namespace()
+	m.clusterReleasesMenu.SetItem(0, listItem{
+		title:       "Current Namespace",
+		description: fmt.Sprintf("View releases in '%s'", m.defaultNamespace),
+	})
+	m.clusterVersion = ""
+	m.clusterChecked = false
+	// Loads still running read the previous cluster
+	m.clusterGen++
+	m.releaseLoadSeq++
+	m.cancelAllLoads()
+	m.loading = false
+	m.loadingMore = false
+	m.releases = nil
+	m.namespaces = nil
+	m.kubeContexts = nil
+	m.contextList.SetItems([]list.Item{})
+}
+
+// settingsItems lists the settings with their current values
+func (m model) settingsItems() []list.Item {
+	items := make([]list.Item, len(config.Settings))
+	for i, setting := range config.Settings {
+		value := m.config.Get(setting.Key)
+		if value == "" {
+			value = "not set"
+		}
+		items[i] = listItem{title: setting.Title, description: value + " | " + setting.Description}
+	}
+	return items
+}
+
+// applySetting saves a setting edited on the Settings screen to the config
+// file and applies it to the running session
+func (m model) applySetting(setting config.Setting, value string) (tea.Model, tea.Cmd) {
+	updated := *m.config
+	if err := updated.Set(setting.Key, value); err != nil {
+		return m, m.setSuccessMsg(fmt.Sprintf("Invalid %s: %v", setting.Title, err))
+	}
+
+	var cmd tea.Cmd
+	switch setting.Key {
+	case "kubeconfig":
+		if err := m.helmClient.SetKubeConfig(expandHomeList(updated.KubeConfig)); err != nil {
+			return m, m.setSuccessMsg(fmt.Sprintf("Invalid %s: %v", setting.Title, err))
+		}
+		// The context in use may not exist in the new kubeconfig
+		m.helmClient.SetContext("")
+		m.kubeContext = ""
+		m.resetCluster()
+		cmd = loadKubeContext(m.helmClient)
+	case "default_namespace":
+		namespace := updated.Namespace
+		if namespace == "" {
+			namespace = os.Getenv("HELM_NAMESPACE")
+		}
+		m.helmClient.SetNamespace(namespace)
+		m.resetCluster()
+	case "helm_driver":
+		driver := updated.HelmDriver
+		if driver == "" {
+			driver = os.Getenv("HELM_DRIVER")
+		}
+		if driver == "" {
+			driver = "secret"
+		}
+		if err := m.helmClient.SetDriver(driver); err != nil {
+			return m, m.setSuccessMsg(fmt.Sprintf("Invalid %s: %v", setting.Title, err))
+		}
+		m.resetCluster()
+	case "cache_ttl":
+		m.cache.SetTTL(updated.CacheDuration())
+	case "theme":
+		applyTheme(updated.Theme, m.darkBackground)
+	}
+
+	m.config = &updated
+	m.settingsList.SetItems(m.settingsItems())
+	if err := updated.Save(); err != nil {
+		return m, tea.Batch(m.setSuccessMsg(fmt.Sprintf("%s changed for this session only: %v", setting.Title, err)), cmd)
+	}
+	return m, tea.Batch(m.setSuccessMsg(fmt.Sprintf("%s saved", setting.Title)), cmd)
+}
+
+// applyTheme picks the color variants of adaptive colors: the detected
+// terminal background for auto, or the one the theme names
+func applyTheme(theme string, darkBackground bool) {
+	switch theme {
+	case config.ThemeDark:
+		lipgloss.SetHasDarkBackground(true)
+	case config.ThemeLight:
+		lipgloss.SetHasDarkBackground(false)
+	default:
+		lipgloss.SetHasDarkBackground(darkBackground)
+	}
+}
+
+// showsChartVersion reports whether the chart screens are about version of
+// chart; an empty version matches any
+func (m model) showsChartVersion(chart, version string) bool {
+	if m.selectedChart >= len(m.charts) || m.charts[m.selectedChart].Name != chart {
+		return false
+	}
+	return version == "" || (m.selectedVersion < len(m.versions) && m.versions[m.selectedVersion].Version == version)
+}
+
+// showsRelease reports whether the release screens are about the release
+// name in namespace
+func (m model) showsRelease(name, namespace string) bool {
+	if m.selectedRelease >= len(m.releases) {
+		return false
+	}
+	release := m.releases[m.selectedRelease]
+	return release.Name == name && release.Namespace == namespace
+}
+
+// currentRelease returns the release selected in the release list, or the
+// one open in the release detail view
+func (m model) currentRelease() (helm.Release, bool) {
+	switch m.state {
+	case stateReleaseDetail:
+		if m.selectedRelease < len(m.releases) {
+			return m.releases[m.selectedRelease], true
+		}
+	case stateReleaseList:
+		if selectedItem := m.releaseList.SelectedItem(); selectedItem != nil {
+			for _, release := range m.releases {
+				if isReleaseItem(selectedItem.(listItem), release) {
+					return release, true
+				}
+			}
+		}
+	}
+	return helm.Release{}, false
+}
+
+// isReleaseItem reports whether a release list item shows release; the
+// all-namespaces view can list several releases with the same name
+func isReleaseItem(item listItem, release helm.Release) bool {
+	return item.title == release.Name && item.namespace == release.Namespace
+}
+
+// guessChartRef suggests the repository chart a release was installed from:
+// the first loaded repository with a chart of the same name
+func (m model) guessChartRef(release helm.Release) string {
+	name := release.Chart
+	if i := strings.LastIndex(name, "-"); i > 0 && i+1 < len(name) && name[i+1] >= '0' && name[i+1] <= '9' {
+		name = name[:i]
+	}
+	for _, repo := range m.repos {
+		if entry, ok := m.chartCache[repo.Name]; ok {
+			for _, c := range entry.charts {
+				if c.Name == repo.Name+"/"+name {
+					return c.Name
+				}
+			}
+		}
+	}
+	if len(m.repos) > 0 {
+		return m.repos[0].Name + "/" + name
+	}
+	return name
+}
+
+// releaseKey identifies a release across namespaces
+func releaseKey(release helm.Release) string {
+	return release.Namespace + "/" + release.Name
+}
+
+// marked returns the releases marked for a batch action, in list order
+func (m model) marked() []helm.Release {
+	var marked []helm.Release
+	for _, release := range m.releases {
+		if m.markedReleases[releaseKey(release)] {
+			marked = append(marked, release)
+		}
+	}
+	return marked
+}
+
+// batchRepositories returns the repositories the charts of releases come
+// from, guessed like the upgrade preview does, and the releases no
+// repository could be guessed for
+func (m model) batchRepositories(releases []helm.Release) (repos, skipped []string) {
+	for _, release := range releases {
+		repoName, _, found := strings.Cut(m.guessChartRef(release), "/")
+		if !found {
+			skipped = append(skipped, release.Name)
+			continue
+		}
+		if !slices.Contains(repos, repoName) {
+			repos = append(repos, repoName)
+		}
+	}
+	return repos, skipped
+}
+
+// isYes reports whether a confirmation prompt was answered yes
+func isYes(answer string) bool {
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// findGotoTargets fuzzy-matches query against every repository, chart,
+// release and namespace lazyhelm has loaded so far
+func (m model) findGotoTargets(query string) []gotoTarget {
+	if strings.TrimSpace(query) == "" {
+		return nil
+	}
+
+	var targets []gotoTarget
+	for i, repo := range m.repos {
+		targets = append(targets, gotoTarget{label: "repo " + repo.Name, repo: i, release: -1})
+
+		charts := m.chartCache[repo.Name].charts
+		if i == m.selectedRepo && len(m.charts) > 0 {
+			charts = m.charts
+		}
+		for j := range charts {
+			targets = append(targets, gotoTarget{label: "chart " + charts[j].Name, repo: i, chart: &charts[j], charts: charts, release: -1})
+		}
+	}
+	for i, release := range m.releases {
+		targets = append(targets, gotoTarget{label: fmt.Sprintf("release %s/%s", release.Namespace, release.Name), release: i})
+	}
+	for _, ns := range m.namespaces {
+		targets = append(targets, gotoTarget{label: "namespace " + ns, release: -1, namespace: ns})
+	}
+
+	labels := make([]string, len(targets))
+	for i, target := range targets {
+		labels[i] = target.label
+	}
+	matches := fuzzy.Find(query, labels)
+	result := make([]gotoTarget, len(matches))
+	for i, match := range matches {
+		result[i] = targets[match.Index]
+	}
+	return result
+}
+
+// addRecent records a chart version or release as viewed for the Recent
+// screen, saving the list right away
+func (m *model) addRecent(item config.RecentItem) tea.Cmd {
+	item.Viewed = time.Now()
+	m.recent = config.AddRecent(m.recent, item)
+	if err := config.SaveRecent(m.recent); err != nil {
+		return m.setSuccessMsg(err.Error())
+	}
+	return nil
+}
+
+func (m model) recentItems() []list.Item {
+	items := make([]list.Item, len(m.recent))
+	for i, recent := range m.recent {
+		kind := "Chart version"
+		if recent.Kind == config.KindRelease {
+			kind = "Release"
+		}
+		items[i] = listItem{
+			title:       recent.String(),
+			description: fmt.Sprintf("%s, viewed %s ago", kind, formatAge(time.Since(recent.Viewed))),
+		}
+	}
+	return items
+}
+
+func (m model) isFavorite(fav config.Favorite) bool {
+	return slices.Contains(m.favorites, fav)
+}
+
+func (m model) favoriteItems() []list.Item {
+	items := make([]list.Item, len(m.favorites))
+	for i, fav := range m.favorites {
+		description := "Chart"
+		if fav.Kind == config.KindRelease {
+			description = "Release"
+		}
+		items[i] = listItem{title: fav.String(), description: description}
+	}
+	return items
+}
+
+func (m model) currentFavorite() (config.Favorite, bool) {
+	if selectedItem := m.favoritesList.SelectedItem(); selectedItem != nil {
+		for _, fav := range m.favorites {
+			if fav.String() == selectedItem.(listItem).title {
+				return fav, true
+			}
+		}
+	}
+	return config.Favorite{}, false
+}
+
+// currentChart returns the chart highlighted in the chart list, or shown in
+// the version list
+func (m model) currentChart() (helm.Chart, bool) {
+	switch m.state {
+	case stateChartDetail:
+		if m.selectedChart < len(m.charts) {
+			return m.charts[m.selectedChart], true
+		}
+	case stateChartList:
+		if selectedItem := m.chartList.SelectedItem(); selectedItem != nil {
+			for _, chart := range m.charts {
+				if m.selectedRepo < len(m.repos) && chart.Name == m.repos[m.selectedRepo].Name+"/"+selectedItem.(listItem).title {
+					return chart, true
+				}
+			}
+		}
+	}
+	return helm.Chart{}, false
+}
+
+// toggleFavorite stars or unstars the chart or release under the cursor.
+// Lists are re-sorted with their favorites first the next time they load.
+func (m model) toggleFavorite() (tea.Model, tea.Cmd) {
+	var fav config.Favorite
+	switch m.state {
+	case stateChartList, stateChartDetail:
+		chart, ok := m.currentChart()
+		if !ok {
+			return m, nil
+		}
+		fav = config.Favorite{Kind: config.KindChart, Name: chart.Name}
+	case stateReleaseList, stateReleaseDetail:
+		release, ok := m.currentRelease()
+		if !ok {
+			return m, nil
+		}
+		fav = config.Favorite{Kind: config.KindRelease, Name: release.Name, Namespace: release.Namespace}
+	case stateFavorites:
+		var ok bool
+		if fav, ok = m.currentFavorite(); !ok {
+			return m, nil
+		}
+	default:
+		return m, nil
+	}
+
+	favorites, starred := config.ToggleFavorite(m.favorites, fav)
+	if err := config.SaveFavorites(favorites); err != nil {
+		return m, m.setSuccessMsg(err.Error())
+	}
+	m.favorites = favorites
+
+	switch m.state {
+	case stateChartList:
+		item := m.chartList.SelectedItem().(listItem)
+		item.description = strings.TrimPrefix(item.description, "★ ")
+		if starred {
+			item.description = "★ " + item.description
+		}
+		m.chartList.SetItem(m.chartList.Index(), item)
+	case stateReleaseList:
+		release, _ := m.currentRelease()
+		m.releaseList.SetItem(m.releaseList.Index(), listItem{title: release.Name, description: m.releaseDescription(release), namespace: release.Namespace})
+	case stateFavorites:
+		m.favoritesList.SetItems(m.favoriteItems())
+	}
+	if starred {
+		return m, m.setSuccessMsg("★ Starred " + fav.String())
+	}
+	return m, m.setSuccessMsg("Unstarred " + fav.String())
+}
+
+// openItem reopens a recent or favorite chart (at its version, if any) or
+// release. The screens leading to it load first; their loaded messages finish the jump while
+// pendingOpen is set.
+func (m model) openItem(recent config.RecentItem) (tea.Model, tea.Cmd) {
+	m.cancelAllLoads()
+	if recent.Kind == config.KindRelease {
+		m.pendingOpen = &recent
+		m.selectedNamespace = recent.Namespace
+		m.namespacePicked = false
+		m.state = stateReleaseList
+		m.startLoading()
+		return m, m.loadReleases(recent.Namespace)
+	}
+	repoName, _, _ := strings.Cut(recent.Name, "/")
+	for i, repo := range m.repos {
+		if repo.Name == repoName {
+			m.pendingOpen = &recent
+			return m.gotoTarget(gotoTarget{repo: i, release: -1})
+		}
+	}
+	return m, m.setSuccessMsg(fmt.Sprintf("Repository %s isn't configured anymore", repoName))
+}
+
+// gotoTarget navigates straight to a goto match, loading what the target
+// screen needs
+func (m model) gotoTarget(target gotoTarget) (tea.Model, tea.Cmd) {
+	// A release is opened over the release list, which keeps loading
+	if target.release >= 0 {
+		m.cancelAllLoads(stateReleaseList)
+	} else {
+		m.cancelAllLoads()
+	}
+	m.diffMode = false
+	m.searchMatches = []int{}
+	m.lastSearchQuery = ""
+	m.horizontalOffset = 0
+
+	switch {
+	case target.release >= 0:
+		release := m.releases[target.release]
+		m.selectedRelease = target.release
+		for i, item := range m.releaseList.Items() {
+			if isReleaseItem(item.(listItem), release) {
+				m.releaseList.Select(i)
+				break
+			}
+		}
+		m.releaseHistory = nil
+		m.releaseStatus = nil
+		m.releaseResources = nil
+		m.resourcesErr = nil
+		m.resourcesLoading = true
+		m.state = stateReleaseDetail
+		m.startLoading()
+		return m, tea.Batch(
+			m.addRecent(config.RecentItem{Kind: config.KindRelease, Name: release.Name, Namespace: release.Namespace}),
+			m.inCluster(loadReleaseHistory(m.loadContext(stateReleaseDetail), m.helmClient, release.Name, release.Namespace)),
+			m.inCluster(loadReleaseStatus(m.loadContext(stateReleaseDetail), m.helmClient, release.Name, release.Namespace)),
+			m.inCluster(loadReleaseResources(m.loadContext(stateReleaseDetail), m.helmClient, release.Name, release.Namespace)),
+		)
+
+	case target.namespace != "":
+		m.selectedNamespace = target.namespace
+		m.namespacePicked = true
+		m.state = stateReleaseList
+		m.startLoading()
+		return m, m.loadReleases(target.namespace)
+
+	case target.chart != nil:
+		m.selectedRepo = target.repo
+		m.charts = target.charts
+		m.repoGrep = nil
+		m.chartList.SetItems(m.chartListItems(m.charts))
+		for i := range m.charts {
+			if m.charts[i].Name == target.chart.Name {
+				m.selectedChart = i
+				break
+			}
+		}
+		for i, item := range m.chartList.Items() {
+			if m.selectedRepo < len(m.repos) && m.repos[m.selectedRepo].Name+"/"+item.(listItem).title == target.chart.Name {
+				m.chartList.Select(i)
+				break
+			}
+		}
+		m.state = stateChartDetail
+		m.startLoading()
+		return m, loadVersions(m.helmClient, m.versionCache, m.config.CacheDuration(), target.chart.Name)
+
+	default:
+		repo := m.repos[target.repo]
+		m.selectedRepo = target.repo
+		for i, item := range m.repoList.Items() {
+			if item.(listItem).title == repo.Name {
+				m.repoList.Select(i)
+				break
+			}
+		}
+		m.state = stateChartList
+		m.startLoading()
+		return m, loadCharts(m.helmClient, m.chartCache, m.config.CacheDuration(), repo.Name)
+	}
+}
+
+// renderValidationReport lists schema violations and keys the chart doesn't
+// know about for a validated values file
+func (m model) renderValidationReport(path string, result *helm.ValuesValidation) string {
+	var content strings.Builder
+	name := filepath.Base(path)
+
+	if !result.HasSchema {
+		content.WriteString(modifiedStyle.Render(" This chart version has no values.schema.json - only unknown keys are checked ") + "\n\n")
+	} else if len(result.Errors) == 0 {
+		content.WriteString(infoStyle.Render(fmt.Sprintf(" ✓ %s matches the chart schema ", name)) + "\n\n")
+	} else {
+		content.WriteString(errorStyle.Render(fmt.Sprintf(" ✗ %s violates the chart schema ", name)) + "\n\n")
+		for _, e := range result.Errors {
+			content.WriteString("  " + e + "\n")
+		}
+		content.WriteString("\n")
+	}
+
+	local, err := os.ReadFile(path)
+	if err != nil {
+		content.WriteString(fmt.Sprintf("Could not re-read %s: %v\n", name, err))
+		return content.String()
+	}
+	unknown, err := ui.UnknownKeys(result.Defaults, string(local))
+	switch {
+	case err != nil:
+		content.WriteString(err.Error() + "\n")
+	case len(unknown) == 0:
+		content.WriteString("No unknown keys.\n")
+	default:
+		content.WriteString(modifiedStyle.Render(fmt.Sprintf(" ⚠ %d key(s) not in the chart defaults ", len(unknown))) + "\n")
+		for _, k := range unknown {
+			content.WriteString("  " + k + "\n")
+		}
+	}
+	return content.String()
+}
+
+func (m model) renderValidation() string {
+	if m.loading {
+		return activePanelStyle.Render(m.loadingView("Pulling chart and validating..."))
+	}
+	return activePanelStyle.Render(m.validationView.View()) + "\n" + helpStyle.Render("  esc: back  ")
+}
+
+// diffReleaseOverrides diffs the release values being viewed against the
+// defaults of the chart version the release runs, showing only overrides
+func (m model) diffReleaseOverrides() (tea.Model, tea.Cmd) {
+	release := m.releases[m.selectedRelease]
+	m.diffLines = nil
+	m.diffView.SetContent("")
+	m.state = stateDiffViewer
+	m.diffReturn = stateReleaseValues
+	m.startLoading()
+	return m, m.inCluster(loadReleaseOverrides(m.loadContext(stateDiffViewer), m.helmClient, release, m.selectedRevision, m.releaseValues))
+}
+
+// showReleaseOverrides shows the overrides diff once the chart defaults are
+// loaded
+func (m model) showReleaseOverrides(msg releaseOverridesLoadedMsg) (tea.Model, tea.Cmd) {
+	overrides, err := ui.DiffOverrides(msg.defaults, msg.values)
+	if err != nil {
+		m.state = stateReleaseValues
+		m.diffReturn = stateMainMenu
+		return m, m.setSuccessMsg(err.Error())
+	}
+
+	diffContent := renderDiffContent(overrides, msg.chart+" defaults", msg.label)
+	if len(overrides) == 0 {
+		diffContent = infoStyle.Render(" ✓ The release uses the chart defaults ") + "\n\n" + diffContent
+	} else if unknown, err := ui.UnknownKeys(msg.defaults, msg.values); err == nil && len(unknown) > 0 {
+		var warning strings.Builder
+		warning.WriteString(modifiedStyle.Render(fmt.Sprintf(" ⚠ %d key(s) are not in the chart defaults ", len(unknown))) + "\n")
+		for _, k := range unknown {
+			warning.WriteString("  " + k + "\n")
+		}
+		diffContent = warning.String() + "\n" + diffContent
+	}
+
+	m.setDiffContent(diffContent)
+	m.diffView.GotoTop()
+	m.valuesDiff = nil
+	m.diffUnified = ui.UnifiedDiff(msg.defaults, msg.values, msg.chart+" defaults", msg.label)
+	m.searchMatches = []int{}
+	m.lastSearchQuery = ""
+	return m, nil
+}
+
+// diffAgainstLocalFile diffs the chart defaults being viewed against a local
+// override file, listing override keys the chart doesn't define
+func (m model) diffAgainstLocalFile(path string) (tea.Model, tea.Cmd) {
+	path = expandHome(path)
+	local, err := os.ReadFile(path)
+	if err != nil {
+		return m, m.setSuccessMsg(fmt.Sprintf("Error reading %s: %v", path, err))
+	}
+
+	label := "defaults"
+	if m.selectedVersion < len(m.versions) {
+		label = fmt.Sprintf("v%s defaults", m.versions[m.selectedVersion].Version)
+	}
+
+	diffContent := renderDiffContent(ui.DiffYAML(m.values, string(local)), label, filepath.Base(path))
+	unknown, err := ui.UnknownKeys(m.values, string(local))
+	switch {
+	case err != nil:
+		diffContent = errorStyle.Render(" "+err.Error()+" ") + "\n\n" + diffContent
+	case len(unknown) > 0:
+		var warning strings.Builder
+		warning.WriteString(modifiedStyle.Render(fmt.Sprintf(" ⚠ %d key(s) in %s are not in the chart defaults ", len(unknown), filepath.Base(path))) + "\n")
+		for _, k := range unknown {
+			warning.WriteString("  " + k + "\n")
+		}
+		diffContent = warning.String() + "\n" + diffContent
+	default:
+		diffContent = infoStyle.Render(fmt.Sprintf(" ✓ All keys in %s exist in the chart defaults ", filepath.Base(path))) + "\n\n" + diffContent
+	}
+
+	m.setDiffContent(diffContent)
+	m.diffView.GotoTop()
+	m.state = stateDiffViewer
+	m.diffReturn = stateValueViewer
+	m.valuesDiff = &valuesDiff{old: m.values, new: string(local), label1: label, label2: filepath.Base(path), lineDiff: diffContent}
+	m.diffUnified = ui.UnifiedDiff(m.values, string(local), label, filepath.Base(path))
+	m.searchMatches = []int{}
+	m.lastSearchQuery = ""
+	return m, nil
+}
+
+// activeViewer returns the viewport of the values, release values or diff
+// viewer being shown, together with its lines
+func (m *model) activeViewer() (*viewport.Model, []string) {
+	switch m.state {
+	case stateValueViewer:
+		// The outline has a cursor of its own
+		if !m.valuesOutline {
+			return &m.valuesView, m.valuesLines
+		}
+	case stateReleaseValues:
+		return &m.releaseValuesView, m.releaseValuesLines
+	case stateReleaseNotes:
+		return &m.releaseNotesView, m.releaseNotesLines
+	case stateReleaseDrift:
+		return &m.releaseDriftView, m.releaseDriftLines
+	case stateReleaseEvents:
+		return &m.releaseEventsView, m.releaseEventsLines
+	case stateArtifactHubSecurity:
+		return &m.ahSecurityView, m.ahSecurityLines
+	case statePodLogs:
+		return &m.podLogsView, m.logs.lines[:m.logs.shown]
+	case stateDiffViewer:
+		return &m.diffView, m.diffLines
+	case stateChartReadme:
+		return &m.readmeView, m.readmeLines
+	case stateTemplatePreview:
+		return &m.templateView, m.templateLines
+	case stateChartFile:
+		return &m.chartFileView, m.chartFileLines
+	}
+	return nil, nil
+}
+
+// handleViewerKeys implements the vim-style motions of the viewers: gg/G,
+// ctrl+d/ctrl+u and {/} to jump between top-level sections
+func (m *model) handleViewerKeys(msg tea.KeyMsg, view *viewport.Model, lines []string) bool {
+	pendingG := m.pendingG
+	m.pendingG = false
+
+	switch msg.String() {
+	case "g":
+		if pendingG {
+			view.GotoTop()
+		} else {
+			m.pendingG = true
+		}
+	case "G":
+		view.GotoBottom()
+	case "ctrl+d":
+		view.HalfPageDown()
+	case "ctrl+u":
+		view.HalfPageUp()
+	case "}":
+		for i := view.YOffset + 1; i < len(lines); i++ {
+			if isSectionStart(lines[i], m.state == stateDiffViewer) {
+				view.SetYOffset(i)
+				break
+			}
+		}
+	case "{":
+		for i := view.YOffset - 1; i >= 0; i-- {
+			if isSectionStart(lines[i], m.state == stateDiffViewer) || i == 0 {
+				view.SetYOffset(i)
+				break
+			}
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// isSectionStart reports whether a viewer line starts a paragraph: a blank
+// line or an unindented (top-level) YAML key, ignoring diff markers
+func isSectionStart(line string, diff bool) bool {
+	plain := ansi.Strip(line)
+	if diff && len(plain) >= 2 {
+		plain = plain[2:]
+	}
+	if strings.TrimSpace(plain) == "" {
+		return true
+	}
+	return !strings.HasPrefix(plain, " ") && !strings.HasPrefix(plain, "#")
+}
+
+func (m model) handleBack() (tea.Model, tea.Cmd) {
+	// Clear success message and search results
+	m.successMsg = ""
+	m.searchMatches = []int{}
+	m.lastSearchQuery = ""
+	m.horizontalOffset = 0
+	m.pendingOpen = nil
+
+	if m.diffMode {
+		m.diffMode = false
+		return m, nil
+	}
+
+	// Results of the screen being left would only be discarded
+	m.cancelLoads(m.state)
+
+	switch m.state {
+	case stateBrowseMenu:
+		m.state = stateMainMenu
+	case stateRepoList:
+		m.state = stateBrowseMenu
+	case stateRepoInfo:
+		m.state = stateRepoList
+		m.repoInfo = nil
+	case stateChartInfo:
+		m.state = stateChartDetail
+		m.chartInfo = nil
+	case stateTemplatePreview:
+		m.state = m.templateReturn
+		m.templateLines = nil
+		m.templateHighlighted = nil
+		m.templateResources = nil
+		m.templateSources = nil
+	case stateChartReadme:
+		m.state = m.readmeReturn
+		m.readme = ""
+		m.readmeLines = nil
+	case stateChartFiles:
+		m.state = stateChartDetail
+		m.chartFiles = nil
+		m.chartFileList.SetItems([]list.Item{})
+	case stateChartFile:
+		m.state = stateChartFiles
+		m.chartFileLines = nil
+	case stateChartSchema:
+		m.state = m.schemaReturn
+		m.valuesSchema = nil
+		m.schemaList.SetItems([]list.Item{})
+	case stateValidation:
+		m.state = stateValueViewer
+	case stateChartList:
+		m.state = stateRepoList
+		m.charts = nil
+		m.chartList.SetItems([]list.Item{})
+	case stateChartDetail:
+		m.state = stateChartList
+		m.versions = nil
+		m.versionList.SetItems([]list.Item{})
+	case stateValueViewer:
+		m.state = stateChartDetail
+		m.values = ""
+		m.valuesLines = nil
+	case stateDiffViewer:
+		// Return to release history if we were comparing revisions, otherwise chart detail
+		if m.diffReturn != stateMainMenu {
+			m.state = m.diffReturn
+			m.diffReturn = stateMainMenu
+		} else if m.compareRevision >= 0 {
+			m.state = stateReleaseHistory
+			m.compareRevision = -1
+		} else {
+			m.state = stateChartDetail
+		}
+	case stateRegistries:
+		m.state = stateBrowseMenu
+	case stateArtifactHubSearch:
+		m.state = stateBrowseMenu
+		m.ahPackages = nil
+		m.ahPackageList.SetItems([]list.Item{})
+	case stateArtifactHubPackageDetail:
+		m.state = stateArtifactHubSearch
+		m.ahSelectedPackage = nil
+		m.ahVersionList.SetItems([]list.Item{})
+	case stateArtifactHubVersions:
+		m.state = stateArtifactHubPackageDetail
+	case stateArtifactHubSecurity:
+		m.state = stateArtifactHubPackageDetail
+		m.ahSecurityLines = nil
+	case stateClusterReleasesMenu:
+		m.state = stateMainMenu
+	case stateSettings, stateRecent, stateFavorites, stateActionLog, stateHelmfile:
+		m.state = stateMainMenu
+	case stateNamespaceList:
+		m.state = stateClusterReleasesMenu
+		m.namespaces = nil
+		m.namespaceList.SetItems([]list.Item{})
+	case stateContextList:
+		m.state = stateClusterReleasesMenu
+		m.kubeContexts = nil
+		m.contextList.SetItems([]list.Item{})
+	case stateReleaseList:
+		if m.namespacePicked {
+			// Came from "Select Namespace"
+			m.state = stateNamespaceList
+		} else {
+			// Came from "Current Namespace" or "All Namespaces"
+			m.state = stateClusterReleasesMenu
+		}
+		m.releases = nil
+		m.releaseLoadSeq++
+		m.loadingMore = false
+		m.releaseList.SetItems([]list.Item{})
+	case stateReleaseDetail:
+		m.state = stateReleaseList
+	case stateReleaseHistory:
+		m.state = stateReleaseDetail
+		m.updateReleaseDetailView()
+	case stateReleaseValues:
+		m.state = stateReleaseHistory
+		m.releaseValues = ""
+		m.releaseValuesLines = nil
+		m.selectedRevision = 0
+		m.horizontalOffset = 0
+	case stateReleaseNotes:
+		m.state = m.releaseReturn
+		m.releaseNotes = ""
+		m.releaseNotesLines = nil
+		if m.state == stateReleaseDetail {
+			m.updateReleaseDetailView()
+		}
+	case stateReleaseDrift:
+		m.state = m.releaseReturn
+		m.releaseDriftLines = nil
+		if m.state == stateReleaseDetail {
+			m.updateReleaseDetailView()
+		}
+	case stateReleaseEvents:
+		m.state = m.releaseReturn
+		m.releaseEventsLines = nil
+		if m.state == stateReleaseDetail {
+			m.updateReleaseDetailView()
+		}
+	case stateReleaseTest:
+		// A running test keeps going; its result is shown as a toast
+		m.state = m.test.returnTo
+	case statePodPicker:
+		m.state = stateReleaseDetail
+		m.logTargets = nil
+		m.updateReleaseDetailView()
+	case statePodLogs:
+		m.logs.cancel()
+		m.state = m.logs.returnTo
+		m.logs = nil
+		m.searchMatches = []int{}
+		m.lastSearchQuery = ""
+		if m.state == stateReleaseDetail {
+			m.updateReleaseDetailView()
+		}
+	}
+	return m, nil
+}
+
+func (m model) handleEnter() (tea.Model, tea.Cmd) {
+	// Clear success message
+	m.successMsg = ""
+
+	switch m.state {
+	case stateMainMenu:
+		selectedItem := m.mainMenu.SelectedItem()
+		if selectedItem != nil {
+			item := selectedItem.(listItem)
+			switch item.title {
+			case "Browse Repositories":
+				m.state = stateBrowseMenu
+				return m, nil
+			case "Cluster Releases":
+				m.state = stateClusterReleasesMenu
+				// Load kubectl context
+				return m, loadKubeContext(m.helmClient)
+			case "Favorites":
+				m.state = stateFavorites
+				m.favoritesList.SetItems(m.favoriteItems())
+				m.favoritesList.ResetSelected()
+				return m, nil
+			case "Helmfile":
+				m.mode = helmfilePathMode
+				m.searchInput.Reset()
+				m.searchInput.Placeholder = "helmfile.yaml"
+				m.searchInput.SetValue(m.helmfilePath)
+				m.searchInput.Focus()
+				return m, nil
+			case "Action Log":
+				m.state = stateActionLog
+				m.updateActionLogView()
+				m.actionLogView.GotoBottom()
+				return m, nil
+			case "Recent":
+				m.state = stateRecent
+				m.recentList.SetItems(m.recentItems())
+				m.recentList.ResetSelected()
+				return m, nil
+			case "Settings":
+				m.state = stateSettings
+				m.settingsList.SetItems(m.settingsItems())
+				return m, nil
+			}
+		}
+
+	case stateRecent:
+		selectedItem := m.recentList.SelectedItem()
+		if selectedItem == nil {
+			return m, nil
+		}
+		for _, recent := range m.recent {
+			if recent.String() == selectedItem.(listItem).title {
+				return m.openItem(recent)
+			}
+		}
+
+	case stateHelmfile:
+		if release, ok := m.currentHelmfileRelease(); ok {
+			m.helmfileRelease = release
+			m.mode = helmfileActionMode
+			m.searchInput.Reset()
+			m.searchInput.Placeholder = fmt.Sprintf("'%s': (v)alues in %s, (c)hart, (r)elease in the cluster?", release.Name, m.helmfile.Environment)
+			m.searchInput.Focus()
+		}
+		return m, nil
+
+	case stateFavorites:
+		if fav, ok := m.currentFavorite(); ok {
+			return m.openItem(config.RecentItem{Kind: fav.Kind, Name: fav.Name, Namespace: fav.Namespace})
+		}
+
+	case stateBrowseMenu:
+		selectedItem := m.browseMenu.SelectedItem()
+		if selectedItem != nil {
+			item := selectedItem.(listItem)
+			switch item.title {
+			case "Local Repositories":
+				m.state = stateRepoList
+				return m, nil
+			case "Search Artifact Hub":
+				m.mode = searchMode
+				m.searchInput.Reset()
+				m.searchInput.Placeholder = "Search Artifact Hub..."
+				m.searchInput.Focus()
+				m.state = stateArtifactHubSearch
+				return m, nil
+			case "OCI Registries":
+				m.state = stateRegistries
+				m.startLoading()
+				return m, loadRegistries(m.helmClient)
+			}
+		}
+
+	case stateClusterReleasesMenu:
+		selectedItem := m.clusterReleasesMenu.SelectedItem()
+		if selectedItem != nil {
+			item := selectedItem.(listItem)
+			switch item.title {
+			case "Current Namespace":
+				m.state = stateReleaseList
+				m.selectedNamespace = m.defaultNamespace
+				m.namespacePicked = false
+				m.startLoading()
+				return m, m.loadReleases(m.defaultNamespace)
+			case "All Namespaces":
+				m.state = stateReleaseList
+				m.selectedNamespace = "" // Empty means all namespaces
+				m.namespacePicked = false
+				m.startLoading()
+				return m, m.loadReleases("")
+			case "Select Namespace":
+				m.state = stateNamespaceList
+				m.startLoading()
+				return m, m.inCluster(loadNamespaces(m.loadContext(stateNamespaceList), m.helmClient))
+			case "Switch Context":
+				m.state = stateContextList
+				m.startLoading()
+				return m, loadKubeContexts(m.helmClient)
+			}
+		}
+
+	case stateSettings:
+		selectedItem := m.settingsList.SelectedItem()
+		if selectedItem != nil {
+			m.editSetting = config.Settings[m.settingsList.Index()]
+			m.mode = settingMode
+			m.searchInput.Reset()
+			m.searchInput.SetValue(m.config.Get(m.editSetting.Key))
+			m.searchInput.Placeholder = m.editSetting.Description
+			m.searchInput.Focus()
+		}
+
+	case statePodPicker:
+		selectedItem := m.podPickerList.SelectedItem()
+		if selectedItem == nil {
+			return m, nil
+		}
+		for _, target := range m.logTargets {
+			if target.title == selectedItem.(listItem).title {
+				return m.followPodLogs(target)
+			}
+		}
+
+	case stateChartFiles:
+		selectedItem := m.chartFileList.SelectedItem()
+		if selectedItem == nil {
+			return m, nil
+		}
+		path := selectedItem.(listItem).description
+		for _, f := range m.chartFiles {
+			if f.Path == path {
+				content := chartFileContent(f)
+				m.chartFileLines = strings.Split(content, "\n")
+				m.chartFileView.SetContent(content)
+				m.chartFileView.GotoTop()
+				m.state = stateChartFile
+				break
+			}
+		}
+
+	case stateContextList:
+		selectedItem := m.contextList.SelectedItem()
+		if selectedItem != nil {
+			item := selectedItem.(listItem)
+			return m.switchContext(item.title)
+		}
+
+	case stateNamespaceList:
+		selectedItem := m.namespaceList.SelectedItem()
+		if selectedItem != nil {
+			item := selectedItem.(listItem)
+			m.selectedNamespace = item.title
+			m.namespacePicked = true
+			m.state = stateReleaseList
+			m.startLoading()
+			return m, m.loadReleases(item.title)
+		}
+
+	case stateReleaseList:
+		selectedItem := m.releaseList.SelectedItem()
+		if selectedItem != nil {
+			item := selectedItem.(listItem)
+			// Find the release by name and namespace
+			for i, release := range m.releases {
+				if isReleaseItem(item, release) {
+					m.selectedRelease = i
+					m.state = stateReleaseDetail
+					m.startLoading()
+					m.releaseResources = nil
+					m.resourcesErr = nil
+					m.resourcesLoading = true
+					// Load history, status and resources for the detail view
+					return m, tea.Batch(
+						m.addRecent(config.RecentItem{Kind: config.KindRelease, Name: release.Name, Namespace: release.Namespace}),
+						m.inCluster(loadReleaseHistory(m.loadContext(stateReleaseDetail), m.helmClient, release.Name, release.Namespace)),
+						m.inCluster(loadReleaseStatus(m.loadContext(stateReleaseDetail), m.helmClient, release.Name, release.Namespace)),
+						m.inCluster(loadReleaseResources(m.loadContext(stateReleaseDetail), m.helmClient, release.Name, release.Namespace)),
+					)
+				}
+			}
+		}
+
+	case stateReleaseHistory:
+		selectedItem := m.releaseHistoryList.SelectedItem()
+		if selectedItem != nil && m.selectedRelease < len(m.releases) {
+			item := selectedItem.(listItem)
+			release := m.releases[m.selectedRelease]
+
+			// Find the selected revision index
+			var selectedIdx int = -1
+			for i, rev := range m.releaseHistory {
+				revTitle := fmt.Sprintf("Revision %d", rev.Revision)
+				if revTitle == item.title {
+					selectedIdx = i
+					break
+				}
+			}
+
+			if selectedIdx >= 0 {
+				if m.diffMode {
+					if selectedIdx == m.compareRevision {
+						return m, m.setSuccessMsg("Please select a different revision to compare")
+					}
+
+					revision1 := m.releaseHistory[m.compareRevision].Revision
+					revision2 := m.releaseHistory[selectedIdx].Revision
+
+					// Fetching both revisions can take seconds on a slow cluster
+					m.diffLines = nil
+					m.diffView.SetContent("")
+					m.state = stateDiffViewer
+					m.diffMode = false
+					m.startLoading()
+					return m, m.inCluster(loadRevisionDiff(m.loadContext(stateDiffViewer), m.helmClient, release, revision1, revision2, m.diffManifests))
+				}
+
+				// Normal flow: view values for selected revision
+				rev := m.releaseHistory[selectedIdx]
+				m.selectedRevision = rev.Revision
+				m.state = stateReleaseValues
+				m.loadingVals = true
+				ctx := m.loadContext(stateReleaseValues)
+				return m, func() tea.Msg {
+					values, err := m.helmClient.GetReleaseValuesByRevision(ctx, release.Name, release.Namespace, rev.Revision)
+					if err != nil {
+						return releaseValuesLoadedMsg{err: err}
+					}
+					return releaseValuesLoadedMsg{values: values}
+				}
+			}
+		}
+
+	case stateRepoList:
+		selectedItem := m.repoList.SelectedItem()
+		if selectedItem != nil {
+			item := selectedItem.(listItem)
+			// Find the correct index in the full list by matching the title
+			for i, repo := range m.repos {
+				if repo.Name == item.title {
+					m.selectedRepo = i
+					m.state = stateChartList
+					m.startLoading()
+					return m, loadCharts(m.helmClient, m.chartCache, m.config.CacheDuration(), repo.Name)
+				}
+			}
+		}
+
+	case stateChartList:
+		selectedItem := m.chartList.SelectedItem()
+		if selectedItem != nil {
+			item := selectedItem.(listItem)
+			// Find the correct index in the full list by matching the title
+			for i, chart := range m.charts {
+				chartName := chart.Name
+				if m.selectedRepo < len(m.repos) {
+					chartName = strings.TrimPrefix(chartName, m.repos[m.selectedRepo].Name+"/")
+				}
+				if chartName == item.title {
+					m.selectedChart = i
+					m.state = stateChartDetail
+					m.startLoading()
+					return m, loadVersions(m.helmClient, m.versionCache, m.config.CacheDuration(), m.charts[i].Name)
+				}
+			}
+		}
+
+	case stateChartDetail:
+		selectedItem := m.versionList.SelectedItem()
+		if selectedItem != nil {
+			item := selectedItem.(listItem)
+			// Find the correct index in the full list by matching the title
+			var selectedIdx int = -1
+			for i, ver := range m.versions {
+				if "v"+ver.Version == item.title {
+					selectedIdx = i
+					break
+				}
+			}
+
+			if selectedIdx >= 0 {
+				if m.diffMode {
+					if selectedIdx == m.compareVersion {
+						return m, m.setSuccessMsg("Please select a different version to compare")
+					}
+
+					chartName := m.charts[m.selectedChart].Name
+					version1 := m.versions[m.compareVersion].Version
+					version2 := m.versions[selectedIdx].Version
+
+					// Fetching both versions can take seconds on a slow repo
+					m.diffLines = nil
+					m.diffView.SetContent("")
+					m.state = stateDiffViewer
+					m.diffMode = false
+					m.startLoading()
+					refs := [2]chartVersionRef{{chartName, version1}, {chartName, version2}}
+					return m, loadVersionDiff(m.helmClient, m.cache, refs, [2]string{"v" + version1, "v" + version2})
+				}
+
+				m.selectedVersion = selectedIdx
+				m.state = stateValueViewer
+				m.loadingVals = true
+				chartName := m.charts[m.selectedChart].Name
+				version := m.versions[selectedIdx].Version
+				return m, tea.Batch(
+					m.addRecent(config.RecentItem{Kind: config.KindChart, Name: chartName, Version: version}),
+					loadValuesByVersion(m.helmClient, m.cache, chartName, version),
+				)
+			}
+		}
+
+	case stateArtifactHubSearch:
+		selectedItem := m.ahPackageList.SelectedItem()
+		if selectedItem != nil {
+			item := selectedItem.(listItem)
+			// Find the correct package in the full list by matching the name
+			for i, pkg := range m.ahPackages {
+				if pkg.Name == item.title {
+					m.ahSelectedPkg = i
+					m.state = stateArtifactHubPackageDetail
+					m.ahLoading = true
+					return m, loadArtifactHubPackage(m.artifactHubClient, pkg.Repository.Name, pkg.Name)
+				}
+			}
+		}
+
+	case stateArtifactHubVersions:
+		// Can't view values from Artifact Hub - need to add repo first
+		return m, m.setSuccessMsg("Add the repository first (press 'a'), then browse it from the main menu to view values")
+	}
+
+	return m, nil
+}
+
+func (m model) handleSearch() (tea.Model, tea.Cmd) {
+	if m.state == stateRepoList || m.state == stateChartList || m.state == stateChartDetail || m.state == stateValueViewer || m.state == stateDiffViewer || m.state == stateReleaseValues || m.state == stateReleaseDetail || m.state == stateReleaseList || m.state == stateChartReadme || m.state == stateReleaseNotes || m.state == statePodLogs || m.state == stateTemplatePreview {
+		m.successMsg = "" // Clear success message
+		// Matches are lines of the whole document
+		if m.state == stateValueViewer && m.valuesOutline {
+			m.valuesOutline = false
+			m.updateValuesViewWithSearch()
+		}
+		m.mode = searchMode
+		m.searchInput.Reset()
+		m.searchInput.Placeholder = "Search..."
+		m.searchInput.Focus()
+	}
+	if m.state == stateArtifactHubSearch {
+		// Allow searching again in Artifact Hub
+		m.successMsg = ""
+		m.mode = searchMode
+		m.searchInput.Reset()
+		m.searchInput.Placeholder = "Search Artifact Hub..."
+		m.searchInput.Focus()
+	}
+	return m, nil
+}
+
+func (m model) handleInputMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	// Search toggles apply to the query being typed
+	if m.mode == searchMode && m.searchesLines() && (key.Matches(msg, m.keys.SearchCase) || key.Matches(msg, m.keys.SearchWord)) {
+		m.toggleSearchOption(msg)
+		if m.searchInput.Value() != "" {
+			m = m.searchAgain(m.searchInput.Value())
+		}
+		return m, nil
+	}
+
+	// Tab completes the key of an override, backspace on an empty prompt
+	// drops the last one
+	if m.mode == templateSetMode || m.mode == upgradeSetMode {
+		switch msg.String() {
+		case "tab":
+			if len(m.overrideMatches) > 0 {
+				m.searchInput.SetValue(m.overrideMatches[0].Path + "=")
+				m.searchInput.CursorEnd()
+				m.overrideMatches = nil
+			}
+			return m, nil
+		case "backspace":
+			if m.searchInput.Value() == "" && len(m.overrides) > 0 {
+				m.overrides = m.overrides[:len(m.overrides)-1]
+				return m, nil
+			}
+		}
+	}
+
+	if m.mode == templateShowOnlyMode && msg.String() == "tab" {
+		if len(m.templateSourceMatches) > 0 {
+			m.searchInput.SetValue(m.templateSourceMatches[0])
+			m.searchInput.CursorEnd()
+			m.templateSourceMatches = nil
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc":
+		// Clean up temp file if canceling save edit mode
+		if m.mode == saveEditMode && m.editTempFile != "" {
+			os.Remove(m.editTempFile)
+			m.editTempFile = ""
+			m.editedContent = ""
+			m.editSchemaErrors = nil
+		}
+
+		// Restore original lists if we were in search mode
+		if m.mode == searchMode {
+			switch m.state {
+			case stateRepoList:
+				m.repoList.SetItems(m.repoItems(m.repos))
+
+			case stateChartList:
+				m.chartList.SetItems(m.chartListItems(m.charts))
+
+			case stateChartDetail:
+				m.versionList.SetItems(m.versionListItems(m.versions))
+
+			case stateValueViewer:
+				// Clear search results
+				m.searchMatches = []int{}
+				m.lastSearchQuery = ""
+
+			case stateReleaseValues:
+				// Clear search results
+				m.searchMatches = []int{}
+				m.lastSearchQuery = ""
+
+			case stateReleaseDetail:
+				// Clear search results and restore original content
+				m.searchMatches = []int{}
+				m.lastSearchQuery = ""
+				m.updateReleaseDetailView()
+
+			case stateChartReadme:
+				m.searchMatches = []int{}
+				m.lastSearchQuery = ""
+				m.updateReadmeViewWithSearch()
+
+			case stateTemplatePreview:
+				m.searchMatches = []int{}
+				m.lastSearchQuery = ""
+				m.updateTemplateViewWithSearch()
+
+			case stateReleaseNotes:
+				m.searchMatches = []int{}
+				m.lastSearchQuery = ""
+				m.updateReleaseNotesViewWithSearch()
+
+			case statePodLogs:
+				m.searchMatches = []int{}
+				m.lastSearchQuery = ""
+				m.updatePodLogsView()
+
+			case stateReleaseList:
+				// Restore full release list
+				m.releaseFilter = ""
+				m.releaseList.SetItems(m.releaseListItems(m.releases))
+
+			case stateDiffViewer:
+				// Clear search results and restore original content
+				m.searchMatches = []int{}
+				m.lastSearchQuery = ""
+				m.updateDiffViewWithSearch() // Restore original without highlights
+
+			case stateArtifactHubSearch:
+				// Return to repo list
+				m.state = stateRepoList
+				m.ahPackages = nil
+				m.ahPackageList.SetItems([]list.Item{})
+			}
+		}
+
+		m.mode = normalMode
+		m.searchInput.Blur()
+		m.searchInput.EchoMode = textinput.EchoNormal
+		m.addRepoStep = addRepoNameStep
+		m.newRepoURL = "" // Reset pre-filled URL
+		m.newRepoOpts = helm.RepositoryOptions{}
+		return m, nil
+
+	case "enter":
+		switch m.mode {
+		case searchMode:
+			// Check if we're in Artifact Hub search state
+			if m.state == stateArtifactHubSearch {
+				query := m.searchInput.Value()
+				if query != "" {
+					m.mode = normalMode
+					m.searchInput.Blur()
+					m.ahLoading = true
+					searchCmd := searchArtifactHub(m.artifactHubClient, query, m.ahFilters, 0)
+					m.lastAction = &repeatableAction{
+						label: fmt.Sprintf("Artifact Hub search '%s'", query),
+						prepare: func(m *model) {
+							m.state = stateArtifactHubSearch
+							m.ahLoading = true
+						},
+						cmd: searchCmd,
+					}
+					return m, searchCmd
+				}
+			}
+			m.mode = normalMode
+			m.searchInput.Blur()
+			if m.searchErr != nil {
+				return m, m.setSuccessMsg(m.searchErr.Error())
+			}
+
+		case addRepoMode:
+			switch m.addRepoStep {
+			case addRepoNameStep:
+				inputName := m.searchInput.Value()
+				// If coming from Artifact Hub and no name provided, use default
+				if inputName == "" && m.newRepoURL != "" && m.ahSelectedPackage != nil {
+					inputName = m.ahSelectedPackage.Repository.Name
+				}
+				m.newRepoName = inputName
+
+				// If URL is already set (from Artifact Hub), skip asking for URL
+				if m.newRepoURL != "" {
+					return m.submitNewRepo()
+				}
+
+				// Otherwise ask for URL (normal flow)
+				m.nextAddRepoStep(addRepoURLStep, "Repository URL...")
+
+			case addRepoURLStep:
+				m.newRepoURL = m.searchInput.Value()
+				if helm.IsGitURL(m.newRepoURL) {
+					m.newGitSource = helm.GitSource{Name: m.newRepoName, URL: strings.TrimSpace(m.newRepoURL)}
+					m.nextAddRepoStep(addRepoGitRefStep, "default branch")
+					break
+				}
+				m.nextAddRepoStep(addRepoAuthStep, "y/N")
+
+			case addRepoGitRefStep:
+				m.newGitSource.Ref = strings.TrimSpace(m.searchInput.Value())
+				m.nextAddRepoStep(addRepoGitPathStep, "repository root, e.g. deploy/charts")
+
+			case addRepoGitPathStep:
+				m.newGitSource.Path = strings.Trim(strings.TrimSpace(m.searchInput.Value()), "/")
+				m.mode = normalMode
+				m.searchInput.Blur()
+				m.addRepoStep = addRepoNameStep
+				m.newRepoURL = ""
+				m.startLoading()
+				return m, addGitSource(m.helmClient, m.newGitSource)
+
+			case addRepoAuthStep:
+				if !isYes(m.searchInput.Value()) {
+					return m.submitNewRepo()
+				}
+				m.nextAddRepoStep(addRepoUsernameStep, "optional")
+
+			case addRepoUsernameStep:
+				m.newRepoOpts.Username = strings.TrimSpace(m.searchInput.Value())
+				if m.newRepoOpts.Username == "" {
+					m.nextAddRepoStep(addRepoCAFileStep, "optional, e.g. ~/certs/ca.pem")
+					break
+				}
+				m.nextAddRepoStep(addRepoPasswordStep, "")
+				m.searchInput.EchoMode = textinput.EchoPassword
+
+			case addRepoPasswordStep:
+				m.newRepoOpts.Password = m.searchInput.Value()
+				m.nextAddRepoStep(addRepoCAFileStep, "optional, e.g. ~/certs/ca.pem")
+
+			case addRepoCAFileStep:
+				m.newRepoOpts.CAFile = expandHome(strings.TrimSpace(m.searchInput.Value()))
+				m.nextAddRepoStep(addRepoCertFileStep, "optional")
+
+			case addRepoCertFileStep:
+				m.newRepoOpts.CertFile = expandHome(strings.TrimSpace(m.searchInput.Value()))
+				if m.newRepoOpts.CertFile == "" {
+					m.nextAddRepoStep(addRepoInsecureStep, "y/N")
+					break
+				}
+				m.nextAddRepoStep(addRepoKeyFileStep, "")
+
+			case addRepoKeyFileStep:
+				m.newRepoOpts.KeyFile = expandHome(strings.TrimSpace(m.searchInput.Value()))
+				m.nextAddRepoStep(addRepoInsecureStep, "y/N")
+
+			case addRepoInsecureStep:
+				m.newRepoOpts.InsecureSkipTLSVerify = isYes(m.searchInput.Value())
+				return m.submitNewRepo()
+			}
+
+		case renameRepoMode:
+			oldName := m.renameRepoFrom
+			newName := strings.TrimSpace(m.searchInput.Value())
+			m.mode = normalMode
+			m.searchInput.Blur()
+			m.renameRepoFrom = ""
+
+			if newName == "" || newName == oldName {
+				return m, nil
+			}
+			return m, func() tea.Msg {
+				if err := m.helmClient.RenameRepository(oldName, newName); err != nil {
+					return operationDoneMsg{err: err}
+				}
+
+				repos, repoErr := m.helmClient.ListRepositories()
+				if repoErr != nil {
+					return operationDoneMsg{success: fmt.Sprintf("Repository '%s' renamed, but failed to reload list", oldName)}
+				}
+
+				return repoRenamedMsg{repos: repos, oldName: oldName, newName: newName}
+			}
+
+		case confirmDuplicateRepoMode:
+			response := strings.ToLower(m.searchInput.Value())
+			m.mode = normalMode
+			m.searchInput.Blur()
+
+			opts := m.newRepoOpts
+			m.newRepoOpts = helm.RepositoryOptions{}
+			if response == "y" || response == "yes" {
+				return m, addRepository(m.helmClient, m.newRepoName, m.newRepoURL, opts)
+			}
+
+			// Reuse the existing entry instead of adding a duplicate
+			existing := helm.FindRepositoryByURL(m.repos, m.newRepoURL)
+			m.newRepoURL = ""
+			if existing == nil {
+				return m, nil
+			}
+			if m.state == stateRepoList {
+				for i, item := range m.repoList.Items() {
+					if item.(listItem).title == existing.Name {
+						m.repoList.Select(i)
+						break
+					}
+				}
+			}
+			return m, m.setSuccessMsg(fmt.Sprintf("Using existing repository '%s'", existing.Name))
+
+		case exportValuesMode:
+			path := m.searchInput.Value()
+			if path == "" {
+				path = m.config.ExportPath
+			}
+			m.mode = normalMode
+			m.searchInput.Blur()
+
+			sink, err := m.exportSink(path)
+			if err != nil {
+				return m, m.setSuccessMsg(fmt.Sprintf("Export failed: %v", err))
+			}
+
+			if m.state == stateReleaseValues {
+				exportCmd := func() tea.Msg {
+					err := sink.Write([]byte(m.releaseValues))
+					if err != nil {
+						return operationDoneMsg{err: err}
+					}
+					if m.selectedRevision > 0 {
+						return operationDoneMsg{success: fmt.Sprintf("Values (revision %d) exported to %s", m.selectedRevision, sink)}
+					}
+					return operationDoneMsg{success: fmt.Sprintf("Values exported to %s", sink)}
+				}
+				m.lastAction = &repeatableAction{label: "export values to " + path, cmd: exportCmd}
+				return m, exportCmd
+			}
+
+			chartName := m.charts[m.selectedChart].Name
+			if m.state == stateValueViewer && m.selectedVersion < len(m.versions) {
+				version := m.versions[m.selectedVersion].Version
+				exportCmd := func() tea.Msg {
+					values, err := m.helmClient.GetChartValuesByVersion(chartName, version)
+					if err != nil {
+						return operationDoneMsg{err: err}
+					}
+					err = sink.Write([]byte(values))
+					if err != nil {
+						return operationDoneMsg{err: err}
+					}
+					return operationDoneMsg{success: fmt.Sprintf("Values (v%s) exported to %s", version, sink)}
+				}
+				m.lastAction = &repeatableAction{label: "export values to " + path, cmd: exportCmd}
+				return m, exportCmd
+			}
+			exportCmd := exportValues(m.helmClient, chartName, sink)
+			m.lastAction = &repeatableAction{label: "export values to " + path, cmd: exportCmd}
+			return m, exportCmd
+
+		case validateValuesMode:
+			path := m.searchInput.Value()
+			if path == "" {
+				path = "./values.yaml"
+			}
+			path = expandHome(path)
+			m.mode = normalMode
+			m.searchInput.Blur()
+
+			chartName := m.charts[m.selectedChart].Name
+			version := m.versions[m.selectedVersion].Version
+			m.state = stateValidation
+			m.startLoading()
+			return m, func() tea.Msg {
+				result, err := m.helmClient.ValidateValuesFile(chartName, version, path)
+				return valuesValidatedMsg{path: path, result: result, err: err}
+			}
+
+		case settingMode:
+			m.mode = normalMode
+			m.searchInput.Blur()
+			return m.applySetting(m.editSetting, m.searchInput.Value())
+
+		case selectorMode:
+			m.releaseSelector = strings.TrimSpace(m.searchInput.Value())
+			m.mode = normalMode
+			m.searchInput.Blur()
+			m.startLoading()
+			return m, m.loadReleases(m.selectedNamespace)
+
+		case localDiffMode:
+			path := m.searchInput.Value()
+			if path == "" {
+				path = "./values.yaml"
+			}
+			m.mode = normalMode
+			m.searchInput.Blur()
+			return m.diffAgainstLocalFile(path)
+
+		case releaseGrepMode:
+			m.mode = normalMode
+			m.searchInput.Blur()
+			query := strings.TrimSpace(m.searchInput.Value())
+			namespace := m.selectedNamespace
+			if rest, ok := strings.CutPrefix(query, "-A "); ok {
+				query, namespace = strings.TrimSpace(rest), ""
+			}
+			if query == "" {
+				return m, nil
+			}
+			// Pages still loading would be mixed with the matches
+			m.releaseLoadSeq++
+			m.loadingMore = false
+			gen := m.startLoading()
+			return m, m.inCluster(grepReleaseValues(m.loadContext(stateReleaseList), m.helmClient, namespace, query, gen))
+
+		case repoGrepMode:
+			m.mode = normalMode
+			m.searchInput.Blur()
+			query := strings.TrimSpace(m.searchInput.Value())
+			if query == "" || m.selectedRepo >= len(m.repos) {
+				return m, nil
+			}
+			gen := m.startLoading()
+			return m, grepRepoValues(m.loadContext(stateChartList), m.helmClient, m.cache, m.repos[m.selectedRepo].Name, m.charts, query, gen)
+
+		case jumpKeyMode:
+			m.mode = normalMode
+			m.searchInput.Blur()
+			if len(m.keyMatches) == 0 {
+				return m, nil
+			}
+			// Shown like a search match on the key, so n/N and y work as usual
+			target := m.keyMatches[0]
+			m.keyMatches = nil
+			m.searchMatches = []int{target.Line}
+			m.currentMatchIndex = 0
+			m.lastSearchQuery = target.Path[strings.LastIndexAny(target.Path, ".]")+1:]
+			m.searchPattern = nil
+			if m.state == stateReleaseValues {
+				m.updateReleaseValuesViewWithSearch()
+			} else {
+				m.updateValuesViewWithSearch()
+			}
+			m = m.jumpToMatch()
+			return m, nil
+
+		case gotoMode:
+			m.mode = normalMode
+			m.searchInput.Blur()
+			matches := m.gotoMatches
+			m.gotoMatches = nil
+			if len(matches) == 0 {
+				return m, nil
+			}
+			return m.gotoTarget(matches[0])
+
+		case manifestFormatMode:
+			m.manifestFormat = strings.TrimSpace(m.searchInput.Value())
+			if m.manifestFormat == "" {
+				m.manifestFormat = gitops.Formats[0]
+			}
+			m.mode = manifestPathMode
+			m.searchInput.Reset()
+			m.searchInput.Placeholder = gitops.DefaultFileName(m.manifestFormat, m.manifestRef)
+
+		case manifestPathMode:
+			path := m.searchInput.Value()
+			if path == "" {
+				path = m.searchInput.Placeholder
+			}
+			m.mode = normalMode
+			m.searchInput.Blur()
+
+			manifestCmd := generateManifest(m.helmClient, m.manifestFormat, m.manifestRef, path)
+			m.lastAction = &repeatableAction{label: fmt.Sprintf("%s manifest to %s", m.manifestFormat, path), cmd: manifestCmd}
+			return m, manifestCmd
+
+		case templatePathMode:
+			m.templatePath = m.searchInput.Value()
+			if m.templatePath == "" {
+				m.templatePath = m.config.TemplatePath
+			}
+			m.mode = templateReleaseMode
+			m.searchInput.Reset()
+			m.searchInput.Placeholder = "myrelease"
+			m.searchInput.SetValue(m.templateRelease)
+
+		case templateReleaseMode:
+			m.templateRelease = strings.TrimSpace(m.searchInput.Value())
+			m.mode = templateNamespaceMode
+			m.searchInput.Reset()
+			m.searchInput.Placeholder = m.helmClient.Namespace()
+			m.searchInput.SetValue(m.templateNamespace)
+
+		case templateNamespaceMode:
+			m.templateNamespace = strings.TrimSpace(m.searchInput.Value())
+			m.mode = templateKubeVersionMode
+			m.searchInput.Reset()
+			m.searchInput.Placeholder = "e.g. 1.29.0 (optional)..."
+			m.searchInput.SetValue(m.templateKubeVersion)
+
+		case templateKubeVersionMode:
+			m.templateKubeVersion = strings.TrimSpace(m.searchInput.Value())
+			m.mode = templateValuesMode
+			m.searchInput.Reset()
+			m.searchInput.Placeholder = "Values file (optional)..."
+
+		case templateValuesMode:
+			m.templateValues = m.searchInput.Value()
+			chartName, version := m.charts[m.selectedChart].Name, ""
+			if m.state == stateValueViewer && m.selectedVersion < len(m.versions) {
+				version = m.versions[m.selectedVersion].Version
+			}
+			return m, m.startOverrides(templateSetMode, chartName, version)
+
+		case templateShowOnlyMode:
+			m.mode = normalMode
+			m.searchInput.Blur()
+			m.templateSourceMatches = nil
+			opts := m.templateOpts
+			opts.ShowOnly = nil
+			for _, template := range strings.Split(m.searchInput.Value(), ",") {
+				if template = strings.TrimSpace(template); template != "" {
+					opts.ShowOnly = append(opts.ShowOnly, template)
+				}
+			}
+			m.startLoading()
+			return m, previewTemplate(m.helmClient, m.templateChart, opts)
+
+		case templateSetMode, upgradeSetMode:
+			if override := strings.TrimSpace(m.searchInput.Value()); override != "" {
+				if err := helm.ParseOverride(override); err != nil {
+					return m, m.setSuccessMsg(err.Error())
+				}
+				m.overrides = append(m.overrides, override)
+				m.overrideMatches = nil
+				m.searchInput.Reset()
+				return m, nil
+			}
+			if m.mode == upgradeSetMode && m.upgradeDryRun {
+				opts := helm.DryRunOptions{
+					Chart:      m.upgradeOpts.Chart,
+					Version:    m.upgradeOpts.Version,
+					ValuesFile: m.upgradeOpts.ValuesFile,
+					Set:        m.overrides,
+				}
+				m.mode = normalMode
+				m.searchInput.Blur()
+				return m, tea.Batch(
+					m.setSuccessMsg(fmt.Sprintf("Running a server-side dry run of '%s'...", m.upgradeRel.Name)),
+					m.inCluster(dryRunRelease(m.loadContext(m.state), m.helmClient, m.upgradeRel, opts, m.clusterVersion, m.state)),
+				)
+			}
+			if m.mode == upgradeSetMode {
+				m.upgradeOpts.Set = m.overrides
+				m.mode = normalMode
+				m.searchInput.Blur()
+				return m, tea.Batch(
+					m.setSuccessMsg(fmt.Sprintf("Running helm diff upgrade for '%s'...", m.upgradeRel.Name)),
+					m.inCluster(previewUpgrade(m.loadContext(m.state), m.helmClient, m.upgradeRel, m.upgradeOpts, m.clusterVersion, m.state)),
+				)
+			}
+			m.mode = templatePostRendererMode
+			m.searchInput.Reset()
+			m.searchInput.Placeholder = "Post-renderer command (optional)..."
+			m.searchInput.SetValue(m.postRenderer)
+
+		case templatePostRendererMode:
+			m.postRenderer = strings.TrimSpace(m.searchInput.Value())
+			m.mode = templateValidateMode
+			m.searchInput.Reset()
+			if m.templateCheck {
+				m.searchInput.Placeholder = "Validate against the cluster? (Y/n)"
+			} else {
+				m.searchInput.Placeholder = "Validate against the cluster? (y/N)"
+			}
+
+		case templateValidateMode:
+			switch strings.ToLower(strings.TrimSpace(m.searchInput.Value())) {
+			case "y", "yes":
+				m.templateCheck = true
+			case "n", "no":
+				m.templateCheck = false
+			}
+			m.mode = normalMode
+			m.searchInput.Blur()
+
+			opts := helm.TemplateOptions{
+				ReleaseName:  m.templateRelease,
+				Namespace:    m.templateNamespace,
+				KubeVersion:  m.templateKubeVersion,
+				ValuesFile:   m.templateValues,
+				Set:          m.overrides,
+				OutputDir:    m.templatePath,
+				PostRenderer: m.postRenderer,
+				Validate:     m.templateCheck,
+			}
+			chartName := m.charts[m.selectedChart].Name
+			if m.state == stateValueViewer && m.selectedVersion < len(m.versions) {
+				opts.Version = m.versions[m.selectedVersion].Version
+			}
+			if m.templatePath == templatePreviewTarget {
+				m.templateReturn = m.state
+				m.templateChart = chartName
+				m.templateSources = nil
+				m.templateLines = nil
+				m.searchMatches = []int{}
+				m.lastSearchQuery = ""
+				m.state = stateTemplatePreview
+				m.startLoading()
+				return m, previewTemplate(m.helmClient, chartName, opts)
+			}
+			sink, err := m.exportSink(m.templatePath)
+			if err != nil {
+				return m, m.setSuccessMsg(fmt.Sprintf("Template failed: %v", err))
 			}
-			if m.state == stateReleaseDetail && m.selectedRelease < len(m.releases) {
-				release := m.releases[m.selectedRelease]
-				m.state = stateReleaseValues
-				m.loadingVals = true
-				return m, loadReleaseValues(m.helmClient, release.Name, release.Namespace)
+			templateCmd := generateTemplate(m.helmClient, chartName, opts)
+			if !export.IsFile(sink) {
+				templateCmd = renderTemplate(m.helmClient, chartName, opts, sink)
 			}
-			return m, nil
+			m.lastAction = &repeatableAction{label: "template to " + m.templatePath, cmd: templateCmd}
+			return m, m.background(templateCmd)
 
-		case key.Matches(msg, m.keys.Copy):
-			if m.state == stateValueViewer && len(m.valuesLines) > 0 {
-				var lineNum int
-				// If we have search matches, use the current match line
-				if len(m.searchMatches) > 0 && m.currentMatchIndex < len(m.searchMatches) {
-					lineNum = m.searchMatches[m.currentMatchIndex]
-				} else {
-					// Otherwise use the current viewport position (center of visible area)
-					lineNum = m.valuesView.YOffset + m.valuesView.Height/2
-					if lineNum >= len(m.valuesLines) {
-						lineNum = len(m.valuesLines) - 1
-					}
+		case saveEditMode:
+			path := m.searchInput.Value()
+			if path == "" {
+				path = "./custom-values.yaml"
+			}
+			m.mode = normalMode
+			m.searchInput.Blur()
+
+			// Save the edited values
+			sink, err := m.exportSink(path)
+			if err == nil {
+				err = sink.Write([]byte(m.editedContent))
+			}
+
+			// Clean up temp file
+			if m.editTempFile != "" {
+				os.Remove(m.editTempFile)
+				m.editTempFile = ""
+			}
+
+			m.editedContent = "" // Clear edited content
+			m.editSchemaErrors = nil
+			if err != nil {
+				return m, m.setSuccessMsg(fmt.Sprintf("Error saving: %v", err))
+			} else {
+				return m, m.setSuccessMsg(fmt.Sprintf("✓ Values saved to %s", sink))
+			}
+
+		case upgradeChartMode:
+			m.upgradeOpts.Chart = strings.TrimSpace(m.searchInput.Value())
+			if m.upgradeOpts.Chart == "" {
+				m.upgradeOpts.Chart = m.searchInput.Placeholder
+			}
+			m.mode = upgradeVersionMode
+			m.searchInput.Reset()
+			m.searchInput.Placeholder = "latest"
+
+		case upgradeVersionMode:
+			m.upgradeOpts.Version = strings.TrimSpace(m.searchInput.Value())
+			m.mode = upgradeValuesMode
+			m.searchInput.Reset()
+			m.searchInput.Placeholder = "Values file (empty reuses the release values)..."
+
+		case registryHostMode:
+			m.registryHost = strings.TrimSpace(m.searchInput.Value())
+			if m.registryHost == "" {
+				m.registryHost = m.searchInput.Placeholder
+			}
+			m.mode = registryUsernameMode
+			m.searchInput.Reset()
+			m.searchInput.Placeholder = ""
+
+		case registryUsernameMode:
+			m.registryUser = strings.TrimSpace(m.searchInput.Value())
+			m.mode = registryPasswordMode
+			m.searchInput.Reset()
+			m.searchInput.Placeholder = "password or access token"
+			m.searchInput.EchoMode = textinput.EchoPassword
+
+		case registryPasswordMode:
+			password := m.searchInput.Value()
+			m.mode = normalMode
+			m.searchInput.Reset()
+			m.searchInput.EchoMode = textinput.EchoNormal
+			m.searchInput.Blur()
+			m.startLoading()
+			return m, loginRegistry(m.helmClient, m.registryHost, m.registryUser, password)
+
+		case confirmRegistryLogoutMode:
+			m.mode = normalMode
+			m.searchInput.Blur()
+			if !isYes(m.searchInput.Value()) {
+				return m, m.setSuccessMsg("Logout cancelled")
+			}
+			m.startLoading()
+			return m, logoutRegistry(m.helmClient, m.registryHost)
+
+		case helmfilePathMode:
+			path := strings.TrimSpace(m.searchInput.Value())
+			if path == "" {
+				path = m.searchInput.Placeholder
+			}
+			m.mode = normalMode
+			m.searchInput.Blur()
+			m.helmfileList.ResetSelected()
+			return m, loadHelmfile(expandHome(path), gitops.DefaultEnvironment)
+
+		case helmfileEnvMode:
+			environment := strings.TrimSpace(m.searchInput.Value())
+			if environment == "" {
+				environment = m.searchInput.Placeholder
+			}
+			m.mode = normalMode
+			m.searchInput.Blur()
+			return m, loadHelmfile(m.helmfile.Path, environment)
+
+		case helmfileActionMode:
+			m.mode = normalMode
+			m.searchInput.Blur()
+			release := m.helmfileRelease
+			switch strings.ToLower(strings.TrimSpace(m.searchInput.Value())) {
+			case "v", "values":
+				values, err := m.helmfile.ReleaseValues(release)
+				if err != nil {
+					return m, m.setSuccessMsg(err.Error())
+				}
+				content := fmt.Sprintf("Values of %s in environment %s (%s)\n\n", release.Name, m.helmfile.Environment, m.helmfile.Path)
+				if values == "" {
+					content += helpStyle.Render("The release sets no values") + "\n"
+				}
+				for _, line := range strings.Split(strings.TrimSuffix(values, "\n"), "\n") {
+					content += ui.HighlightYAML(line) + "\n"
+				}
+				m.setDiffContent(content)
+				m.diffView.GotoTop()
+				m.state = stateDiffViewer
+				m.diffReturn = stateHelmfile
+				m.valuesDiff = nil
+				m.diffUnified = values
+				m.searchMatches = []int{}
+				m.lastSearchQuery = ""
+				return m, nil
+			case "c", "chart":
+				repoName, _, isRepoChart := strings.Cut(release.Chart, "/")
+				if !isRepoChart || repoName == "." || repoName == ".." || repoName == "" || strings.Contains(repoName, ":") {
+					return m, m.setSuccessMsg(fmt.Sprintf("%s isn't a repository chart", release.Chart))
+				}
+				if !slices.ContainsFunc(m.repos, func(repo helm.Repository) bool { return repo.Name == repoName }) {
+					return m, m.setSuccessMsg(fmt.Sprintf("Add the %s repository to browse %s", repoName, release.Chart))
+				}
+				return m.openItem(config.RecentItem{Kind: config.KindChart, Name: release.Chart, Version: release.Version})
+			case "r", "release":
+				namespace := release.Namespace
+				if namespace == "" {
+					namespace = m.helmClient.Namespace()
 				}
+				return m.openItem(config.RecentItem{Kind: config.KindRelease, Name: release.Name, Namespace: namespace})
+			}
+			return m, nil
 
-				yamlPath := ui.GetYAMLPath(m.valuesLines, lineNum)
-				var copyCmd tea.Cmd
-				if yamlPath != "" {
-					err := clipboard.WriteAll(yamlPath)
-					if err != nil {
-						copyCmd = m.setSuccessMsg("Failed to copy to clipboard")
-					} else {
-						copyCmd = m.setSuccessMsg("Copied: " + yamlPath)
+		case dryRunReleaseMode:
+			m.upgradeRel.Name = strings.TrimSpace(m.searchInput.Value())
+			if m.upgradeRel.Name == "" {
+				m.upgradeRel.Name = m.searchInput.Placeholder
+			}
+			m.mode = dryRunNamespaceMode
+			m.searchInput.Reset()
+			m.searchInput.Placeholder = m.defaultNamespace
+
+		case dryRunNamespaceMode:
+			m.upgradeRel.Namespace = strings.TrimSpace(m.searchInput.Value())
+			if m.upgradeRel.Namespace == "" {
+				m.upgradeRel.Namespace = m.searchInput.Placeholder
+			}
+			m.mode = upgradeValuesMode
+			m.searchInput.Reset()
+			m.searchInput.Placeholder = "Values file (optional)..."
+
+		case upgradeValuesMode:
+			if path := strings.TrimSpace(m.searchInput.Value()); path != "" {
+				m.upgradeOpts.ValuesFile = expandHome(path)
+			}
+			return m, m.startOverrides(upgradeSetMode, m.upgradeOpts.Chart, m.upgradeOpts.Version)
+
+		case ahFilterMode:
+			m.mode = normalMode
+			m.searchInput.Blur()
+			opts, err := artifacthub.ParseSearchOptions(m.searchInput.Value())
+			if err != nil {
+				return m, m.setSuccessMsg(err.Error())
+			}
+			opts.Sort = m.ahFilters.Sort
+			m.ahFilters = opts
+			m.ahLoading = true
+			return m, searchArtifactHub(m.artifactHubClient, m.ahQuery, m.ahFilters, 0)
+
+		case fixPendingMode:
+			release := m.fixRel
+			switch strings.ToLower(strings.TrimSpace(m.searchInput.Value())) {
+			case "r", "rollback":
+				m.fixAction = "rollback"
+				m.mode = confirmFixPendingMode
+				m.searchInput.Reset()
+				m.searchInput.Placeholder = fmt.Sprintf("Roll '%s' back to its previous revision? Workloads will be redeployed; a pending-install has nothing to roll back to (y/n)", release.Name)
+			case "d", "delete":
+				m.fixAction = "unlock"
+				m.mode = confirmFixPendingMode
+				m.searchInput.Reset()
+				m.searchInput.Placeholder = fmt.Sprintf("Delete the record of revision %s of '%s'? Only if no helm command is still running on it; cluster objects are left as they are (y/n)", release.Revision, release.Name)
+			case "u", "uninstall":
+				// Same questions as x
+				m.uninstallRel = release
+				m.uninstallOpts = helm.UninstallOptions{}
+				m.mode = uninstallKeepHistoryMode
+				m.searchInput.Reset()
+				m.searchInput.Placeholder = "Keep release history (--keep-history)? (y/N)"
+			default:
+				m.mode = normalMode
+				m.searchInput.Blur()
+				return m, m.setSuccessMsg("Nothing changed")
+			}
+
+		case confirmFixPendingMode:
+			m.mode = normalMode
+			m.searchInput.Blur()
+			if !isYes(m.searchInput.Value()) {
+				return m, m.setSuccessMsg("Nothing changed")
+			}
+
+			release := m.fixRel
+			if m.fixAction == "rollback" {
+				timeout := m.config.OperationTimeout()
+				return m, m.background(func() tea.Msg {
+					if err := m.helmClient.RollbackRelease(release.Name, release.Namespace, 0, timeout); err != nil {
+						return operationDoneMsg{err: err}
 					}
-				} else {
-					copyCmd = m.setSuccessMsg("No YAML path found for current line")
-				}
-				return m, copyCmd
+					return operationDoneMsg{success: fmt.Sprintf("Release '%s' rolled back", release.Name), refresh: true}
+				})
 			}
-			if m.state == stateReleaseValues && len(m.releaseValuesLines) > 0 {
-				var lineNum int
-				// If we have search matches, use the current match line
-				if len(m.searchMatches) > 0 && m.currentMatchIndex < len(m.searchMatches) {
-					lineNum = m.searchMatches[m.currentMatchIndex]
-				} else {
-					// Otherwise use the current viewport position (center of visible area)
-					lineNum = m.releaseValuesView.YOffset + m.releaseValuesView.Height/2
-					if lineNum >= len(m.releaseValuesLines) {
-						lineNum = len(m.releaseValuesLines) - 1
+			return m, m.background(func() tea.Msg {
+				revision, err := m.helmClient.UnlockRelease(release.Name, release.Namespace)
+				if err != nil {
+					return operationDoneMsg{err: err}
+				}
+				return operationDoneMsg{success: fmt.Sprintf("Deleted pending revision %d of '%s'", revision, release.Name), refresh: true}
+			})
+
+		case batchActionMode:
+			marked := m.marked()
+			switch strings.ToLower(strings.TrimSpace(m.searchInput.Value())) {
+			case "e", "export":
+				m.mode = batchExportMode
+				m.searchInput.Reset()
+				m.searchInput.Placeholder = "Directory for the values files (<namespace>_<release>-values.yaml)..."
+			case "u", "update":
+				m.mode = normalMode
+				m.searchInput.Blur()
+				repos, skipped := m.batchRepositories(marked)
+				if len(repos) == 0 {
+					return m, m.setSuccessMsg("None of the marked releases comes from a known repository")
+				}
+				return m, m.background(func() tea.Msg {
+					for _, repoName := range repos {
+						if err := m.helmClient.UpdateRepository(repoName); err != nil {
+							return operationDoneMsg{err: err}
+						}
 					}
+					success := fmt.Sprintf("Updated %d repositories: %s", len(repos), strings.Join(repos, ", "))
+					if len(skipped) > 0 {
+						success += fmt.Sprintf(" (no repository for %s)", strings.Join(skipped, ", "))
+					}
+					return operationDoneMsg{success: success}
+				})
+			case "x", "uninstall":
+				names := make([]string, len(marked))
+				for i, release := range marked {
+					names[i] = release.Namespace + "/" + release.Name
 				}
+				m.mode = confirmBatchUninstallMode
+				m.searchInput.Reset()
+				m.searchInput.Placeholder = fmt.Sprintf("Uninstall %d releases: %s? (y/n)", len(marked), strings.Join(names, ", "))
+			default:
+				m.mode = normalMode
+				m.searchInput.Blur()
+				return m, m.setSuccessMsg("Nothing changed")
+			}
 
-				yamlPath := ui.GetYAMLPath(m.releaseValuesLines, lineNum)
-				var copyCmd tea.Cmd
-				if yamlPath != "" {
-					err := clipboard.WriteAll(yamlPath)
+		case batchExportMode:
+			dir := expandHome(strings.TrimSpace(m.searchInput.Value()))
+			if dir == "" {
+				dir = "."
+			}
+			m.mode = normalMode
+			m.searchInput.Blur()
+			marked := m.marked()
+			return m, m.background(func() tea.Msg {
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					return operationDoneMsg{err: err}
+				}
+				for _, release := range marked {
+					values, err := m.helmClient.GetReleaseValues(context.Background(), release.Name, release.Namespace)
 					if err != nil {
-						copyCmd = m.setSuccessMsg("Failed to copy to clipboard")
-					} else {
-						copyCmd = m.setSuccessMsg("Copied: " + yamlPath)
+						return operationDoneMsg{err: err}
+					}
+					path := filepath.Join(dir, fmt.Sprintf("%s_%s-values.yaml", release.Namespace, release.Name))
+					if err := os.WriteFile(path, []byte(values), 0644); err != nil {
+						return operationDoneMsg{err: err}
 					}
-				} else {
-					copyCmd = m.setSuccessMsg("No YAML path found for current line")
 				}
-				return m, copyCmd
+				return operationDoneMsg{success: fmt.Sprintf("Values of %d releases exported to %s", len(marked), dir)}
+			})
+
+		case exportDiffMode:
+			path := m.searchInput.Value()
+			if path == "" {
+				path = m.searchInput.Placeholder
+			}
+			m.mode = normalMode
+			m.searchInput.Blur()
+			if m.diffUnified == "" {
+				return m, m.setSuccessMsg("No changes to export")
 			}
-			return m, nil
 
-		case key.Matches(msg, m.keys.Diff):
-			if m.state == stateChartDetail && len(m.versions) > 1 {
-				m.diffMode = true
-				m.compareVersion = m.versionList.Index()
-			} else if m.state == stateReleaseHistory && len(m.releaseHistory) > 1 {
-				m.diffMode = true
-				m.compareRevision = m.releaseHistoryList.Index()
+			sink, err := m.exportSink(path)
+			if err != nil {
+				return m, m.setSuccessMsg(fmt.Sprintf("Export failed: %v", err))
+			}
+			diff := m.diffUnified
+			return m, func() tea.Msg {
+				if err := sink.Write([]byte(diff)); err != nil {
+					return operationDoneMsg{err: err}
+				}
+				return operationDoneMsg{success: fmt.Sprintf("Diff exported to %s", sink)}
 			}
-			return m, nil
 
-		case key.Matches(msg, m.keys.Edit):
-			if m.state == stateValueViewer {
-				if m.values == "" {
-					return m, m.setSuccessMsg("No values to edit")
+		case exportReposMode:
+			path := m.searchInput.Value()
+			if path == "" {
+				path = m.searchInput.Placeholder
+			}
+			m.mode = normalMode
+			m.searchInput.Blur()
+
+			sink, err := m.exportSink(path)
+			if err != nil {
+				return m, m.setSuccessMsg(fmt.Sprintf("Export failed: %v", err))
+			}
+			return m, func() tea.Msg {
+				data, err := m.helmClient.ExportRepositories()
+				if err == nil {
+					err = sink.Write(data)
 				}
-				// Show which editor will be used
-				editor := os.Getenv("EDITOR")
-				if editor == "" {
-					editor = os.Getenv("VISUAL")
-				}
-				if editor == "" {
-					// Check which editor will be found
-					for _, cmd := range []string{"nvim", "vim", "vi"} {
-						if _, err := exec.LookPath(cmd); err == nil {
-							editor = cmd
-							break
-						}
-					}
+				if err != nil {
+					return operationDoneMsg{err: err}
 				}
-				editorCmd := m.setSuccessMsg(fmt.Sprintf("Opening %s...", editor))
-				return m, tea.Batch(editorCmd, openEditorCmd(m.values))
+				return operationDoneMsg{success: fmt.Sprintf("Repositories exported to %s", sink)}
 			}
-			return m, nil
 
-		case key.Matches(msg, m.keys.NextMatch):
-			if (m.state == stateValueViewer || m.state == stateDiffViewer) && len(m.searchMatches) > 0 {
-				m.currentMatchIndex = (m.currentMatchIndex + 1) % len(m.searchMatches)
-				if m.state == stateValueViewer {
-					m.updateValuesViewWithSearch()
-				} else if m.state == stateDiffViewer {
-					m.updateDiffViewWithSearch()
-				}
-				return m.jumpToMatch(), nil
+		case importReposPathMode:
+			m.importReposPath = strings.TrimSpace(m.searchInput.Value())
+			if m.importReposPath == "" {
+				m.importReposPath = m.searchInput.Placeholder
 			}
+			m.importReposPath = expandHome(m.importReposPath)
+			m.mode = importReposStrategyMode
+			m.searchInput.Reset()
+			m.searchInput.Placeholder = "(m)erge with the configured repositories, or (r)eplace them"
 			return m, nil
 
-		case key.Matches(msg, m.keys.PrevMatch):
-			if (m.state == stateValueViewer || m.state == stateDiffViewer) && len(m.searchMatches) > 0 {
-				m.currentMatchIndex = (m.currentMatchIndex - 1 + len(m.searchMatches)) % len(m.searchMatches)
-				if m.state == stateValueViewer {
-					m.updateValuesViewWithSearch()
-				} else if m.state == stateDiffViewer {
-					m.updateDiffViewWithSearch()
-				}
-				return m.jumpToMatch(), nil
+		case importReposStrategyMode:
+			m.mode = normalMode
+			m.searchInput.Blur()
+			var replace bool
+			switch strings.ToLower(strings.TrimSpace(m.searchInput.Value())) {
+			case "m", "merge":
+			case "r", "replace":
+				replace = true
+			default:
+				return m, m.setSuccessMsg("Import cancelled")
 			}
-			return m, nil
+			m.startLoading()
+			return m, importRepositories(m.helmClient, m.importReposPath, replace)
 
-		case msg.String() == "h":
-			// 'h' for history in release detail view
-			if m.state == stateReleaseDetail && m.selectedRelease < len(m.releases) {
-				m.state = stateReleaseHistory
-				return m, nil
+		case confirmBatchUninstallMode:
+			m.mode = normalMode
+			m.searchInput.Blur()
+			if !isYes(m.searchInput.Value()) {
+				return m, m.setSuccessMsg("Uninstall cancelled")
 			}
-			return m, nil
 
-		case key.Matches(msg, m.keys.Left):
-			// Horizontal scroll left (arrow key only)
-			if m.state == stateValueViewer {
-				if m.horizontalOffset > 0 {
-					m.horizontalOffset -= 5
-					if m.horizontalOffset < 0 {
-						m.horizontalOffset = 0
+			marked := m.marked()
+			opts := helm.UninstallOptions{Timeout: m.config.OperationTimeout()}
+			return m, m.background(func() tea.Msg {
+				var result batchUninstalledMsg
+				for _, release := range marked {
+					if err := m.helmClient.UninstallRelease(release.Name, release.Namespace, opts); err != nil {
+						result.failed = append(result.failed, release)
+						result.errs = append(result.errs, err)
+						continue
 					}
-					m.updateValuesViewWithSearch()
+					result.uninstalled = append(result.uninstalled, release)
 				}
-			} else if m.state == stateReleaseValues {
-				if m.horizontalOffset > 0 {
-					m.horizontalOffset -= 5
-					if m.horizontalOffset < 0 {
-						m.horizontalOffset = 0
-					}
-					m.updateReleaseValuesViewWithSearch()
+				return result
+			})
+
+		case uninstallKeepHistoryMode:
+			m.uninstallOpts.KeepHistory = isYes(m.searchInput.Value())
+			m.mode = uninstallWaitMode
+			m.searchInput.Reset()
+			m.searchInput.Placeholder = "Wait for resources to be deleted (--wait)? (y/N)"
+
+		case uninstallWaitMode:
+			m.uninstallOpts.Wait = isYes(m.searchInput.Value())
+			m.mode = confirmUninstallMode
+			m.searchInput.Reset()
+			m.searchInput.Placeholder = fmt.Sprintf("Uninstall '%s' from namespace '%s'? (y/n)", m.uninstallRel.Name, m.uninstallRel.Namespace)
+
+		case confirmUninstallMode:
+			m.mode = normalMode
+			m.searchInput.Blur()
+			if !isYes(m.searchInput.Value()) {
+				return m, m.setSuccessMsg("Uninstall cancelled")
+			}
+
+			release, opts := m.uninstallRel, m.uninstallOpts
+			opts.Timeout = m.config.OperationTimeout()
+			return m, m.background(func() tea.Msg {
+				if err := m.helmClient.UninstallRelease(release.Name, release.Namespace, opts); err != nil {
+					return operationDoneMsg{err: err}
 				}
-			} else if m.state == stateReleaseDetail {
-				if m.horizontalOffset > 0 {
-					m.horizontalOffset -= 5
-					if m.horizontalOffset < 0 {
-						m.horizontalOffset = 0
-					}
-					m.updateReleaseDetailView()
+				success := fmt.Sprintf("Release '%s' uninstalled", release.Name)
+				if opts.KeepHistory {
+					success += " (history kept)"
 				}
-			}
-			return m, nil
+				return operationDoneMsg{success: success, refresh: true}
+			})
 
-		case key.Matches(msg, m.keys.Right):
-			// Horizontal scroll right (arrow key only)
-			if m.state == stateValueViewer {
-				m.horizontalOffset += 5
-				m.updateValuesViewWithSearch()
-			} else if m.state == stateReleaseValues {
-				m.horizontalOffset += 5
-				m.updateReleaseValuesViewWithSearch()
-			} else if m.state == stateReleaseDetail {
-				m.horizontalOffset += 5
-				m.updateReleaseDetailView()
-			}
-			return m, nil
-		}
+		case confirmRemoveRepoMode:
+			response := strings.ToLower(m.searchInput.Value())
+			m.mode = normalMode
+			m.searchInput.Blur()
+
+			if response == "y" || response == "yes" {
+				// Remove the repository - use selected item to handle filtered lists
+				selectedItem := m.repoList.SelectedItem()
+				if selectedItem != nil {
+					item := selectedItem.(listItem)
+					repoName := item.title
+					return m, func() tea.Msg {
+						removed, err := m.helmClient.RemoveRepository(repoName)
+						if err != nil {
+							return operationDoneMsg{err: err}
+						}
 
-	case chartsLoadedMsg:
-		m.loading = false
-		if msg.err != nil {
-			m.err = msg.err
-			return m, nil
-		}
+						// Reload repositories
+						repos, repoErr := m.helmClient.ListRepositories()
+						if repoErr != nil {
+							return operationDoneMsg{success: fmt.Sprintf("Repository '%s' removed, but failed to reload list", repoName)}
+						}
 
-		m.charts = msg.charts
-		items := make([]list.Item, len(msg.charts))
-		for i, chart := range msg.charts {
-			name := chart.Name
-			if m.selectedRepo < len(m.repos) {
-				name = strings.TrimPrefix(name, m.repos[m.selectedRepo].Name+"/")
-			}
-			items[i] = listItem{
-				title:       name,
-				description: chart.Description,
+						return repoRemovedMsg{repos: repos, repoName: repoName, removed: removed}
+					}
+				}
 			}
+			return m, nil
 		}
-		m.chartList.SetItems(items)
 		return m, nil
+	}
 
-	case versionsLoadedMsg:
-		m.loading = false
-		if msg.err != nil {
-			m.err = msg.err
-			return m, nil
-		}
+	m.searchInput, cmd = m.searchInput.Update(msg)
 
-		m.versions = msg.versions
-		items := make([]list.Item, len(msg.versions))
-		for i, ver := range msg.versions {
-			desc := ""
-			if ver.AppVersion != "" {
-				desc = "App: " + ver.AppVersion
-			}
-			items[i] = listItem{
-				title:       "v" + ver.Version,
-				description: desc,
+	if m.mode == gotoMode {
+		m.gotoMatches = m.findGotoTargets(m.searchInput.Value())
+		return m, cmd
+	}
+
+	if m.mode == templateSetMode || m.mode == upgradeSetMode {
+		m.updateOverrideMatches()
+		return m, cmd
+	}
+
+	if m.mode == templateShowOnlyMode {
+		m.templateSourceMatches = nil
+		if typed := m.searchInput.Value(); typed != "" && !strings.Contains(typed, ",") {
+			for _, match := range fuzzy.Find(typed, m.templateSources) {
+				m.templateSourceMatches = append(m.templateSourceMatches, m.templateSources[match.Index])
 			}
 		}
-		m.versionList.SetItems(items)
-		return m, nil
+		return m, cmd
+	}
 
-	case valuesLoadedMsg:
-		m.loadingVals = false
-		if msg.err != nil {
-			m.err = msg.err
-			return m, nil
+	if m.mode == jumpKeyMode {
+		paths := make([]string, len(m.keyPaths))
+		for i, keyPath := range m.keyPaths {
+			paths[i] = keyPath.Path
 		}
+		m.keyMatches = nil
+		for _, match := range fuzzy.Find(m.searchInput.Value(), paths) {
+			m.keyMatches = append(m.keyMatches, m.keyPaths[match.Index])
+		}
+		return m, cmd
+	}
 
-		m.values = msg.values
-		m.valuesLines = strings.Split(msg.values, "\n")
-		highlighted := ui.HighlightYAMLContent(msg.values)
-		m.valuesView.SetContent(highlighted)
-		m.updateValuesViewWithSearch()
-		return m, nil
+	m.searchErr = nil
+	if m.mode == searchMode && m.searchInput.Value() != "" {
+		query := strings.ToLower(m.searchInput.Value())
 
-	case operationDoneMsg:
-		if msg.err != nil {
-			m.err = msg.err
-			return m, nil
-		} else {
-			return m, m.setSuccessMsg(msg.success)
+		// Text viewers keep the matches of the last valid pattern while a
+		// regular expression is being typed
+		pattern, err := ui.NewSearchPattern(m.searchInput.Value(), m.searchOptions)
+		if err == nil {
+			m.searchPattern = pattern
+		} else if m.searchPattern == nil {
+			m.searchPattern = new(ui.SearchPattern)
+		}
+		if m.searchesLines() {
+			m.searchErr = err
 		}
 
+		switch m.state {
+		case stateRepoList:
+			matches := fuzzy.Find(query, reposToStrings(m.repos))
+			matched := make([]helm.Repository, len(matches))
+			for i, match := range matches {
+				matched[i] = m.repos[match.Index]
+			}
+			m.repoList.SetItems(m.repoItems(matched))
 
-	case reposReloadedMsg:
-		if msg.err == nil {
-			m.repos = msg.repos
-			items := make([]list.Item, len(msg.repos))
-			for i, repo := range msg.repos {
-				items[i] = listItem{
-					title:       repo.Name,
-					description: repo.URL,
-				}
+		case stateChartList:
+			matches := fuzzy.Find(query, chartsToStrings(m.charts))
+			matched := make([]helm.Chart, len(matches))
+			for i, match := range matches {
+				matched[i] = m.charts[match.Index]
 			}
-			m.repoList.SetItems(items)
-			m.mode = normalMode
-			return m, m.setSuccessMsg(fmt.Sprintf("Repository '%s' added successfully", m.newRepoName))
-		}
-		return m, nil
+			m.chartList.SetItems(m.chartListItems(matched))
 
-	case repoRemovedMsg:
-		if msg.err == nil {
-			m.repos = msg.repos
-			items := make([]list.Item, len(msg.repos))
-			for i, repo := range msg.repos {
-				items[i] = listItem{
-					title:       repo.Name,
-					description: repo.URL,
-				}
+		case stateChartDetail:
+			matches := fuzzy.Find(query, versionsToStrings(m.versions))
+			matched := make([]helm.ChartVersion, len(matches))
+			for i, match := range matches {
+				matched[i] = m.versions[match.Index]
 			}
-			m.repoList.SetItems(items)
-			m.mode = normalMode
-			return m, m.setSuccessMsg(fmt.Sprintf("Repository '%s' removed successfully", msg.repoName))
-		}
-		return m, nil
+			m.versionList.SetItems(m.versionListItems(matched))
 
-	case editorFinishedMsg:
-		if msg.err != nil {
-			return m, m.setSuccessMsg(fmt.Sprintf("Editor error: %v", msg.err))
-		}
+		case stateReleaseList:
+			m.releaseFilter = query
+			m.releaseList.SetItems(m.filteredReleaseItems())
 
-		// Validate YAML
-		var yamlData interface{}
-		if err := yaml.Unmarshal([]byte(msg.content), &yamlData); err != nil {
-			// Clean up temp file
-			if msg.filePath != "" {
-				os.Remove(msg.filePath)
-			}
-			return m, m.setSuccessMsg(fmt.Sprintf("Invalid YAML: %v", err))
+		case stateValueViewer, stateReleaseValues, stateReleaseDetail, stateChartReadme, stateReleaseNotes, statePodLogs, stateDiffViewer, stateTemplatePreview:
+			m = m.searchText(m.searchInput.Value())
 		}
+	}
 
-		// Save edited content and temp file path, then ask where to save
-		m.editedContent = msg.content
-		m.editTempFile = msg.filePath
-		m.mode = saveEditMode
-		m.searchInput.Reset()
-		m.searchInput.Placeholder = "./custom-values.yaml"
-		m.searchInput.Focus()
-		return m, nil
+	return m, cmd
+}
 
-	case artifactHubSearchMsg:
-		m.ahLoading = false
-		if msg.err != nil {
-			m.err = msg.err
-			return m, nil
-		}
+// toggleSearchOption flips the search toggle bound to msg
+func (m *model) toggleSearchOption(msg tea.KeyMsg) {
+	if key.Matches(msg, m.keys.SearchCase) {
+		m.searchOptions.CaseSensitive = !m.searchOptions.CaseSensitive
+	} else {
+		m.searchOptions.WholeWord = !m.searchOptions.WholeWord
+	}
+}
 
-		m.ahPackages = msg.packages
-		items := make([]list.Item, len(msg.packages))
-		for i, pkg := range msg.packages {
-			badges := pkg.GetBadges()
-			stars := fmt.Sprintf("⭐%d", pkg.Stars)
-			security := pkg.SecurityReport.GetSecurityBadge()
+// searchOptionsLabel describes the search toggles, e.g. "case-sensitive,
+// whole word"
+func (m model) searchOptionsLabel() string {
+	label := "ignore case"
+	if m.searchOptions.CaseSensitive {
+		label = "case-sensitive"
+	}
+	if m.searchOptions.WholeWord {
+		label += ", whole word"
+	}
+	return label
+}
 
-			desc := fmt.Sprintf("%s | %s %s | %s", pkg.Repository.DisplayName, stars, badges, security)
-			items[i] = listItem{
-				title:       pkg.Name,
-				description: desc,
+// searchAgain repeats a text viewer search, e.g. after a toggle changed
+func (m model) searchAgain(query string) model {
+	pattern, err := ui.NewSearchPattern(query, m.searchOptions)
+	if m.mode == searchMode {
+		m.searchErr = err
+	}
+	if err != nil {
+		return m
+	}
+	m.searchPattern = pattern
+	return m.searchText(query)
+}
+
+// searchText finds the lines of the current text viewer matching
+// m.searchPattern, highlighting and scrolling to the first one
+func (m model) searchText(query string) model {
+	switch m.state {
+	case stateValueViewer:
+		// Find all matches in values
+		m.searchMatches = []int{}
+		m.lastSearchQuery = query
+		for i, line := range m.valuesLines {
+			if m.searchPattern.Match(line) {
+				m.searchMatches = append(m.searchMatches, i)
 			}
 		}
-		m.ahPackageList.SetItems(items)
-		return m, nil
 
-	case artifactHubPackageMsg:
-		m.ahLoading = false
-		if msg.err != nil {
-			m.err = msg.err
-			return m, nil
-		}
+		// Update the view with highlighted search terms
+		m.updateValuesViewWithSearch()
 
-		m.ahSelectedPackage = msg.pkg
+		// Jump to first match
+		if len(m.searchMatches) > 0 {
+			m.currentMatchIndex = 0
+			targetLine := m.searchMatches[0]
+			if targetLine > m.valuesView.Height/2 {
+				targetLine = targetLine - m.valuesView.Height/2
+			} else {
+				targetLine = 0
+			}
+			m.valuesView.YOffset = targetLine
+		}
 
-		// Populate version list
-		if len(msg.pkg.AvailableVersions) > 0 {
-			items := make([]list.Item, len(msg.pkg.AvailableVersions))
-			for i, ver := range msg.pkg.AvailableVersions {
-				desc := ""
-				if ver.ContainsSecurityUpdates {
-					desc = "🛡️ Security update"
-				}
-				if ver.Prerelease {
-					desc += " [Pre-release]"
-				}
-				items[i] = listItem{
-					title:       "v" + ver.Version,
-					description: desc,
-				}
+	case stateReleaseValues:
+		// Find all matches in release values
+		m.searchMatches = []int{}
+		m.lastSearchQuery = query
+		for i, line := range m.releaseValuesLines {
+			if m.searchPattern.Match(line) {
+				m.searchMatches = append(m.searchMatches, i)
 			}
-			m.ahVersionList.SetItems(items)
 		}
-		return m, nil
 
-	case clearSuccessMsgMsg:
-		m.successMsg = ""
-		return m, nil
+		// Update the view with highlighted search terms
+		m.updateReleaseValuesViewWithSearch()
 
-	case releasesLoadedMsg:
-		m.loading = false
-		if msg.err != nil {
-			m.err = msg.err
-			return m, nil
+		// Jump to first match
+		if len(m.searchMatches) > 0 {
+			m.currentMatchIndex = 0
+			targetLine := m.searchMatches[0]
+			if targetLine > m.releaseValuesView.Height/2 {
+				targetLine = targetLine - m.releaseValuesView.Height/2
+			} else {
+				targetLine = 0
+			}
+			m.releaseValuesView.YOffset = targetLine
 		}
 
-		m.releases = msg.releases
-		items := make([]list.Item, len(msg.releases))
-		for i, release := range msg.releases {
-			desc := fmt.Sprintf("%s | %s | %s", release.Namespace, release.Chart, release.Status)
-			items[i] = listItem{
-				title:       release.Name,
-				description: desc,
+	case stateReleaseDetail:
+		// Find all matches in status, history and notes
+		m.searchMatches = []int{}
+		m.lastSearchQuery = query
+		for i, line := range m.releaseDetailLines {
+			if m.searchPattern.Match(line) {
+				m.searchMatches = append(m.searchMatches, i)
 			}
 		}
-		m.releaseList.SetItems(items)
-		return m, nil
-
-	case namespacesLoadedMsg:
-		m.loading = false
-		if msg.err != nil {
-			m.err = msg.err
-			return m, nil
+		m.currentMatchIndex = 0
+		m.updateReleaseDetailView()
+		m = m.jumpToMatch()
+
+	case stateChartReadme:
+		m.searchMatches = []int{}
+		m.lastSearchQuery = query
+		for i, line := range m.readmeLines {
+			if m.searchPattern.Match(ansi.Strip(line)) {
+				m.searchMatches = append(m.searchMatches, i)
+			}
 		}
-
-		m.namespaces = msg.namespaces
-		items := make([]list.Item, len(msg.namespaces))
-		for i, ns := range msg.namespaces {
-			items[i] = listItem{
-				title:       ns,
-				description: "Kubernetes namespace",
+		m.currentMatchIndex = 0
+		m.updateReadmeViewWithSearch()
+		m = m.jumpToMatch()
+
+	case stateReleaseNotes:
+		m.searchMatches = []int{}
+		m.lastSearchQuery = query
+		for i, line := range m.releaseNotesLines {
+			if m.searchPattern.Match(line) {
+				m.searchMatches = append(m.searchMatches, i)
 			}
 		}
-		m.namespaceList.SetItems(items)
-		return m, nil
-
-	case releaseHistoryLoadedMsg:
-		m.loading = false
-		if msg.err != nil {
-			m.err = msg.err
-			return m, nil
+		m.currentMatchIndex = 0
+		m.updateReleaseNotesViewWithSearch()
+		m = m.jumpToMatch()
+
+	case stateTemplatePreview:
+		m.searchMatches = []int{}
+		m.lastSearchQuery = query
+		for i, line := range m.templateLines {
+			if m.searchPattern.Match(line) {
+				m.searchMatches = append(m.searchMatches, i)
+			}
 		}
-
-		m.releaseHistory = msg.history
-		items := make([]list.Item, len(msg.history))
-		for i, rev := range msg.history {
-			desc := fmt.Sprintf("%s | %s | %s", rev.Status, rev.Chart, rev.Updated)
-			items[i] = listItem{
-				title:       fmt.Sprintf("Revision %d", rev.Revision),
-				description: desc,
+		m.currentMatchIndex = 0
+		m.updateTemplateViewWithSearch()
+		m = m.jumpToMatch()
+
+	case statePodLogs:
+		// Pause so the view stays on the matches
+		m.logs.following = false
+		m.searchMatches = []int{}
+		m.lastSearchQuery = query
+		for i, line := range m.logs.lines[:m.logs.shown] {
+			if m.searchPattern.Match(line) {
+				m.searchMatches = append(m.searchMatches, i)
 			}
 		}
-		m.releaseHistoryList.SetItems(items)
+		m.currentMatchIndex = 0
+		m.updatePodLogsView()
+		m = m.jumpToMatch()
 
-		// Update detail view if we're showing it
-		if m.state == stateReleaseDetail {
-			m.updateReleaseDetailView()
+	case stateDiffViewer:
+		// Find all matches in diff
+		m.searchMatches = []int{}
+		m.lastSearchQuery = query
+		for i, line := range m.diffLines {
+			// Changed words are styled apart from the rest of their line
+			if m.searchPattern.Match(ansi.Strip(line)) {
+				m.searchMatches = append(m.searchMatches, i)
+			}
 		}
-		return m, nil
 
-	case releaseValuesLoadedMsg:
-		m.loadingVals = false
-		if msg.err != nil {
-			m.err = msg.err
-			return m, nil
+		// Update the view with highlighted search terms
+		m.updateDiffViewWithSearch()
+
+		// Jump to first match
+		if len(m.searchMatches) > 0 {
+			m.currentMatchIndex = 0
+			targetLine := m.searchMatches[0]
+			if targetLine > m.diffView.Height/2 {
+				targetLine = targetLine - m.diffView.Height/2
+			} else {
+				targetLine = 0
+			}
+			m.diffView.YOffset = targetLine
 		}
+	}
+	return m
+}
 
-		m.releaseValues = msg.values
-		m.releaseValuesLines = strings.Split(msg.values, "\n")
-		highlighted := ui.HighlightYAMLContent(msg.values)
-		m.releaseValuesView.SetContent(highlighted)
+// submitNewRepo adds the repository collected by the add-repo prompt, asking
+// for confirmation first when its URL is already configured under another name
+func (m model) submitNewRepo() (tea.Model, tea.Cmd) {
+	if existing := helm.FindRepositoryByURL(m.repos, m.newRepoURL); existing != nil && existing.Name != m.newRepoName {
+		m.mode = confirmDuplicateRepoMode
+		m.searchInput.Reset()
+		m.searchInput.Placeholder = fmt.Sprintf("URL already added as '%s'. Add '%s' anyway? (y/n, n reuses '%s')", existing.Name, m.newRepoName, existing.Name)
+		m.searchInput.Focus()
 		return m, nil
+	}
 
-	case releaseStatusLoadedMsg:
-		m.loading = false
-		if msg.err != nil {
-			m.err = msg.err
-			return m, nil
+	m.mode = normalMode
+	m.searchInput.Blur()
+	opts := m.newRepoOpts
+	m.newRepoOpts = helm.RepositoryOptions{}
+	return m, addRepository(m.helmClient, m.newRepoName, m.newRepoURL, opts)
+}
+
+// nextAddRepoStep moves the add-repo prompt to step with an empty input
+func (m *model) nextAddRepoStep(step int, placeholder string) {
+	m.addRepoStep = step
+	m.searchInput.Reset()
+	m.searchInput.EchoMode = textinput.EchoNormal
+	m.searchInput.Placeholder = placeholder
+}
+
+// expandHome expands a leading ~/ in a path typed at a prompt
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
 		}
+	}
+	return path
+}
 
-		m.releaseStatus = msg.status
+// expandHomeList expands each path of a KUBECONFIG-style path list
+func expandHomeList(paths string) string {
+	list := filepath.SplitList(paths)
+	for i, path := range list {
+		list[i] = expandHome(path)
+	}
+	return strings.Join(list, string(filepath.ListSeparator))
+}
 
-		// Update detail view if we're showing it
-		if m.state == stateReleaseDetail {
-			m.updateReleaseDetailView()
-		}
-		return m, nil
+func reposToStrings(repos []helm.Repository) []string {
+	result := make([]string, len(repos))
+	for i, r := range repos {
+		result[i] = r.Name
+	}
+	return result
+}
 
-	case kubeContextLoadedMsg:
-		if msg.err != nil {
-			// Context error is not fatal, just don't show it
-			m.kubeContext = "unknown"
-		} else {
-			m.kubeContext = msg.context
-		}
-		return m, nil
+func chartsToStrings(charts []helm.Chart) []string {
+	result := make([]string, len(charts))
+	for i, c := range charts {
+		result[i] = c.Name
 	}
+	return result
+}
 
+// valuesCursor returns the lines of the values being viewed and the line the
+// copy actions apply to: the current search match, or the middle of the
+// visible area
+func (m model) valuesCursor() ([]string, int, bool) {
+	var lines []string
+	var view viewport.Model
 	switch m.state {
-	case stateMainMenu:
-		m.mainMenu, cmd = m.mainMenu.Update(msg)
-		cmds = append(cmds, cmd)
-	case stateBrowseMenu:
-		m.browseMenu, cmd = m.browseMenu.Update(msg)
-		cmds = append(cmds, cmd)
-	case stateRepoList:
-		m.repoList, cmd = m.repoList.Update(msg)
-		cmds = append(cmds, cmd)
-	case stateChartList:
-		m.chartList, cmd = m.chartList.Update(msg)
-		cmds = append(cmds, cmd)
-	case stateChartDetail:
-		m.versionList, cmd = m.versionList.Update(msg)
-		cmds = append(cmds, cmd)
 	case stateValueViewer:
-		m.valuesView, cmd = m.valuesView.Update(msg)
-		cmds = append(cmds, cmd)
-	case stateDiffViewer:
-		m.diffView, cmd = m.diffView.Update(msg)
-		cmds = append(cmds, cmd)
-	case stateArtifactHubSearch:
-		m.ahPackageList, cmd = m.ahPackageList.Update(msg)
-		cmds = append(cmds, cmd)
-	case stateArtifactHubPackageDetail:
-		m.ahVersionList, cmd = m.ahVersionList.Update(msg)
-		cmds = append(cmds, cmd)
-	case stateArtifactHubVersions:
-		m.ahVersionList, cmd = m.ahVersionList.Update(msg)
-		cmds = append(cmds, cmd)
-	case stateClusterReleasesMenu:
-		m.clusterReleasesMenu, cmd = m.clusterReleasesMenu.Update(msg)
-		cmds = append(cmds, cmd)
-	case stateNamespaceList:
-		m.namespaceList, cmd = m.namespaceList.Update(msg)
-		cmds = append(cmds, cmd)
-	case stateReleaseList:
-		m.releaseList, cmd = m.releaseList.Update(msg)
-		cmds = append(cmds, cmd)
-	case stateReleaseDetail:
-		m.releaseDetailView, cmd = m.releaseDetailView.Update(msg)
-		cmds = append(cmds, cmd)
-	case stateReleaseHistory:
-		m.releaseHistoryList, cmd = m.releaseHistoryList.Update(msg)
-		cmds = append(cmds, cmd)
+		lines, view = m.valuesLines, m.valuesView
 	case stateReleaseValues:
-		m.releaseValuesView, cmd = m.releaseValuesView.Update(msg)
-		cmds = append(cmds, cmd)
+		lines, view = m.releaseValuesLines, m.releaseValuesView
+	default:
+		return nil, 0, false
+	}
+	if len(lines) == 0 {
+		return nil, 0, false
 	}
 
-	return m, tea.Batch(cmds...)
+	if len(m.searchMatches) > 0 && m.currentMatchIndex < len(m.searchMatches) {
+		return lines, m.searchMatches[m.currentMatchIndex], true
+	}
+	lineNum := view.YOffset + view.Height/2
+	if lineNum >= len(lines) {
+		lineNum = len(lines) - 1
+	}
+	return lines, lineNum, true
 }
 
-func (m model) handleBack() (tea.Model, tea.Cmd) {
-	// Clear success message and search results
-	m.successMsg = ""
-	m.searchMatches = []int{}
-	m.lastSearchQuery = ""
-	m.horizontalOffset = 0
+// yamlPath returns the path of a values line in the configured path format,
+// or in the other one when alternate is set
+func (m model) yamlPath(lines []string, lineNum int, alternate bool) string {
+	setFormat := m.config.PathFormat == config.PathFormatSet
+	if alternate {
+		setFormat = !setFormat
+	}
+	if setFormat {
+		return ui.GetYAMLSetPath(lines, lineNum)
+	}
+	return ui.GetYAMLPath(lines, lineNum)
+}
 
-	if m.diffMode {
-		m.diffMode = false
-		return m, nil
+// installSnippet returns a ready-to-run helm install command for a chart
+// version, named after the chart and targeting the default namespace
+func (m model) installSnippet(chartName, version string) string {
+	releaseName := chartName
+	if idx := strings.LastIndex(chartName, "/"); idx >= 0 {
+		releaseName = chartName[idx+1:]
+	}
+	return fmt.Sprintf("helm install %s %s --version %s -n %s", releaseName, chartName, version, m.defaultNamespace)
+}
+
+// selectedChartRef describes the chart version under the cursor, released
+// under the chart's short name into the default namespace
+func (m model) selectedChartRef() (gitops.ChartRef, bool) {
+	if m.selectedChart >= len(m.charts) || m.selectedRepo >= len(m.repos) {
+		return gitops.ChartRef{}, false
 	}
 
+	var version string
 	switch m.state {
-	case stateBrowseMenu:
-		m.state = stateMainMenu
-	case stateRepoList:
-		m.state = stateBrowseMenu
-	case stateChartList:
-		m.state = stateRepoList
-		m.charts = nil
-		m.chartList.SetItems([]list.Item{})
 	case stateChartDetail:
-		m.state = stateChartList
-		m.versions = nil
-		m.versionList.SetItems([]list.Item{})
+		selectedItem := m.versionList.SelectedItem()
+		if selectedItem == nil {
+			return gitops.ChartRef{}, false
+		}
+		version = strings.TrimPrefix(selectedItem.(listItem).title, "v")
 	case stateValueViewer:
-		m.state = stateChartDetail
-		m.values = ""
-		m.valuesLines = nil
-	case stateDiffViewer:
-		// Return to release history if we were comparing revisions, otherwise chart detail
-		if m.compareRevision >= 0 {
-			m.state = stateReleaseHistory
-			m.compareRevision = -1
-		} else {
-			m.state = stateChartDetail
+		if m.selectedVersion >= len(m.versions) {
+			return gitops.ChartRef{}, false
 		}
-	case stateArtifactHubSearch:
-		m.state = stateBrowseMenu
-		m.ahPackages = nil
-		m.ahPackageList.SetItems([]list.Item{})
-	case stateArtifactHubPackageDetail:
-		m.state = stateArtifactHubSearch
-		m.ahSelectedPackage = nil
-		m.ahVersionList.SetItems([]list.Item{})
-	case stateArtifactHubVersions:
-		m.state = stateArtifactHubPackageDetail
-	case stateClusterReleasesMenu:
-		m.state = stateMainMenu
-	case stateNamespaceList:
-		m.state = stateClusterReleasesMenu
-		m.namespaces = nil
-		m.namespaceList.SetItems([]list.Item{})
-	case stateReleaseList:
-		if m.selectedNamespace == "" {
-			// Came from "All Namespaces"
-			m.state = stateClusterReleasesMenu
+		version = m.versions[m.selectedVersion].Version
+	default:
+		return gitops.ChartRef{}, false
+	}
+
+	repo := m.repos[m.selectedRepo]
+	chartName := strings.TrimPrefix(m.charts[m.selectedChart].Name, repo.Name+"/")
+	return gitops.ChartRef{
+		RepoName:  repo.Name,
+		RepoURL:   repo.URL,
+		Chart:     chartName,
+		Version:   version,
+		Release:   chartName,
+		Namespace: m.defaultNamespace,
+	}, true
+}
+
+// filteredReleaseItems builds the release list entries matching the active
+// search filter, or all of them when there's none
+func (m model) filteredReleaseItems() []list.Item {
+	if m.releaseFilter == "" {
+		return m.releaseListItems(m.releases)
+	}
+	matches := fuzzy.Find(m.releaseFilter, releasesToStrings(m.releases))
+	matched := make([]helm.Release, len(matches))
+	for i, match := range matches {
+		matched[i] = m.releases[match.Index]
+	}
+	return m.releaseListItems(matched)
+}
+
+// releaseListItems builds the release list entries, describing each release
+// with the columns chosen in the config
+func (m model) releaseListItems(releases []helm.Release) []list.Item {
+	var starred, items []list.Item
+	for _, release := range releases {
+		description := m.releaseDescription(release)
+		if m.releaseGrep != nil {
+			description = release.Namespace + " | " + m.releaseGrep.matches[releaseKey(release)]
+		}
+		item := listItem{
+			title:       release.Name,
+			description: description,
+			namespace:   release.Namespace,
+		}
+		if m.isFavorite(config.Favorite{Kind: config.KindRelease, Name: release.Name, Namespace: release.Namespace}) {
+			starred = append(starred, item)
 		} else {
-			// Came from "Select Namespace"
-			m.state = stateNamespaceList
+			items = append(items, item)
 		}
-		m.releases = nil
-		m.releaseList.SetItems([]list.Item{})
-	case stateReleaseDetail:
-		m.state = stateReleaseList
-	case stateReleaseHistory:
-		m.state = stateReleaseDetail
-		m.updateReleaseDetailView()
-	case stateReleaseValues:
-		m.state = stateReleaseHistory
-		m.releaseValues = ""
-		m.releaseValuesLines = nil
-		m.selectedRevision = 0
-		m.horizontalOffset = 0
 	}
-	return m, nil
+	// Favorites first
+	return append(starred, items...)
 }
 
-func (m model) handleEnter() (tea.Model, tea.Cmd) {
-	// Clear success message
-	m.successMsg = ""
-
-	switch m.state {
-	case stateMainMenu:
-		selectedItem := m.mainMenu.SelectedItem()
-		if selectedItem != nil {
-			item := selectedItem.(listItem)
-			switch item.title {
-			case "Browse Repositories":
-				m.state = stateBrowseMenu
-				return m, nil
-			case "Cluster Releases":
-				m.state = stateClusterReleasesMenu
-				// Load kubectl context
-				return m, func() tea.Msg {
-					ctx, err := m.helmClient.GetCurrentContext()
-					if err != nil {
-						return kubeContextLoadedMsg{err: err}
-					}
-					return kubeContextLoadedMsg{context: ctx}
-				}
-			case "Settings":
-				return m, m.setSuccessMsg("Feature coming soon!")
+func (m model) releaseDescription(release helm.Release) string {
+	var fields []string
+	if m.isFavorite(config.Favorite{Kind: config.KindRelease, Name: release.Name, Namespace: release.Namespace}) {
+		fields = append(fields, "★")
+	}
+	if m.markedReleases[releaseKey(release)] {
+		fields = append(fields, "● marked")
+	}
+	if helm.IsPendingStatus(release.Status) {
+		fields = append(fields, "⚠ stuck in "+release.Status)
+	}
+	for _, column := range m.config.ReleaseColumns {
+		switch column {
+		case config.ColumnNamespace:
+			fields = append(fields, release.Namespace)
+		case config.ColumnChart:
+			fields = append(fields, release.Chart)
+		case config.ColumnAppVersion:
+			fields = append(fields, release.AppVersion)
+		case config.ColumnRevision:
+			fields = append(fields, "rev "+release.Revision)
+		case config.ColumnStatus:
+			fields = append(fields, release.Status)
+		case config.ColumnUpdated:
+			// helm prints e.g. "2025-01-02 15:04:05.999 +0000 UTC"; minutes are enough
+			updated := release.Updated
+			if len(updated) > 16 {
+				updated = updated[:16]
 			}
+			fields = append(fields, updated)
 		}
+	}
+	return strings.Join(fields, " | ")
+}
 
-	case stateBrowseMenu:
-		selectedItem := m.browseMenu.SelectedItem()
-		if selectedItem != nil {
-			item := selectedItem.(listItem)
-			switch item.title {
-			case "Local Repositories":
-				m.state = stateRepoList
-				return m, nil
-			case "Search Artifact Hub":
-				m.mode = searchMode
-				m.searchInput.Reset()
-				m.searchInput.Placeholder = "Search Artifact Hub..."
-				m.searchInput.Focus()
-				m.state = stateArtifactHubSearch
-				return m, nil
+// chartListItems builds the chart list entries, favorites first, leaving out
+// deprecated charts when they are hidden
+func (m model) chartListItems(charts []helm.Chart) []list.Item {
+	var starred []list.Item
+	items := make([]list.Item, 0, len(charts))
+	for _, chart := range charts {
+		if m.hideDeprecated && chart.Deprecated {
+			continue
+		}
+		description := chartDescription(chart)
+		if m.repoGrep != nil {
+			hit, ok := m.repoGrep.matches[chart.Name]
+			if !ok {
+				continue
 			}
+			description = hit
+		}
+		name := chart.Name
+		if m.selectedRepo < len(m.repos) {
+			name = strings.TrimPrefix(name, m.repos[m.selectedRepo].Name+"/")
+		}
+		if m.isFavorite(config.Favorite{Kind: config.KindChart, Name: chart.Name}) {
+			starred = append(starred, listItem{title: name, description: "★ " + description})
+			continue
+		}
+		items = append(items, listItem{
+			title:       name,
+			description: description,
+		})
+	}
+	return append(starred, items...)
+}
+
+// versionListItems builds the version list, flagging versions whose
+// kubeVersion constraint the connected cluster doesn't satisfy
+func (m model) versionListItems(versions []helm.ChartVersion) []list.Item {
+	items := make([]list.Item, len(versions))
+	for i, ver := range versions {
+		desc := ""
+		if ver.AppVersion != "" {
+			desc = "App: " + ver.AppVersion
+		}
+		if !helm.KubeVersionCompatible(ver.KubeVersion, m.clusterVersion) {
+			desc = strings.TrimSpace(fmt.Sprintf("⚠ needs Kubernetes %s, cluster is %s  %s", ver.KubeVersion, m.clusterVersion, desc))
+		}
+		items[i] = listItem{
+			title:       "v" + ver.Version,
+			description: desc,
+		}
+	}
+	return items
+}
+
+// chartDescription renders the latest version and appVersion of a chart as
+// fixed-width columns ahead of its description
+func chartDescription(chart helm.Chart) string {
+	appVersion := chart.AppVersion
+	if appVersion == "" {
+		appVersion = "-"
+	}
+	desc := fmt.Sprintf("%-12s %-12s %s", "v"+chart.Version, "app "+appVersion, chart.Description)
+	if chart.Deprecated {
+		desc = "⚠ DEPRECATED " + desc
+	}
+	return desc
+}
+
+func versionsToStrings(versions []helm.ChartVersion) []string {
+	result := make([]string, len(versions))
+	for i, v := range versions {
+		result[i] = v.Version
+	}
+	return result
+}
+
+func releasesToStrings(releases []helm.Release) []string {
+	result := make([]string, len(releases))
+	for i, r := range releases {
+		result[i] = r.Name
+	}
+	return result
+}
+
+func (m model) jumpToMatch() model {
+	if len(m.searchMatches) == 0 {
+		return m
+	}
+
+	targetLine := m.searchMatches[m.currentMatchIndex]
+
+	// Center the match on screen based on current state
+	if m.state == stateValueViewer {
+		if targetLine > m.valuesView.Height/2 {
+			m.valuesView.YOffset = targetLine - m.valuesView.Height/2
+		} else {
+			m.valuesView.YOffset = 0
+		}
+	} else if m.state == stateReleaseValues {
+		if targetLine > m.releaseValuesView.Height/2 {
+			m.releaseValuesView.YOffset = targetLine - m.releaseValuesView.Height/2
+		} else {
+			m.releaseValuesView.YOffset = 0
 		}
-
-	case stateClusterReleasesMenu:
-		selectedItem := m.clusterReleasesMenu.SelectedItem()
-		if selectedItem != nil {
-			item := selectedItem.(listItem)
-			switch item.title {
-			case "All Namespaces":
-				m.state = stateReleaseList
-				m.selectedNamespace = "" // Empty means all namespaces
-				m.loading = true
-				return m, loadReleases(m.helmClient, "")
-			case "Select Namespace":
-				m.state = stateNamespaceList
-				m.loading = true
-				return m, loadNamespaces(m.helmClient)
-			}
+	} else if m.state == stateReleaseDetail {
+		if targetLine > m.releaseDetailView.Height/2 {
+			m.releaseDetailView.YOffset = targetLine - m.releaseDetailView.Height/2
+		} else {
+			m.releaseDetailView.YOffset = 0
 		}
-
-	case stateNamespaceList:
-		selectedItem := m.namespaceList.SelectedItem()
-		if selectedItem != nil {
-			item := selectedItem.(listItem)
-			m.selectedNamespace = item.title
-			m.state = stateReleaseList
-			m.loading = true
-			return m, loadReleases(m.helmClient, item.title)
+	} else if m.state == stateDiffViewer {
+		if targetLine > m.diffView.Height/2 {
+			m.diffView.YOffset = targetLine - m.diffView.Height/2
+		} else {
+			m.diffView.YOffset = 0
 		}
-
-	case stateReleaseList:
-		selectedItem := m.releaseList.SelectedItem()
-		if selectedItem != nil {
-			item := selectedItem.(listItem)
-			// Find the release by name
-			for i, release := range m.releases {
-				if release.Name == item.title {
-					m.selectedRelease = i
-					m.state = stateReleaseDetail
-					m.loading = true
-					// Load both history and status for the detail view
-					return m, tea.Batch(
-						loadReleaseHistory(m.helmClient, release.Name, release.Namespace),
-						loadReleaseStatus(m.helmClient, release.Name, release.Namespace),
-					)
-				}
-			}
+	} else if m.state == stateChartReadme {
+		if targetLine > m.readmeView.Height/2 {
+			m.readmeView.YOffset = targetLine - m.readmeView.Height/2
+		} else {
+			m.readmeView.YOffset = 0
+		}
+	} else if m.state == stateReleaseNotes {
+		if targetLine > m.releaseNotesView.Height/2 {
+			m.releaseNotesView.YOffset = targetLine - m.releaseNotesView.Height/2
+		} else {
+			m.releaseNotesView.YOffset = 0
 		}
+	} else if m.state == statePodLogs {
+		m.podLogsView.SetYOffset(targetLine - m.podLogsView.Height/2)
+	} else if m.state == stateTemplatePreview {
+		m.templateView.SetYOffset(targetLine - m.templateView.Height/2)
+	}
 
-	case stateReleaseHistory:
-		selectedItem := m.releaseHistoryList.SelectedItem()
-		if selectedItem != nil && m.selectedRelease < len(m.releases) {
-			item := selectedItem.(listItem)
-			release := m.releases[m.selectedRelease]
+	return m
+}
 
-			// Find the selected revision index
-			var selectedIdx int = -1
-			for i, rev := range m.releaseHistory {
-				revTitle := fmt.Sprintf("Revision %d", rev.Revision)
-				if revTitle == item.title {
-					selectedIdx = i
-					break
-				}
+// outlineLines lists the lines of the values outline left visible by the
+// collapsed blocks
+func (m model) outlineLines() []int {
+	var visible []int
+	for i := 0; i < len(m.valuesLines); {
+		visible = append(visible, i)
+		if m.valuesFolded[i] {
+			i = m.valuesBlockEnd[i]
+		} else {
+			i++
+		}
+	}
+	return visible
+}
+
+// updateValuesOutline renders the values outline: ▸ marks a collapsed block,
+// ▾ an expanded one
+func (m *model) updateValuesOutline(viewportWidth int) {
+	visible := m.outlineLines()
+	rendered := make([]string, len(visible))
+	for n, i := range visible {
+		marker := "  "
+		if m.valuesBlockEnd[i] > i+1 {
+			marker = "▾ "
+			if m.valuesFolded[i] {
+				marker = "▸ "
 			}
+		}
+		visibleLine, hasMore := ui.ScrollLine(m.valuesLines[i], m.horizontalOffset, viewportWidth-5-lipgloss.Width(m.lineNumber(i, len(m.valuesLines))))
+		line := ui.HighlightYAMLLine(visibleLine)
+		if i == m.outlineCursor {
+			line = highlightStyle.Render(visibleLine)
+		}
+		if m.valuesFolded[i] {
+			line += helpStyle.Render(fmt.Sprintf(" … %d lines", m.valuesBlockEnd[i]-i-1))
+		} else if hasMore {
+			line += lipgloss.NewStyle().Foreground(lipgloss.Color("141")).Bold(true).Render(" →")
+		}
+		rendered[n] = m.lineNumber(i, len(m.valuesLines)) + marker + line
+	}
+	m.valuesView.SetContent(strings.Join(rendered, "\n"))
+}
 
-			if selectedIdx >= 0 {
-				if m.diffMode {
-					if selectedIdx == m.compareRevision {
-						return m, m.setSuccessMsg("Please select a different revision to compare")
-					}
+// searchesLines reports whether / searches the lines of a text viewer, where
+// re: queries are regular expressions, rather than filtering a list
+func (m model) searchesLines() bool {
+	switch m.state {
+	case stateValueViewer, stateReleaseValues, stateDiffViewer, stateReleaseDetail, stateChartReadme, stateReleaseNotes, statePodLogs, stateTemplatePreview:
+		return true
+	}
+	return false
+}
 
-					revision1 := m.releaseHistory[m.compareRevision].Revision
-					revision2 := m.releaseHistory[selectedIdx].Revision
+// searchRange finds the current search in a line of a text viewer
+func (m model) searchRange(line string) (start, end int) {
+	if m.lastSearchQuery == "" {
+		return -1, -1
+	}
+	if m.searchPattern != nil {
+		return m.searchPattern.Index(line)
+	}
+	return ui.IndexFold(line, m.lastSearchQuery)
+}
 
-					// Get values for both revisions
-					values1, err := m.helmClient.GetReleaseValuesByRevision(release.Name, release.Namespace, revision1)
-					if err != nil {
-						m.err = err
-						m.diffMode = false
-						return m, nil
-					}
+func (m *model) updateValuesViewWithSearch() {
+	lines := strings.Split(m.values, "\n")
+	viewportWidth := m.valuesView.Width
+	if viewportWidth <= 0 {
+		viewportWidth = m.termWidth - 6 // Default to full screen minus borders/padding
+	}
+	if m.valuesOutline {
+		m.updateValuesOutline(viewportWidth)
+		return
+	}
 
-					values2, err := m.helmClient.GetReleaseValuesByRevision(release.Name, release.Namespace, revision2)
-					if err != nil {
-						m.err = err
-						m.diffMode = false
-						return m, nil
-					}
+	// Get the current match line (only this one should be highlighted)
+	var currentMatchLine int = -1
+	if len(m.searchMatches) > 0 && m.currentMatchIndex < len(m.searchMatches) {
+		currentMatchLine = m.searchMatches[m.currentMatchIndex]
+	}
 
-					diffLines := ui.DiffYAML(values1, values2)
-					diffContent := m.renderDiffContent(diffLines, fmt.Sprintf("rev%d", revision1), fmt.Sprintf("rev%d", revision2))
+	query := strings.ToLower(m.lastSearchQuery)
+	highlightedLines := make([]string, len(lines))
 
-					// Save diff lines for search functionality
-					m.diffLines = strings.Split(diffContent, "\n")
+	for i, line := range lines {
+		// Apply horizontal scrolling, in display cells
+		gutter := m.lineNumber(i, len(lines))
+		visibleLine, hasMore := ui.ScrollLine(line, m.horizontalOffset, viewportWidth-3-lipgloss.Width(gutter)) // -3 for indicator
 
-					m.diffView.SetContent(diffContent)
-					m.state = stateDiffViewer
-					m.diffMode = false
-					return m, nil
-				}
+		// Apply syntax highlighting
+		var highlighted string
+		// Only highlight if this is THE CURRENT match (not all matches)
+		if i == currentMatchLine && query != "" {
+			// This line is the CURRENT match - find and highlight it
+			if start, end := m.searchRange(visibleLine); start >= 0 {
+				// Split the line into 3 parts
+				before := visibleLine[:start]
+				match := visibleLine[start:end]
+				after := visibleLine[end:]
 
-				// Normal flow: view values for selected revision
-				rev := m.releaseHistory[selectedIdx]
-				m.selectedRevision = rev.Revision
-				m.state = stateReleaseValues
-				m.loadingVals = true
-				return m, func() tea.Msg {
-					values, err := m.helmClient.GetReleaseValuesByRevision(release.Name, release.Namespace, rev.Revision)
-					if err != nil {
-						return releaseValuesLoadedMsg{err: err}
-					}
-					return releaseValuesLoadedMsg{values: values}
-				}
-			}
-		}
+				// Apply YAML highlighting to before and after, but not to match
+				beforeHighlighted := ui.HighlightYAMLLine(before)
+				afterHighlighted := ui.HighlightYAMLLine(after)
+				matchHighlighted := highlightStyle.Render(match)
 
-	case stateRepoList:
-		selectedItem := m.repoList.SelectedItem()
-		if selectedItem != nil {
-			item := selectedItem.(listItem)
-			// Find the correct index in the full list by matching the title
-			for i, repo := range m.repos {
-				if repo.Name == item.title {
-					m.selectedRepo = i
-					m.state = stateChartList
-					m.loading = true
-					return m, loadCharts(m.helmClient, m.chartCache, repo.Name)
-				}
+				highlighted = beforeHighlighted + matchHighlighted + afterHighlighted
+			} else {
+				// Fallback to normal highlighting if match not found in visible portion
+				highlighted = ui.HighlightYAMLLine(visibleLine)
 			}
+		} else {
+			// Normal line - just apply YAML highlighting
+			highlighted = ui.HighlightYAMLLine(visibleLine)
 		}
 
-	case stateChartList:
-		selectedItem := m.chartList.SelectedItem()
-		if selectedItem != nil {
-			item := selectedItem.(listItem)
-			// Find the correct index in the full list by matching the title
-			for i, chart := range m.charts {
-				chartName := chart.Name
-				if m.selectedRepo < len(m.repos) {
-					chartName = strings.TrimPrefix(chartName, m.repos[m.selectedRepo].Name+"/")
-				}
-				if chartName == item.title {
-					m.selectedChart = i
-					m.state = stateChartDetail
-					m.loading = true
-					return m, loadVersions(m.helmClient, m.versionCache, m.charts[i].Name)
-				}
-			}
+		// Add continuation indicator if line continues
+		if hasMore {
+			arrowStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("141")).Bold(true)
+			highlighted += arrowStyle.Render(" →")
 		}
 
-	case stateChartDetail:
-		selectedItem := m.versionList.SelectedItem()
-		if selectedItem != nil {
-			item := selectedItem.(listItem)
-			// Find the correct index in the full list by matching the title
-			var selectedIdx int = -1
-			for i, ver := range m.versions {
-				if "v"+ver.Version == item.title {
-					selectedIdx = i
-					break
-				}
-			}
+		highlightedLines[i] = gutter + highlighted
+	}
 
-			if selectedIdx >= 0 {
-				if m.diffMode {
-					if selectedIdx == m.compareVersion {
-						return m, m.setSuccessMsg("Please select a different version to compare")
-					}
+	m.valuesView.SetContent(strings.Join(highlightedLines, "\n"))
+}
 
-					chartName := m.charts[m.selectedChart].Name
-					version1 := m.versions[m.compareVersion].Version
-					version2 := m.versions[selectedIdx].Version
+func (m *model) updateReleaseValuesViewWithSearch() {
+	lines := strings.Split(m.releaseValues, "\n")
+	viewportWidth := m.releaseValuesView.Width
+	if viewportWidth <= 0 {
+		viewportWidth = m.termWidth - 6 // Default to full screen minus borders/padding
+	}
 
-					values1, found1 := m.cache.Get(chartName, version1)
-					if !found1 {
-						v, err := m.helmClient.GetChartValuesByVersion(chartName, version1)
-						if err != nil {
-							m.err = err
-							m.diffMode = false
-							return m, nil
-						}
-						values1 = v
-						m.cache.Set(chartName, version1, values1)
-					}
+	// Get the current match line (only this one should be highlighted)
+	var currentMatchLine int = -1
+	if len(m.searchMatches) > 0 && m.currentMatchIndex < len(m.searchMatches) {
+		currentMatchLine = m.searchMatches[m.currentMatchIndex]
+	}
 
-					values2, found2 := m.cache.Get(chartName, version2)
-					if !found2 {
-						v, err := m.helmClient.GetChartValuesByVersion(chartName, version2)
-						if err != nil {
-							m.err = err
-							m.diffMode = false
-							return m, nil
-						}
-						values2 = v
-						m.cache.Set(chartName, version2, values2)
-					}
+	query := strings.ToLower(m.lastSearchQuery)
+	highlightedLines := make([]string, len(lines))
 
-					diffLines := ui.DiffYAML(values1, values2)
-					diffContent := m.renderDiffContent(diffLines, version1, version2)
+	for i, line := range lines {
+		// Apply horizontal scrolling, in display cells
+		gutter := m.lineNumber(i, len(lines))
+		visibleLine, hasMore := ui.ScrollLine(line, m.horizontalOffset, viewportWidth-3-lipgloss.Width(gutter)) // -3 for indicator
 
-					// Save diff lines for search functionality
-					m.diffLines = strings.Split(diffContent, "\n")
+		// Apply syntax highlighting
+		var highlighted string
+		// Only highlight if this is THE CURRENT match (not all matches)
+		if i == currentMatchLine && query != "" {
+			// This line is the CURRENT match - find and highlight it
+			if start, end := m.searchRange(visibleLine); start >= 0 {
+				// Split the line into 3 parts
+				before := visibleLine[:start]
+				match := visibleLine[start:end]
+				after := visibleLine[end:]
 
-					m.diffView.SetContent(diffContent)
-					m.state = stateDiffViewer
-					m.diffMode = false
-					return m, nil
-				}
+				// Apply YAML highlighting to before and after, but not to match
+				beforeHighlighted := ui.HighlightYAMLLine(before)
+				afterHighlighted := ui.HighlightYAMLLine(after)
+				matchHighlighted := highlightStyle.Render(match)
 
-				m.selectedVersion = selectedIdx
-				m.state = stateValueViewer
-				m.loadingVals = true
-				chartName := m.charts[m.selectedChart].Name
-				version := m.versions[selectedIdx].Version
-				return m, loadValuesByVersion(m.helmClient, m.cache, chartName, version)
+				highlighted = beforeHighlighted + matchHighlighted + afterHighlighted
+			} else {
+				// Fallback to normal highlighting if match not found in visible portion
+				highlighted = ui.HighlightYAMLLine(visibleLine)
 			}
+		} else {
+			// Normal line - just apply YAML highlighting
+			highlighted = ui.HighlightYAMLLine(visibleLine)
+		}
+
+		// Add continuation indicator if line continues
+		if hasMore {
+			arrowStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("141")).Bold(true)
+			highlighted += arrowStyle.Render(" →")
 		}
 
-	case stateArtifactHubSearch:
-		selectedItem := m.ahPackageList.SelectedItem()
-		if selectedItem != nil {
-			item := selectedItem.(listItem)
-			// Find the correct package in the full list by matching the name
-			for i, pkg := range m.ahPackages {
-				if pkg.Name == item.title {
-					m.ahSelectedPkg = i
-					m.state = stateArtifactHubPackageDetail
-					m.ahLoading = true
-					return m, loadArtifactHubPackage(m.artifactHubClient, pkg.Repository.Name, pkg.Name)
-				}
+		highlightedLines[i] = gutter + highlighted
+	}
+
+	m.releaseValuesView.SetContent(strings.Join(highlightedLines, "\n"))
+}
+
+func (m *model) updateDiffViewWithSearch() {
+	if len(m.diffLines) == 0 {
+		return
+	}
+
+	// Get the current match line (only this one should be highlighted)
+	var currentMatchLine int = -1
+	if len(m.searchMatches) > 0 && m.currentMatchIndex < len(m.searchMatches) {
+		currentMatchLine = m.searchMatches[m.currentMatchIndex]
+	}
+
+	query := strings.ToLower(m.lastSearchQuery)
+	highlightedLines := make([]string, len(m.diffLines))
+
+	for i, line := range m.diffLines {
+		// Only highlight if this is THE CURRENT match
+		if i == currentMatchLine && query != "" {
+			// This line is the CURRENT match - find and highlight it, without
+			// its diff colors so styling doesn't split the match
+			plain := ansi.Strip(line)
+			if start, end := m.searchRange(plain); start >= 0 {
+				// Highlight the match in yellow background
+				highlightedLines[i] = plain[:start] + highlightStyle.Render(plain[start:end]) + plain[end:]
+			} else {
+				// Fallback to normal line if match not found
+				highlightedLines[i] = line
 			}
+		} else {
+			// Normal line - no highlighting
+			highlightedLines[i] = line
 		}
-
-	case stateArtifactHubVersions:
-		// Can't view values from Artifact Hub - need to add repo first
-		return m, m.setSuccessMsg("Add the repository first (press 'a'), then browse it from the main menu to view values")
 	}
 
-	return m, nil
+	m.diffView.SetContent(strings.Join(m.numberLines(highlightedLines), "\n"))
 }
 
-func (m model) handleSearch() (tea.Model, tea.Cmd) {
-	if m.state == stateRepoList || m.state == stateChartList || m.state == stateChartDetail || m.state == stateValueViewer || m.state == stateDiffViewer || m.state == stateReleaseValues || m.state == stateReleaseList {
-		m.successMsg = "" // Clear success message
-		m.mode = searchMode
-		m.searchInput.Reset()
-		m.searchInput.Placeholder = "Search..."
-		m.searchInput.Focus()
+// setDiffContent shows content in the diff viewer and keeps its lines for
+// search
+func (m *model) setDiffContent(content string) {
+	m.diffLines = strings.Split(content, "\n")
+	m.diffView.SetContent(strings.Join(m.numberLines(m.diffLines), "\n"))
+}
+
+// numberLines prefixes rendered lines with their line number when the
+// line-number gutter is on
+func (m model) numberLines(lines []string) []string {
+	if !m.lineNumbers {
+		return lines
 	}
-	if m.state == stateArtifactHubSearch {
-		// Allow searching again in Artifact Hub
-		m.successMsg = ""
-		m.mode = searchMode
-		m.searchInput.Reset()
-		m.searchInput.Placeholder = "Search Artifact Hub..."
-		m.searchInput.Focus()
+	numbered := make([]string, len(lines))
+	for i, line := range lines {
+		numbered[i] = m.lineNumber(i, len(lines)) + line
 	}
-	return m, nil
+	return numbered
 }
 
-func (m model) handleInputMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	var cmd tea.Cmd
+// lineNumber renders the gutter of line i (0-based) of a document of total
+// lines, or "" when the gutter is off
+func (m model) lineNumber(i, total int) string {
+	if !m.lineNumbers {
+		return ""
+	}
+	return helpStyle.Render(fmt.Sprintf("%*d ", len(fmt.Sprint(total)), i+1))
+}
 
-	switch msg.String() {
-	case "esc":
-		// Clean up temp file if canceling save edit mode
-		if m.mode == saveEditMode && m.editTempFile != "" {
-			os.Remove(m.editTempFile)
-			m.editTempFile = ""
-			m.editedContent = ""
+// openTab opens a new tab on the current screen, from where it's navigated
+// on its own
+func (m model) openTab() (tea.Model, tea.Cmd) {
+	if m.loadingScreen() {
+		return m, m.setSuccessMsg("Wait for the screen to load before opening a tab")
+	}
+	if len(m.tabs) == 0 {
+		m.tabs = make([]model, 1)
+	}
+	// The tabs mustn't share what a screen changes in place
+	tab := m
+	tab.tabs = nil
+	tab.markedReleases = maps.Clone(m.markedReleases)
+	tab.valuesFolded = maps.Clone(m.valuesFolded)
+	tab.loadContexts = nil
+	tab.loadCancels = nil
+	// Each tab follows pod logs with a stream of its own, so closing or
+	// leaving one doesn't stop the other's
+	var logsCmd tea.Cmd
+	if m.logs != nil {
+		logs := *m.logs
+		logs.lines = slices.Clone(m.logs.lines)
+		if logs.running {
+			logs.lines, logs.shown, logs.following = nil, 0, true
+			tab.podLogsView.SetContent("")
+			logsCmd = startPodLogs(m.helmClient, &logs)
 		}
+		tab.logs = &logs
+	}
+	m.tabs = append(m.tabs, tab)
 
-		// Restore original lists if we were in search mode
-		if m.mode == searchMode {
-			switch m.state {
-			case stateRepoList:
-				items := make([]list.Item, len(m.repos))
-				for i, repo := range m.repos {
-					items[i] = listItem{
-						title:       repo.Name,
-						description: repo.URL,
-					}
-				}
-				m.repoList.SetItems(items)
-
-			case stateChartList:
-				items := make([]list.Item, len(m.charts))
-				for i, chart := range m.charts {
-					name := chart.Name
-					if m.selectedRepo < len(m.repos) {
-						name = strings.TrimPrefix(name, m.repos[m.selectedRepo].Name+"/")
-					}
-					items[i] = listItem{
-						title:       name,
-						description: chart.Description,
-					}
-				}
-				m.chartList.SetItems(items)
+	updated, cmd := m.switchTab(len(m.tabs) - 1)
+	m = updated.(model)
+	return m, tea.Batch(cmd, logsCmd, m.setSuccessMsg(fmt.Sprintf("Opened tab %d (ctrl+w closes it)", m.activeTab+1)))
+}
 
-			case stateChartDetail:
-				items := make([]list.Item, len(m.versions))
-				for i, ver := range m.versions {
-					desc := ""
-					if ver.AppVersion != "" {
-						desc = "App: " + ver.AppVersion
-					}
-					items[i] = listItem{
-						title:       "v" + ver.Version,
-						description: desc,
-					}
-				}
-				m.versionList.SetItems(items)
+// closeTab closes the active tab, stopping what it still runs, and moves to
+// the tab before it
+func (m model) closeTab() (tea.Model, tea.Cmd) {
+	if len(m.tabs) < 2 {
+		return m, m.setSuccessMsg("This is the only tab")
+	}
+	if m.loadingScreen() {
+		return m, m.setSuccessMsg("Wait for the screen to load before closing the tab")
+	}
+	m.cancelAllLoads()
+	if m.logs != nil && m.logs.running {
+		m.logs.cancel()
+	}
 
-			case stateValueViewer:
-				// Clear search results
-				m.searchMatches = []int{}
-				m.lastSearchQuery = ""
+	closed := m.activeTab
+	next := max(0, closed-1)
+	tab := m.tabs[next]
+	if closed == 0 {
+		tab = m.tabs[1]
+	}
+	tabs := slices.Delete(m.tabs, closed, closed+1)
+	tabs[next] = model{}
+	if len(tabs) == 1 {
+		tabs = nil
+	}
+	tab.carryGlobals(m)
+	tab.tabs = tabs
+	tab.activeTab = next
+	width, height := m.termWidth, m.termHeight
+	return tab, tea.Batch(tab.setSuccessMsg(fmt.Sprintf("Closed tab %d", closed+1)), func() tea.Msg {
+		return tea.WindowSizeMsg{Width: width, Height: height}
+	})
+}
 
-			case stateReleaseValues:
-				// Clear search results
-				m.searchMatches = []int{}
-				m.lastSearchQuery = ""
+// switchTab puts the active tab in the background and brings tab i to the
+// front, resized to the terminal
+func (m model) switchTab(i int) (tea.Model, tea.Cmd) {
+	if i == m.activeTab || i >= len(m.tabs) {
+		return m, nil
+	}
+	// A screen's loaders deliver to whichever tab is active
+	if m.loadingScreen() {
+		return m, m.setSuccessMsg("Wait for the screen to load before switching tabs")
+	}
+	// What the tab still loads in the background would land in the other one
+	m.cancelAllLoads()
+	tabs := m.tabs
+	current := m
+	current.tabs = nil
+	tabs[m.activeTab] = current
+
+	tab := tabs[i]
+	tabs[i] = model{}
+	tab.carryGlobals(m)
+	tab.tabs = tabs
+	tab.activeTab = i
+	width, height := m.termWidth, m.termHeight
+	return tab, func() tea.Msg {
+		return tea.WindowSizeMsg{Width: width, Height: height}
+	}
+}
 
-			case stateReleaseList:
-				// Restore full release list
-				items := make([]list.Item, len(m.releases))
-				for i, release := range m.releases {
-					desc := fmt.Sprintf("%s | %s | %s", release.Namespace, release.Chart, release.Status)
-					items[i] = listItem{
-						title:       release.Name,
-						description: desc,
-					}
-				}
-				m.releaseList.SetItems(items)
+// carryGlobals copies to a tab brought to the front what isn't per tab:
+// settings, the repositories, the cluster, the terminal and running
+// background work
+func (m *model) carryGlobals(from model) {
+	m.config = from.config
+	m.repos = from.repos
+	m.repoUpdating = from.repoUpdating
+	m.kubeContext = from.kubeContext
+	m.kubeContexts = from.kubeContexts
+	m.defaultNamespace = from.defaultNamespace
+	m.clusterVersion = from.clusterVersion
+	m.clusterChecked = from.clusterChecked
+	m.clusterGen = from.clusterGen
+	m.termWidth = from.termWidth
+	m.termHeight = from.termHeight
+	m.spinner = from.spinner
+	m.spinning = from.spinning
+	m.loadingSince = from.loadingSince
+	m.backgroundOps = from.backgroundOps
+	m.successMsg = from.successMsg
+	m.successSeq = from.successSeq
+	m.undoRepo = from.undoRepo
+	m.lastAction = from.lastAction
+	m.lineNumbers = from.lineNumbers
+	m.searchOptions = from.searchOptions
+	m.hideDeprecated = from.hideDeprecated
+	m.recent = from.recent
+	m.favorites = from.favorites
+	m.actionLog = from.actionLog
+}
 
-			case stateDiffViewer:
-				// Clear search results and restore original content
-				m.searchMatches = []int{}
-				m.lastSearchQuery = ""
-				m.updateDiffViewWithSearch() // Restore original without highlights
+// loadingScreen reports whether the current screen is still loading
+func (m model) loadingScreen() bool {
+	return m.loading || m.loadingVals || m.loadingMore || m.resourcesLoading || m.ahLoading || m.ahLoadingMore
+}
 
-			case stateArtifactHubSearch:
-				// Return to repo list
-				m.state = stateRepoList
-				m.ahPackages = nil
-				m.ahPackageList.SetItems([]list.Item{})
-			}
+// renderTabBar lists the tabs, named after the screen they're on, when more
+// than one is open
+func (m model) renderTabBar() string {
+	if len(m.tabs) < 2 {
+		return ""
+	}
+	var bar strings.Builder
+	for i, tab := range m.tabs {
+		if i == m.activeTab {
+			tab = m
 		}
+		parts := strings.Split(tab.getBreadcrumb(), " > ")
+		label := ansi.Truncate(parts[len(parts)-1], 20, "…")
+		if i == m.activeTab {
+			bar.WriteString(highlightStyle.Render(fmt.Sprintf(" %d %s ", i+1, label)))
+		} else {
+			bar.WriteString(helpStyle.Render(fmt.Sprintf(" %d %s ", i+1, label)))
+		}
+	}
+	return bar.String() + " "
+}
 
-		m.mode = normalMode
-		m.searchInput.Blur()
-		m.addRepoStep = 0
-		m.newRepoURL = "" // Reset pre-filled URL
-		return m, nil
-
-	case "enter":
-		switch m.mode {
-		case searchMode:
-			// Check if we're in Artifact Hub search state
-			if m.state == stateArtifactHubSearch {
-				query := m.searchInput.Value()
-				if query != "" {
-					m.mode = normalMode
-					m.searchInput.Blur()
-					m.ahLoading = true
-					return m, searchArtifactHub(m.artifactHubClient, query)
-				}
-			}
-			m.mode = normalMode
-			m.searchInput.Blur()
+func (m model) View() string {
+	if m.err != nil {
+		return errorStyle.Render(fmt.Sprintf(" Error: %v ", m.err)) + "\n\n" +
+			helpStyle.Render("Press 'q' to quit")
+	}
 
-		case addRepoMode:
-			if m.addRepoStep == 0 {
-				inputName := m.searchInput.Value()
-				// If coming from Artifact Hub and no name provided, use default
-				if inputName == "" && m.newRepoURL != "" && m.ahSelectedPackage != nil {
-					inputName = m.ahSelectedPackage.Repository.Name
-				}
-				m.newRepoName = inputName
+	if m.state == stateHelp {
+		return m.renderHelp()
+	}
 
-				// If URL is already set (from Artifact Hub), skip asking for URL
-				if m.newRepoURL != "" {
-					m.mode = normalMode
-					m.searchInput.Blur()
-					return m, addRepository(m.helmClient, m.newRepoName, m.newRepoURL)
-				}
+	var content string
 
-				// Otherwise ask for URL (normal flow)
-				m.addRepoStep = 1
-				m.searchInput.Reset()
-				m.searchInput.Placeholder = "Repository URL..."
-			} else {
-				m.newRepoURL = m.searchInput.Value()
-				m.mode = normalMode
-				m.searchInput.Blur()
-				return m, addRepository(m.helmClient, m.newRepoName, m.newRepoURL)
-			}
+	breadcrumb := m.getBreadcrumb()
+	if breadcrumb != "" {
+		tabBar := m.renderTabBar()
+		breadcrumbLine := tabBar + breadcrumbStyle.Render(" "+breadcrumb+" ")
 
-		case exportValuesMode:
-			path := m.searchInput.Value()
-			if path == "" {
-				path = "./values.yaml"
-			}
-			m.mode = normalMode
-			m.searchInput.Blur()
+		// Add kubectl context on the right if in cluster releases section
+		if ((m.state >= stateClusterReleasesMenu && m.state <= stateReleaseTest) ||
+			(m.state == stateDiffViewer && m.compareRevision >= 0)) && m.kubeContext != "" {
+			contextInfo := infoStyle.Render(fmt.Sprintf(" kubectl: %s | ns: %s ", m.kubeContext, m.effectiveNamespace()))
+			// Calculate spacing to push context to the right
+			breadcrumbWidth := ansi.StringWidth(breadcrumb) + 2 + lipgloss.Width(tabBar)
+			contextWidth := lipgloss.Width(contextInfo)
+			spacer := strings.Repeat(" ", max(1, m.termWidth-breadcrumbWidth-contextWidth-4))
+			breadcrumbLine = breadcrumbLine + spacer + contextInfo
+		}
 
-			if m.state == stateReleaseValues {
-				return m, func() tea.Msg {
-					err := os.WriteFile(path, []byte(m.releaseValues), 0644)
-					if err != nil {
-						return operationDoneMsg{err: err}
-					}
-					if m.selectedRevision > 0 {
-						return operationDoneMsg{success: fmt.Sprintf("Values (revision %d) exported to %s", m.selectedRevision, path)}
-					}
-					return operationDoneMsg{success: fmt.Sprintf("Values exported to %s", path)}
-				}
-			}
+		content += breadcrumbLine + "\n\n"
+	}
 
-			chartName := m.charts[m.selectedChart].Name
-			if m.state == stateValueViewer && m.selectedVersion < len(m.versions) {
-				version := m.versions[m.selectedVersion].Version
-				return m, tea.Batch(func() tea.Msg {
-					values, err := m.helmClient.GetChartValuesByVersion(chartName, version)
-					if err != nil {
-						return operationDoneMsg{err: err}
-					}
-					err = os.WriteFile(path, []byte(values), 0644)
-					if err != nil {
-						return operationDoneMsg{err: err}
-					}
-					return operationDoneMsg{success: fmt.Sprintf("Values (v%s) exported to %s", version, path)}
-				})
-			}
-			return m, exportValues(m.helmClient, chartName, path)
+	// Show search info AFTER breadcrumb for better visibility
+	if (m.state == stateValueViewer || m.state == stateReleaseValues || m.state == stateReleaseDetail || m.state == stateDiffViewer || m.state == stateChartReadme || m.state == stateReleaseNotes || m.state == statePodLogs || m.state == stateTemplatePreview) && len(m.searchMatches) > 0 {
+		content += m.renderSearchHeader() + "\n"
+	}
 
-		case templatePathMode:
-			m.templatePath = m.searchInput.Value()
-			if m.templatePath == "" {
-				m.templatePath = "./output/"
-			}
-			m.mode = templateValuesMode
-			m.searchInput.Reset()
-			m.searchInput.Placeholder = "Values file (optional)..."
+	switch m.state {
+	case stateMainMenu:
+		content += m.renderMainMenu()
+	case stateBrowseMenu:
+		content += m.renderBrowseMenu()
+	case stateRepoList:
+		content += m.renderRepoList()
+	case stateRepoInfo:
+		content += m.renderRepoInfo()
+	case stateChartList:
+		content += m.renderChartList()
+	case stateChartDetail:
+		content += m.renderChartDetail()
+	case stateChartInfo:
+		content += m.renderChartInfo()
+	case stateChartReadme:
+		content += m.renderChartReadme()
+	case stateTemplatePreview:
+		content += m.renderTemplatePreview()
+	case stateChartFiles:
+		content += m.renderChartFiles()
+	case stateChartFile:
+		content += activePanelStyle.Render(m.chartFileView.View())
+	case stateChartSchema:
+		content += m.renderChartSchema()
+	case stateValidation:
+		content += m.renderValidation()
+	case stateValueViewer:
+		content += m.renderValueViewer()
+	case stateDiffViewer:
+		content += m.renderDiffViewer()
+	case stateArtifactHubSearch:
+		content += m.renderArtifactHubSearch()
+	case stateArtifactHubPackageDetail:
+		content += m.renderArtifactHubPackageDetail()
+	case stateArtifactHubVersions:
+		content += m.renderArtifactHubVersions()
+	case stateArtifactHubSecurity:
+		content += m.renderArtifactHubSecurity()
+	case stateClusterReleasesMenu:
+		content += m.renderClusterReleasesMenu()
+	case stateNamespaceList:
+		content += m.renderNamespaceList()
+	case stateContextList:
+		content += m.renderContextList()
+	case stateSettings:
+		content += m.renderSettings()
+	case stateRecent:
+		content += m.renderRecent()
+	case stateFavorites:
+		content += m.renderFavorites()
+	case stateActionLog:
+		content += m.renderActionLog()
+	case stateHelmfile:
+		content += m.renderHelmfile()
+	case stateRegistries:
+		content += m.renderRegistries()
+	case stateReleaseList:
+		content += m.renderReleaseList()
+	case stateReleaseDetail:
+		content += m.renderReleaseDetail()
+	case stateReleaseHistory:
+		content += m.renderReleaseHistory()
+	case stateReleaseValues:
+		content += m.renderReleaseValues()
+	case stateReleaseNotes:
+		content += m.renderReleaseNotes()
+	case stateReleaseDrift:
+		content += m.renderReleaseDrift()
+	case stateReleaseEvents:
+		content += m.renderReleaseEvents()
+	case stateReleaseTest:
+		content += m.renderReleaseTest()
+	case statePodPicker:
+		content += m.renderPodPicker()
+	case statePodLogs:
+		content += m.renderPodLogs()
+	}
 
-		case templateValuesMode:
-			m.templateValues = m.searchInput.Value()
-			m.mode = normalMode
-			m.searchInput.Blur()
+	footer := "\n"
+	if m.backgroundOps > 0 {
+		footer += m.loadingView(fmt.Sprintf("%d operation(s) running in the background...", m.backgroundOps)) + "\n"
+	}
+	if m.successMsg != "" {
+		footer += successStyle.Render(" " + m.successMsg + " ") + "\n"
+	}
 
-			chartName := m.charts[m.selectedChart].Name
-			if m.state == stateValueViewer && m.selectedVersion < len(m.versions) {
-				version := m.versions[m.selectedVersion].Version
-				chartName = fmt.Sprintf("%s --version %s", chartName, version)
-			}
-			return m, generateTemplate(m.helmClient, chartName, m.templateValues, m.templatePath)
+	if m.mode != normalMode {
+		footer += m.renderInputPrompt() + "\n"
+	}
 
-		case saveEditMode:
-			path := m.searchInput.Value()
-			if path == "" {
-				path = "./custom-values.yaml"
-			}
-			m.mode = normalMode
-			m.searchInput.Blur()
+	footer += "\n" + helpStyle.Render(" "+m.helpView.ShortHelpView(m.keys.ShortHelp())+" ")
 
-			// Expand home directory if needed
-			if strings.HasPrefix(path, "~/") {
-				home, err := os.UserHomeDir()
-				if err == nil {
-					path = filepath.Join(home, path[2:])
-				}
-			}
+	return content + footer
+}
 
-			// Save the edited values
-			err := os.WriteFile(path, []byte(m.editedContent), 0644)
+// matchPreview trims a search match's line and truncates it to width cells,
+// keeping multi-byte characters and ANSI sequences whole
+func matchPreview(line string, width int) string {
+	return ansi.Truncate(strings.TrimSpace(line), width, "...")
+}
 
-			// Clean up temp file
-			if m.editTempFile != "" {
-				os.Remove(m.editTempFile)
-				m.editTempFile = ""
-			}
+func (m model) renderSearchHeader() string {
+	if len(m.searchMatches) == 0 {
+		return ""
+	}
 
-			m.editedContent = "" // Clear edited content
-			if err != nil {
-				return m, m.setSuccessMsg(fmt.Sprintf("Error saving: %v", err))
-			} else {
-				return m, m.setSuccessMsg(fmt.Sprintf("✓ Values saved to %s", path))
-			}
+	var header string
 
-		case confirmRemoveRepoMode:
-			response := strings.ToLower(m.searchInput.Value())
-			m.mode = normalMode
-			m.searchInput.Blur()
+	// Match counter - always visible
+	matchInfo := fmt.Sprintf(" Match %d/%d ", m.currentMatchIndex+1, len(m.searchMatches))
+	header += infoStyle.Render(matchInfo) + " "
+	if m.searchOptions.CaseSensitive || m.searchOptions.WholeWord {
+		header += helpStyle.Render("["+m.searchOptionsLabel()+"]") + " "
+	}
 
-			if response == "y" || response == "yes" {
-				// Remove the repository - use selected item to handle filtered lists
-				selectedItem := m.repoList.SelectedItem()
-				if selectedItem != nil {
-					item := selectedItem.(listItem)
-					repoName := item.title
-					return m, func() tea.Msg {
-						err := m.helmClient.RemoveRepository(repoName)
-						if err != nil {
-							return operationDoneMsg{err: err}
-						}
+	// Show YAML path or line content based on state
+	if m.state == stateValueViewer {
+		matchLine := m.searchMatches[m.currentMatchIndex]
+		yamlPath := ui.GetYAMLPath(m.valuesLines, matchLine)
 
-						// Reload repositories
-						repos, repoErr := m.helmClient.ListRepositories()
-						if repoErr != nil {
-							return operationDoneMsg{success: fmt.Sprintf("Repository '%s' removed, but failed to reload list", repoName)}
-						}
+		if yamlPath != "" {
+			header += pathStyle.Render(" " + yamlPath + " ")
+		} else if matchLine < len(m.valuesLines) {
+			lineContent := matchPreview(m.valuesLines[matchLine], 60)
+			header += pathStyle.Render(fmt.Sprintf(" Line %d: %s ", matchLine+1, lineContent))
+		}
+		header += " " + helpStyle.Render("n=next N=prev y=copy Y=copy+value")
+	} else if m.state == stateReleaseValues {
+		matchLine := m.searchMatches[m.currentMatchIndex]
+		yamlPath := ui.GetYAMLPath(m.releaseValuesLines, matchLine)
 
-						return repoRemovedMsg{repos: repos, repoName: repoName}
-					}
-				}
-			}
-			return m, nil
+		if yamlPath != "" {
+			header += pathStyle.Render(" " + yamlPath + " ")
+		} else if matchLine < len(m.releaseValuesLines) {
+			lineContent := matchPreview(m.releaseValuesLines[matchLine], 60)
+			header += pathStyle.Render(fmt.Sprintf(" Line %d: %s ", matchLine+1, lineContent))
 		}
-		return m, nil
+		header += " " + helpStyle.Render("n=next N=prev y=copy Y=copy+value")
+	} else if m.state == stateChartReadme {
+		matchLine := m.searchMatches[m.currentMatchIndex]
+		if matchLine < len(m.readmeLines) {
+			lineContent := matchPreview(ansi.Strip(m.readmeLines[matchLine]), 60)
+			header += pathStyle.Render(fmt.Sprintf(" Line %d: %s ", matchLine+1, lineContent))
+		}
+		header += " " + helpStyle.Render("n=next N=prev")
+	} else if m.state == stateReleaseNotes {
+		matchLine := m.searchMatches[m.currentMatchIndex]
+		if matchLine < len(m.releaseNotesLines) {
+			lineContent := matchPreview(m.releaseNotesLines[matchLine], 60)
+			header += pathStyle.Render(fmt.Sprintf(" Line %d: %s ", matchLine+1, lineContent))
+		}
+		header += " " + helpStyle.Render("n=next N=prev")
+	} else if m.state == stateTemplatePreview {
+		matchLine := m.searchMatches[m.currentMatchIndex]
+		if res, ok := m.templateResourceAt(matchLine); ok {
+			header += pathStyle.Render(fmt.Sprintf(" %s %s ", res.Kind, res.Name))
+		}
+		if matchLine < len(m.templateLines) {
+			lineContent := matchPreview(m.templateLines[matchLine], 60)
+			header += pathStyle.Render(fmt.Sprintf(" Line %d: %s ", matchLine+1, lineContent))
+		}
+		header += " " + helpStyle.Render("n=next N=prev")
+	} else if m.state == statePodLogs {
+		matchLine := m.searchMatches[m.currentMatchIndex]
+		if matchLine < len(m.logs.lines) {
+			lineContent := matchPreview(m.logs.lines[matchLine], 60)
+			header += pathStyle.Render(fmt.Sprintf(" Line %d: %s ", matchLine+1, lineContent))
+		}
+		header += " " + helpStyle.Render("n=next N=prev f=follow")
+	} else if m.state == stateReleaseDetail {
+		matchLine := m.searchMatches[m.currentMatchIndex]
+		if matchLine < len(m.releaseDetailLines) {
+			lineContent := matchPreview(m.releaseDetailLines[matchLine], 60)
+			header += pathStyle.Render(fmt.Sprintf(" Line %d: %s ", matchLine+1, lineContent))
+		}
+		header += " " + helpStyle.Render("n=next N=prev")
+	} else if m.state == stateDiffViewer {
+		matchLine := m.searchMatches[m.currentMatchIndex]
+		if matchLine < len(m.diffLines) {
+			lineContent := matchPreview(m.diffLines[matchLine], 80)
+			header += pathStyle.Render(fmt.Sprintf(" %s ", lineContent))
+		}
+		header += " " + helpStyle.Render("n=next N=prev")
 	}
 
-	m.searchInput, cmd = m.searchInput.Update(msg)
+	return header
+}
 
-	if m.mode == searchMode && m.searchInput.Value() != "" {
-		query := strings.ToLower(m.searchInput.Value())
+// effectiveNamespace returns the namespace the cluster releases screens are
+// currently scoped to, falling back to the default namespace
+func (m model) effectiveNamespace() string {
+	if m.state >= stateReleaseList || m.state == stateDiffViewer {
+		if m.selectedNamespace == "" {
+			return "all"
+		}
+		return m.selectedNamespace
+	}
+	return m.defaultNamespace
+}
 
-		switch m.state {
-		case stateRepoList:
-			matches := fuzzy.Find(query, reposToStrings(m.repos))
-			items := make([]list.Item, len(matches))
-			for i, match := range matches {
-				repo := m.repos[match.Index]
-				items[i] = listItem{
-					title:       repo.Name,
-					description: repo.URL,
-				}
-			}
-			m.repoList.SetItems(items)
+func (m model) getBreadcrumb() string {
+	parts := []string{"LazyHelm"}
 
-		case stateChartList:
-			matches := fuzzy.Find(query, chartsToStrings(m.charts))
-			items := make([]list.Item, len(matches))
-			for i, match := range matches {
-				chart := m.charts[match.Index]
-				name := chart.Name
-				if m.selectedRepo < len(m.repos) {
-					name = strings.TrimPrefix(name, m.repos[m.selectedRepo].Name+"/")
-				}
-				items[i] = listItem{
-					title:       name,
-					description: chart.Description,
-				}
-			}
-			m.chartList.SetItems(items)
+	// Cluster Releases navigation
+	if m.state >= stateClusterReleasesMenu && m.state <= stateReleaseTest {
+		parts = append(parts, "Cluster Releases")
 
-		case stateChartDetail:
-			matches := fuzzy.Find(query, versionsToStrings(m.versions))
-			items := make([]list.Item, len(matches))
-			for i, match := range matches {
-				ver := m.versions[match.Index]
-				desc := ""
-				if ver.AppVersion != "" {
-					desc = "App: " + ver.AppVersion
-				}
-				items[i] = listItem{
-					title:       "v" + ver.Version,
-					description: desc,
-				}
-			}
-			m.versionList.SetItems(items)
+		if m.state == stateNamespaceList {
+			parts = append(parts, "Select Namespace")
+		}
 
-		case stateReleaseList:
-			matches := fuzzy.Find(query, releasesToStrings(m.releases))
-			items := make([]list.Item, len(matches))
-			for i, match := range matches {
-				release := m.releases[match.Index]
-				desc := fmt.Sprintf("%s | %s | %s", release.Namespace, release.Chart, release.Status)
-				items[i] = listItem{
-					title:       release.Name,
-					description: desc,
-				}
-			}
-			m.releaseList.SetItems(items)
+		if m.state == stateContextList {
+			parts = append(parts, "Switch Context")
+		}
 
-		case stateValueViewer:
-			// Find all matches in values
-			m.searchMatches = []int{}
-			m.lastSearchQuery = query
-			for i, line := range m.valuesLines {
-				if strings.Contains(strings.ToLower(line), query) {
-					m.searchMatches = append(m.searchMatches, i)
-				}
-			}
+		if m.state >= stateReleaseList && m.selectedNamespace != "" {
+			parts = append(parts, m.selectedNamespace)
+		}
 
-			// Update the view with highlighted search terms
-			m.updateValuesViewWithSearch()
+		if m.state >= stateReleaseList && m.selectedRelease < len(m.releases) {
+			parts = append(parts, m.releases[m.selectedRelease].Name)
+		}
 
-			// Jump to first match
-			if len(m.searchMatches) > 0 {
-				m.currentMatchIndex = 0
-				targetLine := m.searchMatches[0]
-				if targetLine > m.valuesView.Height/2 {
-					targetLine = targetLine - m.valuesView.Height/2
-				} else {
-					targetLine = 0
-				}
-				m.valuesView.YOffset = targetLine
-			}
+		if m.state == stateReleaseHistory {
+			parts = append(parts, "history")
+		}
 
-		case stateReleaseValues:
-			// Find all matches in release values
-			m.searchMatches = []int{}
-			m.lastSearchQuery = query
-			for i, line := range m.releaseValuesLines {
-				if strings.Contains(strings.ToLower(line), query) {
-					m.searchMatches = append(m.searchMatches, i)
-				}
-			}
+		if m.state == stateReleaseTest {
+			parts = append(parts, "test")
+		}
 
-			// Update the view with highlighted search terms
-			m.updateReleaseValuesViewWithSearch()
+		if m.state == stateReleaseNotes {
+			parts = append(parts, "notes")
+		}
 
-			// Jump to first match
-			if len(m.searchMatches) > 0 {
-				m.currentMatchIndex = 0
-				targetLine := m.searchMatches[0]
-				if targetLine > m.releaseValuesView.Height/2 {
-					targetLine = targetLine - m.releaseValuesView.Height/2
-				} else {
-					targetLine = 0
-				}
-				m.releaseValuesView.YOffset = targetLine
-			}
+		if m.state == stateReleaseDrift {
+			parts = append(parts, "drift")
+		}
 
-		case stateDiffViewer:
-			// Find all matches in diff
-			m.searchMatches = []int{}
-			m.lastSearchQuery = query
-			for i, line := range m.diffLines {
-				if strings.Contains(strings.ToLower(line), query) {
-					m.searchMatches = append(m.searchMatches, i)
-				}
-			}
+		if m.state == stateReleaseEvents {
+			parts = append(parts, "events")
+		}
 
-			// Update the view with highlighted search terms
-			m.updateDiffViewWithSearch()
+		if m.state == statePodPicker {
+			parts = append(parts, "pods")
+		}
 
-			// Jump to first match
-			if len(m.searchMatches) > 0 {
-				m.currentMatchIndex = 0
-				targetLine := m.searchMatches[0]
-				if targetLine > m.diffView.Height/2 {
-					targetLine = targetLine - m.diffView.Height/2
-				} else {
-					targetLine = 0
-				}
-				m.diffView.YOffset = targetLine
+		if m.state == statePodLogs {
+			parts = append(parts, "logs", m.logs.target.title)
+		}
+
+		if m.state == stateReleaseValues {
+			if m.selectedRevision > 0 {
+				parts = append(parts, fmt.Sprintf("revision %d", m.selectedRevision))
 			}
+			parts = append(parts, "values")
 		}
+
+		return strings.Join(parts, " > ")
 	}
 
-	return m, cmd
-}
+	if m.state == stateSettings {
+		parts = append(parts, "Settings")
+		return strings.Join(parts, " > ")
+	}
 
-func reposToStrings(repos []helm.Repository) []string {
-	result := make([]string, len(repos))
-	for i, r := range repos {
-		result[i] = r.Name
+	if m.state == stateRecent {
+		parts = append(parts, "Recent")
+		return strings.Join(parts, " > ")
 	}
-	return result
-}
 
-func chartsToStrings(charts []helm.Chart) []string {
-	result := make([]string, len(charts))
-	for i, c := range charts {
-		result[i] = c.Name
+	if m.state == stateFavorites {
+		parts = append(parts, "Favorites")
+		return strings.Join(parts, " > ")
 	}
-	return result
-}
 
-func versionsToStrings(versions []helm.ChartVersion) []string {
-	result := make([]string, len(versions))
-	for i, v := range versions {
-		result[i] = v.Version
+	if m.state == stateActionLog {
+		parts = append(parts, "Action Log")
+		return strings.Join(parts, " > ")
 	}
-	return result
-}
 
-func releasesToStrings(releases []helm.Release) []string {
-	result := make([]string, len(releases))
-	for i, r := range releases {
-		result[i] = r.Name
+	if m.state == stateHelmfile && m.helmfile != nil {
+		parts = append(parts, "Helmfile", m.helmfile.Environment)
+		return strings.Join(parts, " > ")
 	}
-	return result
-}
 
-func (m model) jumpToMatch() model {
-	if len(m.searchMatches) == 0 {
-		return m
+	if m.state == stateDiffViewer && m.diffReturn == stateHelmfile && m.helmfile != nil {
+		parts = append(parts, "Helmfile", m.helmfile.Environment, m.helmfileRelease.Name, "values")
+		return strings.Join(parts, " > ")
 	}
 
-	targetLine := m.searchMatches[m.currentMatchIndex]
+	if m.state == stateRegistries {
+		parts = append(parts, "OCI Registries")
+		return strings.Join(parts, " > ")
+	}
 
-	// Center the match on screen based on current state
-	if m.state == stateValueViewer {
-		if targetLine > m.valuesView.Height/2 {
-			m.valuesView.YOffset = targetLine - m.valuesView.Height/2
-		} else {
-			m.valuesView.YOffset = 0
-		}
-	} else if m.state == stateReleaseValues {
-		if targetLine > m.releaseValuesView.Height/2 {
-			m.releaseValuesView.YOffset = targetLine - m.releaseValuesView.Height/2
-		} else {
-			m.releaseValuesView.YOffset = 0
-		}
-	} else if m.state == stateDiffViewer {
-		if targetLine > m.diffView.Height/2 {
-			m.diffView.YOffset = targetLine - m.diffView.Height/2
-		} else {
-			m.diffView.YOffset = 0
-		}
+	// Artifact Hub navigation
+	if m.state == stateArtifactHubSearch {
+		parts = append(parts, "Artifact Hub")
+		return strings.Join(parts, " > ")
 	}
 
-	return m
-}
+	if m.state == stateArtifactHubPackageDetail && m.ahSelectedPackage != nil {
+		parts = append(parts, "Artifact Hub", m.ahSelectedPackage.Name)
+		return strings.Join(parts, " > ")
+	}
 
-func (m *model) updateValuesViewWithSearch() {
-	lines := strings.Split(m.values, "\n")
-	viewportWidth := m.valuesView.Width
-	if viewportWidth <= 0 {
-		viewportWidth = m.termWidth - 6 // Default to full screen minus borders/padding
+	if m.state == stateArtifactHubVersions && m.ahSelectedPackage != nil {
+		parts = append(parts, "Artifact Hub", m.ahSelectedPackage.Name, "Versions")
+		return strings.Join(parts, " > ")
 	}
 
-	// Get the current match line (only this one should be highlighted)
-	var currentMatchLine int = -1
-	if len(m.searchMatches) > 0 && m.currentMatchIndex < len(m.searchMatches) {
-		currentMatchLine = m.searchMatches[m.currentMatchIndex]
+	if m.state == stateArtifactHubSecurity && m.ahSelectedPackage != nil {
+		parts = append(parts, "Artifact Hub", m.ahSelectedPackage.Name, "Security")
+		return strings.Join(parts, " > ")
 	}
 
-	query := strings.ToLower(m.lastSearchQuery)
-	highlightedLines := make([]string, len(lines))
+	if m.state == stateChartReadme && m.readmeReturn == stateArtifactHubPackageDetail && m.ahSelectedPackage != nil {
+		parts = append(parts, "Artifact Hub", m.ahSelectedPackage.Name, "README")
+		return strings.Join(parts, " > ")
+	}
 
-	for i, line := range lines {
-		// Apply horizontal scrolling
-		visibleLine := line
-		hasMore := false
+	if m.state == stateChartSchema && m.schemaReturn == stateArtifactHubPackageDetail && m.ahSelectedPackage != nil {
+		parts = append(parts, "Artifact Hub", m.ahSelectedPackage.Name, "schema")
+		return strings.Join(parts, " > ")
+	}
 
-		// Calculate actual display width considering the line content
-		if len(line) > m.horizontalOffset {
-			visibleLine = line[m.horizontalOffset:]
+	// Regular Helm navigation
+	if m.selectedRepo < len(m.repos) {
+		parts = append(parts, m.repos[m.selectedRepo].Name)
+	}
 
-			// Truncate if longer than viewport width
-			if len(visibleLine) > viewportWidth-3 { // -3 for indicator
-				visibleLine = visibleLine[:viewportWidth-3]
-				hasMore = true
-			}
-		} else {
-			visibleLine = ""
+	if m.state >= stateChartList && m.selectedChart < len(m.charts) {
+		name := m.charts[m.selectedChart].Name
+		if m.selectedRepo < len(m.repos) {
+			name = strings.TrimPrefix(name, m.repos[m.selectedRepo].Name+"/")
 		}
+		parts = append(parts, name)
+	}
 
-		// Apply syntax highlighting
-		var highlighted string
-		// Only highlight if this is THE CURRENT match (not all matches)
-		if i == currentMatchLine && query != "" {
-			// This line is the CURRENT match - find and highlight it
-			lowerLine := strings.ToLower(visibleLine)
-			idx := strings.Index(lowerLine, query)
-			if idx >= 0 && idx+len(query) <= len(visibleLine) {
-				// Split the line into 3 parts
-				before := visibleLine[:idx]
-				match := visibleLine[idx : idx+len(query)]
-				after := visibleLine[idx+len(query):]
+	if m.state >= stateChartDetail && m.selectedVersion < len(m.versions) {
+		parts = append(parts, "v"+m.versions[m.selectedVersion].Version)
+	}
 
-				// Apply YAML highlighting to before and after, but not to match
-				beforeHighlighted := ui.HighlightYAMLLine(before)
-				afterHighlighted := ui.HighlightYAMLLine(after)
-				matchHighlighted := highlightStyle.Render(match)
+	if m.state == stateRepoInfo || m.state == stateChartInfo {
+		parts = append(parts, "info")
+	}
 
-				highlighted = beforeHighlighted + matchHighlighted + afterHighlighted
-			} else {
-				// Fallback to normal highlighting if match not found in visible portion
-				highlighted = ui.HighlightYAMLLine(visibleLine)
-			}
-		} else {
-			// Normal line - just apply YAML highlighting
-			highlighted = ui.HighlightYAMLLine(visibleLine)
-		}
+	if m.state == stateChartReadme {
+		parts = append(parts, "README")
+	}
 
-		// Add continuation indicator if line continues
-		if hasMore {
-			arrowStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("141")).Bold(true)
-			highlighted += arrowStyle.Render(" →")
+	if m.state == stateChartFiles {
+		parts = append(parts, "files")
+	}
+
+	if m.state == stateChartFile {
+		if item := m.chartFileList.SelectedItem(); item != nil {
+			parts = append(parts, item.(listItem).description)
 		}
+	}
 
-		highlightedLines[i] = highlighted
+	if m.state == stateChartSchema {
+		parts = append(parts, "schema")
 	}
 
-	m.valuesView.SetContent(strings.Join(highlightedLines, "\n"))
-}
+	if m.state == stateTemplatePreview {
+		parts = append(parts, "template")
+	}
 
-func (m *model) updateReleaseValuesViewWithSearch() {
-	lines := strings.Split(m.releaseValues, "\n")
-	viewportWidth := m.releaseValuesView.Width
-	if viewportWidth <= 0 {
-		viewportWidth = m.termWidth - 6 // Default to full screen minus borders/padding
+	if m.state == stateValueViewer {
+		parts = append(parts, "values")
 	}
 
-	// Get the current match line (only this one should be highlighted)
-	var currentMatchLine int = -1
-	if len(m.searchMatches) > 0 && m.currentMatchIndex < len(m.searchMatches) {
-		currentMatchLine = m.searchMatches[m.currentMatchIndex]
+	if m.state == stateValidation {
+		parts = append(parts, "validate")
 	}
 
-	query := strings.ToLower(m.lastSearchQuery)
-	highlightedLines := make([]string, len(lines))
+	if m.state == stateDiffViewer {
+		parts = append(parts, "diff")
+	}
 
-	for i, line := range lines {
-		// Apply horizontal scrolling
-		visibleLine := line
-		hasMore := false
+	return strings.Join(parts, " > ")
+}
 
-		// Calculate actual display width considering the line content
-		if len(line) > m.horizontalOffset {
-			visibleLine = line[m.horizontalOffset:]
+func (m model) renderMainMenu() string {
+	return activePanelStyle.Render(m.mainMenu.View())
+}
 
-			// Truncate if longer than viewport width
-			if len(visibleLine) > viewportWidth-3 { // -3 for indicator
-				visibleLine = visibleLine[:viewportWidth-3]
-				hasMore = true
-			}
-		} else {
-			visibleLine = ""
-		}
+func (m model) renderBrowseMenu() string {
+	return activePanelStyle.Render(m.browseMenu.View())
+}
 
-		// Apply syntax highlighting
-		var highlighted string
-		// Only highlight if this is THE CURRENT match (not all matches)
-		if i == currentMatchLine && query != "" {
-			// This line is the CURRENT match - find and highlight it
-			lowerLine := strings.ToLower(visibleLine)
-			idx := strings.Index(lowerLine, query)
-			if idx >= 0 && idx+len(query) <= len(visibleLine) {
-				// Split the line into 3 parts
-				before := visibleLine[:idx]
-				match := visibleLine[idx : idx+len(query)]
-				after := visibleLine[idx+len(query):]
+func (m model) renderRepoList() string {
+	if m.loading {
+		// Cloning a git source or importing a repository list
+		return activePanelStyle.Render(m.loadingView("Updating repositories..."))
+	}
+	if len(m.repos) == 0 {
+		return fmt.Sprintf("No repositories found in %s.\nPress 'a' to add a repository.\n\nPress 'q' to quit\n", m.helmClient.RepositoryConfig())
+	}
+	return activePanelStyle.Render(m.repoList.View())
+}
 
-				// Apply YAML highlighting to before and after, but not to match
-				beforeHighlighted := ui.HighlightYAMLLine(before)
-				afterHighlighted := ui.HighlightYAMLLine(after)
-				matchHighlighted := highlightStyle.Render(match)
+func (m model) renderRepoInfo() string {
+	if m.loading {
+		return activePanelStyle.Render(m.loadingView("Loading repository info..."))
+	}
+	if m.repoInfo == nil {
+		return activePanelStyle.Render("No repository selected.")
+	}
 
-				highlighted = beforeHighlighted + matchHighlighted + afterHighlighted
-			} else {
-				// Fallback to normal highlighting if match not found in visible portion
-				highlighted = ui.HighlightYAMLLine(visibleLine)
-			}
-		} else {
-			// Normal line - just apply YAML highlighting
-			highlighted = ui.HighlightYAMLLine(visibleLine)
-		}
+	info := m.repoInfo
+	var content strings.Builder
 
-		// Add continuation indicator if line continues
-		if hasMore {
-			arrowStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("141")).Bold(true)
-			highlighted += arrowStyle.Render(" →")
-		}
+	content.WriteString(infoStyle.Render(fmt.Sprintf(" Repository: %s ", info.Name)) + "\n\n")
+	content.WriteString(fmt.Sprintf("URL:        %s\n", info.URL))
+	content.WriteString(fmt.Sprintf("Index file: %s\n", info.IndexPath))
 
-		highlightedLines[i] = highlighted
+	if info.CachedAt.IsZero() {
+		content.WriteString("\n" + errorStyle.Render(" No cached index found - press u to download it ") + "\n\n")
+		content.WriteString(helpStyle.Render("  u: update repository | esc: back  "))
+		return activePanelStyle.Render(content.String())
 	}
 
-	m.releaseValuesView.SetContent(strings.Join(highlightedLines, "\n"))
-}
-
-func (m *model) updateDiffViewWithSearch() {
-	if len(m.diffLines) == 0 {
-		return
+	content.WriteString(fmt.Sprintf("Index size: %s\n", formatBytes(info.IndexSize)))
+	content.WriteString(fmt.Sprintf("Cached:     %s (%s ago)\n", info.CachedAt.Format("2006-01-02 15:04"), formatAge(time.Since(info.CachedAt))))
+	if !info.Generated.IsZero() {
+		content.WriteString(fmt.Sprintf("Generated:  %s (%s ago)\n", info.Generated.Format("2006-01-02 15:04"), formatAge(time.Since(info.Generated))))
 	}
-
-	// Get the current match line (only this one should be highlighted)
-	var currentMatchLine int = -1
-	if len(m.searchMatches) > 0 && m.currentMatchIndex < len(m.searchMatches) {
-		currentMatchLine = m.searchMatches[m.currentMatchIndex]
+	content.WriteString(fmt.Sprintf("Charts:     %d (%d versions)\n", info.ChartCount, info.VersionCount))
+	if !info.UpstreamModified.IsZero() {
+		content.WriteString(fmt.Sprintf("Upstream:   %s\n", info.UpstreamModified.Format("2006-01-02 15:04")))
 	}
+	content.WriteString("\n")
 
-	query := strings.ToLower(m.lastSearchQuery)
-	highlightedLines := make([]string, len(m.diffLines))
+	if info.Stale {
+		content.WriteString(modifiedStyle.Render(" ⚠ Upstream index is newer than the cached copy - search results may be stale, press u to update ") + "\n\n")
+	}
 
-	for i, line := range m.diffLines {
-		// Only highlight if this is THE CURRENT match
-		if i == currentMatchLine && query != "" {
-			// This line is the CURRENT match - find and highlight it
-			lowerLine := strings.ToLower(line)
-			idx := strings.Index(lowerLine, query)
-			if idx >= 0 && idx+len(query) <= len(line) {
-				// Split the line into 3 parts
-				before := line[:idx]
-				match := line[idx : idx+len(query)]
-				after := line[idx+len(query):]
+	content.WriteString(helpStyle.Render("  u: update repository | esc: back  "))
+	return activePanelStyle.Render(content.String())
+}
 
-				// Highlight the match in yellow background
-				matchHighlighted := highlightStyle.Render(match)
+func (m model) renderChartInfo() string {
+	if m.loading {
+		return activePanelStyle.Render(m.loadingView("Loading chart info..."))
+	}
+	if m.chartInfo == nil {
+		return activePanelStyle.Render("No chart selected.")
+	}
 
-				highlightedLines[i] = before + matchHighlighted + after
-			} else {
-				// Fallback to normal line if match not found
-				highlightedLines[i] = line
-			}
-		} else {
-			// Normal line - no highlighting
-			highlightedLines[i] = line
+	info := m.chartInfo
+	orNone := func(s string) string {
+		if s == "" {
+			return "-"
 		}
+		return s
 	}
 
-	m.diffView.SetContent(strings.Join(highlightedLines, "\n"))
-}
-
-func (m model) View() string {
-	if m.err != nil {
-		return errorStyle.Render(fmt.Sprintf(" Error: %v ", m.err)) + "\n\n" +
-			helpStyle.Render("Press 'q' to quit")
+	var content strings.Builder
+	content.WriteString(infoStyle.Render(fmt.Sprintf(" Chart: %s v%s ", info.Name, info.Version)) + "\n\n")
+	if info.Deprecated {
+		content.WriteString(modifiedStyle.Render(" ⚠ This chart is deprecated ") + "\n\n")
+	}
+	if info.Description != "" {
+		content.WriteString(info.Description + "\n\n")
+	}
+	content.WriteString(fmt.Sprintf("App version:  %s\n", orNone(info.AppVersion)))
+	content.WriteString(fmt.Sprintf("Chart API:    %s (%s)\n", orNone(info.APIVersion), info.Type))
+	content.WriteString(fmt.Sprintf("License:      %s\n", orNone(info.License)))
+	content.WriteString(fmt.Sprintf("Home:         %s\n", orNone(info.Home)))
+	content.WriteString(fmt.Sprintf("Icon:         %s\n", orNone(info.Icon)))
+	if info.KubeVersion != "" {
+		content.WriteString(fmt.Sprintf("Kubernetes:   %s\n", m.kubeVersionCheck(info.KubeVersion)))
+	}
+	if len(info.Keywords) > 0 {
+		content.WriteString(fmt.Sprintf("Keywords:     %s\n", strings.Join(info.Keywords, ", ")))
 	}
 
-	if m.state == stateHelp {
-		return m.renderHelp()
+	content.WriteString("\nSources:\n")
+	if len(info.Sources) == 0 {
+		content.WriteString("  -\n")
+	}
+	for _, source := range info.Sources {
+		content.WriteString("  " + source + "\n")
 	}
 
-	var content string
+	content.WriteString("\nMaintainers:\n")
+	if len(info.Maintainers) == 0 {
+		content.WriteString("  -\n")
+	}
+	for _, mt := range info.Maintainers {
+		line := "  " + mt.Name
+		if mt.Email != "" {
+			line += " <" + mt.Email + ">"
+		}
+		if mt.URL != "" {
+			line += " " + mt.URL
+		}
+		content.WriteString(line + "\n")
+	}
 
-	breadcrumb := m.getBreadcrumb()
-	if breadcrumb != "" {
-		breadcrumbLine := breadcrumbStyle.Render(" " + breadcrumb + " ")
+	content.WriteString("\nDependencies:\n")
+	if len(info.Dependencies) == 0 {
+		content.WriteString("  -\n")
+	}
+	for _, dep := range info.Dependencies {
+		content.WriteString("  " + formatDependency(dep) + "\n")
+	}
+	content.WriteString("\n")
 
-		// Add kubectl context on the right if in cluster releases section
-		if ((m.state >= stateClusterReleasesMenu && m.state <= stateReleaseValues) ||
-			(m.state == stateDiffViewer && m.compareRevision >= 0)) && m.kubeContext != "" {
-			contextInfo := infoStyle.Render(fmt.Sprintf(" kubectl: %s ", m.kubeContext))
-			// Calculate spacing to push context to the right
-			breadcrumbWidth := len(breadcrumb) + 2
-			contextWidth := len(m.kubeContext) + 10
-			spacer := strings.Repeat(" ", max(1, m.termWidth-breadcrumbWidth-contextWidth-4))
-			breadcrumbLine = breadcrumbLine + spacer + contextInfo
-		}
+	content.WriteString(helpStyle.Render("  o: open home page | esc: back  "))
+	return activePanelStyle.Render(content.String())
+}
 
-		content += breadcrumbLine + "\n\n"
+// kubeVersionCheck annotates a kubeVersion constraint with whether the
+// cluster satisfies it, once the cluster version is known
+func (m model) kubeVersionCheck(constraint string) string {
+	switch {
+	case m.clusterVersion == "":
+		return constraint
+	case helm.KubeVersionCompatible(constraint, m.clusterVersion):
+		return constraint + fmt.Sprintf(" (cluster %s ✓)", m.clusterVersion)
+	default:
+		return constraint + " " + errorStyle.Render(fmt.Sprintf("✗ cluster is %s", m.clusterVersion))
 	}
+}
 
-	// Show search info AFTER breadcrumb for better visibility
-	if (m.state == stateValueViewer || m.state == stateReleaseValues || m.state == stateDiffViewer) && len(m.searchMatches) > 0 {
-		content += m.renderSearchHeader() + "\n"
+func formatDependency(dep helm.Dependency) string {
+	line := dep.Name + " " + dep.Version
+	if dep.Repository != "" {
+		line += " (" + dep.Repository + ")"
 	}
+	if dep.Condition != "" {
+		line += " if " + dep.Condition
+	}
+	return line
+}
 
-	switch m.state {
-	case stateMainMenu:
-		content += m.renderMainMenu()
-	case stateBrowseMenu:
-		content += m.renderBrowseMenu()
-	case stateRepoList:
-		content += m.renderRepoList()
-	case stateChartList:
-		content += m.renderChartList()
-	case stateChartDetail:
-		content += m.renderChartDetail()
-	case stateValueViewer:
-		content += m.renderValueViewer()
-	case stateDiffViewer:
-		content += m.renderDiffViewer()
-	case stateArtifactHubSearch:
-		content += m.renderArtifactHubSearch()
-	case stateArtifactHubPackageDetail:
-		content += m.renderArtifactHubPackageDetail()
-	case stateArtifactHubVersions:
-		content += m.renderArtifactHubVersions()
-	case stateClusterReleasesMenu:
-		content += m.renderClusterReleasesMenu()
-	case stateNamespaceList:
-		content += m.renderNamespaceList()
-	case stateReleaseList:
-		content += m.renderReleaseList()
-	case stateReleaseDetail:
-		content += m.renderReleaseDetail()
-	case stateReleaseHistory:
-		content += m.renderReleaseHistory()
-	case stateReleaseValues:
-		content += m.renderReleaseValues()
+// renderChartMetadata summarizes the Chart.yaml of the highlighted version
+// next to the version list
+func (m model) renderChartMetadata(info *helm.ChartInfo) string {
+	var content strings.Builder
+	content.WriteString(titleStyle.Render(" Chart.yaml ") + "\n\n")
+	content.WriteString(fmt.Sprintf("apiVersion:   %s (%s)\n", info.APIVersion, info.Type))
+	if info.AppVersion != "" {
+		content.WriteString(fmt.Sprintf("appVersion:   %s\n", info.AppVersion))
+	}
+	if info.KubeVersion != "" {
+		content.WriteString(fmt.Sprintf("kubeVersion:  %s\n", m.kubeVersionCheck(info.KubeVersion)))
+	}
+	if len(info.Keywords) > 0 {
+		content.WriteString(fmt.Sprintf("keywords:     %s\n", strings.Join(info.Keywords, ", ")))
+	}
+	if len(info.Maintainers) > 0 {
+		names := make([]string, len(info.Maintainers))
+		for i, mt := range info.Maintainers {
+			names[i] = mt.Name
+		}
+		content.WriteString(fmt.Sprintf("maintainers:  %s\n", strings.Join(names, ", ")))
+	}
+	if len(info.Sources) > 0 {
+		content.WriteString("\nsources:\n")
+		for _, source := range info.Sources {
+			content.WriteString("  " + source + "\n")
+		}
 	}
+	if len(info.Dependencies) > 0 {
+		content.WriteString("\ndependencies:\n")
+		for _, dep := range info.Dependencies {
+			content.WriteString("  " + formatDependency(dep) + "\n")
+		}
+	}
+	return strings.TrimRight(content.String(), "\n")
+}
 
-	footer := "\n"
-	if m.successMsg != "" {
-		footer += successStyle.Render(" " + m.successMsg + " ") + "\n"
+func (m model) renderChartList() string {
+	if m.loading {
+		return m.loadingView("Loading charts...")
 	}
-
-	if m.mode != normalMode {
-		footer += m.renderInputPrompt() + "\n"
+	if len(m.charts) == 0 {
+		return "No charts found."
 	}
-
-	footer += "\n" + helpStyle.Render(" "+m.helpView.ShortHelpView(m.keys.ShortHelp())+" ")
-
-	return content + footer
+	if m.repoGrep != nil {
+		header := infoStyle.Render(fmt.Sprintf(" Charts whose values have '%s' ", m.repoGrep.query)) + " " + helpStyle.Render("c: clear")
+		return header + "\n" + activePanelStyle.Render(m.chartList.View())
+	}
+	return activePanelStyle.Render(m.chartList.View())
 }
 
-func (m model) renderSearchHeader() string {
-	if len(m.searchMatches) == 0 {
-		return ""
+func (m model) renderChartDetail() string {
+	if m.loading {
+		return activePanelStyle.Render(m.loadingView("Loading versions..."))
+	}
+	if len(m.versions) == 0 {
+		return activePanelStyle.Render("No versions found.")
 	}
 
-	var header string
-
-	// Match counter - always visible
-	matchInfo := fmt.Sprintf(" Match %d/%d ", m.currentMatchIndex+1, len(m.searchMatches))
-	header += infoStyle.Render(matchInfo) + " "
-
-	// Show YAML path or line content based on state
-	if m.state == stateValueViewer {
-		matchLine := m.searchMatches[m.currentMatchIndex]
-		yamlPath := ui.GetYAMLPath(m.valuesLines, matchLine)
-
-		if yamlPath != "" {
-			header += pathStyle.Render(" " + yamlPath + " ")
-		} else if matchLine < len(m.valuesLines) {
-			lineContent := strings.TrimSpace(m.valuesLines[matchLine])
-			if len(lineContent) > 60 {
-				lineContent = lineContent[:60] + "..."
-			}
-			header += pathStyle.Render(fmt.Sprintf(" Line %d: %s ", matchLine+1, lineContent))
+	if m.diffMode {
+		selectedVersion := "unknown"
+		if m.compareVersion < len(m.versions) {
+			selectedVersion = "v" + m.versions[m.compareVersion].Version
 		}
-		header += " " + helpStyle.Render("n=next N=prev y=copy")
-	} else if m.state == stateReleaseValues {
-		matchLine := m.searchMatches[m.currentMatchIndex]
-		yamlPath := ui.GetYAMLPath(m.releaseValuesLines, matchLine)
+		diffMsg := fmt.Sprintf(" Diff mode: First version = %s | Select second version to compare ", selectedVersion)
+		return infoStyle.Render(diffMsg) + "\n\n" + activePanelStyle.Render(m.versionList.View())
+	}
 
-		if yamlPath != "" {
-			header += pathStyle.Render(" " + yamlPath + " ")
-		} else if matchLine < len(m.releaseValuesLines) {
-			lineContent := strings.TrimSpace(m.releaseValuesLines[matchLine])
-			if len(lineContent) > 60 {
-				lineContent = lineContent[:60] + "..."
+	versions := activePanelStyle.Render(m.versionList.View())
+	if selectedItem := m.versionList.SelectedItem(); selectedItem != nil {
+		for _, ver := range m.versions {
+			if "v"+ver.Version == selectedItem.(listItem).title && ver.Metadata != nil {
+				width := max(30, m.termWidth-lipgloss.Width(versions)-6)
+				metadata := panelStyle.Width(width).Render(m.renderChartMetadata(ver.Metadata))
+				return lipgloss.JoinHorizontal(lipgloss.Top, versions, metadata)
 			}
-			header += pathStyle.Render(fmt.Sprintf(" Line %d: %s ", matchLine+1, lineContent))
-		}
-		header += " " + helpStyle.Render("n=next N=prev y=copy")
-	} else if m.state == stateDiffViewer {
-		matchLine := m.searchMatches[m.currentMatchIndex]
-		if matchLine < len(m.diffLines) {
-			lineContent := strings.TrimSpace(m.diffLines[matchLine])
-			if len(lineContent) > 80 {
-				lineContent = lineContent[:80] + "..."
-			}
-			header += pathStyle.Render(fmt.Sprintf(" %s ", lineContent))
 		}
-		header += " " + helpStyle.Render("n=next N=prev")
 	}
-
-	return header
+	return versions
 }
 
-func (m model) getBreadcrumb() string {
-	parts := []string{"LazyHelm"}
+func (m model) renderChartReadme() string {
+	if m.loading {
+		return activePanelStyle.Render(m.loadingView("Loading README..."))
+	}
+	return activePanelStyle.Render(m.readmeView.View())
+}
 
-	// Cluster Releases navigation
-	if m.state >= stateClusterReleasesMenu && m.state <= stateReleaseValues {
-		parts = append(parts, "Cluster Releases")
+func (m model) renderChartFiles() string {
+	if m.loading {
+		return activePanelStyle.Render(m.loadingView("Loading chart files..."))
+	}
+	if len(m.chartFiles) == 0 {
+		return activePanelStyle.Render("No files found.")
+	}
+	return activePanelStyle.Render(m.chartFileList.View())
+}
 
-		if m.state == stateNamespaceList {
-			parts = append(parts, "Select Namespace")
+func (m model) renderChartSchema() string {
+	if m.loading {
+		return activePanelStyle.Render(m.loadingView("Loading values schema..."))
+	}
+	if len(m.valuesSchema) == 0 {
+		if m.schemaReturn == stateArtifactHubPackageDetail {
+			return activePanelStyle.Render("This package has no values schema on Artifact Hub.")
 		}
+		return activePanelStyle.Render("This chart version has no values.schema.json.")
+	}
+	return activePanelStyle.Render(m.schemaList.View())
+}
 
-		if m.state >= stateReleaseList && m.selectedNamespace != "" {
-			parts = append(parts, m.selectedNamespace)
+// schemaItems lists schema properties by values path, required ones marked
+// with *; the description holds type, default, allowed values and the
+// schema's own description
+func schemaItems(props []helm.SchemaProperty) []list.Item {
+	items := make([]list.Item, len(props))
+	for i, prop := range props {
+		title := prop.Path
+		if prop.Required {
+			title += " *"
 		}
-
-		if m.state >= stateReleaseList && m.selectedRelease < len(m.releases) {
-			parts = append(parts, m.releases[m.selectedRelease].Name)
+		details := []string{prop.Type}
+		if prop.Type == "" {
+			details[0] = "any"
 		}
-
-		if m.state == stateReleaseHistory {
-			parts = append(parts, "history")
+		if prop.Default != "" {
+			details = append(details, "default: "+prop.Default)
+		}
+		if len(prop.Enum) > 0 {
+			details = append(details, "one of: "+strings.Join(prop.Enum, ", "))
 		}
+		if prop.Description != "" {
+			details = append(details, prop.Description)
+		}
+		items[i] = listItem{title: title, description: strings.Join(details, " | ")}
+	}
+	return items
+}
 
-		if m.state == stateReleaseValues {
-			if m.selectedRevision > 0 {
-				parts = append(parts, fmt.Sprintf("revision %d", m.selectedRevision))
+// chartFileItems lays the chart files out as a tree: a directory entry is
+// listed before its files, and names are indented by depth. The description
+// holds the full path, empty for directories.
+func chartFileItems(files []helm.ChartFile) []list.Item {
+	var items []list.Item
+	seenDirs := make(map[string]bool)
+	for _, f := range files {
+		parts := strings.Split(f.Path, "/")
+		for depth := 1; depth < len(parts); depth++ {
+			dir := strings.Join(parts[:depth], "/")
+			if !seenDirs[dir] {
+				seenDirs[dir] = true
+				items = append(items, listItem{title: strings.Repeat("  ", depth-1) + parts[depth-1] + "/"})
 			}
-			parts = append(parts, "values")
 		}
-
-		return strings.Join(parts, " > ")
+		title := fmt.Sprintf("%s%s (%s)", strings.Repeat("  ", len(parts)-1), parts[len(parts)-1], formatBytes(int64(len(f.Data))))
+		items = append(items, listItem{title: title, description: f.Path})
 	}
+	return items
+}
 
-	// Artifact Hub navigation
-	if m.state == stateArtifactHubSearch {
-		parts = append(parts, "Artifact Hub")
-		return strings.Join(parts, " > ")
+// chartFileContent returns a chart file ready for the viewer: YAML and
+// templates highlighted, binary files (e.g. bundled subcharts) summarized
+func chartFileContent(f helm.ChartFile) string {
+	if !utf8.Valid(f.Data) {
+		return fmt.Sprintf("Binary file, %s", formatBytes(int64(len(f.Data))))
 	}
-
-	if m.state == stateArtifactHubPackageDetail && m.ahSelectedPackage != nil {
-		parts = append(parts, "Artifact Hub", m.ahSelectedPackage.Name)
-		return strings.Join(parts, " > ")
+	switch filepath.Ext(f.Path) {
+	case ".yaml", ".yml", ".tpl":
+		return ui.HighlightYAMLContent(string(f.Data))
 	}
+	return string(f.Data)
+}
 
-	if m.state == stateArtifactHubVersions && m.ahSelectedPackage != nil {
-		parts = append(parts, "Artifact Hub", m.ahSelectedPackage.Name, "Versions")
-		return strings.Join(parts, " > ")
+// renderReadme renders the README markdown at the viewport width
+func (m *model) renderReadme() {
+	rendered, err := ui.RenderMarkdown(m.readme, m.readmeView.Width-4)
+	if err != nil {
+		// Still readable as plain text
+		rendered = m.readme
 	}
+	m.readmeLines = strings.Split(strings.TrimRight(rendered, "\n"), "\n")
+	m.updateReadmeViewWithSearch()
+}
 
-	// Regular Helm navigation
-	if m.selectedRepo < len(m.repos) {
-		parts = append(parts, m.repos[m.selectedRepo].Name)
+// updateReadmeViewWithSearch highlights the current search match in the
+// rendered README; the match line loses its markdown styling
+func (m *model) updateReadmeViewWithSearch() {
+	currentMatchLine := -1
+	if len(m.searchMatches) > 0 && m.currentMatchIndex < len(m.searchMatches) {
+		currentMatchLine = m.searchMatches[m.currentMatchIndex]
 	}
 
-	if m.state >= stateChartList && m.selectedChart < len(m.charts) {
-		name := m.charts[m.selectedChart].Name
-		if m.selectedRepo < len(m.repos) {
-			name = strings.TrimPrefix(name, m.repos[m.selectedRepo].Name+"/")
+	lines := slices.Clone(m.readmeLines)
+	if currentMatchLine >= 0 && currentMatchLine < len(lines) {
+		plain := ansi.Strip(lines[currentMatchLine])
+		if start, end := m.searchRange(plain); start >= 0 {
+			lines[currentMatchLine] = plain[:start] + highlightStyle.Render(plain[start:end]) + plain[end:]
 		}
-		parts = append(parts, name)
 	}
+	m.readmeView.SetContent(strings.Join(lines, "\n"))
+}
 
-	if m.state >= stateChartDetail && m.selectedVersion < len(m.versions) {
-		parts = append(parts, "v"+m.versions[m.selectedVersion].Version)
+func (m *model) updateTemplateViewWithSearch() {
+	currentMatchLine := -1
+	if len(m.searchMatches) > 0 && m.currentMatchIndex < len(m.searchMatches) {
+		currentMatchLine = m.searchMatches[m.currentMatchIndex]
 	}
 
-	if m.state == stateValueViewer {
-		parts = append(parts, "values")
+	lines := slices.Clone(m.templateHighlighted)
+	if currentMatchLine >= 0 && currentMatchLine < len(lines) {
+		plain := m.templateLines[currentMatchLine]
+		if start, end := m.searchRange(plain); start >= 0 {
+			lines[currentMatchLine] = plain[:start] + highlightStyle.Render(plain[start:end]) + plain[end:]
+		}
 	}
+	m.templateView.SetContent(strings.Join(lines, "\n"))
+}
 
-	if m.state == stateDiffViewer {
-		parts = append(parts, "diff")
-	}
+// startOverrides asks for the key=value overrides of the template or upgrade
+// preview being set up, completing keys from the values of the chart
+func (m *model) startOverrides(mode inputMode, chartName, version string) tea.Cmd {
+	m.mode = mode
+	m.overrides = nil
+	m.overrideKeys = nil
+	m.overrideMatches = nil
+	m.searchInput.Reset()
+	m.searchInput.Placeholder = "key=value, e.g. image.tag=1.2 (empty to continue)..."
+	return loadOverrideKeys(m.helmClient, m.cache, chartName, version)
+}
 
-	return strings.Join(parts, " > ")
+// updateOverrideMatches completes the key of the override being typed
+func (m *model) updateOverrideMatches() {
+	m.overrideMatches = nil
+	typed := m.searchInput.Value()
+	if typed == "" || strings.Contains(typed, "=") {
+		return
+	}
+	paths := make([]string, len(m.overrideKeys))
+	for i, keyPath := range m.overrideKeys {
+		paths[i] = keyPath.Path
+	}
+	for _, match := range fuzzy.Find(typed, paths) {
+		m.overrideMatches = append(m.overrideMatches, m.overrideKeys[match.Index])
+	}
 }
 
-func (m model) renderMainMenu() string {
-	return activePanelStyle.Render(m.mainMenu.View())
+// templateResourceAt returns the object of the template preview a line
+// belongs to
+func (m model) templateResourceAt(line int) (ui.ManifestResource, bool) {
+	for i := len(m.templateResources) - 1; i >= 0; i-- {
+		if m.templateResources[i].Line <= line {
+			return m.templateResources[i], true
+		}
+	}
+	return ui.ManifestResource{}, false
 }
 
-func (m model) renderBrowseMenu() string {
-	return activePanelStyle.Render(m.browseMenu.View())
+// adjacentTemplateResource returns the object after, or before, the one at
+// the top of the template preview
+func (m model) adjacentTemplateResource(next bool) (ui.ManifestResource, bool) {
+	top := m.templateView.YOffset
+	if next {
+		for _, res := range m.templateResources {
+			if res.Line > top {
+				return res, true
+			}
+		}
+		return ui.ManifestResource{}, false
+	}
+	for i := len(m.templateResources) - 1; i >= 0; i-- {
+		if m.templateResources[i].Line < top {
+			return m.templateResources[i], true
+		}
+	}
+	return ui.ManifestResource{}, false
 }
 
-func (m model) renderRepoList() string {
-	if len(m.repos) == 0 {
-		return "No repositories found.\nPress 'a' to add a repository.\n\nPress 'q' to quit\n"
+func (m model) renderTemplatePreview() string {
+	if m.loading {
+		return activePanelStyle.Render(m.loadingView("Rendering the chart templates..."))
 	}
-	return activePanelStyle.Render(m.repoList.View())
+	only := ""
+	if len(m.templateOpts.ShowOnly) > 0 {
+		only = pathStyle.Render(" only " + strings.Join(m.templateOpts.ShowOnly, ", ") + " ") + " "
+	}
+	if len(m.templateResources) == 0 {
+		return only + "\n\n" + activePanelStyle.Render(m.templateView.View()) + "\n" + helpStyle.Render("  No resources rendered | f: show only one template | esc: back  ")
+	}
+
+	header := infoStyle.Render(fmt.Sprintf(" %d resources ", len(m.templateResources)))
+	for i, res := range m.templateResources {
+		if next := i + 1; res.Line <= m.templateView.YOffset && (next == len(m.templateResources) || m.templateResources[next].Line > m.templateView.YOffset) {
+			current := fmt.Sprintf(" %d/%d %s %s ", i+1, len(m.templateResources), res.Kind, res.Name)
+			if res.Source != "" {
+				current += "(" + res.Source + ") "
+			}
+			header = infoStyle.Render(current)
+			break
+		}
+	}
+	hint := helpStyle.Render("  ]/[: next/previous resource | f: show only one template | /: search | esc: back  ")
+	return only + header + "\n\n" + activePanelStyle.Render(m.templateView.View()) + "\n" + hint
 }
 
-func (m model) renderChartList() string {
+func (m model) renderReleaseNotes() string {
 	if m.loading {
-		return "Loading charts..."
+		return activePanelStyle.Render(m.loadingView("Loading release notes..."))
 	}
-	if len(m.charts) == 0 {
-		return "No charts found."
+	return activePanelStyle.Render(m.releaseNotesView.View()) + "\n" + helpStyle.Render("  /: search | y: copy notes | esc: back  ")
+}
+
+func (m model) renderReleaseDrift() string {
+	if m.loading {
+		return activePanelStyle.Render(m.loadingView("Comparing the release manifest with the cluster..."))
 	}
-	return activePanelStyle.Render(m.chartList.View())
+	return activePanelStyle.Render(m.releaseDriftView.View()) + "\n" + helpStyle.Render("  esc: back  ")
 }
 
-func (m model) renderChartDetail() string {
+func (m model) renderReleaseEvents() string {
 	if m.loading {
-		return activePanelStyle.Render("Loading versions...")
+		return activePanelStyle.Render(m.loadingView("Loading release events..."))
 	}
-	if len(m.versions) == 0 {
-		return activePanelStyle.Render("No versions found.")
+	return activePanelStyle.Render(m.releaseEventsView.View()) + "\n" + helpStyle.Render("  esc: back  ")
+}
+
+// renderEventsReport lists the events about the release objects, warnings
+// first, like kubectl get events
+func renderEventsReport(events []helm.ReleaseEvent) string {
+	var content strings.Builder
+	if len(events) == 0 {
+		content.WriteString(infoStyle.Render(" No recent events for this release's objects ") + "\n")
+		return content.String()
+	}
+	warnings := 0
+	for _, ev := range events {
+		if ev.Type == "Warning" {
+			warnings++
+		}
+	}
+	if warnings > 0 {
+		content.WriteString(modifiedStyle.Render(fmt.Sprintf(" ⚠ %d warnings in %d events ", warnings, len(events))) + "\n\n")
+	} else {
+		content.WriteString(infoStyle.Render(fmt.Sprintf(" ✓ %d events, no warnings ", len(events))) + "\n\n")
+	}
+	for _, ev := range events {
+		age := "unknown"
+		if !ev.LastSeen.IsZero() {
+			age = formatAge(time.Since(ev.LastSeen)) + " ago"
+		}
+		header := fmt.Sprintf("%s  %s  %s", ev.Reason, ev.Object, helpStyle.Render(age))
+		if ev.Count > 1 {
+			header += helpStyle.Render(fmt.Sprintf(" (x%d)", ev.Count))
+		}
+		if ev.Type == "Warning" {
+			header = errorStyle.Render("Warning") + " " + header
+		}
+		content.WriteString(header + "\n")
+		for _, line := range strings.Split(ev.Message, "\n") {
+			content.WriteString("  " + line + "\n")
+		}
+		content.WriteString("\n")
 	}
+	return content.String()
+}
 
-	if m.diffMode {
-		selectedVersion := "unknown"
-		if m.compareVersion < len(m.versions) {
-			selectedVersion = "v" + m.versions[m.compareVersion].Version
+// renderDriftReport lists the release objects changed or deleted out-of-band
+// with the fields whose live value differs from the manifest
+func renderDriftReport(drift *helm.ReleaseDrift) string {
+	var content strings.Builder
+	if len(drift.Resources) == 0 {
+		content.WriteString(infoStyle.Render(fmt.Sprintf(" ✓ No drift: all %d objects match the release manifest ", drift.Checked)) + "\n")
+		return content.String()
+	}
+	content.WriteString(modifiedStyle.Render(fmt.Sprintf(" ⚠ %d of %d objects drifted from the release manifest ", len(drift.Resources), drift.Checked)) + "\n\n")
+	for _, res := range drift.Resources {
+		name := res.Name
+		if res.Namespace != "" {
+			name = res.Namespace + "/" + name
 		}
-		diffMsg := fmt.Sprintf(" Diff mode: First version = %s | Select second version to compare ", selectedVersion)
-		return infoStyle.Render(diffMsg) + "\n\n" + activePanelStyle.Render(m.versionList.View())
+		if res.Missing {
+			content.WriteString(fmt.Sprintf("%s %s %s\n\n", res.Kind, name, errorStyle.Render("deleted from the cluster")))
+			continue
+		}
+		content.WriteString(fmt.Sprintf("%s %s\n", res.Kind, name))
+		for _, field := range res.Fields {
+			content.WriteString("  " + field.Path + "\n")
+			content.WriteString("    " + removedStyle.Render("- "+field.Expected) + "\n")
+			if field.Live == "" {
+				content.WriteString("    " + addedStyle.Render("+ (removed)") + "\n")
+			} else {
+				content.WriteString("    " + addedStyle.Render("+ "+field.Live) + "\n")
+			}
+		}
+		content.WriteString("\n")
+	}
+	return content.String()
+}
+
+// wrapReleaseNotes wraps the release notes to the viewport width
+func (m *model) wrapReleaseNotes() {
+	wrapped := ansi.Wrap(strings.TrimRight(m.releaseNotes, "\n"), max(20, m.releaseNotesView.Width-4), "")
+	m.releaseNotesLines = strings.Split(wrapped, "\n")
+	m.updateReleaseNotesViewWithSearch()
+}
+
+// updateReleaseNotesViewWithSearch highlights the current search match in
+// the release notes
+func (m *model) updateReleaseNotesViewWithSearch() {
+	currentMatchLine := -1
+	if len(m.searchMatches) > 0 && m.currentMatchIndex < len(m.searchMatches) {
+		currentMatchLine = m.searchMatches[m.currentMatchIndex]
 	}
 
-	return activePanelStyle.Render(m.versionList.View())
+	lines := slices.Clone(m.releaseNotesLines)
+	if currentMatchLine >= 0 && currentMatchLine < len(lines) {
+		line := lines[currentMatchLine]
+		if start, end := m.searchRange(line); start >= 0 {
+			lines[currentMatchLine] = line[:start] + highlightStyle.Render(line[start:end]) + line[end:]
+		}
+	}
+	m.releaseNotesView.SetContent(strings.Join(lines, "\n"))
 }
 
 func (m model) renderValueViewer() string {
 	if m.loadingVals {
-		return activePanelStyle.Render("Loading values...")
+		return activePanelStyle.Render(m.loadingView("Loading values..."))
 	}
 	if m.values == "" {
 		return activePanelStyle.Render("No values available.")
@@ -2899,6 +9383,10 @@ func (m model) renderValueViewer() string {
 		header = helpStyle.Render(scrollInfo) + "\n\n"
 	}
 
+	if m.valuesOutline {
+		header += helpStyle.Render(" Outline | ↑/↓: move | enter/space: fold/unfold | z: full view ") + "\n\n"
+	}
+
 	if header != "" {
 		return header + activePanelStyle.Render(m.valuesView.View())
 	}
@@ -2907,35 +9395,51 @@ func (m model) renderValueViewer() string {
 }
 
 func (m model) renderDiffViewer() string {
+	if m.loading && m.diffLines == nil {
+		return activePanelStyle.Render(m.loadingView("Loading the documents to compare..."))
+	}
 	return activePanelStyle.Render(m.diffView.View())
 }
 
-func (m model) renderDiffContent(diffLines []ui.DiffLine, version1, version2 string) string {
-	// Detect if we're comparing revisions (starts with "rev") or versions
-	var label1, label2 string
-	if len(version1) > 3 && version1[:3] == "rev" {
-		// Release revision
-		label1 = "Revision " + version1[3:]
-		label2 = "Revision " + version2[3:]
-	} else {
-		// Chart version
-		label1 = "v" + version1
-		label2 = "v" + version2
-	}
-
+func renderDiffContent(diffLines []ui.DiffLine, label1, label2 string) string {
 	header := fmt.Sprintf("Comparing %s (old) → %s (new)\n", label1, label2)
 	header += fmt.Sprintf("Showing only changes (%d lines)\n\n", len(diffLines))
 
 	var content strings.Builder
 	content.WriteString(header)
 
-	for _, line := range diffLines {
-		switch line.Type {
-		case "added":
+	// A run of removed lines followed by added lines replaces them: the
+	// lines are paired up in order and only the changed words highlighted
+	rendered := make([]string, len(diffLines))
+	for i := 0; i < len(diffLines); i++ {
+		if diffLines[i].Type != "removed" {
+			continue
+		}
+		removedEnd := i
+		for removedEnd < len(diffLines) && diffLines[removedEnd].Type == "removed" {
+			removedEnd++
+		}
+		addedEnd := removedEnd
+		for addedEnd < len(diffLines) && diffLines[addedEnd].Type == "added" {
+			addedEnd++
+		}
+		for j := 0; j < min(removedEnd-i, addedEnd-removedEnd); j++ {
+			oldSegments, newSegments := ui.WordDiff(diffLines[i+j].Line, diffLines[removedEnd+j].Line)
+			rendered[i+j] = renderSegments("- ", oldSegments, removedStyle, removedWordStyle)
+			rendered[removedEnd+j] = renderSegments("+ ", newSegments, addedStyle, addedWordStyle)
+		}
+		i = addedEnd - 1
+	}
+
+	for i, line := range diffLines {
+		switch {
+		case rendered[i] != "":
+			content.WriteString(rendered[i])
+		case line.Type == "added":
 			content.WriteString(addedStyle.Render("+ " + line.Line))
-		case "removed":
+		case line.Type == "removed":
 			content.WriteString(removedStyle.Render("- " + line.Line))
-		case "unchanged":
+		case line.Type == "unchanged":
 			content.WriteString("  " + line.Line)
 		}
 		content.WriteString("\n")
@@ -2944,6 +9448,41 @@ func (m model) renderDiffContent(diffLines []ui.DiffLine, version1, version2 str
 	return content.String()
 }
 
+// renderPathDiff lists the values changed between two documents by YAML path
+func renderPathDiff(changes []ui.PathChange, label1, label2 string) string {
+	var content strings.Builder
+	content.WriteString(fmt.Sprintf("Comparing %s (old) → %s (new) by YAML path\n", label1, label2))
+	content.WriteString(fmt.Sprintf("%d value(s) changed; formatting, comments and key order are ignored\n\n", len(changes)))
+	if len(changes) == 0 {
+		content.WriteString(infoStyle.Render(" ✓ Both documents hold the same values ") + "\n")
+	}
+	for _, change := range changes {
+		switch change.Type {
+		case "added":
+			content.WriteString(addedStyle.Render("+ "+change.Path+": "+change.New) + "\n")
+		case "removed":
+			content.WriteString(removedStyle.Render("- "+change.Path+": "+change.Old) + "\n")
+		default:
+			content.WriteString(modifiedStyle.Render("~ "+change.Path+": "+change.Old+" → "+change.New) + "\n")
+		}
+	}
+	return content.String()
+}
+
+// renderSegments renders a changed line with its changed words in wordStyle
+func renderSegments(prefix string, segments []ui.Segment, style, wordStyle lipgloss.Style) string {
+	var line strings.Builder
+	line.WriteString(style.Render(prefix))
+	for _, segment := range segments {
+		if segment.Changed {
+			line.WriteString(wordStyle.Render(segment.Text))
+		} else {
+			line.WriteString(style.Render(segment.Text))
+		}
+	}
+	return line.String()
+}
+
 func (m model) renderHelp() string {
 	help := "\n  LazyHelm - Help\n\n"
 
@@ -2953,7 +9492,12 @@ func (m model) renderHelp() string {
 	help += "    enter       Select item / Go deeper\n"
 	help += "    esc         Go back to previous screen\n"
 	help += "    q           Quit application\n"
-	help += "    ?           Toggle this help screen\n\n"
+	help += "    ?           Toggle this help screen\n"
+	help += "    ctrl+t      Open a new tab on the current screen\n"
+	help += "    ctrl+w      Close the tab\n"
+	help += "    tab/1-9     Next tab / go to a tab (shift+tab: previous)\n"
+	help += "    .           Repeat last action (export, template, search, update)\n"
+	help += "    g           Go to any loaded repo, chart, release or namespace\n\n"
 
 	help += "  Search & Filter:\n"
 	help += "    /           Search/filter in current view\n"
@@ -2962,59 +9506,320 @@ func (m model) renderHelp() string {
 	help += "    N           Previous search result\n\n"
 
 	help += "  Repository Management:\n"
-	help += "    a           Add new repository\n"
+	help += "    a           Add new repository, or a git source when the URL is a git repository\n"
 	help += "    r           Remove selected repository\n"
+	help += "    R           Rename selected repository\n"
+	help += "    U           Undo the last repository removal\n"
 	help += "    u           Update repository index (helm repo update)\n"
-	help += "    s           Search Artifact Hub\n\n"
+	help += "    i           Show repository index info (size, age, staleness)\n"
+	help += "    *           Pin/unpin the selected repository to the top of the list\n"
+	help += "    shift+↑/↓   Move the selected repository up or down (also < and >)\n"
+	help += "    w           Export the repository list (names and URLs) to share it\n"
+	help += "    I           Import a repository list, merging it or replacing the configured repositories\n"
+	help += "    s           Search Artifact Hub\n"
+	help += "    C           Security report of an Artifact Hub package: CVEs by severity\n"
+	help += "    F           Filter Artifact Hub results: verified, official, signed, license=<SPDX>\n"
+	help += "    O           Sort Artifact Hub results by relevance, stars or last updated\n"
+	help += "    p           List every package of the same organization or repository (Artifact Hub package)\n\n"
 
 	help += "  Chart & Version Actions:\n"
 	help += "    v           View all versions (in chart list)\n"
-	help += "    d           Diff two versions (select first, then second)\n\n"
+	help += "    D           Hide/show deprecated charts (in chart list)\n"
+	help += "    A           Search the values of all charts of the repository (in chart list)\n"
+	help += "    d           Diff two versions (select first, then second)\n"
+	help += "    z           Outline of the values with foldable keys (in values viewer)\n"
+	help += "    ctrl+g      Jump to a key by fuzzy YAML path, e.g. img.pullpol (in values viewers)\n"
+	help += "    B           Pin a version, then press B on a version of another chart to diff their values\n"
+	help += "    K           Switch a values diff between lines and YAML paths (in diff viewer)\n"
+	help += "    w           Export the diff being viewed as a unified diff (in diff viewer)\n"
+	help += "    y           Copy helm install command for the selected version\n"
+	help += "    I           Dry-run an install of the selected version on the cluster (--dry-run=server)\n"
+	help += "    m           Generate a GitOps manifest (Argo CD, Flux, helmfile)\n"
+	help += "    i           Show chart info (maintainers, sources, license)\n"
+	help += "    R           Read the chart README (in version list or Artifact Hub package, / to search)\n"
+	help += "    f           Browse the chart's files: templates, CRDs, helpers\n"
+	help += "    S           Browse the chart's values.schema.json (also on Artifact Hub packages, / to filter)\n"
+	help += "    o           Open chart home page in browser (also on Artifact Hub packages)\n\n"
 
 	help += "  Cluster Releases:\n"
 	help += "    v           View release values (in release list)\n"
+	help += "    A           Search the values of all releases, -A for all namespaces (in release list)\n"
+	help += "    l           Filter releases by label selector (c clears it)\n"
+	help += "    T           Run helm test for the selected release\n"
+	help += "    x           Uninstall the selected release (asks for confirmation)\n"
+	help += "    F           Fix a release stuck in pending-*: rollback, delete the lock, or uninstall\n"
+	help += "    space       Mark/unmark a release for a batch action (in release list)\n"
+	help += "    *           Star/unstar a chart or release; starred ones are listed first and under Favorites\n"
+	help += "    b           Batch action on the marked releases: export values, update repos, uninstall\n"
 	help += "    h           View release history & revisions\n"
+	help += "    o           Read the release notes (/ to search, y to copy)\n"
+	help += "    D           Detect drift between the release manifest and the cluster\n"
+	help += "    E           Show the Kubernetes events of the release objects, warnings first\n"
+	help += "    r           Refresh the resources and pods of the release (in release detail)\n"
+	help += "    L           Follow the logs of a release pod (f follows/pauses, / searches)\n"
+	help += "    P           Preview an upgrade with the helm-diff plugin (nothing is changed), with optional key=value overrides\n"
+	help += "    I           Dry-run an upgrade (--dry-run=server): objects with server defaults and admission errors\n"
 	help += "    d           Diff two revisions (select first, then second)\n"
-	help += "    w           Export release values to file\n\n"
+	help += "    m           Diff the manifests of two revisions (in revision history)\n"
+	help += "    w           Export release values to file\n"
+	help += "    d           Show only the overrides of the chart defaults (in release values)\n\n"
 
 	help += "  Values View:\n"
 	help += "    e           Edit values in external editor ($EDITOR)\n"
 	help += "    w           Write/export values to file\n"
-	help += "    t           Generate Helm template\n"
+	help += "    t           Generate Helm template (@preview shows it here: ]/[ jump between resources)\n"
+	help += "                Asks for release name, namespace and Kubernetes version, then key=value overrides\n"
+	help += "                (--set); tab completes keys from the chart values\n"
+	help += "    f           Render only some templates, like --show-only (in template preview)\n"
+	help += "    m           Generate a GitOps manifest with these values\n"
+	help += "    d           Diff these defaults against a local values file\n"
+	help += "    V           Validate a local values file against the chart schema\n"
 	help += "    y           Copy YAML path to clipboard\n"
-	help += "    ←/→         Scroll horizontally for long lines\n\n"
+	help += "    Y           Copy YAML path with its value (a.b.c: value)\n"
+	help += "    ctrl+y      Copy YAML path in --set format (or dotted, see path_format)\n"
+	help += "    alt+y       Copy the value, or the whole nested block of a key\n"
+	help += "    alt+s       Copy as a helm flag, e.g. --set image.tag=1.2.3\n"
+	help += "    /re:expr    Search with a regular expression (in values, diff and text viewers)\n"
+	help += "    alt+c       Toggle case-sensitive search (also while typing the search)\n"
+	help += "    alt+w       Toggle whole-word search (also while typing the search)\n"
+	help += "    #           Toggle line numbers (in values and diff viewers)\n"
+	help += "    ←/→         Scroll horizontally for long lines\n"
+	help += "    gg/G        Jump to top/bottom (also in diffs)\n"
+	help += "    ctrl+d/u    Half page down/up\n"
+	help += "    {/}         Previous/next top-level section\n\n"
+
+	help += "  OCI Registries:\n"
+	help += "    a           Log in to a registry (helm registry login)\n"
+	help += "    x           Log out of the selected registry (helm registry logout)\n"
+	help += "    r           Check again which registries authenticate\n\n"
+
+	help += "  Helmfile:\n"
+	help += "    enter       Values of the release in the environment, its chart or the deployed release\n"
+	help += "    e           Switch environment\n"
+	help += "    r           Reload the helmfile\n\n"
 
 	help += "  Tips:\n"
 	help += "    • Horizontal scroll: Lines ending with → continue beyond screen\n"
 	help += "    • Search shows match count and current YAML path\n"
-	help += "    • Editor: Uses $EDITOR/$VISUAL, falls back to nvim→vim→vi\n"
+	help += "    • Editor: Uses the editor setting, then $EDITOR/$VISUAL, falls back to nvim→vim→vi\n"
 	help += "    • Diff: Press d on first version, enter on second to compare\n"
-	help += "    • YAML validation happens automatically when editing\n\n"
+	help += "    • YAML and schema validation happen automatically when editing\n\n"
 
 	help += "  Press ? or esc to close this help\n"
 	return help
 }
 
+// renderDryRun lists the objects of a server-side dry run, rejected ones
+// with the reason, as the API server would store them
+func renderDryRun(release helm.Release, opts helm.DryRunOptions, result *helm.DryRunResult, warning string) string {
+	action := "install"
+	if result.Upgrade {
+		action = "upgrade"
+	}
+	target := opts.Chart
+	if opts.Version != "" {
+		target += " " + opts.Version
+	}
+	var content strings.Builder
+	fmt.Fprintf(&content, "Server-side dry run: %s %s in %s → %s\n\n", action, release.Name, release.Namespace, target)
+	if warning != "" {
+		content.WriteString(errorStyle.Render(" "+warning+" ") + "\n")
+	}
+
+	rejected := 0
+	for _, obj := range result.Objects {
+		if obj.Err != nil {
+			rejected++
+		}
+	}
+	if rejected == 0 {
+		content.WriteString(infoStyle.Render(fmt.Sprintf(" ✓ The API server accepts all %d objects ", len(result.Objects))) + "\n")
+	} else {
+		content.WriteString(errorStyle.Render(fmt.Sprintf(" ✗ The API server rejects %d of %d objects ", rejected, len(result.Objects))) + "\n")
+	}
+	content.WriteString(helpStyle.Render("Objects are shown with server defaults; hooks aren't sent") + "\n")
+
+	for _, obj := range result.Objects {
+		content.WriteString("\n" + pathStyle.Render(fmt.Sprintf(" %s %s ", obj.Kind, obj.Name)))
+		if obj.Source != "" {
+			content.WriteString(" " + helpStyle.Render(obj.Source))
+		}
+		content.WriteString("\n")
+		if obj.Err != nil {
+			content.WriteString(errorStyle.Render(" ✗ "+obj.Err.Error()+" ") + "\n")
+		}
+		for _, line := range strings.Split(obj.Manifest, "\n") {
+			content.WriteString(ui.HighlightYAML(line) + "\n")
+		}
+	}
+	return content.String()
+}
+
 func (m model) renderInputPrompt() string {
 	var prompt string
 	switch m.mode {
 	case searchMode:
 		prompt = "Search: " + m.searchInput.View()
-	case addRepoMode:
-		if m.addRepoStep == 0 {
-			prompt = "Repository name: " + m.searchInput.View()
-		} else {
-			prompt = "Repository URL: " + m.searchInput.View()
+		if m.searchesLines() && (m.searchOptions.CaseSensitive || m.searchOptions.WholeWord) {
+			prompt = "Search (" + m.searchOptionsLabel() + "): " + m.searchInput.View()
+		}
+		if m.searchErr != nil {
+			return searchInputStyle.Render(" "+prompt+" ") + "\n" + errorStyle.Render(" "+m.searchErr.Error()+" ")
 		}
+	case addRepoMode:
+		label := map[int]string{
+			addRepoNameStep:     "Repository name",
+			addRepoURLStep:      "Repository URL",
+			addRepoAuthStep:     "Needs credentials or TLS settings?",
+			addRepoUsernameStep: "Username",
+			addRepoPasswordStep: "Password",
+			addRepoCAFileStep:   "CA file",
+			addRepoCertFileStep: "Client certificate file",
+			addRepoKeyFileStep:  "Client key file",
+			addRepoInsecureStep: "Skip TLS verification (insecure)?",
+			addRepoGitRefStep:   "Branch or tag",
+			addRepoGitPathStep:  "Path to the charts",
+		}[m.addRepoStep]
+		prompt = label + ": " + m.searchInput.View()
 	case exportValuesMode:
-		prompt = "Export to: " + m.searchInput.View()
+		prompt = "Export to (file, @clipboard or |command): " + m.searchInput.View()
+	case exportReposMode:
+		prompt = "Export repositories to (file, @clipboard or |command): " + m.searchInput.View()
+	case importReposPathMode:
+		prompt = "Import repositories from: " + m.searchInput.View()
+	case exportDiffMode:
+		prompt = "Export diff to (file, @clipboard or |command): " + m.searchInput.View()
 	case templatePathMode:
-		prompt = "Output directory: " + m.searchInput.View()
+		prompt = "Output directory (or @clipboard, |command, @preview): " + m.searchInput.View()
+	case templateReleaseMode:
+		prompt = "Release name: " + m.searchInput.View()
+	case templateNamespaceMode:
+		prompt = "Namespace: " + m.searchInput.View()
+	case templateKubeVersionMode:
+		prompt = "Kubernetes version (optional): " + m.searchInput.View()
 	case templateValuesMode:
 		prompt = "Values file (optional): " + m.searchInput.View()
+	case templatePostRendererMode:
+		prompt = "Post-renderer (optional): " + m.searchInput.View()
+	case templateShowOnlyMode:
+		prompt = "Show only templates (comma-separated, globs allowed, tab completes): " + m.searchInput.View()
+		var matches []string
+		for i, template := range m.templateSourceMatches {
+			if i == 5 {
+				break
+			}
+			if i == 0 {
+				matches = append(matches, highlightStyle.Render(" "+template+" "))
+			} else {
+				matches = append(matches, " "+template+" ")
+			}
+		}
+		if len(matches) > 0 {
+			return searchInputStyle.Render(" "+prompt+" ") + "\n" + strings.Join(matches, "\n")
+		}
+	case templateSetMode, upgradeSetMode:
+		prompt = "Override (tab completes the key, backspace drops the last one): " + m.searchInput.View()
+		var lines []string
+		for _, override := range m.overrides {
+			lines = append(lines, helpStyle.Render(" --set "+override+" "))
+		}
+		for i, target := range m.overrideMatches {
+			if i == 5 {
+				break
+			}
+			if i == 0 {
+				lines = append(lines, highlightStyle.Render(" "+target.Path+" "))
+			} else {
+				lines = append(lines, " "+target.Path+" ")
+			}
+		}
+		if len(lines) > 0 {
+			return searchInputStyle.Render(" "+prompt+" ") + "\n" + strings.Join(lines, "\n")
+		}
 	case saveEditMode:
-		prompt = "Save to: " + m.searchInput.View()
-	case confirmRemoveRepoMode:
+		prompt = "Save to (file, @clipboard or |command): " + m.searchInput.View()
+		if n := len(m.editSchemaErrors); n > 0 {
+			prompt = fmt.Sprintf("⚠ %d schema violation(s) - save anyway to: ", n) + m.searchInput.View()
+		}
+	case renameRepoMode:
+		prompt = "Rename to: " + m.searchInput.View()
+	case manifestFormatMode:
+		prompt = fmt.Sprintf("Manifest format (%s): ", strings.Join(gitops.Formats, "/")) + m.searchInput.View()
+	case manifestPathMode:
+		prompt = "Write manifest to: " + m.searchInput.View()
+	case localDiffMode:
+		prompt = "Compare defaults with local file: " + m.searchInput.View()
+	case validateValuesMode:
+		prompt = "Validate local file against schema: " + m.searchInput.View()
+	case selectorMode:
+		prompt = "Label selector (empty for none): " + m.searchInput.View()
+	case ahFilterMode:
+		prompt = "Filters (verified, official, signed, license=<SPDX>, org=<name>, repo=<name>; empty for none): " + m.searchInput.View()
+	case upgradeChartMode:
+		prompt = fmt.Sprintf("Upgrade '%s' to chart: ", m.upgradeRel.Name) + m.searchInput.View()
+		if m.upgradeDryRun {
+			prompt = fmt.Sprintf("Dry-run an upgrade of '%s' to chart: ", m.upgradeRel.Name) + m.searchInput.View()
+		}
+	case registryHostMode:
+		prompt = "Log in to registry: " + m.searchInput.View()
+	case registryUsernameMode:
+		prompt = fmt.Sprintf("Username for %s: ", m.registryHost) + m.searchInput.View()
+	case registryPasswordMode:
+		prompt = "Password: " + m.searchInput.View()
+	case helmfilePathMode:
+		prompt = "Helmfile: " + m.searchInput.View()
+	case helmfileEnvMode:
+		prompt = fmt.Sprintf("Environment (%s): ", strings.Join(m.helmfile.Environments, ", ")) + m.searchInput.View()
+	case dryRunReleaseMode:
+		prompt = "Dry-run an install as release: " + m.searchInput.View()
+	case dryRunNamespaceMode:
+		prompt = "In namespace: " + m.searchInput.View()
+	case upgradeVersionMode:
+		prompt = "Chart version: " + m.searchInput.View()
+	case upgradeValuesMode:
+		prompt = "Values file: " + m.searchInput.View()
+	case settingMode:
+		prompt = m.editSetting.Title + " (empty for default): " + m.searchInput.View()
+	case releaseGrepMode:
+		prompt = fmt.Sprintf("Search values of all releases in %s (YAML path or text): ", m.effectiveNamespace()) + m.searchInput.View()
+	case repoGrepMode:
+		prompt = fmt.Sprintf("Search values of all %d charts (YAML path or text): ", len(m.charts)) + m.searchInput.View()
+	case jumpKeyMode:
+		prompt = "Jump to key: " + m.searchInput.View()
+		var matches []string
+		for i, target := range m.keyMatches {
+			if i == 5 {
+				break
+			}
+			if i == 0 {
+				matches = append(matches, highlightStyle.Render(" "+target.Path+" "))
+			} else {
+				matches = append(matches, " "+target.Path+" ")
+			}
+		}
+		if len(matches) > 0 {
+			return searchInputStyle.Render(" "+prompt+" ") + "\n" + strings.Join(matches, "\n")
+		}
+	case gotoMode:
+		prompt = "Go to: " + m.searchInput.View()
+		var matches []string
+		for i, target := range m.gotoMatches {
+			if i == 5 {
+				break
+			}
+			if i == 0 {
+				matches = append(matches, highlightStyle.Render(" "+target.label+" "))
+			} else {
+				matches = append(matches, " "+target.label+" ")
+			}
+		}
+		if len(matches) > 0 {
+			return searchInputStyle.Render(" "+prompt+" ") + "\n" + strings.Join(matches, "\n")
+		}
+	case confirmRemoveRepoMode, confirmDuplicateRepoMode, templateValidateMode,
+		uninstallKeepHistoryMode, uninstallWaitMode, confirmUninstallMode,
+		fixPendingMode, confirmFixPendingMode, batchActionMode, batchExportMode,
+		confirmBatchUninstallMode, helmfileActionMode, confirmRegistryLogoutMode, importReposStrategyMode:
 		prompt = m.searchInput.Placeholder + " " + m.searchInput.View()
 	default:
 		return ""
@@ -3022,8 +9827,113 @@ func (m model) renderInputPrompt() string {
 	return searchInputStyle.Render(" " + prompt + " ")
 }
 
-func openEditorCmd(content string) tea.Cmd {
-	// Get editor from environment, fallback to nvim/vim/vi
+// formatBytes renders a byte count in human readable units
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// orderRepos sorts repositories for the repository list: pinned ones first,
+// then in the order the user moved them to
+func orderRepos(repos []helm.Repository, cfg *config.Config) []helm.Repository {
+	byName := make(map[string]helm.Repository, len(repos))
+	names := make([]string, len(repos))
+	for i, repo := range repos {
+		byName[repo.Name] = repo
+		names[i] = repo.Name
+	}
+	ordered := make([]helm.Repository, len(repos))
+	for i, name := range cfg.OrderRepos(names) {
+		ordered[i] = byName[name]
+	}
+	return ordered
+}
+
+// repoListItems lists repositories with the age of their index, flagging
+// indexes older than repo_refresh and those being updated in the background
+func repoListItems(repos []helm.Repository, updating map[string]bool, cfg *config.Config) []list.Item {
+	staleAfter := cfg.RepoRefreshAge()
+	if staleAfter == 0 {
+		staleAfter = 24 * time.Hour
+	}
+	items := make([]list.Item, len(repos))
+	for i, repo := range repos {
+		var age string
+		switch {
+		case updating[repo.Name]:
+			age = "updating…"
+		case repo.IndexUpdated.IsZero():
+			age = "never updated"
+		default:
+			age = fmt.Sprintf("updated %s ago", formatAge(time.Since(repo.IndexUpdated)))
+			if time.Since(repo.IndexUpdated) > staleAfter {
+				age += ", stale"
+			}
+		}
+		description := fmt.Sprintf("%s · %s", repo.URL, age)
+		if repo.Git {
+			description = "git · " + description
+		}
+		if cfg.RepoPinned(repo.Name) {
+			description = "pinned · " + description
+		}
+		items[i] = listItem{
+			title:       repo.Name,
+			description: description,
+		}
+	}
+	return items
+}
+
+func (m model) repoItems(repos []helm.Repository) []list.Item {
+	return repoListItems(repos, m.repoUpdating, m.config)
+}
+
+// applyRepoLayout saves pins or an order of the repository list changed in
+// updated and shows the list in its new order, selected still selected
+func (m *model) applyRepoLayout(updated config.Config, selected, message string) tea.Cmd {
+	m.config = &updated
+	m.repos = orderRepos(m.repos, m.config)
+	m.repoList.SetItems(m.repoItems(m.repos))
+	for i, repo := range m.repos {
+		if repo.Name == selected {
+			m.repoList.Select(i)
+		}
+	}
+	if err := updated.Save(); err != nil {
+		return m.setSuccessMsg(fmt.Sprintf("%s for this session only: %v", message, err))
+	}
+	return m.setSuccessMsg(message)
+}
+
+// formatAge renders a duration the way humans talk about it ("3d", "5h", "12m")
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+// editorCommand returns the editor to open values in: the editor setting,
+// then $EDITOR / $VISUAL, falling back to nvim/vim/vi
+func (m model) editorCommand() string {
+	if m.config.Editor != "" {
+		return m.config.Editor
+	}
 	editor := os.Getenv("EDITOR")
 	if editor == "" {
 		editor = os.Getenv("VISUAL")
@@ -3031,12 +9941,16 @@ func openEditorCmd(content string) tea.Cmd {
 	if editor == "" {
 		// Try to find nvim, vim, then vi in that order
 		for _, cmd := range []string{"nvim", "vim", "vi"} {
-			if path, err := exec.LookPath(cmd); err == nil {
-				editor = path
+			if _, err := exec.LookPath(cmd); err == nil {
+				editor = cmd
 				break
 			}
 		}
 	}
+	return editor
+}
+
+func openEditorCmd(editor, content string) tea.Cmd {
 	if editor == "" {
 		return func() tea.Msg {
 			return editorFinishedMsg{err: fmt.Errorf("no editor found (tried nvim, vim, vi)")}
@@ -3094,21 +10008,56 @@ func openEditorCmd(content string) tea.Cmd {
 	})
 }
 
+// ahPackageItems lists Artifact Hub packages with their repository, stars,
+// badges and security summary
+func ahPackageItems(packages []artifacthub.Package) []list.Item {
+	items := make([]list.Item, len(packages))
+	for i, pkg := range packages {
+		badges := pkg.GetBadges()
+		stars := fmt.Sprintf("⭐%d", pkg.Stars)
+		security := pkg.SecurityReport.GetSecurityBadge()
+
+		desc := fmt.Sprintf("%s | %s %s | %s", pkg.Repository.DisplayName, stars, badges, security)
+		items[i] = listItem{
+			title:       pkg.Name,
+			description: desc,
+		}
+	}
+	return items
+}
+
+// updateArtifactHubTitle shows how many of the search results are loaded
+func (m *model) updateArtifactHubTitle() {
+	title := fmt.Sprintf("Artifact Hub: %d of %d results", len(m.ahPackages), m.ahTotal)
+	if filters := m.ahFilters.String(); filters != "" {
+		title += " (" + filters + ")"
+	}
+	sort := m.ahFilters.Sort
+	if sort == "" {
+		sort = artifacthub.SortModes[0]
+	}
+	title += ", by " + strings.ReplaceAll(sort, "_", " ")
+	if m.ahLoadingMore {
+		title += ", loading more..."
+	}
+	m.ahPackageList.Title = title
+}
+
 func (m model) renderArtifactHubSearch() string {
 	if m.ahLoading {
-		return activePanelStyle.Render("Searching Artifact Hub...")
+		return activePanelStyle.Render(m.loadingView("Searching Artifact Hub..."))
 	}
 	if len(m.ahPackages) == 0 {
 		return activePanelStyle.Render("No packages found.\nTry a different search query.\n\nPress 'esc' to go back")
 	}
 
-	hint := "\n" + helpStyle.Render("  enter: view details | a: add repository | esc: back  ")
+	hint := "\n" + helpStyle.Render("  enter: view details | a: add repository | F: filters | O: sort | esc: back  ")
 	return activePanelStyle.Render(m.ahPackageList.View()) + hint
 }
 
 func (m model) renderArtifactHubPackageDetail() string {
 	if m.ahLoading {
-		return activePanelStyle.Render("Loading package details...")
+		return activePanelStyle.Render(m.loadingView("Loading package details..."))
 	}
 
 	if m.ahSelectedPackage == nil {
@@ -3152,17 +10101,67 @@ func (m model) renderArtifactHubPackageDetail() string {
 			len(pkg.AvailableVersions),
 		))
 
-	hint := "\n" + helpStyle.Render("  a: add repository | v: view versions | esc: back  ")
+	hint := "\n" + helpStyle.Render("  a: add repository | v: view versions | p: same publisher | R: README | S: values schema | C: security report | o: open in browser | esc: back  ")
 
 	return info + hint
 }
 
+func (m model) renderArtifactHubSecurity() string {
+	if m.ahLoading {
+		return activePanelStyle.Render(m.loadingView("Loading security report..."))
+	}
+	return activePanelStyle.Render(m.ahSecurityView.View()) + "\n" + helpStyle.Render("  esc: back  ")
+}
+
+// renderSecurityReport lists the vulnerabilities of a package grouped by
+// severity, most severe first; scanned is false when Artifact Hub has no
+// report for it
+func renderSecurityReport(pkg *artifacthub.Package, vulns []artifacthub.Vulnerability, scanned bool) string {
+	var content strings.Builder
+	if !scanned {
+		content.WriteString(modifiedStyle.Render(fmt.Sprintf(" ? No security report for %s %s: Artifact Hub hasn't scanned its images ", pkg.Name, pkg.Version)) + "\n")
+		return content.String()
+	}
+	if len(vulns) == 0 {
+		content.WriteString(infoStyle.Render(fmt.Sprintf(" ✓ No known vulnerabilities in the images of %s %s ", pkg.Name, pkg.Version)) + "\n")
+		return content.String()
+	}
+	content.WriteString(modifiedStyle.Render(fmt.Sprintf(" %d vulnerabilities in the images of %s %s ", len(vulns), pkg.Name, pkg.Version)) + "\n")
+
+	severity := ""
+	for _, v := range vulns {
+		if v.Severity != severity {
+			severity = v.Severity
+			count := 0
+			for _, other := range vulns {
+				if other.Severity == severity {
+					count++
+				}
+			}
+			label := fmt.Sprintf(" %s (%d) ", severity, count)
+			if severity == "CRITICAL" || severity == "HIGH" {
+				label = errorStyle.Render(label)
+			}
+			content.WriteString("\n" + label + "\n")
+		}
+		fix := "no fix yet"
+		if v.FixedVersion != "" {
+			fix = "fixed in " + v.FixedVersion
+		}
+		content.WriteString(fmt.Sprintf("  %s  %s %s (%s)  %s\n", v.ID, v.Package, v.InstalledVersion, fix, helpStyle.Render(v.Image)))
+		if v.Title != "" {
+			content.WriteString("    " + v.Title + "\n")
+		}
+	}
+	return content.String()
+}
+
 func (m model) renderArtifactHubVersions() string {
 	if len(m.ahSelectedPackage.AvailableVersions) == 0 {
 		return activePanelStyle.Render("No versions available")
 	}
 
-	hint := "\n" + helpStyle.Render("  a: add repository to view values | esc: back  ")
+	hint := "\n" + helpStyle.Render("  a: add repository to view values | o: open in browser | esc: back  ")
 	return activePanelStyle.Render(m.ahVersionList.View()) + hint
 }
 
@@ -3172,7 +10171,7 @@ func (m model) renderClusterReleasesMenu() string {
 
 func (m model) renderNamespaceList() string {
 	if m.loading {
-		return "Loading namespaces..."
+		return m.loadingView("Loading namespaces...")
 	}
 	if len(m.namespaces) == 0 {
 		return "No namespaces with Helm releases found."
@@ -3180,19 +10179,266 @@ func (m model) renderNamespaceList() string {
 	return activePanelStyle.Render(m.namespaceList.View())
 }
 
+func (m model) renderSettings() string {
+	return activePanelStyle.Render(m.settingsList.View())
+}
+
+// logAction records an operation in the session action log, and appends it
+// to the action_log file when one is configured
+func (m *model) logAction(message string, failed bool) {
+	entry := actionLogEntry{time: time.Now(), message: message, failed: failed}
+	m.actionLog = append(m.actionLog, entry)
+	if m.state == stateActionLog {
+		m.updateActionLogView()
+	}
+	if m.config.ActionLog == "" {
+		return
+	}
+	outcome := "ok"
+	if failed {
+		outcome = "failed"
+	}
+	// A write that failed since the last action is reported with this one,
+	// on the Action Log screen rather than over the operation's toast
+	if err := m.actionLogFile.failure(); err != nil {
+		m.actionLog = append(m.actionLog, actionLogEntry{time: time.Now(), message: "Failed to write the action log: " + err.Error(), failed: true})
+	}
+	line := fmt.Sprintf("%s %s %s %s\n", entry.time.Format(time.RFC3339), m.kubeContext, outcome, message)
+	m.actionLogFile.write(expandHome(m.config.ActionLog), line)
+}
+
+// actionLogWriter appends lines to the action_log file in order from a
+// goroutine of its own, so Update never waits on the disk
+type actionLogWriter struct {
+	lines chan actionLogLine
+	done  chan struct{}
+	mu    sync.Mutex
+	err   error // First failed write not reported yet
+}
+
+type actionLogLine struct {
+	path string
+	text string
+}
+
+func newActionLogWriter() *actionLogWriter {
+	w := &actionLogWriter{lines: make(chan actionLogLine, 64), done: make(chan struct{})}
+	go func() {
+		defer close(w.done)
+		for line := range w.lines {
+			if err := appendToFile(line.path, line.text); err != nil {
+				w.mu.Lock()
+				if w.err == nil {
+					w.err = err
+				}
+				w.mu.Unlock()
+			}
+		}
+	}()
+	return w
+}
+
+func (w *actionLogWriter) write(path, text string) {
+	w.lines <- actionLogLine{path: path, text: text}
+}
+
+// failure returns the error of a write that failed since it was last called
+func (w *actionLogWriter) failure() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	err := w.err
+	w.err = nil
+	return err
+}
+
+// close waits for the lines still queued to be written
+func (w *actionLogWriter) close() {
+	close(w.lines)
+	<-w.done
+}
+
+// appendToFile appends text to a file, creating it and its directory if
+// needed. The action log is kept private to the user, like the config file.
+func appendToFile(path, text string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	// Files written by earlier versions were readable by everyone
+	if err := f.Chmod(0o600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (m *model) updateActionLogView() {
+	var content strings.Builder
+	for _, entry := range m.actionLog {
+		mark := successStyle.Render("✓")
+		if entry.failed {
+			mark = errorStyle.Render("✗")
+		}
+		content.WriteString(fmt.Sprintf("%s %s %s\n", helpStyle.Render(entry.time.Format("15:04:05")), mark, entry.message))
+	}
+	m.actionLogView.SetContent(content.String())
+}
+
+func (m model) renderActionLog() string {
+	if len(m.actionLog) == 0 {
+		return "Nothing done yet: repository changes, exports, templates and release operations show up here."
+	}
+	return activePanelStyle.Render(m.actionLogView.View()) + "\n" + helpStyle.Render("  esc: back  ")
+}
+
+// registryItems lists the registries with stored credentials and whether
+// they authenticate
+func (m model) registryItems() []list.Item {
+	items := make([]list.Item, len(m.registries))
+	for i, registry := range m.registries {
+		status := helpStyle.Render("checking…")
+		if err, checked := m.registryStatus[registry.Host]; checked && err == nil {
+			status = successStyle.Render("✓ authenticated")
+		} else if checked {
+			status = errorStyle.Render("✗ " + err.Error())
+		}
+		source := registry.Source + " config"
+		if registry.Helper != "" {
+			source += ", " + registry.Helper + " helper"
+		}
+		items[i] = listItem{title: registry.Host, description: status + " · " + source}
+	}
+	return items
+}
+
+func (m model) currentRegistry() (helm.Registry, bool) {
+	index := m.registryList.Index()
+	if index < 0 || index >= len(m.registries) {
+		return helm.Registry{}, false
+	}
+	return m.registries[index], true
+}
+
+func (m model) renderRegistries() string {
+	if m.loading {
+		return activePanelStyle.Render(m.loadingView("Reading registry credentials..."))
+	}
+	if len(m.registries) == 0 {
+		return "No registry credentials found in the helm registry config or the Docker config.\nPress 'a' to log in to a registry."
+	}
+	hint := "\n" + helpStyle.Render("  a: log in | x: log out | r: check again | esc: back  ")
+	return activePanelStyle.Render(m.registryList.View()) + hint
+}
+
+// helmfileItems lists the releases of the open helmfile
+func (m model) helmfileItems() []list.Item {
+	items := make([]list.Item, len(m.helmfile.Releases))
+	for i, release := range m.helmfile.Releases {
+		description := release.Chart
+		if release.Version != "" {
+			description += "@" + release.Version
+		}
+		if release.Namespace != "" {
+			description += " · " + release.Namespace
+		}
+		if !release.Installed {
+			description += " · not installed"
+		}
+		items[i] = listItem{title: release.Name, description: description}
+	}
+	return items
+}
+
+func (m model) currentHelmfileRelease() (gitops.HelmfileRelease, bool) {
+	if m.helmfile == nil {
+		return gitops.HelmfileRelease{}, false
+	}
+	index := m.helmfileList.Index()
+	if index < 0 || index >= len(m.helmfile.Releases) {
+		return gitops.HelmfileRelease{}, false
+	}
+	return m.helmfile.Releases[index], true
+}
+
+func (m model) renderHelmfile() string {
+	header := infoStyle.Render(fmt.Sprintf(" %s · environment %s ", m.helmfile.Path, m.helmfile.Environment)) + "\n\n"
+	if len(m.helmfile.Releases) == 0 {
+		return header + "The helmfile has no releases."
+	}
+	hint := "\n" + helpStyle.Render("  enter: values, chart or release | e: environment | r: reload | esc: back  ")
+	return header + activePanelStyle.Render(m.helmfileList.View()) + hint
+}
+
+func (m model) renderFavorites() string {
+	if len(m.favorites) == 0 {
+		return "No favorites yet: press * on a chart or release to star it."
+	}
+	hint := "\n" + helpStyle.Render("  enter: open | *: unstar | esc: back  ")
+	return activePanelStyle.Render(m.favoritesList.View()) + hint
+}
+
+func (m model) renderRecent() string {
+	if len(m.recent) == 0 {
+		return "No charts or releases viewed yet."
+	}
+	hint := "\n" + helpStyle.Render("  enter: open | esc: back  ")
+	return activePanelStyle.Render(m.recentList.View()) + hint
+}
+
+func (m model) renderContextList() string {
+	if m.loading {
+		return m.loadingView("Loading kube contexts...")
+	}
+	if len(m.kubeContexts) == 0 {
+		return "No contexts found in kubeconfig."
+	}
+	return activePanelStyle.Render(m.contextList.View())
+}
+
 func (m model) renderReleaseList() string {
 	if m.loading {
-		return "Loading releases..."
+		return m.loadingView("Loading releases...")
+	}
+	if m.releaseGrep != nil && len(m.releases) == 0 {
+		return fmt.Sprintf("No release sets '%s'. ", m.releaseGrep.query) + helpStyle.Render("c: clear")
 	}
 	if len(m.releases) == 0 {
 		return "No releases found."
 	}
 
+	count := fmt.Sprintf("%d releases", len(m.releases))
+	if m.loadingMore {
+		count += ", loading more..."
+	}
+
+	if m.releaseSelector != "" {
+		count += ", selector " + m.releaseSelector
+	}
+
+	stuck := 0
+	for _, release := range m.releases {
+		if helm.IsPendingStatus(release.Status) {
+			stuck++
+		}
+	}
+
 	var header string
-	if m.selectedNamespace == "" {
-		header = infoStyle.Render(" Showing releases from all namespaces ") + "\n\n"
+	if m.releaseGrep != nil {
+		header = infoStyle.Render(fmt.Sprintf(" Releases whose values set '%s' (%s) ", m.releaseGrep.query, count)) + " " + helpStyle.Render("c: clear") + "\n\n"
+	} else if m.selectedNamespace == "" {
+		header = infoStyle.Render(fmt.Sprintf(" Showing releases from all namespaces (%s) ", count)) + "\n\n"
 	} else {
-		header = infoStyle.Render(fmt.Sprintf(" Namespace: %s ", m.selectedNamespace)) + "\n\n"
+		header = infoStyle.Render(fmt.Sprintf(" Namespace: %s (%s) ", m.selectedNamespace, count)) + "\n\n"
+	}
+	if stuck > 0 {
+		header += modifiedStyle.Render(fmt.Sprintf(" ⚠ %d releases stuck in a pending state: F to fix ", stuck)) + "\n\n"
 	}
 
 	return header + activePanelStyle.Render(m.releaseList.View())
@@ -3212,9 +10458,22 @@ func (m *model) updateReleaseDetailView() {
 	// Status section
 	if m.releaseStatus != nil {
 		content.WriteString("Status: " + m.releaseStatus.Status + "\n")
+		if helm.IsPendingStatus(m.releaseStatus.Status) {
+			content.WriteString(modifiedStyle.Render(" ⚠ Stuck: helm refuses other operations until this is fixed. Press F to roll back, unlock or uninstall ") + "\n")
+		}
 		if m.releaseStatus.Description != "" {
 			content.WriteString("Description: " + m.releaseStatus.Description + "\n")
 		}
+		if !m.releaseStatus.LastDeployed.IsZero() {
+			deployed := fmt.Sprintf("Revision %d deployed %s ago", m.releaseStatus.Revision, formatAge(time.Since(m.releaseStatus.LastDeployed)))
+			if m.releaseStatus.DeployDuration > 0 {
+				deployed += fmt.Sprintf(" (took %s)", m.releaseStatus.DeployDuration)
+			}
+			content.WriteString(deployed + "\n")
+		}
+		if !m.releaseStatus.FirstDeployed.IsZero() {
+			content.WriteString(fmt.Sprintf("First installed %s ago (%s)\n", formatAge(time.Since(m.releaseStatus.FirstDeployed)), m.releaseStatus.FirstDeployed.Format("2006-01-02 15:04")))
+		}
 		content.WriteString("\n")
 	}
 
@@ -3225,6 +10484,39 @@ func (m *model) updateReleaseDetailView() {
 	content.WriteString(fmt.Sprintf("Updated:    %s\n", release.Updated))
 	content.WriteString("\n")
 
+	// Labels set with helm install/upgrade --labels, and chart annotations
+	if m.releaseStatus != nil {
+		writeMetadataSection(&content, "Labels", m.releaseStatus.Labels)
+		writeMetadataSection(&content, "Annotations", m.releaseStatus.Annotations)
+	}
+
+	// Resources of the manifest, with their pods
+	content.WriteString("Resources:")
+	if m.resourcesLoading {
+		content.WriteString(" (refreshing...)")
+	}
+	content.WriteString("\n")
+	if m.resourcesErr != nil {
+		content.WriteString(fmt.Sprintf("  Error: %v\n", m.resourcesErr))
+	} else if len(m.releaseResources) > 0 {
+		for _, res := range m.releaseResources {
+			mark := "✓"
+			if !res.Ready {
+				mark = "✗"
+			}
+			indent := "  "
+			if res.Pod {
+				indent = "      "
+			}
+			content.WriteString(fmt.Sprintf("%s%s %s/%s  %s\n", indent, mark, res.Kind, res.Name, res.Status))
+		}
+	} else if m.resourcesLoading {
+		content.WriteString("  Loading...\n")
+	} else {
+		content.WriteString("  None\n")
+	}
+	content.WriteString("\n")
+
 	// History section
 	content.WriteString("Revision History:\n")
 	if len(m.releaseHistory) > 0 {
@@ -3249,29 +10541,31 @@ func (m *model) updateReleaseDetailView() {
 		content.WriteString("\n")
 	}
 
-	content.WriteString(helpStyle.Render("  v: view current values | h: interactive history | esc: back  "))
+	content.WriteString(helpStyle.Render("  v: view current values | h: interactive history | o: notes | D: drift | E: events | r: refresh resources | L: pod logs | /: search | esc: back  "))
 
 	// Apply horizontal scrolling
 	lines := strings.Split(content.String(), "\n")
+	m.releaseDetailLines = lines
 	viewportWidth := m.releaseDetailView.Width
 	if viewportWidth <= 0 {
 		viewportWidth = m.termWidth - 6
 	}
 
+	currentMatchLine := -1
+	if len(m.searchMatches) > 0 && m.currentMatchIndex < len(m.searchMatches) {
+		currentMatchLine = m.searchMatches[m.currentMatchIndex]
+	}
+	query := strings.ToLower(m.lastSearchQuery)
+
 	scrolledLines := make([]string, len(lines))
 	for i, line := range lines {
-		visibleLine := line
-		hasMore := false
+		visibleLine, hasMore := ui.ScrollLine(line, m.horizontalOffset, viewportWidth-3)
 
-		if len(line) > m.horizontalOffset {
-			visibleLine = line[m.horizontalOffset:]
-
-			if len(visibleLine) > viewportWidth-3 {
-				visibleLine = visibleLine[:viewportWidth-3]
-				hasMore = true
+		// Highlight the current match
+		if i == currentMatchLine && query != "" {
+			if start, end := m.searchRange(visibleLine); start >= 0 {
+				visibleLine = visibleLine[:start] + highlightStyle.Render(visibleLine[start:end]) + visibleLine[end:]
 			}
-		} else {
-			visibleLine = ""
 		}
 
 		if hasMore {
@@ -3284,9 +10578,21 @@ func (m *model) updateReleaseDetailView() {
 	m.releaseDetailView.SetContent(strings.Join(scrolledLines, "\n"))
 }
 
+// writeMetadataSection writes a sorted key=value block, skipped when empty
+func writeMetadataSection(content *strings.Builder, title string, entries map[string]string) {
+	if len(entries) == 0 {
+		return
+	}
+	content.WriteString(title + ":\n")
+	for _, k := range slices.Sorted(maps.Keys(entries)) {
+		content.WriteString(fmt.Sprintf("  %s=%s\n", k, entries[k]))
+	}
+	content.WriteString("\n")
+}
+
 func (m model) renderReleaseDetail() string {
 	if m.loading {
-		return activePanelStyle.Render("Loading release details...")
+		return activePanelStyle.Render(m.loadingView("Loading release details..."))
 	}
 
 	if m.selectedRelease >= len(m.releases) {
@@ -3308,7 +10614,7 @@ func (m model) renderReleaseDetail() string {
 
 func (m model) renderReleaseHistory() string {
 	if m.loading {
-		return activePanelStyle.Render("Loading revision history...")
+		return activePanelStyle.Render(m.loadingView("Loading revision history..."))
 	}
 	if len(m.releaseHistory) == 0 {
 		return activePanelStyle.Render("No revision history found.")
@@ -3319,17 +10625,99 @@ func (m model) renderReleaseHistory() string {
 		if m.compareRevision < len(m.releaseHistory) {
 			selectedRevision = fmt.Sprintf("Revision %d", m.releaseHistory[m.compareRevision].Revision)
 		}
-		diffMsg := fmt.Sprintf(" Diff mode: First revision = %s | Select second revision to compare ", selectedRevision)
+		compared := "values"
+		if m.diffManifests {
+			compared = "manifests"
+		}
+		diffMsg := fmt.Sprintf(" Diff mode (%s): First revision = %s | Select second revision to compare ", compared, selectedRevision)
 		return infoStyle.Render(diffMsg) + "\n\n" + activePanelStyle.Render(m.releaseHistoryList.View())
 	}
 
-	hint := "\n" + helpStyle.Render("  Select a revision to view its values | esc: back  ")
+	hint := "\n" + helpStyle.Render("  Select a revision to view its values | d: diff values | m: diff manifests | esc: back  ")
 	return activePanelStyle.Render(m.releaseHistoryList.View()) + hint
 }
 
+func (m model) renderReleaseTest() string {
+	var status string
+	switch {
+	case m.test.running:
+		status = infoStyle.Render(fmt.Sprintf(" Running helm test for %s... ", m.test.release.Name))
+	case m.test.err != nil:
+		status = errorStyle.Render(fmt.Sprintf(" ✗ Tests failed: %v ", m.test.err))
+	default:
+		status = successStyle.Render(" ✓ All tests passed ")
+	}
+
+	body := m.releaseTestView.View()
+	if len(m.test.lines) == 0 {
+		body = "Waiting for output..."
+	}
+	return status + "\n\n" + activePanelStyle.Render(body)
+}
+
+func (m model) renderPodPicker() string {
+	hint := "\n" + helpStyle.Render("  Select a pod to follow its logs | esc: back  ")
+	return activePanelStyle.Render(m.podPickerList.View()) + hint
+}
+
+func (m model) renderPodLogs() string {
+	var status string
+	switch {
+	case m.logs.err != nil:
+		status = errorStyle.Render(fmt.Sprintf(" ✗ %v ", m.logs.err))
+	case !m.logs.running:
+		status = infoStyle.Render(fmt.Sprintf(" Logs of %s ended ", m.logs.target.title))
+	case m.logs.following:
+		status = infoStyle.Render(fmt.Sprintf(" Following %s ", m.logs.target.title))
+	default:
+		status = modifiedStyle.Render(fmt.Sprintf(" Paused: %d new lines ", len(m.logs.lines)-m.logs.shown))
+	}
+
+	body := m.podLogsView.View()
+	if len(m.logs.lines) == 0 {
+		body = "Waiting for logs..."
+	}
+	return status + "\n\n" + activePanelStyle.Render(body) + "\n" + helpStyle.Render("  f: follow/pause | /: search | esc: back  ")
+}
+
+// followPodLogs opens the logs screen and starts streaming a pod container
+func (m model) followPodLogs(target podLogTarget) (tea.Model, tea.Cmd) {
+	m.logs = &podLogs{target: target, following: true, running: true, returnTo: m.state}
+	m.state = statePodLogs
+	m.searchMatches = []int{}
+	m.lastSearchQuery = ""
+	m.podLogsView.SetContent("")
+	return m, startPodLogs(m.helmClient, m.logs)
+}
+
+// updatePodLogsView shows the received log lines, unless paused, keeping
+// the view at the end while following and highlighting the current match
+func (m *model) updatePodLogsView() {
+	if m.logs.following {
+		m.logs.shown = len(m.logs.lines)
+	}
+
+	currentMatchLine := -1
+	if len(m.searchMatches) > 0 && m.currentMatchIndex < len(m.searchMatches) {
+		currentMatchLine = m.searchMatches[m.currentMatchIndex]
+	}
+
+	lines := slices.Clone(m.logs.lines[:m.logs.shown])
+	if currentMatchLine >= 0 && currentMatchLine < len(lines) {
+		line := lines[currentMatchLine]
+		if start, end := m.searchRange(line); start >= 0 {
+			lines[currentMatchLine] = line[:start] + highlightStyle.Render(line[start:end]) + line[end:]
+		}
+	}
+	m.podLogsView.SetContent(strings.Join(lines, "\n"))
+	if m.logs.following {
+		m.podLogsView.GotoBottom()
+	}
+}
+
 func (m model) renderReleaseValues() string {
 	if m.loadingVals {
-		return activePanelStyle.Render("Loading values...")
+		return activePanelStyle.Render(m.loadingView("Loading values..."))
 	}
 	if m.releaseValues == "" {
 		return activePanelStyle.Render("No values available.")
@@ -3354,6 +10742,70 @@ func (m model) renderReleaseValues() string {
 	return activePanelStyle.Render(m.releaseValuesView.View())
 }
 
+// options holds the command line flags lazyhelm was started with
+type options struct {
+	repositoryConfig string
+	namespace        string
+	helmDriver       string
+	kubeContext      string
+	kubeConfig       string
+}
+
+// register adds the flags of opts to a flag set
+func (opts *options) register(fs *flag.FlagSet) {
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&opts.repositoryConfig, "repository-config", "", "path to an alternate repositories.yaml")
+	fs.StringVar(&opts.namespace, "namespace", "", "default namespace for cluster releases")
+	fs.StringVar(&opts.namespace, "n", "", "default namespace for cluster releases")
+	fs.StringVar(&opts.helmDriver, "helm-driver", "", "release storage backend")
+	fs.StringVar(&opts.kubeContext, "kube-context", "", "kube context for cluster releases")
+	fs.StringVar(&opts.kubeConfig, "kubeconfig", "", "kubeconfig file(s) to use")
+}
+
+func printUsage() {
+	fmt.Println("LazyHelm - A fast, intuitive Terminal User Interface (TUI) for managing Helm charts")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  lazyhelm [flags]   Start the TUI")
+	fmt.Println("  lazyhelm diff [flags] <repo/chart> <version1> <version2>")
+	fmt.Println("                     Print the diff of the default values of two chart versions")
+	fmt.Println("  lazyhelm diff-release [flags] <release> <revision1> <revision2>")
+	fmt.Println("                     Print the diff of the values (--manifest: manifests) of two revisions")
+	fmt.Println("  lazyhelm --version Show version information")
+	fmt.Println("  lazyhelm --help    Show this help message")
+	fmt.Println()
+	fmt.Println("Flags:")
+	fmt.Println("  --repository-config <path>  Use an alternate repositories.yaml (default: $HELM_REPOSITORY_CONFIG)")
+	fmt.Println("  -n, --namespace <name>      Default namespace for Cluster Releases (default: $HELM_NAMESPACE)")
+	fmt.Println("  --helm-driver <driver>      Release storage: secret, configmap, memory or sql (default: $HELM_DRIVER)")
+	fmt.Println("  --kube-context <name>       Kube context for Cluster Releases (default: $HELM_KUBECONTEXT)")
+	fmt.Println("  --kubeconfig <paths>        Kubeconfig file(s) to use, merged like $KUBECONFIG (default: $KUBECONFIG)")
+	fmt.Println()
+	fmt.Println("Diff flags:")
+	fmt.Println("  --no-color                  Print the diff without colors (also when not writing to a terminal)")
+	fmt.Println("  --exit-code                 Exit with 1 when the documents differ, 0 when they don't (2 on errors)")
+	fmt.Println("  --manifest                  Compare rendered manifests instead of values (diff-release)")
+	fmt.Println("  --output <target>           Write the diff to a file, @clipboard or |command instead of stdout (-)")
+	fmt.Println()
+	fmt.Println("For more information, visit: https://github.com/alessandropitocchi/lazyhelm")
+}
+
+func parseOptions(args []string) (options, error) {
+	var opts options
+
+	fs := flag.NewFlagSet("lazyhelm", flag.ContinueOnError)
+	opts.register(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if fs.NArg() > 0 {
+		return opts, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	return opts, nil
+}
+
 func main() {
 	// Check for version flag
 	if len(os.Args) > 1 {
@@ -3365,25 +10817,39 @@ func main() {
 			os.Exit(0)
 		}
 		if arg == "--help" || arg == "-h" || arg == "help" {
-			fmt.Println("LazyHelm - A fast, intuitive Terminal User Interface (TUI) for managing Helm charts")
-			fmt.Println()
-			fmt.Println("Usage:")
-			fmt.Println("  lazyhelm           Start the TUI")
-			fmt.Println("  lazyhelm --version Show version information")
-			fmt.Println("  lazyhelm --help    Show this help message")
-			fmt.Println()
-			fmt.Println("For more information, visit: https://github.com/alessandropitocchi/lazyhelm")
+			printUsage()
 			os.Exit(0)
 		}
 	}
 
+	// The Helm SDK and client-go log warnings to stderr, which would draw
+	// over the TUI
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	klog.LogToStderr(false)
+	klog.SetOutput(io.Discard)
+
+	if len(os.Args) > 1 && (os.Args[1] == "diff" || os.Args[1] == "diff-release") {
+		os.Exit(runDiff(os.Args[1], os.Args[2:]))
+	}
+
+	opts, err := parseOptions(os.Args[1:])
+	if err != nil {
+		fmt.Printf("Error: %v\n\n", err)
+		printUsage()
+		os.Exit(1)
+	}
+
+	m := initialModel(opts)
 	p := tea.NewProgram(
-		initialModel(),
+		m,
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(),
 	)
 
-	if _, err := p.Run(); err != nil {
+	_, err = p.Run()
+	// Every tab shares the writer; the last actions may still be queued
+	m.actionLogFile.close()
+	if err != nil {
 		fmt.Printf("Error: %v", err)
 		os.Exit(1)
 	}
@@ -16,50 +16,252 @@ package artifacthub
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
 const (
 	baseURL = "https://artifacthub.io/api/v1"
+	webURL  = "https://artifacthub.io"
 	// kind 0 = Helm charts
 	helmKind = 0
+	// rateLimitRetries is how many times a rate limited request is retried
+	rateLimitRetries = 3
 )
 
+// ErrRateLimited is returned when requests are still rate limited after
+// retrying
+var ErrRateLimited = errors.New("Artifact Hub rate limit exceeded, try again in a minute")
+
+// ErrNoSecurityReport is returned for a package version whose images were
+// never scanned, which doesn't mean they have no vulnerabilities
+var ErrNoSecurityReport = errors.New("no security report available")
+
 // Client is the Artifact Hub API client
 type Client struct {
-	httpClient *http.Client
-	baseURL    string
+	httpClient   *http.Client
+	baseURL      string
+	apiKeyID     string
+	apiKeySecret string
+	rateLimited  chan time.Duration
 }
 
-// NewClient creates a new Artifact Hub API client
+// NewClient creates a new Artifact Hub API client, authenticated with the API
+// key in ARTIFACTHUB_API_KEY_ID and ARTIFACTHUB_API_KEY_SECRET when set
 func NewClient() *Client {
 	return &Client{
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		baseURL: baseURL,
+		baseURL:      baseURL,
+		apiKeyID:     os.Getenv("ARTIFACTHUB_API_KEY_ID"),
+		apiKeySecret: os.Getenv("ARTIFACTHUB_API_KEY_SECRET"),
+		rateLimited:  make(chan time.Duration, 1),
+	}
+}
+
+// SetAPIKey authenticates requests with an Artifact Hub API key, which gets
+// a higher rate limit than anonymous requests
+func (c *Client) SetAPIKey(id, secret string) {
+	c.apiKeyID = id
+	c.apiKeySecret = secret
+}
+
+// RateLimited receives how long a rate limited request waits before it's
+// retried
+func (c *Client) RateLimited() <-chan time.Duration {
+	return c.rateLimited
+}
+
+// get sends a GET request, retrying with backoff while the API answers 429
+// Too Many Requests. The wait honors Retry-After when the API sends it.
+func (c *Client) get(requestURL string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.apiKeyID != "" && c.apiKeySecret != "" {
+		req.Header.Set("X-API-KEY-ID", c.apiKeyID)
+		req.Header.Set("X-API-KEY-SECRET", c.apiKeySecret)
+	}
+
+	backoff := 2 * time.Second
+	for attempt := 0; ; attempt++ {
+		resp, err := c.httpClient.Do(req)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+			return resp, err
+		}
+		resp.Body.Close()
+		if attempt == rateLimitRetries {
+			return nil, ErrRateLimited
+		}
+
+		wait := backoff
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			wait = time.Duration(seconds) * time.Second
+		}
+		select {
+		case c.rateLimited <- wait:
+		default:
+		}
+		time.Sleep(wait)
+		backoff *= 2
+	}
+}
+
+// PackageURL returns the Artifact Hub web page of a Helm package
+func PackageURL(repoName, packageName string) string {
+	return fmt.Sprintf("%s/packages/helm/%s/%s", webURL, url.PathEscape(repoName), url.PathEscape(packageName))
+}
+
+// SearchURL returns the Artifact Hub web search for Helm packages matching query
+func SearchURL(query string) string {
+	params := url.Values{}
+	params.Set("ts_query_web", query)
+	params.Set("kind", fmt.Sprintf("%d", helmKind))
+	return fmt.Sprintf("%s/packages/search?%s", webURL, params.Encode())
+}
+
+// SearchPage is a page of search results
+type SearchPage struct {
+	Packages []Package
+	Total    int // Packages matching the query, on every page
+}
+
+// SearchOptions narrows a search to some packages
+type SearchOptions struct {
+	VerifiedPublisher bool
+	Official          bool
+	Signed            bool
+	License           string // SPDX identifier, e.g. Apache-2.0
+	Org               string // Only packages of this organization
+	Repo              string // Only packages of this repository
+	Sort              string // One of SortModes; empty sorts by relevance
+}
+
+// SortModes are the orders search results can be sorted in
+var SortModes = []string{"relevance", "stars", "last_updated"}
+
+// ParseSearchOptions reads filters typed at the filter prompt, separated by
+// spaces or commas: verified, official, signed, license=<SPDX>, org=<name>
+// and repo=<name>. The first letter of the toggles is enough.
+func ParseSearchOptions(filters string) (SearchOptions, error) {
+	var opts SearchOptions
+	for _, filter := range strings.FieldsFunc(filters, func(r rune) bool { return r == ' ' || r == ',' }) {
+		name, value, _ := strings.Cut(filter, "=")
+		name = strings.ToLower(name)
+		switch name {
+		case "v", "verified":
+			opts.VerifiedPublisher = true
+		case "o", "official":
+			opts.Official = true
+		case "s", "signed":
+			opts.Signed = true
+		case "l", "license":
+			if value == "" {
+				return opts, fmt.Errorf("license needs a value, e.g. license=Apache-2.0")
+			}
+			opts.License = value
+		case "org", "repo":
+			if value == "" {
+				return opts, fmt.Errorf("%s needs a value, e.g. %s=bitnami", name, name)
+			}
+			if name == "org" {
+				opts.Org = value
+			} else {
+				opts.Repo = value
+			}
+		default:
+			return opts, fmt.Errorf("unknown filter '%s' (use verified, official, signed, license=<SPDX>, org=<name>, repo=<name>)", filter)
+		}
+	}
+	return opts, nil
+}
+
+// NextSort returns the sort mode after sort in SortModes, wrapping around
+func NextSort(sort string) string {
+	for i, mode := range SortModes {
+		if mode == sort {
+			return SortModes[(i+1)%len(SortModes)]
+		}
 	}
+	return SortModes[1]
 }
 
-// SearchPackages searches for Helm packages on Artifact Hub
-func (c *Client) SearchPackages(query string, limit int) ([]Package, error) {
+// String returns the filters the way ParseSearchOptions reads them; the sort
+// order isn't a filter and is left out
+func (o SearchOptions) String() string {
+	var filters []string
+	if o.VerifiedPublisher {
+		filters = append(filters, "verified")
+	}
+	if o.Official {
+		filters = append(filters, "official")
+	}
+	if o.Signed {
+		filters = append(filters, "signed")
+	}
+	if o.License != "" {
+		filters = append(filters, "license="+o.License)
+	}
+	if o.Org != "" {
+		filters = append(filters, "org="+o.Org)
+	}
+	if o.Repo != "" {
+		filters = append(filters, "repo="+o.Repo)
+	}
+	return strings.Join(filters, " ")
+}
+
+// SearchPackages searches for Helm packages on Artifact Hub, returning up to
+// limit packages starting at offset
+func (c *Client) SearchPackages(query string, opts SearchOptions, limit, offset int) (*SearchPage, error) {
 	if limit == 0 {
 		limit = 20
 	}
 
 	params := url.Values{}
-	params.Add("ts_query_web", query)
+	// No query lists every package matching the filters
+	if query != "" {
+		params.Add("ts_query_web", query)
+	}
 	params.Add("facets", "false")
 	params.Add("limit", fmt.Sprintf("%d", limit))
+	params.Add("offset", fmt.Sprintf("%d", offset))
 	params.Add("kind", fmt.Sprintf("%d", helmKind))
+	if opts.VerifiedPublisher {
+		params.Add("verified_publisher", "true")
+	}
+	if opts.Official {
+		params.Add("official", "true")
+	}
+	if opts.Signed {
+		params.Add("signed", "true")
+	}
+	if opts.License != "" {
+		params.Add("license", opts.License)
+	}
+	if opts.Org != "" {
+		params.Add("org", opts.Org)
+	}
+	if opts.Repo != "" {
+		params.Add("repo", opts.Repo)
+	}
+	if opts.Sort != "" {
+		params.Add("sort", opts.Sort)
+	}
 
 	searchURL := fmt.Sprintf("%s/packages/search?%s", c.baseURL, params.Encode())
 
-	resp, err := c.httpClient.Get(searchURL)
+	resp, err := c.get(searchURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search packages: %w", err)
 	}
@@ -75,14 +277,21 @@ func (c *Client) SearchPackages(query string, limit int) ([]Package, error) {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return searchResp.Packages, nil
+	page := &SearchPage{Packages: searchResp.Packages}
+	// The total is only sent as a header
+	if total, err := strconv.Atoi(resp.Header.Get("Pagination-Total-Count")); err == nil {
+		page.Total = total
+	} else {
+		page.Total = offset + len(searchResp.Packages)
+	}
+	return page, nil
 }
 
 // GetPackageDetails gets detailed information about a specific package
 func (c *Client) GetPackageDetails(repoName, packageName string) (*Package, error) {
 	detailURL := fmt.Sprintf("%s/packages/helm/%s/%s", c.baseURL, repoName, packageName)
 
-	resp, err := c.httpClient.Get(detailURL)
+	resp, err := c.get(detailURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get package details: %w", err)
 	}
@@ -105,7 +314,7 @@ func (c *Client) GetPackageDetails(repoName, packageName string) (*Package, erro
 func (c *Client) GetPackageVersion(repoName, packageName, version string) (*Package, error) {
 	versionURL := fmt.Sprintf("%s/packages/helm/%s/%s/%s", c.baseURL, repoName, packageName, version)
 
-	resp, err := c.httpClient.Get(versionURL)
+	resp, err := c.get(versionURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get package version: %w", err)
 	}
@@ -123,3 +332,100 @@ func (c *Client) GetPackageVersion(repoName, packageName, version string) (*Pack
 
 	return &pkg, nil
 }
+
+// GetValuesSchema gets the values.schema.json of a package version. A
+// package without a schema returns nil and no error.
+func (c *Client) GetValuesSchema(packageID, version string) ([]byte, error) {
+	schemaURL := fmt.Sprintf("%s/packages/%s/%s/values-schema", c.baseURL, url.PathEscape(packageID), url.PathEscape(version))
+
+	resp, err := c.get(schemaURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get values schema: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// severityRank orders vulnerabilities from the most severe
+var severityRank = map[string]int{"CRITICAL": 0, "HIGH": 1, "MEDIUM": 2, "LOW": 3}
+
+// GetSecurityReport gets the vulnerabilities found in the images of a
+// package version, most severe first. The same CVE in the same package of an
+// image is listed once. ErrNoSecurityReport means there is no scan to read.
+func (c *Client) GetSecurityReport(packageID, version string) ([]Vulnerability, error) {
+	reportURL := fmt.Sprintf("%s/packages/%s/%s/security-report", c.baseURL, url.PathEscape(packageID), url.PathEscape(version))
+
+	resp, err := c.get(reportURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get security report: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNoSecurityReport
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var report securityReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	// No image was scanned
+	if len(report) == 0 {
+		return nil, ErrNoSecurityReport
+	}
+
+	var vulns []Vulnerability
+	seen := make(map[string]bool)
+	for image, scan := range report {
+		for _, result := range scan.Results {
+			for _, v := range result.Vulnerabilities {
+				key := image + "|" + v.PkgName + "|" + v.VulnerabilityID
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				vulns = append(vulns, Vulnerability{
+					ID:               v.VulnerabilityID,
+					Severity:         strings.ToUpper(v.Severity),
+					Package:          v.PkgName,
+					InstalledVersion: v.InstalledVersion,
+					FixedVersion:     v.FixedVersion,
+					Title:            v.Title,
+					Image:            image,
+				})
+			}
+		}
+	}
+
+	sort.Slice(vulns, func(i, j int) bool {
+		ri, ok := severityRank[vulns[i].Severity]
+		if !ok {
+			ri = len(severityRank)
+		}
+		rj, ok := severityRank[vulns[j].Severity]
+		if !ok {
+			rj = len(severityRank)
+		}
+		if ri != rj {
+			return ri < rj
+		}
+		if vulns[i].ID != vulns[j].ID {
+			return vulns[i].ID < vulns[j].ID
+		}
+		return vulns[i].Image+vulns[i].Package < vulns[j].Image+vulns[j].Package
+	})
+	return vulns, nil
+}
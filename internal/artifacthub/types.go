@@ -102,3 +102,30 @@ func (p Package) GetBadges() string {
 	}
 	return badges
 }
+
+// Vulnerability is a CVE found in a container image used by a package
+type Vulnerability struct {
+	ID               string // e.g. CVE-2024-1234
+	Severity         string // CRITICAL, HIGH, MEDIUM, LOW or UNKNOWN
+	Package          string // Affected OS or language package
+	InstalledVersion string
+	FixedVersion     string // Empty when there is no fix yet
+	Title            string
+	Image            string
+}
+
+// securityReport is the full security report of a package version: the
+// Trivy scan of each image, keyed by image reference
+type securityReport map[string]struct {
+	Results []struct {
+		Target          string `json:"Target"`
+		Vulnerabilities []struct {
+			VulnerabilityID  string `json:"VulnerabilityID"`
+			PkgName          string `json:"PkgName"`
+			InstalledVersion string `json:"InstalledVersion"`
+			FixedVersion     string `json:"FixedVersion"`
+			Severity         string `json:"Severity"`
+			Title            string `json:"Title"`
+		} `json:"Vulnerabilities"`
+	} `json:"Results"`
+}
@@ -0,0 +1,375 @@
+// Copyright 2025 Alessandro Pitocchi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Clipboard modes
+const (
+	ClipboardAuto   = "auto"   // System clipboard, falling back to OSC52
+	ClipboardSystem = "system" // System clipboard only
+	ClipboardOSC52  = "osc52"  // Always use the OSC52 terminal escape sequence
+)
+
+// Notification modes for background operations that finish while another
+// screen is open
+const (
+	NotifyBell    = "bell"    // Terminal bell
+	NotifyDesktop = "desktop" // notify-send / osascript, falling back to the bell
+	NotifyOff     = "off"
+)
+
+// YAML path formats used when copying paths from the values viewers
+const (
+	PathFormatDotted = "dotted" // image.tag
+	PathFormatSet    = "set"    // Helm --set syntax: escaped dots, [0] indices
+)
+
+// RepoRefreshOff turns off background repository index updates
+const RepoRefreshOff = "off"
+
+// Themes: the color variants used for text whose color depends on the
+// terminal background
+const (
+	ThemeAuto  = "auto" // Detect the terminal background
+	ThemeDark  = "dark"
+	ThemeLight = "light"
+)
+
+// Release list columns
+const (
+	ColumnNamespace  = "namespace"
+	ColumnChart      = "chart"
+	ColumnAppVersion = "app_version"
+	ColumnRevision   = "revision"
+	ColumnStatus     = "status"
+	ColumnUpdated    = "updated"
+)
+
+// ReleaseColumns lists the columns that can be shown in the release list
+var ReleaseColumns = []string{ColumnNamespace, ColumnChart, ColumnAppVersion, ColumnRevision, ColumnStatus, ColumnUpdated}
+
+// Config is the user configuration stored in ~/.config/lazyhelm/config.yaml
+type Config struct {
+	Clipboard      string   `yaml:"clipboard,omitempty"`
+	PathFormat     string   `yaml:"path_format,omitempty"`
+	ReleaseColumns []string `yaml:"release_columns,omitempty"`
+	HelmDriver     string   `yaml:"helm_driver,omitempty"` // Release storage backend, overrides HELM_DRIVER
+	KubeConfig     string   `yaml:"kubeconfig,omitempty"`  // Kubeconfig path list, overrides KUBECONFIG
+	Notify         string   `yaml:"notify,omitempty"`
+	ExportCommand  string   `yaml:"export_command,omitempty"`    // Command a bare "|" export target pipes to
+	CacheTTL       string   `yaml:"cache_ttl,omitempty"`         // How long loaded charts, versions and values are reused
+	RepoRefresh    string   `yaml:"repo_refresh,omitempty"`      // Age after which repository indexes are updated in the background
+	OpTimeout      string   `yaml:"operation_timeout,omitempty"` // How long rollbacks, tests and uninstalls wait, like helm --timeout
+	PinnedRepos    []string `yaml:"pinned_repos,omitempty"`      // Repositories shown first in the repository list
+	RepoOrder      []string `yaml:"repo_order,omitempty"`        // Order of the repository list, set by moving repositories
+	Namespace      string   `yaml:"default_namespace,omitempty"` // Overrides HELM_NAMESPACE
+	Editor         string   `yaml:"editor,omitempty"`            // Overrides $EDITOR / $VISUAL
+	Theme          string   `yaml:"theme,omitempty"`
+	ExportPath     string   `yaml:"export_path,omitempty"`   // Default target of values exports
+	TemplatePath   string   `yaml:"template_path,omitempty"` // Default output directory of templates
+	ActionLog      string   `yaml:"action_log,omitempty"`    // File every operation is appended to
+	// Artifact Hub API key, overrides ARTIFACTHUB_API_KEY_ID/SECRET
+	ArtifactHubAPIKeyID     string `yaml:"artifacthub_api_key_id,omitempty"`
+	ArtifactHubAPIKeySecret string `yaml:"artifacthub_api_key_secret,omitempty"`
+}
+
+// Setting is a config key editable from the Settings screen
+type Setting struct {
+	Key         string
+	Title       string
+	Description string
+}
+
+// Settings lists the editable config keys, in the order they're shown
+var Settings = []Setting{
+	{Key: "kubeconfig", Title: "Kubeconfig", Description: "Kubeconfig file(s) for Cluster Releases, merged like KUBECONFIG"},
+	{Key: "default_namespace", Title: "Default namespace", Description: "Namespace of Cluster Releases > Current Namespace"},
+	{Key: "helm_driver", Title: "Helm storage driver", Description: "secret, configmap, memory or sql"},
+	{Key: "cache_ttl", Title: "Cache TTL", Description: "How long loaded charts, versions and values are reused, e.g. 30m"},
+	{Key: "repo_refresh", Title: "Repository refresh", Description: "Update repository indexes older than this in the background, e.g. 24h, or off"},
+	{Key: "operation_timeout", Title: "Operation timeout", Description: "How long rollbacks, tests and uninstalls wait for resources, e.g. 10m"},
+	{Key: "editor", Title: "Editor", Description: "Command used to edit values, e.g. code --wait"},
+	{Key: "theme", Title: "Theme", Description: "auto, dark or light terminal background"},
+	{Key: "export_path", Title: "Values export path", Description: "Default file, @clipboard or |command for values exports"},
+	{Key: "template_path", Title: "Template output", Description: "Default directory, @clipboard or |command for templates"},
+	{Key: "export_command", Title: "Export command", Description: "Command a bare | export target pipes to"},
+	{Key: "clipboard", Title: "Clipboard", Description: "auto, system or osc52"},
+	{Key: "path_format", Title: "YAML path format", Description: "dotted or set"},
+	{Key: "release_columns", Title: "Release columns", Description: "Comma-separated: " + strings.Join(ReleaseColumns, ", ")},
+	{Key: "notify", Title: "Notifications", Description: "bell, desktop or off"},
+	{Key: "action_log", Title: "Action log file", Description: "File every operation is appended to, for auditing; empty disables it"},
+}
+
+// Default returns the configuration used when no config file exists
+func Default() *Config {
+	return &Config{
+		Clipboard:      ClipboardAuto,
+		PathFormat:     PathFormatDotted,
+		Notify:         NotifyBell,
+		ReleaseColumns: []string{ColumnNamespace, ColumnChart, ColumnStatus},
+		CacheTTL:       "30m",
+		RepoRefresh:    "24h",
+		OpTimeout:      "5m",
+		Theme:          ThemeAuto,
+		ExportPath:     "./values.yaml",
+		TemplatePath:   "./output/",
+	}
+}
+
+// CacheDuration returns CacheTTL as a duration
+func (c *Config) CacheDuration() time.Duration {
+	ttl, err := time.ParseDuration(c.CacheTTL)
+	if err != nil {
+		return 30 * time.Minute
+	}
+	return ttl
+}
+
+// RepoRefreshAge returns how old a repository index may get before it's
+// updated in the background, 0 when RepoRefresh is off
+func (c *Config) RepoRefreshAge() time.Duration {
+	if c.RepoRefresh == RepoRefreshOff {
+		return 0
+	}
+	age, err := time.ParseDuration(c.RepoRefresh)
+	if err != nil {
+		return 24 * time.Hour
+	}
+	return age
+}
+
+// OperationTimeout returns OpTimeout as a duration
+func (c *Config) OperationTimeout() time.Duration {
+	timeout, err := time.ParseDuration(c.OpTimeout)
+	if err != nil {
+		return 5 * time.Minute
+	}
+	return timeout
+}
+
+// Get returns the value of a Settings key as it's edited
+func (c *Config) Get(key string) string {
+	switch key {
+	case "kubeconfig":
+		return c.KubeConfig
+	case "default_namespace":
+		return c.Namespace
+	case "helm_driver":
+		return c.HelmDriver
+	case "cache_ttl":
+		return c.CacheTTL
+	case "repo_refresh":
+		return c.RepoRefresh
+	case "operation_timeout":
+		return c.OpTimeout
+	case "editor":
+		return c.Editor
+	case "theme":
+		return c.Theme
+	case "export_path":
+		return c.ExportPath
+	case "template_path":
+		return c.TemplatePath
+	case "export_command":
+		return c.ExportCommand
+	case "clipboard":
+		return c.Clipboard
+	case "path_format":
+		return c.PathFormat
+	case "release_columns":
+		return strings.Join(c.ReleaseColumns, ", ")
+	case "notify":
+		return c.Notify
+	case "action_log":
+		return c.ActionLog
+	}
+	return ""
+}
+
+// Set changes a Settings key, leaving the config untouched if the value
+// isn't valid. An empty value restores the default.
+func (c *Config) Set(key, value string) error {
+	updated := *c
+	value = strings.TrimSpace(value)
+	if value == "" {
+		value = Default().Get(key)
+	}
+
+	switch key {
+	case "kubeconfig":
+		updated.KubeConfig = value
+	case "default_namespace":
+		updated.Namespace = value
+	case "helm_driver":
+		updated.HelmDriver = value
+	case "cache_ttl":
+		updated.CacheTTL = value
+	case "repo_refresh":
+		updated.RepoRefresh = value
+	case "operation_timeout":
+		updated.OpTimeout = value
+	case "editor":
+		updated.Editor = value
+	case "theme":
+		updated.Theme = value
+	case "export_path":
+		updated.ExportPath = value
+	case "template_path":
+		updated.TemplatePath = value
+	case "export_command":
+		updated.ExportCommand = value
+	case "clipboard":
+		updated.Clipboard = value
+	case "path_format":
+		updated.PathFormat = value
+	case "release_columns":
+		updated.ReleaseColumns = nil
+		for _, column := range strings.Split(value, ",") {
+			if column = strings.TrimSpace(column); column != "" {
+				updated.ReleaseColumns = append(updated.ReleaseColumns, column)
+			}
+		}
+	case "notify":
+		updated.Notify = value
+	case "action_log":
+		updated.ActionLog = value
+	default:
+		return fmt.Errorf("unknown setting '%s'", key)
+	}
+
+	if err := updated.validate(); err != nil {
+		return err
+	}
+	*c = updated
+	return nil
+}
+
+// Save writes the config file, creating its directory if needed
+func (c *Config) Save() error {
+	path, err := Path()
+	if err != nil {
+		return fmt.Errorf("failed to locate config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to save config %s: %w", path, err)
+	}
+
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to save config %s: %w", path, err)
+	}
+	// It can hold the Artifact Hub API key secret. WriteFile keeps the mode of
+	// an existing file, so one saved before is tightened too.
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to save config %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		return fmt.Errorf("failed to save config %s: %w", path, err)
+	}
+	return nil
+}
+
+// Path returns the location of the config file, honoring XDG_CONFIG_HOME
+func Path() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "lazyhelm", "config.yaml"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "lazyhelm", "config.yaml"), nil
+}
+
+// Load reads the config file, returning the defaults if it doesn't exist
+func Load() (*Config, error) {
+	cfg := Default()
+
+	path, err := Path()
+	if err != nil {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return cfg, fmt.Errorf("failed to read config %s: %w", path, err)
+	}
+
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return Default(), fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return Default(), fmt.Errorf("invalid config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) validate() error {
+	switch c.Clipboard {
+	case "", ClipboardAuto, ClipboardSystem, ClipboardOSC52:
+	default:
+		return fmt.Errorf("unknown clipboard mode '%s' (supported: %s, %s, %s)", c.Clipboard, ClipboardAuto, ClipboardSystem, ClipboardOSC52)
+	}
+	switch c.PathFormat {
+	case "", PathFormatDotted, PathFormatSet:
+	default:
+		return fmt.Errorf("unknown path format '%s' (supported: %s, %s)", c.PathFormat, PathFormatDotted, PathFormatSet)
+	}
+	switch c.Theme {
+	case "", ThemeAuto, ThemeDark, ThemeLight:
+	default:
+		return fmt.Errorf("unknown theme '%s' (supported: %s, %s, %s)", c.Theme, ThemeAuto, ThemeDark, ThemeLight)
+	}
+	if c.CacheTTL != "" {
+		if ttl, err := time.ParseDuration(c.CacheTTL); err != nil || ttl <= 0 {
+			return fmt.Errorf("invalid cache_ttl '%s' (expected a duration such as 30m)", c.CacheTTL)
+		}
+	}
+	if c.RepoRefresh != "" && c.RepoRefresh != RepoRefreshOff {
+		if age, err := time.ParseDuration(c.RepoRefresh); err != nil || age <= 0 {
+			return fmt.Errorf("invalid repo_refresh '%s' (expected a duration such as 24h, or %s)", c.RepoRefresh, RepoRefreshOff)
+		}
+	}
+	if c.OpTimeout != "" {
+		if timeout, err := time.ParseDuration(c.OpTimeout); err != nil || timeout <= 0 {
+			return fmt.Errorf("invalid operation_timeout '%s' (expected a duration such as 5m)", c.OpTimeout)
+		}
+	}
+	switch c.Notify {
+	case "", NotifyBell, NotifyDesktop, NotifyOff:
+	default:
+		return fmt.Errorf("unknown notify mode '%s' (supported: %s, %s, %s)", c.Notify, NotifyBell, NotifyDesktop, NotifyOff)
+	}
+	for _, column := range c.ReleaseColumns {
+		if !slices.Contains(ReleaseColumns, column) {
+			return fmt.Errorf("unknown release column '%s' (supported: %s)", column, strings.Join(ReleaseColumns, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2025 Alessandro Pitocchi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"slices"
+	"sort"
+)
+
+// Favorite is a starred chart or release
+type Favorite struct {
+	Kind      string `yaml:"kind"`
+	Name      string `yaml:"name"`                // Chart as repo/chart, or release name
+	Namespace string `yaml:"namespace,omitempty"` // Release namespace
+}
+
+// String returns the chart, or release@namespace
+func (f Favorite) String() string {
+	if f.Kind == KindRelease {
+		return f.Name + "@" + f.Namespace
+	}
+	return f.Name
+}
+
+// ToggleFavorite stars fav, or unstars it when it's already starred. Charts
+// are kept before releases, each sorted by name.
+func ToggleFavorite(favorites []Favorite, fav Favorite) (result []Favorite, starred bool) {
+	if slices.Contains(favorites, fav) {
+		return slices.DeleteFunc(slices.Clone(favorites), func(f Favorite) bool { return f == fav }), false
+	}
+	result = append(slices.Clone(favorites), fav)
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Kind != result[j].Kind {
+			return result[i].Kind == KindChart
+		}
+		return result[i].String() < result[j].String()
+	})
+	return result, true
+}
+
+// LoadFavorites reads the starred charts and releases
+func LoadFavorites() ([]Favorite, error) {
+	var favorites []Favorite
+	err := loadDataFile("favorites.yaml", &favorites)
+	return favorites, err
+}
+
+// SaveFavorites writes the starred charts and releases
+func SaveFavorites(favorites []Favorite) error {
+	return saveDataFile("favorites.yaml", favorites)
+}
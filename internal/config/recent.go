@@ -0,0 +1,119 @@
+// Copyright 2025 Alessandro Pitocchi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kinds of the items kept in the recent and favorites lists
+const (
+	KindChart   = "chart"
+	KindRelease = "release"
+)
+
+// recentLimit is how many recently viewed items are kept
+const recentLimit = 30
+
+// RecentItem is a chart version or a release viewed recently
+type RecentItem struct {
+	Kind      string    `yaml:"kind"`
+	Name      string    `yaml:"name"`                // Chart as repo/chart, or release name
+	Version   string    `yaml:"version,omitempty"`   // Chart version
+	Namespace string    `yaml:"namespace,omitempty"` // Release namespace
+	Viewed    time.Time `yaml:"viewed"`
+}
+
+// String returns chart@version or release@namespace
+func (r RecentItem) String() string {
+	if r.Kind == KindRelease {
+		return r.Name + "@" + r.Namespace
+	}
+	return r.Name + "@" + r.Version
+}
+
+// AddRecent puts item at the front of the most recently viewed items,
+// replacing an older entry of the same chart or release
+func AddRecent(items []RecentItem, item RecentItem) []RecentItem {
+	result := []RecentItem{item}
+	for _, existing := range items {
+		if existing.Kind == item.Kind && existing.Name == item.Name && existing.Namespace == item.Namespace {
+			continue
+		}
+		result = append(result, existing)
+	}
+	if len(result) > recentLimit {
+		result = result[:recentLimit]
+	}
+	return result
+}
+
+// LoadRecent reads the recently viewed items, most recent first
+func LoadRecent() ([]RecentItem, error) {
+	var items []RecentItem
+	err := loadDataFile("recent.yaml", &items)
+	return items, err
+}
+
+// SaveRecent writes the recently viewed items
+func SaveRecent(items []RecentItem) error {
+	return saveDataFile("recent.yaml", items)
+}
+
+// loadDataFile reads a YAML file stored next to the config file into v,
+// leaving v alone when the file doesn't exist
+func loadDataFile(name string, v interface{}) error {
+	path, err := Path()
+	if err != nil {
+		return nil
+	}
+	path = filepath.Join(filepath.Dir(path), name)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return nil
+}
+
+// saveDataFile writes v as a YAML file next to the config file
+func saveDataFile(name string, v interface{}) error {
+	path, err := Path()
+	if err != nil {
+		return fmt.Errorf("failed to locate config: %w", err)
+	}
+	path = filepath.Join(filepath.Dir(path), name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to save %s: %w", path, err)
+	}
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to save %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to save %s: %w", path, err)
+	}
+	return nil
+}
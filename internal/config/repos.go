@@ -0,0 +1,93 @@
+// Copyright 2025 Alessandro Pitocchi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"slices"
+	"sort"
+)
+
+// RepoPinned reports whether a repository is pinned to the top of the
+// repository list
+func (c *Config) RepoPinned(name string) bool {
+	return slices.Contains(c.PinnedRepos, name)
+}
+
+// OrderRepos sorts repository names the way the repository list shows them:
+// pinned ones first, each group in RepoOrder, with names missing from it
+// after the others in their original order
+func (c *Config) OrderRepos(names []string) []string {
+	ordered := slices.Clone(names)
+	rank := func(name string) (int, int) {
+		group := 1
+		if c.RepoPinned(name) {
+			group = 0
+		}
+		place := slices.Index(c.RepoOrder, name)
+		if place < 0 {
+			place = len(c.RepoOrder) + slices.Index(names, name)
+		}
+		return group, place
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		gi, pi := rank(ordered[i])
+		gj, pj := rank(ordered[j])
+		if gi != gj {
+			return gi < gj
+		}
+		return pi < pj
+	})
+	return ordered
+}
+
+// TogglePinnedRepo pins a repository, or unpins it when it's pinned,
+// reporting whether it's now pinned
+func (c *Config) TogglePinnedRepo(name string) bool {
+	if c.RepoPinned(name) {
+		c.PinnedRepos = slices.DeleteFunc(slices.Clone(c.PinnedRepos), func(n string) bool { return n == name })
+		return false
+	}
+	c.PinnedRepos = append(slices.Clone(c.PinnedRepos), name)
+	return true
+}
+
+// MoveRepo moves a repository one place up (delta -1) or down (delta 1) in
+// names, the repository list as shown, and records the resulting order.
+// Repositories don't move past the pinned ones or into them; false means
+// there was nowhere to move.
+func (c *Config) MoveRepo(names []string, name string, delta int) bool {
+	i := slices.Index(names, name)
+	j := i + delta
+	if i < 0 || j < 0 || j >= len(names) || c.RepoPinned(names[i]) != c.RepoPinned(names[j]) {
+		return false
+	}
+	order := slices.Clone(names)
+	order[i], order[j] = order[j], order[i]
+	c.RepoOrder = order
+	return true
+}
+
+// RenameRepo keeps the pin and place of a renamed repository
+func (c *Config) RenameRepo(oldName, newName string) {
+	rename := func(names []string) []string {
+		names = slices.Clone(names)
+		if i := slices.Index(names, oldName); i >= 0 {
+			names[i] = newName
+		}
+		return names
+	}
+	c.PinnedRepos = rename(c.PinnedRepos)
+	c.RepoOrder = rename(c.RepoOrder)
+}
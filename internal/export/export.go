@@ -0,0 +1,147 @@
+// Copyright 2025 Alessandro Pitocchi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package export delivers exported content (values, rendered templates,
+// diffs) to the destination picked at the export prompt.
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/alessandropitocchi/lazyhelm/internal/ui"
+)
+
+// Sink is a destination for exported content
+type Sink interface {
+	Write(data []byte) error
+	// String describes the destination for status messages
+	String() string
+}
+
+// Options controls how export targets are parsed
+type Options struct {
+	ClipboardMode  string // See config.Clipboard
+	DefaultCommand string // Used for a bare "|"
+	AllowStdout    bool   // Only in CLI mode; in the TUI stdout is the screen
+}
+
+// ClipboardTarget is the export target that copies to the clipboard
+const ClipboardTarget = "@clipboard"
+
+// Parse turns an export target into a sink:
+//
+//	@clipboard    the clipboard
+//	-             standard output (CLI mode only)
+//	|command      piped to command's stdin through the shell
+//	anything else a file path
+func Parse(target string, opts Options) (Sink, error) {
+	target = strings.TrimSpace(target)
+	switch {
+	case target == ClipboardTarget:
+		return Clipboard{Mode: opts.ClipboardMode}, nil
+	case target == "-":
+		if !opts.AllowStdout {
+			return nil, fmt.Errorf("stdout export is only available from the command line")
+		}
+		return Writer{W: os.Stdout, Name: "stdout"}, nil
+	case strings.HasPrefix(target, "|"):
+		command := strings.TrimSpace(target[1:])
+		if command == "" {
+			command = opts.DefaultCommand
+		}
+		if command == "" {
+			return nil, fmt.Errorf("no command to pipe to (set export_command in the config or type |command)")
+		}
+		return Command{CommandLine: command}, nil
+	case target == "":
+		return nil, fmt.Errorf("no export target")
+	}
+	return File{Path: target}, nil
+}
+
+// IsFile reports whether sink writes to a file
+func IsFile(sink Sink) bool {
+	_, ok := sink.(File)
+	return ok
+}
+
+// File writes to a file, replacing it
+type File struct {
+	Path string
+}
+
+func (f File) Write(data []byte) error {
+	return os.WriteFile(f.Path, data, 0644)
+}
+
+func (f File) String() string {
+	return f.Path
+}
+
+// Clipboard copies to the clipboard
+type Clipboard struct {
+	Mode string
+}
+
+func (c Clipboard) Write(data []byte) error {
+	return ui.CopyToClipboard(string(data), c.Mode)
+}
+
+func (c Clipboard) String() string {
+	return "clipboard"
+}
+
+// Writer writes to an io.Writer such as stdout
+type Writer struct {
+	W    io.Writer
+	Name string
+}
+
+func (w Writer) Write(data []byte) error {
+	_, err := w.W.Write(data)
+	return err
+}
+
+func (w Writer) String() string {
+	return w.Name
+}
+
+// Command pipes to a shell command, e.g. "kubectl apply -f -"
+type Command struct {
+	CommandLine string
+}
+
+func (c Command) Write(data []byte) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", c.CommandLine)
+	} else {
+		cmd = exec.Command("sh", "-c", c.CommandLine)
+	}
+	cmd.Stdin = bytes.NewReader(data)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %w\nOutput: %s", c.CommandLine, err, string(output))
+	}
+	return nil
+}
+
+func (c Command) String() string {
+	return "'" + c.CommandLine + "'"
+}
@@ -0,0 +1,90 @@
+// Copyright 2025 Alessandro Pitocchi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gitops
+
+import "strings"
+
+type argoApplication struct {
+	APIVersion string     `yaml:"apiVersion"`
+	Kind       string     `yaml:"kind"`
+	Metadata   objectMeta `yaml:"metadata"`
+	Spec       argoSpec   `yaml:"spec"`
+}
+
+type argoSpec struct {
+	Project     string          `yaml:"project"`
+	Source      argoSource      `yaml:"source"`
+	Destination argoDestination `yaml:"destination"`
+	SyncPolicy  argoSyncPolicy  `yaml:"syncPolicy"`
+}
+
+type argoSource struct {
+	RepoURL        string   `yaml:"repoURL"`
+	Chart          string   `yaml:"chart"`
+	TargetRevision string   `yaml:"targetRevision"`
+	Helm           argoHelm `yaml:"helm"`
+}
+
+type argoHelm struct {
+	ReleaseName string `yaml:"releaseName"`
+	Values      string `yaml:"values,omitempty"`
+}
+
+type argoDestination struct {
+	Server    string `yaml:"server"`
+	Namespace string `yaml:"namespace"`
+}
+
+type argoSyncPolicy struct {
+	SyncOptions []string `yaml:"syncOptions"`
+}
+
+// ArgoCDApplication renders an Argo CD Application that installs the chart
+// version with the given values inlined
+func ArgoCDApplication(ref ChartRef) (string, error) {
+	app := argoApplication{
+		APIVersion: "argoproj.io/v1alpha1",
+		Kind:       "Application",
+		Metadata: objectMeta{
+			Name:      ref.Release,
+			Namespace: "argocd",
+		},
+		Spec: argoSpec{
+			Project: "default",
+			Source: argoSource{
+				// Argo CD expects OCI registries without the scheme
+				RepoURL:        strings.TrimPrefix(ref.RepoURL, "oci://"),
+				Chart:          ref.Chart,
+				TargetRevision: ref.Version,
+				Helm: argoHelm{
+					ReleaseName: ref.Release,
+					Values:      strings.TrimSpace(ref.Values) + "\n",
+				},
+			},
+			Destination: argoDestination{
+				Server:    "https://kubernetes.default.svc",
+				Namespace: ref.Namespace,
+			},
+			SyncPolicy: argoSyncPolicy{
+				SyncOptions: []string{"CreateNamespace=true"},
+			},
+		},
+	}
+	if strings.TrimSpace(ref.Values) == "" {
+		app.Spec.Source.Helm.Values = ""
+	}
+
+	return encodeDocuments(app)
+}
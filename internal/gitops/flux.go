@@ -0,0 +1,156 @@
+// Copyright 2025 Alessandro Pitocchi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gitops
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const fluxNamespace = "flux-system"
+
+type fluxHelmRepository struct {
+	APIVersion string                 `yaml:"apiVersion"`
+	Kind       string                 `yaml:"kind"`
+	Metadata   objectMeta             `yaml:"metadata"`
+	Spec       fluxHelmRepositorySpec `yaml:"spec"`
+}
+
+type fluxHelmRepositorySpec struct {
+	Type     string `yaml:"type,omitempty"`
+	Interval string `yaml:"interval"`
+	URL      string `yaml:"url"`
+}
+
+type fluxHelmRelease struct {
+	APIVersion string              `yaml:"apiVersion"`
+	Kind       string              `yaml:"kind"`
+	Metadata   objectMeta          `yaml:"metadata"`
+	Spec       fluxHelmReleaseSpec `yaml:"spec"`
+}
+
+type fluxHelmReleaseSpec struct {
+	Interval        string      `yaml:"interval"`
+	ReleaseName     string      `yaml:"releaseName"`
+	TargetNamespace string      `yaml:"targetNamespace"`
+	Install         fluxInstall `yaml:"install"`
+	Chart           fluxChart   `yaml:"chart"`
+	Values          *yaml.Node  `yaml:"values,omitempty"`
+}
+
+type fluxInstall struct {
+	CreateNamespace bool `yaml:"createNamespace"`
+}
+
+type fluxChart struct {
+	Spec fluxChartSpec `yaml:"spec"`
+}
+
+type fluxChartSpec struct {
+	Chart     string        `yaml:"chart"`
+	Version   string        `yaml:"version"`
+	SourceRef fluxSourceRef `yaml:"sourceRef"`
+}
+
+type fluxSourceRef struct {
+	Kind      string `yaml:"kind"`
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace"`
+}
+
+// FluxManifests renders a Flux HelmRepository for the chart's repository and
+// a HelmRelease that installs the chart version with the given values
+func FluxManifests(ref ChartRef) (string, error) {
+	repo := fluxHelmRepository{
+		APIVersion: "source.toolkit.fluxcd.io/v1",
+		Kind:       "HelmRepository",
+		Metadata: objectMeta{
+			Name:      ref.RepoName,
+			Namespace: fluxNamespace,
+		},
+		Spec: fluxHelmRepositorySpec{
+			Interval: "1h",
+			URL:      ref.RepoURL,
+		},
+	}
+	if strings.HasPrefix(ref.RepoURL, "oci://") {
+		repo.Spec.Type = "oci"
+	}
+
+	values, err := valuesNode(ref.Values)
+	if err != nil {
+		return "", err
+	}
+
+	release := fluxHelmRelease{
+		APIVersion: "helm.toolkit.fluxcd.io/v2",
+		Kind:       "HelmRelease",
+		Metadata: objectMeta{
+			Name:      ref.Release,
+			Namespace: fluxNamespace,
+		},
+		Spec: fluxHelmReleaseSpec{
+			Interval:        "10m",
+			ReleaseName:     ref.Release,
+			TargetNamespace: ref.Namespace,
+			Install:         fluxInstall{CreateNamespace: true},
+			Chart: fluxChart{
+				Spec: fluxChartSpec{
+					Chart:   ref.Chart,
+					Version: ref.Version,
+					SourceRef: fluxSourceRef{
+						Kind:      "HelmRepository",
+						Name:      ref.RepoName,
+						Namespace: fluxNamespace,
+					},
+				},
+			},
+			Values: values,
+		},
+	}
+
+	return encodeDocuments(repo, release)
+}
+
+// valuesNode parses values YAML into a mapping node, keeping comments.
+// Empty values yield nil so the field is omitted.
+func valuesNode(values string) (*yaml.Node, error) {
+	if strings.TrimSpace(values) == "" {
+		return nil, nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(values), &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse values: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	node := doc.Content[0]
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("values must be a YAML mapping")
+	}
+	if len(node.Content) == 0 {
+		return nil, nil
+	}
+	// Comments attached to the document would otherwise be lost
+	if doc.HeadComment != "" && node.HeadComment == "" {
+		node.HeadComment = doc.HeadComment
+	}
+	return node, nil
+}
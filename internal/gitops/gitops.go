@@ -0,0 +1,87 @@
+// Copyright 2025 Alessandro Pitocchi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gitops renders manifests that let a chart picked in lazyhelm be
+// committed to a GitOps repository.
+package gitops
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ChartRef identifies the chart version a manifest points at
+type ChartRef struct {
+	RepoName  string
+	RepoURL   string
+	Chart     string // Chart name without the repository prefix
+	Version   string
+	Release   string
+	Namespace string
+	Values    string // Values YAML to inline
+}
+
+// Formats lists the manifest formats that can be generated
+var Formats = []string{"argocd", "flux", "helmfile"}
+
+// Generate renders the manifest for the given format
+func Generate(format string, ref ChartRef) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "argocd", "argo":
+		return ArgoCDApplication(ref)
+	case "flux":
+		return FluxManifests(ref)
+	case "helmfile":
+		return HelmfileEntry(ref)
+	default:
+		return "", fmt.Errorf("unknown manifest format '%s' (supported: %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// DefaultFileName returns a sensible file name for a generated manifest
+func DefaultFileName(format string, ref ChartRef) string {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "argocd", "argo":
+		return fmt.Sprintf("./%s-application.yaml", ref.Release)
+	case "flux":
+		return fmt.Sprintf("./%s-helmrelease.yaml", ref.Release)
+	case "helmfile":
+		return fmt.Sprintf("./%s-helmfile.yaml", ref.Release)
+	default:
+		return fmt.Sprintf("./%s.yaml", ref.Release)
+	}
+}
+
+func encodeDocuments(docs ...interface{}) (string, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	for _, doc := range docs {
+		if err := enc.Encode(doc); err != nil {
+			return "", err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+type objectMeta struct {
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace,omitempty"`
+}
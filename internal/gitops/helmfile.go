@@ -0,0 +1,76 @@
+// Copyright 2025 Alessandro Pitocchi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gitops
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type helmfileSnippet struct {
+	Repositories []helmfileRepository `yaml:"repositories"`
+	Releases     []helmfileRelease    `yaml:"releases"`
+}
+
+type helmfileRepository struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+	OCI  bool   `yaml:"oci,omitempty"`
+}
+
+type helmfileRelease struct {
+	Name            string       `yaml:"name"`
+	Namespace       string       `yaml:"namespace"`
+	CreateNamespace bool         `yaml:"createNamespace"`
+	Chart           string       `yaml:"chart"`
+	Version         string       `yaml:"version"`
+	Values          []*yaml.Node `yaml:"values,omitempty"`
+}
+
+// HelmfileEntry renders a helmfile snippet with the chart's repository and a
+// releases entry that installs the chart version with the given values inlined
+func HelmfileEntry(ref ChartRef) (string, error) {
+	repo := helmfileRepository{
+		Name: ref.RepoName,
+		URL:  ref.RepoURL,
+	}
+	// helmfile expects OCI registries without the scheme
+	if strings.HasPrefix(ref.RepoURL, "oci://") {
+		repo.URL = strings.TrimPrefix(ref.RepoURL, "oci://")
+		repo.OCI = true
+	}
+
+	release := helmfileRelease{
+		Name:            ref.Release,
+		Namespace:       ref.Namespace,
+		CreateNamespace: true,
+		Chart:           ref.RepoName + "/" + ref.Chart,
+		Version:         ref.Version,
+	}
+
+	values, err := valuesNode(ref.Values)
+	if err != nil {
+		return "", err
+	}
+	if values != nil {
+		release.Values = []*yaml.Node{values}
+	}
+
+	return encodeDocuments(helmfileSnippet{
+		Repositories: []helmfileRepository{repo},
+		Releases:     []helmfileRelease{release},
+	})
+}
@@ -0,0 +1,281 @@
+// Copyright 2025 Alessandro Pitocchi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gitops
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+	"gopkg.in/yaml.v3"
+	"helm.sh/helm/v3/pkg/strvals"
+)
+
+// DefaultEnvironment is the helmfile environment used when none is picked
+const DefaultEnvironment = "default"
+
+// helmfileSeparator splits the parts of a helmfile, rendered one at a time
+var helmfileSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+
+// Helmfile is a helmfile.yaml rendered for one environment
+type Helmfile struct {
+	Path         string
+	Environment  string
+	Environments []string // Declared environments, sorted
+	Releases     []HelmfileRelease
+
+	envValues map[string]interface{}
+}
+
+// HelmfileRelease is a release of a helmfile
+type HelmfileRelease struct {
+	Name      string
+	Namespace string
+	Chart     string // e.g. bitnami/nginx, or a local path like ./charts/app
+	Version   string
+	Installed bool // false for installed: false, which helmfile uninstalls
+
+	values []interface{} // Files and inline values, in order
+	set    []helmfileSet
+}
+
+type helmfileSet struct {
+	Name  string      `yaml:"name"`
+	Value interface{} `yaml:"value"`
+}
+
+// helmfilePart is what lazyhelm reads of a part of a helmfile
+type helmfilePart struct {
+	Values       []interface{} `yaml:"values"`
+	Environments map[string]struct {
+		Values []interface{} `yaml:"values"`
+	} `yaml:"environments"`
+	Releases []struct {
+		Name      string        `yaml:"name"`
+		Namespace string        `yaml:"namespace"`
+		Chart     string        `yaml:"chart"`
+		Version   string        `yaml:"version"`
+		Installed *bool         `yaml:"installed"`
+		Values    []interface{} `yaml:"values"`
+		Set       []helmfileSet `yaml:"set"`
+	} `yaml:"releases"`
+}
+
+// LoadHelmfile reads a helmfile for an environment, DefaultEnvironment when
+// empty. Like helmfile, each part is rendered as a Go template with the
+// environment values read so far. Secrets, bases and nested helmfiles aren't
+// supported.
+func LoadHelmfile(path, environment string) (*Helmfile, error) {
+	if environment == "" {
+		environment = DefaultEnvironment
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read helmfile: %w", err)
+	}
+
+	h := &Helmfile{Path: path, Environment: environment, envValues: map[string]interface{}{}}
+	declared := false
+	for i, text := range helmfileSeparator.Split(string(data), -1) {
+		rendered, err := h.render(fmt.Sprintf("%s part %d", filepath.Base(path), i+1), text, nil)
+		if err != nil {
+			return nil, err
+		}
+		var part helmfilePart
+		if err := yaml.Unmarshal(rendered, &part); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", path, err)
+		}
+
+		if err := h.mergeValues(h.envValues, part.Values, nil); err != nil {
+			return nil, err
+		}
+		for name, env := range part.Environments {
+			if !containsString(h.Environments, name) {
+				h.Environments = append(h.Environments, name)
+			}
+			if name != environment {
+				continue
+			}
+			declared = true
+			if err := h.mergeValues(h.envValues, env.Values, nil); err != nil {
+				return nil, err
+			}
+		}
+		for _, r := range part.Releases {
+			h.Releases = append(h.Releases, HelmfileRelease{
+				Name:      r.Name,
+				Namespace: r.Namespace,
+				Chart:     r.Chart,
+				Version:   r.Version,
+				Installed: r.Installed == nil || *r.Installed,
+				values:    r.Values,
+				set:       r.Set,
+			})
+		}
+	}
+	if !declared && environment != DefaultEnvironment {
+		return nil, fmt.Errorf("environment %q is not defined in %s", environment, path)
+	}
+	sort.Strings(h.Environments)
+	return h, nil
+}
+
+// ReleaseValues merges the values files, inline values and set entries of a
+// release into the values helmfile would pass to helm, as YAML
+func (h *Helmfile) ReleaseValues(release HelmfileRelease) (string, error) {
+	values := map[string]interface{}{}
+	if err := h.mergeValues(values, release.values, &release); err != nil {
+		return "", err
+	}
+	for _, set := range release.set {
+		if err := strvals.ParseInto(fmt.Sprintf("%s=%v", set.Name, set.Value), values); err != nil {
+			return "", fmt.Errorf("invalid set %s: %w", set.Name, err)
+		}
+	}
+	if len(values) == 0 {
+		return "", nil
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(values); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// mergeValues merges values entries into dst, later ones winning. Entries are
+// inline maps or paths relative to the helmfile; *.gotmpl files are rendered
+// first, with release set when they belong to one.
+func (h *Helmfile) mergeValues(dst map[string]interface{}, entries []interface{}, release *HelmfileRelease) error {
+	for _, entry := range entries {
+		switch entry := entry.(type) {
+		case map[string]interface{}:
+			mergeMaps(dst, entry)
+		case string:
+			path := entry
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(filepath.Dir(h.Path), path)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read values: %w", err)
+			}
+			if strings.HasSuffix(path, ".gotmpl") {
+				if data, err = h.render(entry, string(data), release); err != nil {
+					return err
+				}
+			}
+			var values map[string]interface{}
+			if err := yaml.Unmarshal(data, &values); err != nil {
+				return fmt.Errorf("invalid values file %s: %w", entry, err)
+			}
+			mergeMaps(dst, values)
+		}
+	}
+	return nil
+}
+
+// render executes text as a helmfile template: .Environment, .Values (the
+// environment values) and, in release values files, .Release are set
+func (h *Helmfile) render(name, text string, release *HelmfileRelease) ([]byte, error) {
+	if !strings.Contains(text, "{{") {
+		return []byte(text), nil
+	}
+	funcs := sprig.TxtFuncMap()
+	funcs["env"] = os.Getenv
+	funcs["requiredEnv"] = func(name string) (string, error) {
+		if value := os.Getenv(name); value != "" {
+			return value, nil
+		}
+		return "", fmt.Errorf("required env var `%s` is not set", name)
+	}
+	funcs["required"] = func(msg string, value interface{}) (interface{}, error) {
+		if value == nil || value == "" {
+			return nil, errors.New(msg)
+		}
+		return value, nil
+	}
+	funcs["toYaml"] = func(v interface{}) (string, error) {
+		data, err := yaml.Marshal(v)
+		return strings.TrimSuffix(string(data), "\n"), err
+	}
+	funcs["fromYaml"] = func(s string) (map[string]interface{}, error) {
+		var v map[string]interface{}
+		err := yaml.Unmarshal([]byte(s), &v)
+		return v, err
+	}
+	funcs["readFile"] = func(path string) (string, error) {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(h.Path), path)
+		}
+		data, err := os.ReadFile(path)
+		return string(data), err
+	}
+	funcs["exec"] = func(string, ...interface{}) (string, error) {
+		return "", errors.New("exec isn't supported")
+	}
+
+	tmpl, err := template.New(name).Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template %s: %w", name, err)
+	}
+	data := map[string]interface{}{
+		"Environment": map[string]interface{}{"Name": h.Environment, "Values": h.envValues},
+		"Values":      h.envValues,
+		"StateValues": h.envValues,
+	}
+	if release != nil {
+		data["Release"] = map[string]interface{}{
+			"Name":      release.Name,
+			"Namespace": release.Namespace,
+			"Chart":     release.Chart,
+		}
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render %s: %w", name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// mergeMaps deep merges src into dst, src winning
+func mergeMaps(dst, src map[string]interface{}) {
+	for key, value := range src {
+		if srcMap, ok := value.(map[string]interface{}); ok {
+			if dstMap, ok := dst[key].(map[string]interface{}); ok {
+				mergeMaps(dstMap, srcMap)
+				continue
+			}
+		}
+		dst[key] = value
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
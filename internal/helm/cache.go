@@ -67,6 +67,14 @@ func (c *Cache) Set(chartName, version, values string) {
 	}
 }
 
+// SetTTL changes how long entries stay valid, including those already cached
+func (c *Cache) SetTTL(ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ttl = ttl
+}
+
 func (c *Cache) Clear() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
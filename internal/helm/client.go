@@ -15,212 +15,1370 @@
 package helm
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/Masterminds/semver/v3"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/plugin"
+	"helm.sh/helm/v3/pkg/postrender"
+	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
 	"helm.sh/helm/v3/pkg/repo"
+	"helm.sh/helm/v3/pkg/strvals"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/yaml"
 )
 
 type Client struct {
-	settings *cli.EnvSettings
+	// kubeMu guards the kube context, kubeconfig and namespace of settings,
+	// which change while loads started earlier still read them
+	kubeMu        sync.RWMutex
+	settings      *cli.EnvSettings
+	driver        string
+	kubeConfigEnv string // KUBECONFIG lazyhelm was started with
+
+	indexMu sync.Mutex
+	indexes map[string]cachedIndex // Parsed repository indexes, by path
+}
+
+// cachedIndex is a parsed repository index and the file it was read from,
+// which is parsed again once it changes
+type cachedIndex struct {
+	index   *repo.IndexFile
+	modTime time.Time
+	size    int64
 }
 
 func NewClient() *Client {
 	return &Client{
-		settings: cli.New(),
+		settings:      cli.New(),
+		driver:        os.Getenv("HELM_DRIVER"),
+		kubeConfigEnv: os.Getenv("KUBECONFIG"),
+		indexes:       make(map[string]cachedIndex),
+	}
+}
+
+// SetRepositoryConfig points the client at an alternate repositories.yaml
+func (c *Client) SetRepositoryConfig(path string) {
+	c.settings.RepositoryConfig = path
+}
+
+// RepositoryConfig returns the repositories.yaml the client reads from
+func (c *Client) RepositoryConfig() string {
+	return c.settings.RepositoryConfig
+}
+
+// SetKubeConfig selects the kubeconfig used for cluster operations, like
+// --kubeconfig. Several files separated by the OS path list separator are
+// merged the way kubectl merges KUBECONFIG. An empty value goes back to
+// KUBECONFIG or ~/.kube/config.
+func (c *Client) SetKubeConfig(paths string) error {
+	files := filepath.SplitList(paths)
+	for _, file := range files {
+		if _, err := os.Stat(file); err != nil {
+			return fmt.Errorf("kubeconfig %s: %w", file, err)
+		}
+	}
+
+	c.kubeMu.Lock()
+	defer c.kubeMu.Unlock()
+	if len(files) > 1 {
+		// --kubeconfig names a single file: client-go only merges the
+		// files listed in KUBECONFIG
+		c.settings.KubeConfig = ""
+		return os.Setenv("KUBECONFIG", paths)
+	}
+	c.settings.KubeConfig = paths
+	return os.Setenv("KUBECONFIG", c.kubeConfigEnv)
+}
+
+// SetNamespace overrides the namespace used when an operation doesn't name one
+func (c *Client) SetNamespace(namespace string) {
+	c.kubeMu.Lock()
+	defer c.kubeMu.Unlock()
+	c.settings.SetNamespace(namespace)
+}
+
+// Namespace returns the effective default namespace: --namespace, then
+// HELM_NAMESPACE, then the namespace of the current kube context
+func (c *Client) Namespace() string {
+	c.kubeMu.RLock()
+	defer c.kubeMu.RUnlock()
+	return c.settings.Namespace()
+}
+
+// kubeFlags returns the kube context and kubeconfig helm is run with, empty
+// when unset
+func (c *Client) kubeFlags() (kubeContext, kubeConfig string) {
+	c.kubeMu.RLock()
+	defer c.kubeMu.RUnlock()
+	return c.settings.KubeContext, c.settings.KubeConfig
+}
+
+// StorageDrivers lists the values accepted by SetDriver
+var StorageDrivers = []string{"secret", "secrets", "configmap", "configmaps", "memory", "sql"}
+
+// SetDriver selects the release storage backend (HELM_DRIVER). The sql
+// driver also needs HELM_DRIVER_SQL_CONNECTION_STRING in the environment.
+func (c *Client) SetDriver(driver string) error {
+	if !slices.Contains(StorageDrivers, driver) {
+		return fmt.Errorf("unknown storage driver '%s' (supported: secret, configmap, memory, sql)", driver)
+	}
+	c.driver = driver
+	return nil
+}
+
+// Driver returns the release storage backend, "secret" unless configured
+func (c *Client) Driver() string {
+	if c.driver == "" {
+		return "secret"
+	}
+	return c.driver
+}
+
+func (c *Client) resolveNamespace(namespace string) string {
+	if namespace == "" {
+		return c.Namespace()
+	}
+	return namespace
+}
+
+// restClientGetter returns the kube client settings of the client scoped to
+// namespace, so concurrent operations on different namespaces don't share
+// (and race on) the namespace of c.settings. Requests made without a context
+// of their own, like those of the Helm SDK actions, are aborted once ctx is
+// cancelled.
+func (c *Client) restClientGetter(ctx context.Context, namespace string) genericclioptions.RESTClientGetter {
+	c.kubeMu.RLock()
+	s := *c.settings
+	c.kubeMu.RUnlock()
+	return &genericclioptions.ConfigFlags{
+		Namespace:        &namespace,
+		Context:          &s.KubeContext,
+		BearerToken:      &s.KubeToken,
+		APIServer:        &s.KubeAPIServer,
+		CAFile:           &s.KubeCaFile,
+		KubeConfig:       &s.KubeConfig,
+		Impersonate:      &s.KubeAsUser,
+		Insecure:         &s.KubeInsecureSkipTLSVerify,
+		TLSServerName:    &s.KubeTLSServerName,
+		ImpersonateGroup: &s.KubeAsGroups,
+		WrapConfigFn: func(config *rest.Config) *rest.Config {
+			config.Burst = s.BurstLimit
+			config.QPS = s.QPS
+			// API deprecation warnings would be printed over the TUI
+			config.WarningHandler = rest.NoWarnings{}
+			if ctx.Done() != nil {
+				config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+					return contextRoundTripper{ctx: ctx, next: rt}
+				})
+			}
+			return config
+		},
+	}
+}
+
+// contextRoundTripper sends the requests that have no deadline or
+// cancellation of their own with ctx
+type contextRoundTripper struct {
+	ctx  context.Context
+	next http.RoundTripper
+}
+
+func (t contextRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Context().Done() == nil {
+		req = req.WithContext(t.ctx)
+	}
+	return t.next.RoundTrip(req)
+}
+
+// canceled returns ctx.Err() in place of err once ctx is cancelled, as the
+// Helm SDK doesn't always wrap the error of an aborted request
+func canceled(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// actionConfig prepares a Helm SDK action configuration for namespace, using
+// the client's kube settings and storage driver. An empty namespace reads
+// releases from all namespaces. Its cluster requests stop once ctx is
+// cancelled.
+func (c *Client) actionConfig(ctx context.Context, namespace string) (*action.Configuration, error) {
+	cfg := new(action.Configuration)
+	if err := cfg.Init(c.restClientGetter(ctx, namespace), namespace, c.Driver(), func(string, ...interface{}) {}); err != nil {
+		return nil, fmt.Errorf("failed to initialize helm: %w", err)
+	}
+
+	registryClient, err := c.registryClient()
+	if err != nil {
+		return nil, err
+	}
+	cfg.RegistryClient = registryClient
+	return cfg, nil
+}
+
+// registryClient returns an OCI registry client using helm's stored
+// registry credentials
+func (c *Client) registryClient() (*registry.Client, error) {
+	client, err := registry.NewClient(
+		registry.ClientOptEnableCache(true),
+		registry.ClientOptWriter(io.Discard),
+		registry.ClientOptCredentialsFile(c.settings.RegistryConfig),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create registry client: %w", err)
 	}
+	return client, nil
 }
 
 type Repository struct {
-	Name string
-	URL  string
+	Name         string
+	URL          string
+	IndexUpdated time.Time // When the cached index was downloaded, zero if it never was
+	Git          bool      // A GitSource rather than a chart repository
 }
 
 func (c *Client) ListRepositories() ([]Repository, error) {
 	repoFile := c.settings.RepositoryConfig
 
+	f, err := repo.LoadFile(repoFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	sources, err := c.gitSources()
+	if err != nil {
+		return nil, err
+	}
+
+	repos := make([]Repository, 0, len(f.Repositories)+len(sources))
+	for _, r := range f.Repositories {
+		repos = append(repos, c.repository(r.Name, r.URL))
+	}
+	for _, src := range sources {
+		repository := c.repository(src.Name, src.String())
+		repository.Git = true
+		repos = append(repos, repository)
+	}
+
+	return repos, nil
+}
+
+type Chart struct {
+	Name        string
+	Version     string // Latest version
+	AppVersion  string
+	Description string
+	Deprecated  bool
+}
+
+// repository describes a repository with the age of its cached index
+func (c *Client) repository(name, url string) Repository {
+	repository := Repository{
+		Name: name,
+		URL:  url,
+	}
+	if stat, err := os.Stat(filepath.Join(c.settings.RepositoryCache, name+"-index.yaml")); err == nil {
+		repository.IndexUpdated = stat.ModTime()
+	}
+	return repository
+}
+
+// SearchCharts lists the charts of a repository with their latest stable
+// version, read from the cached index like helm search repo
+func (c *Client) SearchCharts(repoName string) ([]Chart, error) {
+	index, err := c.loadIndex(repoName)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			// Never updated: nothing to search yet
+			return []Chart{}, nil
+		}
+		return nil, err
+	}
+
+	charts := make([]Chart, 0, len(index.Entries))
+	for name, versions := range index.Entries {
+		latest := stableVersions(versions)
+		if len(latest) == 0 {
+			continue
+		}
+		charts = append(charts, Chart{
+			Name:        repoName + "/" + name,
+			Version:     latest[0].Version,
+			AppVersion:  latest[0].AppVersion,
+			Description: latest[0].Description,
+			Deprecated:  latest[0].Deprecated,
+		})
+	}
+	sort.Slice(charts, func(i, j int) bool { return charts[i].Name < charts[j].Name })
+	return charts, nil
+}
+
+// loadIndex reads the cached index of a repository, newest versions first.
+// Indexes of big repositories take a while to parse, so the parsed index is
+// kept until the file changes, e.g. after helm repo update. Callers must not
+// modify it.
+func (c *Client) loadIndex(repoName string) (*repo.IndexFile, error) {
+	path := filepath.Join(c.settings.RepositoryCache, repoName+"-index.yaml")
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load index for repository '%s': %w", repoName, err)
+	}
+
+	c.indexMu.Lock()
+	cached, ok := c.indexes[path]
+	c.indexMu.Unlock()
+	if ok && cached.modTime.Equal(stat.ModTime()) && cached.size == stat.Size() {
+		return cached.index, nil
+	}
+
+	index, err := repo.LoadIndexFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load index for repository '%s': %w", repoName, err)
+	}
+	index.SortEntries()
+
+	c.indexMu.Lock()
+	c.indexes[path] = cachedIndex{index: index, modTime: stat.ModTime(), size: stat.Size()}
+	c.indexMu.Unlock()
+	return index, nil
+}
+
+// stableVersions drops pre-release versions, which helm search only shows
+// with --devel
+func stableVersions(versions repo.ChartVersions) repo.ChartVersions {
+	stable := make(repo.ChartVersions, 0, len(versions))
+	for _, cv := range versions {
+		if v, err := semver.NewVersion(cv.Version); err == nil && v.Prerelease() == "" {
+			stable = append(stable, cv)
+		}
+	}
+	return stable
+}
+
+// ChartHomeURL returns the home page of a chart version from the cached
+// repository index, falling back to its first source URL. chartName is
+// "repo/chart"; an empty version means the latest.
+func (c *Client) ChartHomeURL(chartName, version string) (string, error) {
+	meta, err := c.indexChartVersion(chartName, version)
+	if err != nil {
+		return "", err
+	}
+	if meta.Home != "" {
+		return meta.Home, nil
+	}
+	for _, source := range meta.Sources {
+		if source != "" {
+			return source, nil
+		}
+	}
+	return "", fmt.Errorf("chart '%s' has no home or source URL", chartName)
+}
+
+// Maintainer is a chart maintainer as listed in Chart.yaml
+type Maintainer struct {
+	Name  string
+	Email string
+	URL   string
+}
+
+// Dependency is a chart dependency as listed in Chart.yaml
+type Dependency struct {
+	Name       string
+	Version    string // Version constraint
+	Repository string
+	Condition  string
+}
+
+// ChartInfo holds the Chart.yaml metadata of a chart version
+type ChartInfo struct {
+	Name         string
+	Version      string
+	APIVersion   string // v2 for Helm 3 charts, v1 for charts still supporting Helm 2
+	Type         string // application or library
+	AppVersion   string
+	Description  string
+	Home         string
+	Icon         string
+	License      string
+	KubeVersion  string
+	Sources      []string
+	Maintainers  []Maintainer
+	Keywords     []string
+	Dependencies []Dependency
+	Deprecated   bool
+}
+
+// GetChartInfo returns the metadata of a chart version, read from the cached
+// repository index or, when the chart isn't indexed (e.g. OCI), from the
+// chart itself
+func (c *Client) GetChartInfo(chartName, version string) (*ChartInfo, error) {
+	var meta *chart.Metadata
+	if cv, err := c.indexChartVersion(chartName, version); err == nil {
+		meta = cv.Metadata
+	} else {
+		chrt, err := c.loadChart(chartName, version)
+		if err != nil {
+			return nil, err
+		}
+		meta = chrt.Metadata
+	}
+
+	return chartInfo(meta), nil
+}
+
+// chartInfo converts Chart.yaml metadata
+func chartInfo(meta *chart.Metadata) *ChartInfo {
+	info := &ChartInfo{
+		Name:        meta.Name,
+		Version:     meta.Version,
+		APIVersion:  meta.APIVersion,
+		Type:        meta.Type,
+		AppVersion:  meta.AppVersion,
+		Description: meta.Description,
+		Home:        meta.Home,
+		Icon:        meta.Icon,
+		KubeVersion: meta.KubeVersion,
+		Sources:     meta.Sources,
+		Keywords:    meta.Keywords,
+		Deprecated:  meta.Deprecated,
+	}
+	if info.Type == "" {
+		info.Type = "application"
+	}
+	// Chart.yaml has no license field; Artifact Hub's annotation is the convention
+	info.License = meta.Annotations["artifacthub.io/license"]
+	for _, mt := range meta.Maintainers {
+		if mt == nil {
+			continue
+		}
+		info.Maintainers = append(info.Maintainers, Maintainer{Name: mt.Name, Email: mt.Email, URL: mt.URL})
+	}
+	for _, dep := range meta.Dependencies {
+		if dep == nil {
+			continue
+		}
+		info.Dependencies = append(info.Dependencies, Dependency{
+			Name:       dep.Name,
+			Version:    dep.Version,
+			Repository: dep.Repository,
+			Condition:  dep.Condition,
+		})
+	}
+	return info
+}
+
+// loadChart downloads a chart version into helm's repository cache and loads
+// it. An empty version means the latest stable one.
+func (c *Client) loadChart(chartName, version string) (*chart.Chart, error) {
+	if dir, ok, err := c.gitChartDir(chartName, version); ok {
+		if err != nil {
+			return nil, err
+		}
+		return loader.Load(dir)
+	}
+	registryClient, err := c.registryClient()
+	if err != nil {
+		return nil, err
+	}
+	install := action.NewInstall(&action.Configuration{RegistryClient: registryClient})
+	install.Version = version
+
+	path, err := install.LocateChart(chartName, c.settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download chart '%s': %w", chartName, err)
+	}
+	return loader.Load(path)
+}
+
+// chartValues returns a chart's values.yaml as written by its authors,
+// comments included, like helm show values
+func chartValues(chrt *chart.Chart) string {
+	for _, f := range chrt.Raw {
+		if f.Name == chartutil.ValuesfileName {
+			return string(f.Data)
+		}
+	}
+	return ""
+}
+
+// GetChartReadme returns the README of a chart version, like helm show readme
+func (c *Client) GetChartReadme(chartName, version string) (string, error) {
+	chrt, err := c.loadChart(chartName, version)
+	if err != nil {
+		return "", err
+	}
+	// Same lookup order as helm show readme
+	for _, name := range []string{"readme.md", "readme.txt", "readme"} {
+		for _, f := range chrt.Files {
+			if strings.EqualFold(f.Name, name) {
+				return string(f.Data), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("chart '%s' has no README", chartName)
+}
+
+// ChartFile is a file of a chart package, e.g. templates/deployment.yaml
+type ChartFile struct {
+	Path string
+	Data []byte
+}
+
+// GetChartFiles returns every file of a chart version (Chart.yaml, values,
+// templates, CRDs, helpers and bundled subcharts), sorted by path
+func (c *Client) GetChartFiles(chartName, version string) ([]ChartFile, error) {
+	chrt, err := c.loadChart(chartName, version)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]ChartFile, 0, len(chrt.Raw))
+	for _, f := range chrt.Raw {
+		files = append(files, ChartFile{Path: f.Name, Data: f.Data})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// ValuesValidation is the result of validating a values file against a
+// chart's values.schema.json
+type ValuesValidation struct {
+	HasSchema bool
+	Defaults  string   // The chart's default values.yaml
+	Errors    []string // Schema violations, empty when the file is valid
+}
+
+// ValidateValuesFile checks a local values file, merged over the chart
+// defaults the way helm install does, against the chart's schema
+func (c *Client) ValidateValuesFile(chartName, version, valuesFile string) (*ValuesValidation, error) {
+	data, err := os.ReadFile(valuesFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read values file: %w", err)
+	}
+	return c.ValidateValues(chartName, version, data)
+}
+
+// ValidateValues checks values YAML, merged over the chart defaults the way
+// helm install does, against the chart's schema
+func (c *Client) ValidateValues(chartName, version string, data []byte) (*ValuesValidation, error) {
+	chrt, err := c.loadChart(chartName, version)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ValuesValidation{HasSchema: len(chrt.Schema) > 0, Defaults: chartValues(chrt)}
+
+	values, err := chartutil.ReadValues(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse values: %w", err)
+	}
+	merged, err := chartutil.CoalesceValues(chrt, values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge values: %w", err)
+	}
+
+	if err := chartutil.ValidateAgainstSchema(chrt, merged); err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "-"))
+			if line != "" {
+				result.Errors = append(result.Errors, line)
+			}
+		}
+	}
+	return result, nil
+}
+
+// SchemaProperty is a value declared by a chart's values.schema.json
+type SchemaProperty struct {
+	Path        string // Values path, e.g. image.tag; array items end in []
+	Type        string
+	Description string
+	Default     string // JSON encoded
+	Enum        []string
+	Required    bool
+}
+
+// GetValuesSchema returns the values declared by the values.schema.json of a
+// chart version, parents before their properties. A chart without a schema
+// returns no properties and no error.
+func (c *Client) GetValuesSchema(chartName, version string) ([]SchemaProperty, error) {
+	chrt, err := c.loadChart(chartName, version)
+	if err != nil {
+		return nil, err
+	}
+	if len(chrt.Schema) == 0 {
+		return nil, nil
+	}
+	return ParseValuesSchema(chrt.Schema)
+}
+
+// ParseValuesSchema returns the values declared by a values.schema.json
+// document, parents before their properties
+func ParseValuesSchema(data []byte) ([]SchemaProperty, error) {
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("invalid values.schema.json: %w", err)
+	}
+	var props []SchemaProperty
+	schemaProperties(schema, "", &props)
+	return props, nil
+}
+
+// schemaProperties walks the properties (and array items) of a JSON schema
+// node, appending one SchemaProperty per declared value
+func schemaProperties(node map[string]interface{}, prefix string, props *[]SchemaProperty) {
+	required := make(map[string]bool)
+	if list, ok := node["required"].([]interface{}); ok {
+		for _, name := range list {
+			if s, ok := name.(string); ok {
+				required[s] = true
+			}
+		}
+	}
+
+	properties, _ := node["properties"].(map[string]interface{})
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		child, ok := properties[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+		*props = append(*props, schemaProperty(child, path, required[name]))
+		schemaProperties(child, path, props)
+		if items, ok := child["items"].(map[string]interface{}); ok {
+			if _, nested := items["properties"]; nested {
+				schemaProperties(items, path+"[]", props)
+			}
+		}
+	}
+}
+
+func schemaProperty(node map[string]interface{}, path string, required bool) SchemaProperty {
+	prop := SchemaProperty{Path: path, Required: required}
+	prop.Description, _ = node["description"].(string)
+
+	switch t := node["type"].(type) {
+	case string:
+		prop.Type = t
+	case []interface{}:
+		types := make([]string, 0, len(t))
+		for _, v := range t {
+			types = append(types, fmt.Sprint(v))
+		}
+		prop.Type = strings.Join(types, "|")
+	}
+	if ref, ok := node["$ref"].(string); ok && prop.Type == "" {
+		prop.Type = ref
+	}
+	if prop.Type == "array" {
+		if items, ok := node["items"].(map[string]interface{}); ok {
+			if t, ok := items["type"].(string); ok {
+				prop.Type = t + "[]"
+			}
+		}
+	}
+
+	if def, ok := node["default"]; ok {
+		if data, err := json.Marshal(def); err == nil {
+			prop.Default = string(data)
+		}
+	}
+	if enum, ok := node["enum"].([]interface{}); ok {
+		for _, v := range enum {
+			if data, err := json.Marshal(v); err == nil {
+				prop.Enum = append(prop.Enum, string(data))
+			}
+		}
+	}
+	return prop
+}
+
+// indexChartVersion looks up a chart version in the cached repository index
+func (c *Client) indexChartVersion(chartName, version string) (*repo.ChartVersion, error) {
+	repoName, name, ok := strings.Cut(chartName, "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid chart name '%s'", chartName)
+	}
+
+	index, err := c.loadIndex(repoName)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := index.Get(name, version)
+	if err != nil {
+		return nil, fmt.Errorf("chart '%s' not found in index: %w", chartName, err)
+	}
+	return meta, nil
+}
+
+type ChartVersion struct {
+	Version     string
+	AppVersion  string
+	Description string
+	KubeVersion string     // Kubernetes version constraint, empty when unset or unknown
+	Metadata    *ChartInfo // Chart.yaml of the version, as indexed
+}
+
+// GetChartVersions lists the stable versions of a repository chart, newest
+// first, like helm search repo --versions
+func (c *Client) GetChartVersions(chartName string) ([]ChartVersion, error) {
+	repoName, name, ok := strings.Cut(chartName, "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid chart name '%s'", chartName)
+	}
+	index, err := c.loadIndex(repoName)
+	if err != nil {
+		return nil, err
+	}
+
+	stable := stableVersions(index.Entries[name])
+	versions := make([]ChartVersion, len(stable))
+	for i, cv := range stable {
+		versions[i] = ChartVersion{
+			Version:     cv.Version,
+			AppVersion:  cv.AppVersion,
+			Description: cv.Description,
+			KubeVersion: cv.KubeVersion,
+			Metadata:    chartInfo(cv.Metadata),
+		}
+	}
+	return versions, nil
+}
+
+// KubeVersionCompatible reports whether a cluster version satisfies a chart's
+// kubeVersion constraint, using the same check as helm install. An empty
+// constraint or unknown cluster version is treated as compatible.
+func KubeVersionCompatible(constraint, clusterVersion string) bool {
+	if constraint == "" || clusterVersion == "" {
+		return true
+	}
+	return chartutil.IsCompatibleRange(constraint, clusterVersion)
+}
+
+// ChartKubeVersion returns the kubeVersion constraint of a chart version,
+// empty when the chart doesn't set one. An empty version means the latest.
+func (c *Client) ChartKubeVersion(chartName, version string) (string, error) {
+	chrt, err := c.loadChart(chartName, version)
+	if err != nil {
+		return "", err
+	}
+	return chrt.Metadata.KubeVersion, nil
+}
+
+func (c *Client) GetChartValues(chartName string) (string, error) {
+	return c.GetChartValuesByVersion(chartName, "")
+}
+
+func (c *Client) GetChartValuesByVersion(chartName, version string) (string, error) {
+	chrt, err := c.loadChart(chartName, version)
+	if err != nil {
+		return "", err
+	}
+	return chartValues(chrt), nil
+}
+
+// TemplateOptions configures GenerateTemplate and RenderTemplate
+type TemplateOptions struct {
+	ReleaseName  string // Defaults to "myrelease"
+	Namespace    string // Empty means the namespace of the client
+	KubeVersion  string // Kubernetes version for Capabilities.KubeVersion, e.g. 1.29.0; empty means helm's default
+	Version      string // Empty means the latest version
+	ValuesFile   string
+	Set          []string // key=value overrides, like --set
+	OutputDir    string
+	ShowOnly     []string // Templates to keep, like --show-only, e.g. templates/service.yaml; empty keeps all
+	PostRenderer string   // Command line of a post-renderer, e.g. "./kustomize.sh overlay"
+	Validate     bool     // Validate rendered resources against the connected cluster
+}
+
+// ParseOverride checks a key=value override in --set syntax, e.g.
+// image.tag=1.2 or hosts[0].name=example.com
+func ParseOverride(override string) error {
+	if _, err := strvals.Parse(override); err != nil {
+		return fmt.Errorf("invalid override %q: %w", override, err)
+	}
+	return nil
+}
+
+func (c *Client) GenerateTemplate(chartName string, opts TemplateOptions) error {
+	_, err := c.template(chartName, opts, opts.OutputDir)
+	return err
+}
+
+// RenderTemplate renders a chart and returns the manifests instead of
+// writing them to a directory; opts.OutputDir is ignored
+func (c *Client) RenderTemplate(chartName string, opts TemplateOptions) (string, error) {
+	return c.template(chartName, opts, "")
+}
+
+// template renders a chart like helm template: a dry-run install that never
+// touches the cluster unless opts.Validate is set. With an outputDir the
+// manifests are written there, one file per template, and nothing is returned.
+func (c *Client) template(chartName string, opts TemplateOptions, outputDir string) (string, error) {
+	releaseName := opts.ReleaseName
+	if releaseName == "" {
+		releaseName = "myrelease"
+	}
+
+	namespace := c.resolveNamespace(opts.Namespace)
+	cfg, err := c.actionConfig(context.Background(), namespace)
+	if err != nil {
+		return "", err
+	}
+	install := action.NewInstall(cfg)
+	if opts.KubeVersion != "" {
+		kubeVersion, err := chartutil.ParseKubeVersion(opts.KubeVersion)
+		if err != nil {
+			return "", fmt.Errorf("invalid Kubernetes version '%s': %w", opts.KubeVersion, err)
+		}
+		install.KubeVersion = kubeVersion
+	}
+	install.DryRun = true
+	install.DryRunOption = "true"
+	if opts.Validate {
+		install.DryRunOption = "server"
+	}
+	install.ClientOnly = !opts.Validate
+	install.Replace = true
+	install.ReleaseName = releaseName
+	install.Namespace = namespace
+	install.Version = opts.Version
+	if fields := strings.Fields(opts.PostRenderer); len(fields) > 0 {
+		pr, err := postrender.NewExec(fields[0], fields[1:]...)
+		if err != nil {
+			return "", fmt.Errorf("invalid post-renderer: %w", err)
+		}
+		install.PostRenderer = pr
+	}
+
+	path, isGit, err := c.gitChartDir(chartName, opts.Version)
+	if !isGit {
+		path, err = install.LocateChart(chartName, c.settings)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to download chart '%s': %w", chartName, err)
+	}
+	chrt, err := loader.Load(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to load chart: %w", err)
+	}
+
+	valueOpts := values.Options{Values: opts.Set}
+	if opts.ValuesFile != "" {
+		valueOpts.ValueFiles = []string{opts.ValuesFile}
+	}
+	vals, err := valueOpts.MergeValues(getter.All(c.settings))
+	if err != nil {
+		return "", fmt.Errorf("failed to read values: %w", err)
+	}
+
+	rel, err := install.Run(chrt, vals)
+	if err != nil {
+		return "", fmt.Errorf("helm template failed: %w", err)
+	}
+
+	var manifests strings.Builder
+	manifests.WriteString(strings.TrimSpace(rel.Manifest))
+	for _, hook := range rel.Hooks {
+		fmt.Fprintf(&manifests, "\n---\n# Source: %s\n%s", hook.Path, hook.Manifest)
+	}
+	rendered := manifests.String()
+	if len(opts.ShowOnly) > 0 {
+		if rendered, err = showOnly(rendered, opts.ShowOnly); err != nil {
+			return "", err
+		}
+	}
+	if outputDir == "" {
+		return rendered + "\n", nil
+	}
+	return "", writeManifests(outputDir, rendered)
+}
+
+// showOnly keeps the documents of rendered manifests that come from the
+// given templates, like helm template --show-only. Templates are paths in
+// the chart, e.g. templates/service.yaml or charts/redis/templates/*.yaml.
+func showOnly(manifests string, templates []string) (string, error) {
+	var kept []string
+	found := make(map[string]bool)
+	for _, doc := range strings.Split(manifests, "---\n# Source: ") {
+		source, body, ok := strings.Cut(doc, "\n")
+		if !ok {
+			continue
+		}
+		// Sources start with the chart name: mychart/templates/service.yaml
+		_, path, _ := strings.Cut(source, "/")
+		for _, template := range templates {
+			if matched, _ := filepath.Match(filepath.ToSlash(template), path); matched {
+				kept = append(kept, fmt.Sprintf("---\n# Source: %s\n%s", source, strings.TrimSpace(body)))
+				found[template] = true
+				break
+			}
+		}
+	}
+	for _, template := range templates {
+		if !found[template] {
+			return "", fmt.Errorf("could not find template %s in chart", template)
+		}
+	}
+	return strings.Join(kept, "\n"), nil
+}
+
+// writeManifests splits rendered manifests by their "# Source:" template and
+// writes each template's documents to its own file under outputDir, the
+// layout helm template --output-dir produces. Install's own OutputDir would
+// print every file name to stdout, over the TUI.
+func writeManifests(outputDir, manifests string) error {
+	written := make(map[string]bool)
+	for _, doc := range strings.Split(manifests, "---\n# Source: ") {
+		source, body, ok := strings.Cut(doc, "\n")
+		if !ok {
+			continue
+		}
+		path := filepath.Join(outputDir, source)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to write %s: %w", source, err)
+		}
+
+		// Templates rendering several documents share a file
+		flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if written[source] {
+			flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		}
+		f, err := os.OpenFile(path, flags, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", source, err)
+		}
+		_, err = fmt.Fprintf(f, "---\n# Source: %s\n%s\n", source, strings.TrimSpace(body))
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", source, err)
+		}
+		written[source] = true
+	}
+	return nil
+}
+
+// NormalizeRepoURL reduces a repository URL to a comparable form, so that
+// "https://Charts.example.com/stable/" and "https://charts.example.com/stable/index.yaml"
+// are recognized as the same repository
+func NormalizeRepoURL(rawURL string) string {
+	u := strings.TrimSpace(rawURL)
+	u = strings.TrimSuffix(u, "/index.yaml")
+	u = strings.TrimRight(u, "/")
+
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Host == "" {
+		return strings.ToLower(u)
+	}
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	parsed.Host = strings.ToLower(parsed.Host)
+	return parsed.String()
+}
+
+// FindRepositoryByURL returns the configured repository pointing at the same
+// (normalized) URL, or nil if there is none
+func FindRepositoryByURL(repos []Repository, rawURL string) *Repository {
+	target := NormalizeRepoURL(rawURL)
+	for i := range repos {
+		if NormalizeRepoURL(repos[i].URL) == target {
+			return &repos[i]
+		}
+	}
+	return nil
+}
+
+// RepositoryOptions holds the optional credentials and TLS settings of a
+// repository, as set by helm repo add --username, --ca-file and friends
+type RepositoryOptions struct {
+	Username              string
+	Password              string
+	CAFile                string
+	CertFile              string // Client certificate, used with KeyFile
+	KeyFile               string
+	InsecureSkipTLSVerify bool
+}
+
+// validateRepoName rejects repository names helm repo add rejects: chart
+// references are "repo/chart", so a name can't contain a slash. Names are
+// also used as paths in the repository cache, so they can't be empty or
+// leave it either.
+func validateRepoName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("repository name can't be empty")
+	}
+	if strings.Contains(name, "/") {
+		return fmt.Errorf("repository name (%s) contains '/', please specify a different name without '/'", name)
+	}
+	if strings.Contains(name, `\`) || strings.Contains(name, "..") {
+		return fmt.Errorf("invalid repository name '%s'", name)
+	}
+	return nil
+}
+
+func (c *Client) AddRepository(name, url string, opts RepositoryOptions) error {
+	if err := validateRepoName(name); err != nil {
+		return err
+	}
+	repoFile := c.settings.RepositoryConfig
+
+	f, err := repo.LoadFile(repoFile)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to load repositories: %w", err)
+		}
+		f = repo.NewFile()
+	}
+	if _, isGit := c.gitSource(name); isGit || f.Has(name) {
+		return fmt.Errorf("repository '%s' already exists", name)
+	}
+
+	// Download the index first so a bad URL never ends up in the file
+	entry := &repo.Entry{
+		Name:                  name,
+		URL:                   url,
+		Username:              opts.Username,
+		Password:              opts.Password,
+		CAFile:                opts.CAFile,
+		CertFile:              opts.CertFile,
+		KeyFile:               opts.KeyFile,
+		InsecureSkipTLSverify: opts.InsecureSkipTLSVerify,
+	}
+	if err := c.downloadIndex(entry); err != nil {
+		return err
+	}
+
+	f.Update(entry)
+	if err := os.MkdirAll(filepath.Dir(repoFile), 0755); err != nil {
+		return fmt.Errorf("failed to create repository config directory: %w", err)
+	}
+	if err := f.WriteFile(repoFile, 0600); err != nil {
+		return fmt.Errorf("failed to write repositories: %w", err)
+	}
+	return nil
+}
+
+// downloadIndex fetches a repository's index into helm's repository cache
+func (c *Client) downloadIndex(entry *repo.Entry) error {
+	chartRepo, err := repo.NewChartRepository(entry, getter.All(c.settings))
+	if err != nil {
+		return fmt.Errorf("invalid repository '%s': %w", entry.Name, err)
+	}
+	chartRepo.CachePath = c.settings.RepositoryCache
+	if _, err := chartRepo.DownloadIndexFile(); err != nil {
+		return fmt.Errorf("failed to fetch index of '%s' (%s): %w", entry.Name, entry.URL, err)
+	}
+	return nil
+}
+
+// RemovedRepository keeps the full entry of a removed repository, including
+// credentials and TLS settings, so the removal can be undone
+type RemovedRepository struct {
+	entry repo.Entry
+	git   *GitSource // Set when it was a git source
+}
+
+// Name returns the name of the removed repository
+func (r *RemovedRepository) Name() string {
+	return r.entry.Name
+}
+
+// RemoveRepository removes a repository and returns what is needed to restore it
+func (c *Client) RemoveRepository(name string) (*RemovedRepository, error) {
+	if src, ok := c.gitSource(name); ok {
+		if err := c.removeGitSource(name); err != nil {
+			return nil, err
+		}
+		return &RemovedRepository{entry: repo.Entry{Name: name, URL: src.URL}, git: &src}, nil
+	}
+
+	f, err := repo.LoadFile(c.settings.RepositoryConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load repositories: %w", err)
+	}
+	entry := f.Get(name)
+	if entry == nil {
+		return nil, fmt.Errorf("repository '%s' not found", name)
+	}
+	removed := &RemovedRepository{entry: *entry}
+	f.Remove(name)
+	if err := f.WriteFile(c.settings.RepositoryConfig, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write repositories: %w", err)
+	}
+
+	// Drop the cached index like helm repo remove
+	for _, suffix := range []string{"-index.yaml", "-charts.txt"} {
+		os.Remove(filepath.Join(c.settings.RepositoryCache, name+suffix))
+	}
+	return removed, nil
+}
+
+// RestoreRepository re-adds a previously removed repository with its original
+// settings and refreshes its index
+func (c *Client) RestoreRepository(removed *RemovedRepository) error {
+	if removed.git != nil {
+		return c.AddGitSource(*removed.git)
+	}
+	repoFile := c.settings.RepositoryConfig
+
 	f, err := repo.LoadFile(repoFile)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return []Repository{}, nil
+		if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to load repositories: %w", err)
 		}
-		return nil, err
+		f = repo.NewFile()
 	}
-
-	repos := make([]Repository, 0, len(f.Repositories))
-	for _, r := range f.Repositories {
-		repos = append(repos, Repository{
-			Name: r.Name,
-			URL:  r.URL,
-		})
+	if f.Has(removed.entry.Name) {
+		return fmt.Errorf("repository '%s' already exists", removed.entry.Name)
 	}
 
-	return repos, nil
-}
+	entry := removed.entry
+	f.Update(&entry)
+	if err := os.MkdirAll(filepath.Dir(repoFile), 0755); err != nil {
+		return fmt.Errorf("failed to create repository config directory: %w", err)
+	}
+	if err := f.WriteFile(repoFile, 0600); err != nil {
+		return fmt.Errorf("failed to write repositories: %w", err)
+	}
 
-type Chart struct {
-	Name        string
-	Version     string
-	Description string
+	return c.UpdateRepository(entry.Name)
 }
 
-func (c *Client) SearchCharts(repoName string) ([]Chart, error) {
-	// Add trailing slash to search only in this specific repository
-	args := []string{"search", "repo", repoName + "/", "--output", "json"}
+// RenameRepository renames a repository entry in place, keeping its URL,
+// credentials and TLS settings, and moves its cached index to the new name
+func (c *Client) RenameRepository(oldName, newName string) error {
+	if err := validateRepoName(newName); err != nil {
+		return err
+	}
+	if _, ok := c.gitSource(oldName); ok {
+		return fmt.Errorf("'%s' is a git source, which can't be renamed: remove it and add it again", oldName)
+	}
+	if _, ok := c.gitSource(newName); ok {
+		return fmt.Errorf("repository '%s' already exists", newName)
+	}
+	repoFile := c.settings.RepositoryConfig
 
-	cmd := exec.Command("helm", args...)
-	output, err := cmd.CombinedOutput()
+	f, err := repo.LoadFile(repoFile)
 	if err != nil {
-		return nil, fmt.Errorf("helm search failed: %w", err)
+		return fmt.Errorf("failed to load repositories: %w", err)
+	}
+	if f.Has(newName) {
+		return fmt.Errorf("repository '%s' already exists", newName)
 	}
 
-	var results []struct {
-		Name        string `json:"name"`
-		Version     string `json:"version"`
-		Description string `json:"description"`
+	entry := f.Get(oldName)
+	if entry == nil {
+		return fmt.Errorf("repository '%s' not found", oldName)
 	}
 
-	if err := json.Unmarshal(output, &results); err != nil {
-		return nil, err
+	// Rename in place so the entry keeps its position in the file
+	entry.Name = newName
+
+	if err := f.WriteFile(repoFile, 0600); err != nil {
+		return fmt.Errorf("failed to write repositories: %w", err)
 	}
 
-	// Filter to ensure we only get charts from this repository
-	repoPrefix := repoName + "/"
-	charts := make([]Chart, 0)
-	for _, r := range results {
-		// Only include charts that start with "repoName/"
-		if len(r.Name) > len(repoPrefix) && r.Name[:len(repoPrefix)] == repoPrefix {
-			charts = append(charts, Chart{
-				Name:        r.Name,
-				Version:     r.Version,
-				Description: r.Description,
-			})
+	// Move the cached index files so the repo doesn't need an update
+	cacheDir := c.settings.RepositoryCache
+	var errs []error
+	for _, suffix := range []string{"-index.yaml", "-charts.txt"} {
+		oldPath := filepath.Join(cacheDir, oldName+suffix)
+		if _, err := os.Stat(oldPath); err == nil {
+			if err := os.Rename(oldPath, filepath.Join(cacheDir, newName+suffix)); err != nil {
+				errs = append(errs, err)
+			}
 		}
 	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("renamed '%s' to '%s', but failed to move its cached index (update the repository): %w", oldName, newName, err)
+	}
 
-	return charts, nil
-}
-
-type ChartVersion struct {
-	Version     string
-	AppVersion  string
-	Description string
+	return nil
 }
 
-func (c *Client) GetChartVersions(chartName string) ([]ChartVersion, error) {
-	cmd := exec.Command("helm", "search", "repo", chartName, "--versions", "--output", "json")
-	output, err := cmd.CombinedOutput()
+// UpdateRepository downloads a fresh index for a repository, or for every
+// repository when name is empty
+func (c *Client) UpdateRepository(name string) error {
+	f, err := repo.LoadFile(c.settings.RepositoryConfig)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to load repositories: %w", err)
+	}
+	sources, err := c.gitSources()
 	if err != nil {
-		return nil, fmt.Errorf("helm search versions failed: %w", err)
+		return err
 	}
-	
-	var results []struct {
-		Name        string `json:"name"`
-		Version     string `json:"version"`
-		AppVersion  string `json:"app_version"`
-		Description string `json:"description"`
+
+	var entries []*repo.Entry
+	for _, entry := range f.Repositories {
+		if name == "" || entry.Name == name {
+			entries = append(entries, entry)
+		}
 	}
-	
-	if err := json.Unmarshal(output, &results); err != nil {
-		return nil, err
+	var errs []error
+	for _, src := range sources {
+		if name == "" || src.Name == name {
+			if err := c.syncGitSource(src); err != nil {
+				errs = append(errs, err)
+			}
+			if name != "" {
+				return errors.Join(errs...)
+			}
+		}
 	}
-	
-	versions := make([]ChartVersion, len(results))
-	for i, r := range results {
-		versions[i] = ChartVersion{
-			Version:     r.Version,
-			AppVersion:  r.AppVersion,
-			Description: r.Description,
+	if len(entries) == 0 && (name != "" || len(sources) == 0) {
+		if name != "" {
+			return fmt.Errorf("repository '%s' not found", name)
 		}
+		return fmt.Errorf("no repositories configured")
 	}
-	
-	return versions, nil
-}
 
-func (c *Client) GetChartValues(chartName string) (string, error) {
-	cmd := exec.Command("helm", "show", "values", chartName)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return "", fmt.Errorf("helm show values failed: %w", err)
+	for _, entry := range entries {
+		if err := c.downloadIndex(entry); err != nil {
+			errs = append(errs, err)
+		}
 	}
-	return string(output), nil
+	return errors.Join(errs...)
 }
 
-func (c *Client) GetChartValuesByVersion(chartName, version string) (string, error) {
-	cmd := exec.Command("helm", "show", "values", chartName, "--version", version)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return "", fmt.Errorf("helm show values failed: %w", err)
-	}
-	return string(output), nil
+// RepositoryInfo describes the locally cached index of a repository
+type RepositoryInfo struct {
+	Name             string
+	URL              string
+	IndexPath        string
+	IndexSize        int64
+	CachedAt         time.Time // When the index was last downloaded
+	Generated        time.Time // The index's own "generated" timestamp
+	ChartCount       int
+	VersionCount     int
+	UpstreamModified time.Time // Last-Modified of the remote index, zero if unknown
+	Stale            bool
 }
 
-func (c *Client) ExportValues(chartName, outputFile string) error {
-	values, err := c.GetChartValues(chartName)
-	if err != nil {
-		return err
+// GetRepositoryInfo inspects the cached index of a repository and checks
+// whether the upstream index has changed since it was downloaded
+func (c *Client) GetRepositoryInfo(name string) (*RepositoryInfo, error) {
+	f, err := repo.LoadFile(c.settings.RepositoryConfig)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to load repositories: %w", err)
+	}
+	entry := f.Get(name)
+	src, isGit := c.gitSource(name)
+	if isGit {
+		entry = &repo.Entry{Name: src.Name, URL: src.String()}
+	}
+	if entry == nil {
+		return nil, fmt.Errorf("repository '%s' not found", name)
 	}
-	
-	return os.WriteFile(outputFile, []byte(values), 0644)
-}
 
-func (c *Client) GenerateTemplate(chartName, valuesFile, outputPath string) error {
-	releaseName := "myrelease"
-	
-	args := []string{"template", releaseName, chartName, "--output-dir", outputPath}
-	if valuesFile != "" {
-		args = append(args, "-f", valuesFile)
+	info := &RepositoryInfo{
+		Name:      entry.Name,
+		URL:       entry.URL,
+		IndexPath: filepath.Join(c.settings.RepositoryCache, name+"-index.yaml"),
 	}
-	
-	cmd := exec.Command("helm", args...)
-	output, err := cmd.CombinedOutput()
+
+	stat, err := os.Stat(info.IndexPath)
 	if err != nil {
-		return fmt.Errorf("helm template failed: %w\nOutput: %s", err, string(output))
+		// No cached index at all: searching this repo will find nothing
+		info.Stale = true
+		return info, nil
 	}
-	return nil
-}
+	info.IndexSize = stat.Size()
+	info.CachedAt = stat.ModTime()
 
-func (c *Client) AddRepository(name, url string) error {
-	cmd := exec.Command("helm", "repo", "add", name, url)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("helm repo add failed: %w\nOutput: %s", err, string(output))
+	if index, err := c.loadIndex(name); err == nil {
+		info.Generated = index.Generated
+		info.ChartCount = len(index.Entries)
+		for _, versions := range index.Entries {
+			info.VersionCount += len(versions)
+		}
 	}
 
-	// Update repo dopo l'aggiunta
-	cmd = exec.Command("helm", "repo", "update", name)
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("helm repo update failed: %w", err)
+	if !isGit {
+		info.UpstreamModified = upstreamIndexModified(entry)
+	}
+	local := info.Generated
+	if local.IsZero() {
+		local = info.CachedAt
+	}
+	if !info.UpstreamModified.IsZero() && info.UpstreamModified.After(local) {
+		info.Stale = true
 	}
 
-	return nil
+	return info, nil
 }
 
-func (c *Client) RemoveRepository(name string) error {
-	cmd := exec.Command("helm", "repo", "remove", name)
-	output, err := cmd.CombinedOutput()
+// upstreamIndexModified asks the repository server when its index.yaml last
+// changed, without downloading it. Returns the zero time if unknown.
+func upstreamIndexModified(entry *repo.Entry) time.Time {
+	if !strings.HasPrefix(entry.URL, "http://") && !strings.HasPrefix(entry.URL, "https://") {
+		return time.Time{}
+	}
+
+	req, err := http.NewRequest(http.MethodHead, strings.TrimSuffix(entry.URL, "/")+"/index.yaml", nil)
 	if err != nil {
-		return fmt.Errorf("helm repo remove failed: %w\nOutput: %s", err, string(output))
+		return time.Time{}
+	}
+	if entry.Username != "" {
+		req.SetBasicAuth(entry.Username, entry.Password)
 	}
-	return nil
-}
 
-func (c *Client) UpdateRepository(name string) error {
-	args := []string{"repo", "update"}
-	if name != "" {
-		args = append(args, name)
+	httpClient := &http.Client{Timeout: 5 * time.Second}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return time.Time{}
 	}
+	defer resp.Body.Close()
 
-	cmd := exec.Command("helm", args...)
-	output, err := cmd.CombinedOutput()
+	if resp.StatusCode != http.StatusOK {
+		return time.Time{}
+	}
+	modified, err := http.ParseTime(resp.Header.Get("Last-Modified"))
 	if err != nil {
-		return fmt.Errorf("helm repo update failed: %w\nOutput: %s", err, string(output))
+		return time.Time{}
 	}
-	return nil
+	return modified
 }
 
 // Cluster Releases functionality
@@ -245,63 +1403,89 @@ type ReleaseRevision struct {
 }
 
 type ReleaseStatus struct {
-	Name        string
-	Namespace   string
-	Status      string
-	Description string
-	Notes       string
+	Name           string
+	Namespace      string
+	Revision       int
+	Status         string
+	Description    string
+	Notes          string
+	FirstDeployed  time.Time
+	LastDeployed   time.Time
+	DeployDuration time.Duration     // Zero when the storage record can't be read
+	Labels         map[string]string // User labels set with --labels
+	Annotations    map[string]string // Chart annotations
 }
 
 // ListReleases lists all Helm releases in the specified namespace
 // If namespace is empty, lists releases from all namespaces
-func (c *Client) ListReleases(namespace string) ([]Release, error) {
-	args := []string{"list", "--output", "json"}
-	if namespace == "" {
-		args = append(args, "-A") // All namespaces
-	} else {
-		args = append(args, "-n", namespace)
-	}
+func (c *Client) ListReleases(ctx context.Context, namespace string) ([]Release, error) {
+	return c.ListReleasesPage(ctx, namespace, "", 0, 0)
+}
 
-	cmd := exec.Command("helm", args...)
-	output, err := cmd.CombinedOutput()
+// ListReleasesPage lists at most max releases (sorted by name) starting at
+// offset. A max of 0 lists all of them. Cancelling ctx stops it with
+// ctx.Err().
+func (c *Client) ListReleasesPage(ctx context.Context, namespace, selector string, offset, max int) ([]Release, error) {
+	cfg, err := c.actionConfig(ctx, namespace)
 	if err != nil {
-		return nil, fmt.Errorf("helm list failed: %w\nOutput: %s", err, string(output))
-	}
-
-	var results []struct {
-		Name       string `json:"name"`
-		Namespace  string `json:"namespace"`
-		Revision   string `json:"revision"`
-		Updated    string `json:"updated"`
-		Status     string `json:"status"`
-		Chart      string `json:"chart"`
-		AppVersion string `json:"app_version"`
+		return nil, err
 	}
+	list := action.NewList(cfg)
+	list.AllNamespaces = namespace == ""
+	list.Selector = selector
+	list.Offset = offset
+	list.Limit = max
+	// Helm's default of deployed and failed would hide releases stuck in a
+	// pending state, which are the ones that need fixing
+	list.Deployed = true
+	list.Failed = true
+	list.Pending = true
+	list.SetStateMask()
 
-	if err := json.Unmarshal(output, &results); err != nil {
-		return nil, err
+	results, err := list.Run()
+	if err != nil {
+		return nil, canceled(ctx, fmt.Errorf("failed to list releases: %w", err))
 	}
 
 	releases := make([]Release, len(results))
 	for i, r := range results {
+		updated := "-"
+		if !r.Info.LastDeployed.IsZero() {
+			updated = r.Info.LastDeployed.String()
+		}
 		releases[i] = Release{
 			Name:       r.Name,
 			Namespace:  r.Namespace,
-			Revision:   r.Revision,
-			Updated:    r.Updated,
-			Status:     r.Status,
-			Chart:      r.Chart,
-			AppVersion: r.AppVersion,
+			Revision:   strconv.Itoa(r.Version),
+			Updated:    updated,
+			Status:     r.Info.Status.String(),
+			Chart:      chartFullName(r.Chart),
+			AppVersion: chartAppVersion(r.Chart),
 		}
 	}
 
 	return releases, nil
 }
 
+// chartFullName formats a release's chart as helm list does, e.g. nginx-1.2.3
+func chartFullName(chrt *chart.Chart) string {
+	if chrt == nil || chrt.Metadata == nil {
+		return "MISSING"
+	}
+	return fmt.Sprintf("%s-%s", chrt.Name(), chrt.Metadata.Version)
+}
+
+func chartAppVersion(chrt *chart.Chart) string {
+	if chrt == nil || chrt.Metadata == nil {
+		return "MISSING"
+	}
+	return chrt.AppVersion()
+}
+
 // ListNamespaces returns a list of namespaces that have Helm releases
-func (c *Client) ListNamespaces() ([]string, error) {
+func (c *Client) ListNamespaces(ctx context.Context) ([]string, error) {
 	// Get all releases to extract unique namespaces
-	releases, err := c.ListReleases("")
+	releases, err := c.ListReleases(ctx, "")
 	if err != nil {
 		return nil, err
 	}
@@ -321,40 +1505,33 @@ func (c *Client) ListNamespaces() ([]string, error) {
 }
 
 // GetReleaseHistory returns the revision history of a release
-func (c *Client) GetReleaseHistory(releaseName, namespace string) ([]ReleaseRevision, error) {
-	args := []string{"history", releaseName, "--output", "json"}
-	if namespace != "" {
-		args = append(args, "-n", namespace)
-	}
-
-	cmd := exec.Command("helm", args...)
-	output, err := cmd.CombinedOutput()
+func (c *Client) GetReleaseHistory(ctx context.Context, releaseName, namespace string) ([]ReleaseRevision, error) {
+	cfg, err := c.actionConfig(ctx, c.resolveNamespace(namespace))
 	if err != nil {
-		return nil, fmt.Errorf("helm history failed: %w\nOutput: %s", err, string(output))
+		return nil, err
 	}
+	history := action.NewHistory(cfg)
+	history.Max = 256
 
-	var results []struct {
-		Revision    int    `json:"revision"`
-		Updated     string `json:"updated"`
-		Status      string `json:"status"`
-		Chart       string `json:"chart"`
-		AppVersion  string `json:"app_version"`
-		Description string `json:"description"`
+	results, err := history.Run(releaseName)
+	if err != nil {
+		return nil, canceled(ctx, fmt.Errorf("failed to get history of '%s': %w", releaseName, err))
 	}
-
-	if err := json.Unmarshal(output, &results); err != nil {
-		return nil, err
+	releaseutil.SortByRevision(results)
+	// Like helm history, keep only the latest Max revisions
+	if len(results) > history.Max {
+		results = results[len(results)-history.Max:]
 	}
 
 	revisions := make([]ReleaseRevision, len(results))
 	for i, r := range results {
 		revisions[i] = ReleaseRevision{
-			Revision:    r.Revision,
-			Updated:     r.Updated,
-			Status:      r.Status,
-			Chart:       r.Chart,
-			AppVersion:  r.AppVersion,
-			Description: r.Description,
+			Revision:    r.Version,
+			Updated:     r.Info.LastDeployed.Format(time.RFC3339Nano),
+			Status:      r.Info.Status.String(),
+			Chart:       chartFullName(r.Chart),
+			AppVersion:  chartAppVersion(r.Chart),
+			Description: r.Info.Description,
 		}
 	}
 
@@ -362,86 +1539,555 @@ func (c *Client) GetReleaseHistory(releaseName, namespace string) ([]ReleaseRevi
 }
 
 // GetReleaseValues returns the values used for a specific release
-func (c *Client) GetReleaseValues(releaseName, namespace string) (string, error) {
-	args := []string{"get", "values", releaseName}
-	if namespace != "" {
-		args = append(args, "-n", namespace)
+func (c *Client) GetReleaseValues(ctx context.Context, releaseName, namespace string) (string, error) {
+	return c.GetReleaseValuesByRevision(ctx, releaseName, namespace, 0)
+}
+
+// GetReleaseValuesByRevision returns the values used for a specific release
+// revision; revision 0 is the latest one
+func (c *Client) GetReleaseValuesByRevision(ctx context.Context, releaseName, namespace string, revision int) (string, error) {
+	cfg, err := c.actionConfig(ctx, c.resolveNamespace(namespace))
+	if err != nil {
+		return "", err
 	}
+	getValues := action.NewGetValues(cfg)
+	getValues.Version = revision
 
-	cmd := exec.Command("helm", args...)
-	output, err := cmd.CombinedOutput()
+	vals, err := getValues.Run(releaseName)
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
 	if err != nil {
-		return "", fmt.Errorf("helm get values failed: %w\nOutput: %s", err, string(output))
+		if revision > 0 {
+			return "", fmt.Errorf("failed to get values of '%s' (revision %d): %w", releaseName, revision, err)
+		}
+		return "", fmt.Errorf("failed to get values of '%s': %w", releaseName, err)
+	}
+	if vals == nil {
+		vals = map[string]interface{}{}
 	}
 
+	output, err := yaml.Marshal(vals)
+	if err != nil {
+		return "", err
+	}
 	return string(output), nil
 }
 
-// GetReleaseValuesByRevision returns the values used for a specific release revision
-func (c *Client) GetReleaseValuesByRevision(releaseName, namespace string, revision int) (string, error) {
-	args := []string{"get", "values", releaseName, "--revision", fmt.Sprintf("%d", revision)}
-	if namespace != "" {
-		args = append(args, "-n", namespace)
+// GetReleaseChartValues returns the default values.yaml of the chart a
+// release revision was deployed with, like helm show values for that chart
+// version; revision 0 is the latest one
+func (c *Client) GetReleaseChartValues(ctx context.Context, releaseName, namespace string, revision int) (string, error) {
+	cfg, err := c.actionConfig(ctx, c.resolveNamespace(namespace))
+	if err != nil {
+		return "", err
 	}
+	get := action.NewGet(cfg)
+	get.Version = revision
 
-	cmd := exec.Command("helm", args...)
-	output, err := cmd.CombinedOutput()
+	rel, err := get.Run(releaseName)
 	if err != nil {
-		return "", fmt.Errorf("helm get values (revision %d) failed: %w\nOutput: %s", revision, err, string(output))
+		return "", canceled(ctx, fmt.Errorf("failed to get chart of '%s': %w", releaseName, err))
 	}
+	if rel.Chart == nil {
+		return "", fmt.Errorf("release '%s' has no chart", releaseName)
+	}
+	return chartValues(rel.Chart), nil
+}
 
-	return string(output), nil
+// GetReleaseManifest returns the rendered manifest of a release revision,
+// like helm get manifest --revision; revision 0 is the latest one
+func (c *Client) GetReleaseManifest(ctx context.Context, releaseName, namespace string, revision int) (string, error) {
+	cfg, err := c.actionConfig(ctx, c.resolveNamespace(namespace))
+	if err != nil {
+		return "", err
+	}
+	get := action.NewGet(cfg)
+	get.Version = revision
+
+	rel, err := get.Run(releaseName)
+	if err != nil {
+		return "", canceled(ctx, fmt.Errorf("failed to get manifest of '%s' (revision %d): %w", releaseName, revision, err))
+	}
+	return rel.Manifest, nil
+}
+
+// HasDiffPlugin reports whether the helm-diff plugin is installed, along
+// with the helm binary needed to run it
+func (c *Client) HasDiffPlugin() bool {
+	if _, err := exec.LookPath("helm"); err != nil {
+		return false
+	}
+	plugins, err := plugin.FindPlugins(c.settings.PluginsDirectory)
+	if err != nil {
+		return false
+	}
+	for _, p := range plugins {
+		if p.Metadata.Name == "diff" {
+			return true
+		}
+	}
+	return false
+}
+
+// DiffUpgradeOptions configures DiffUpgrade
+type DiffUpgradeOptions struct {
+	Chart      string   // Chart reference, e.g. bitnami/nginx
+	Version    string   // Empty means the latest version
+	ValuesFile string   // Empty reuses the values of the release
+	Set        []string // key=value overrides, like --set
+}
+
+// DiffUpgrade previews an upgrade of a release with helm diff upgrade,
+// returning its colored output. Nothing is changed in the cluster. Cancelling
+// ctx kills helm and returns ctx.Err().
+func (c *Client) DiffUpgrade(ctx context.Context, releaseName, namespace string, opts DiffUpgradeOptions) (string, error) {
+	chartRef := opts.Chart
+	// helm knows nothing of git sources: point it at the checkout
+	if dir, ok, err := c.gitChartDir(opts.Chart, opts.Version); ok {
+		if err != nil {
+			return "", err
+		}
+		chartRef, opts.Version = dir, ""
+	}
+	args := []string{"diff", "upgrade", releaseName, chartRef,
+		"--namespace", c.resolveNamespace(namespace),
+		"--repository-config", c.settings.RepositoryConfig,
+		"--color"}
+	if opts.Version != "" {
+		args = append(args, "--version", opts.Version)
+	}
+	if opts.ValuesFile != "" {
+		args = append(args, "--values", opts.ValuesFile)
+	} else {
+		args = append(args, "--reuse-values")
+	}
+	for _, override := range opts.Set {
+		args = append(args, "--set", override)
+	}
+	kubeContext, kubeConfig := c.kubeFlags()
+	if kubeContext != "" {
+		args = append(args, "--kube-context", kubeContext)
+	}
+	if kubeConfig != "" {
+		args = append(args, "--kubeconfig", kubeConfig)
+	}
+
+	cmd := exec.CommandContext(ctx, "helm", args...)
+	cmd.Env = append(os.Environ(), "HELM_DRIVER="+c.Driver())
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("helm diff upgrade failed: %s", msg)
+		}
+		return "", fmt.Errorf("helm diff upgrade failed: %w", err)
+	}
+	return stdout.String(), nil
+}
+
+// UninstallOptions configures UninstallRelease
+type UninstallOptions struct {
+	KeepHistory bool          // Keep the release records so it can be rolled back
+	Wait        bool          // Wait until all resources are deleted
+	Timeout     time.Duration // Bounds Wait so a stuck finalizer can't hang forever
+}
+
+// UninstallRelease removes a release from the cluster
+func (c *Client) UninstallRelease(releaseName, namespace string, opts UninstallOptions) error {
+	cfg, err := c.actionConfig(context.Background(), c.resolveNamespace(namespace))
+	if err != nil {
+		return err
+	}
+	uninstall := action.NewUninstall(cfg)
+	uninstall.KeepHistory = opts.KeepHistory
+	uninstall.Wait = opts.Wait
+	uninstall.DeletionPropagation = "background"
+	uninstall.Timeout = opts.Timeout
+
+	if _, err := uninstall.Run(releaseName); err != nil {
+		return timedOut(fmt.Errorf("failed to uninstall '%s': %w", releaseName, err), opts.Timeout)
+	}
+	return nil
+}
+
+// IsPendingStatus reports a release left in pending-install, pending-upgrade
+// or pending-rollback. While it is, helm refuses any other operation on the
+// release ("another operation is in progress").
+func IsPendingStatus(status string) bool {
+	return release.Status(status).IsPending()
+}
+
+// RollbackRelease rolls a release back like helm rollback; revision 0 is the
+// previous revision. timeout bounds how long it waits for the release
+// resources.
+func (c *Client) RollbackRelease(releaseName, namespace string, revision int, timeout time.Duration) error {
+	cfg, err := c.actionConfig(context.Background(), c.resolveNamespace(namespace))
+	if err != nil {
+		return err
+	}
+	rollback := action.NewRollback(cfg)
+	rollback.Version = revision
+	rollback.Timeout = timeout
+
+	if err := rollback.Run(releaseName); err != nil {
+		return timedOut(fmt.Errorf("failed to roll back '%s': %w", releaseName, err), timeout)
+	}
+	return nil
+}
+
+// timedOut tells an operation that ran out of its timeout apart from other
+// failures, since helm only reports it as a deadline or wait error
+func timedOut(err error, timeout time.Duration) error {
+	if !errors.Is(err, context.DeadlineExceeded) && !wait.Interrupted(err) &&
+		!strings.Contains(err.Error(), "timed out waiting for the condition") {
+		return err
+	}
+	return fmt.Errorf("timed out after %s: %w (the resources may still be getting ready: check the release events with E, raise operation_timeout in Settings, and fix the release with F if it's left pending)", timeout, err)
+}
+
+// UnlockRelease deletes the record of a release's pending revision (the
+// sh.helm.release.v1.<name>.v<revision> secret), which is what keeps helm
+// from running anything else on a stuck release. The release goes back to
+// its previous revision, or disappears if the pending revision was the first
+// install. Returns the deleted revision.
+func (c *Client) UnlockRelease(releaseName, namespace string) (int, error) {
+	cfg, err := c.actionConfig(context.Background(), c.resolveNamespace(namespace))
+	if err != nil {
+		return 0, err
+	}
+	last, err := cfg.Releases.Last(releaseName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get '%s': %w", releaseName, err)
+	}
+	if !last.Info.Status.IsPending() {
+		return 0, fmt.Errorf("release '%s' is %s, not pending", releaseName, last.Info.Status)
+	}
+	if _, err := cfg.Releases.Delete(releaseName, last.Version); err != nil {
+		return 0, fmt.Errorf("failed to delete revision %d of '%s': %w", last.Version, releaseName, err)
+	}
+	return last.Version, nil
+}
+
+// TestRelease runs the release's test hooks like helm test --logs, sending
+// the test pod logs to lines as the pods run, then the result of each test.
+// timeout bounds how long it waits for each test pod. lines is closed once
+// the tests are done; the returned error says whether they passed.
+func (c *Client) TestRelease(releaseName, namespace string, timeout time.Duration, lines chan<- string) error {
+	defer close(lines)
+
+	ns := c.resolveNamespace(namespace)
+	cfg, err := c.actionConfig(context.Background(), ns)
+	if err != nil {
+		return err
+	}
+	clientset, err := cfg.KubernetesClientSet()
+	if err != nil {
+		return err
+	}
+	test := action.NewReleaseTesting(cfg)
+	test.Namespace = ns
+	test.Timeout = timeout
+
+	// helm only returns once every test pod has finished, so the logs are
+	// followed while it runs. Pods left by an earlier run are told apart by
+	// their UID.
+	var pods []string
+	previous := make(map[string]types.UID)
+	if last, err := cfg.Releases.Last(releaseName); err == nil {
+		pods = testPods(last)
+		for _, pod := range pods {
+			if p, err := clientset.CoreV1().Pods(ns).Get(context.Background(), pod, metav1.GetOptions{}); err == nil {
+				previous[pod] = p.UID
+			}
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	followed := make(chan struct{})
+	go func() {
+		defer close(followed)
+		followTestPods(ctx, clientset, ns, pods, previous, done, lines)
+	}()
+
+	lines <- fmt.Sprintf("Running tests for %s in %s...", releaseName, ns)
+	rel, runErr := test.Run(releaseName)
+	close(done)
+	// The last pod has finished, so its log ends shortly
+	select {
+	case <-followed:
+	case <-time.After(10 * time.Second):
+		cancel()
+		<-followed
+	}
+	if rel == nil {
+		return fmt.Errorf("helm test failed: %w", runErr)
+	}
+
+	for _, hook := range rel.Hooks {
+		if !slices.Contains(hook.Events, release.HookTest) {
+			continue
+		}
+		lines <- fmt.Sprintf("TEST SUITE:     %s", hook.Name)
+		lines <- fmt.Sprintf("Last Started:   %s", hook.LastRun.StartedAt.Format(time.ANSIC))
+		lines <- fmt.Sprintf("Last Completed: %s", hook.LastRun.CompletedAt.Format(time.ANSIC))
+		lines <- fmt.Sprintf("Phase:          %s", hook.LastRun.Phase)
+	}
+
+	if runErr != nil {
+		return timedOut(fmt.Errorf("helm test failed: %w", runErr), timeout)
+	}
+	return nil
+}
+
+// testPods returns the names of the test pods of a release in the order helm
+// runs them: by hook weight, then by name
+func testPods(rel *release.Release) []string {
+	var hooks []*release.Hook
+	for _, hook := range rel.Hooks {
+		if hook.Kind == "Pod" && slices.Contains(hook.Events, release.HookTest) {
+			hooks = append(hooks, hook)
+		}
+	}
+	sort.SliceStable(hooks, func(i, j int) bool {
+		if hooks[i].Weight != hooks[j].Weight {
+			return hooks[i].Weight < hooks[j].Weight
+		}
+		return hooks[i].Name < hooks[j].Name
+	})
+	pods := make([]string, len(hooks))
+	for i, hook := range hooks {
+		pods[i] = hook.Name
+	}
+	return pods
+}
+
+// followTestPods sends the logs of each test pod to lines once helm has
+// created and started it. After done is closed, pods that never showed up
+// are only looked for once, since they won't be created anymore.
+func followTestPods(ctx context.Context, clientset kubernetes.Interface, namespace string, pods []string, previous map[string]types.UID, done <-chan struct{}, lines chan<- string) {
+	started := func(pod string) bool {
+		p, err := clientset.CoreV1().Pods(namespace).Get(ctx, pod, metav1.GetOptions{})
+		return err == nil && p.UID != previous[pod] && p.Status.Phase != corev1.PodPending
+	}
+	waitStarted := func(pod string) bool {
+		for !started(pod) {
+			select {
+			case <-done:
+				return false
+			case <-ctx.Done():
+				return false
+			case <-time.After(time.Second):
+			}
+		}
+		return true
+	}
+
+	for _, pod := range pods {
+		if !waitStarted(pod) {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+		lines <- "POD LOGS: " + pod
+		stream, err := clientset.CoreV1().Pods(namespace).GetLogs(pod, &corev1.PodLogOptions{Follow: true}).Stream(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			lines <- fmt.Sprintf("failed to get logs of %s: %v", pod, err)
+			continue
+		}
+		scanner := bufio.NewScanner(stream)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		stream.Close()
+	}
 }
 
 // GetReleaseStatus returns the status of a release
-func (c *Client) GetReleaseStatus(releaseName, namespace string) (*ReleaseStatus, error) {
-	args := []string{"status", releaseName, "--output", "json"}
-	if namespace != "" {
-		args = append(args, "-n", namespace)
+func (c *Client) GetReleaseStatus(ctx context.Context, releaseName, namespace string) (*ReleaseStatus, error) {
+	cfg, err := c.actionConfig(ctx, c.resolveNamespace(namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	rel, err := action.NewStatus(cfg).Run(releaseName)
+	if err != nil {
+		return nil, canceled(ctx, fmt.Errorf("failed to get status of '%s': %w", releaseName, err))
+	}
+
+	status := &ReleaseStatus{
+		Name:          rel.Name,
+		Namespace:     rel.Namespace,
+		Revision:      rel.Version,
+		Status:        rel.Info.Status.String(),
+		Description:   rel.Info.Description,
+		Notes:         rel.Info.Notes,
+		FirstDeployed: rel.Info.FirstDeployed.Time,
+		LastDeployed:  rel.Info.LastDeployed.Time,
+		Labels:        rel.Labels, // The storage driver already strips its own labels
 	}
+	if rel.Chart != nil && rel.Chart.Metadata != nil {
+		status.Annotations = rel.Chart.Metadata.Annotations
+	}
+	if labels, err := c.releaseRecordLabels(ctx, cfg, rel.Name, rel.Namespace, rel.Version); err == nil {
+		status.DeployDuration, _ = deployDuration(labels)
+	}
+	return status, nil
+}
 
-	cmd := exec.Command("helm", args...)
-	output, err := cmd.CombinedOutput()
+// GetReleaseNotes returns the NOTES.txt rendered for the current revision of
+// a release, like helm get notes
+func (c *Client) GetReleaseNotes(ctx context.Context, releaseName, namespace string) (string, error) {
+	cfg, err := c.actionConfig(ctx, c.resolveNamespace(namespace))
 	if err != nil {
-		return nil, fmt.Errorf("helm status failed: %w\nOutput: %s", err, string(output))
+		return "", err
 	}
 
-	var result struct {
-		Name      string `json:"name"`
-		Namespace string `json:"namespace"`
-		Info      struct {
-			Status      string `json:"status"`
-			Description string `json:"description"`
-			Notes       string `json:"notes"`
-		} `json:"info"`
+	rel, err := action.NewGet(cfg).Run(releaseName)
+	if err != nil {
+		return "", canceled(ctx, fmt.Errorf("failed to get notes of '%s': %w", releaseName, err))
 	}
+	return rel.Info.Notes, nil
+}
 
-	if err := json.Unmarshal(output, &result); err != nil {
+// releaseRecordLabels reads the labels of the storage record (secret or
+// configmap) Helm keeps for a release revision. The driver doesn't hand out
+// its own labels, createdAt/modifiedAt among them.
+func (c *Client) releaseRecordLabels(ctx context.Context, cfg *action.Configuration, releaseName, namespace string, revision int) (map[string]string, error) {
+	clientset, err := cfg.KubernetesClientSet()
+	if err != nil {
 		return nil, err
 	}
 
-	return &ReleaseStatus{
-		Name:        result.Name,
-		Namespace:   result.Namespace,
-		Status:      result.Info.Status,
-		Description: result.Info.Description,
-		Notes:       result.Info.Notes,
-	}, nil
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	opts := metav1.ListOptions{LabelSelector: fmt.Sprintf("owner=helm,name=%s,version=%d", releaseName, revision)}
+
+	var items []metav1.ObjectMeta
+	switch c.Driver() {
+	case "configmap", "configmaps":
+		list, err := clientset.CoreV1().ConfigMaps(namespace).List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read release record: %w", err)
+		}
+		for _, item := range list.Items {
+			items = append(items, item.ObjectMeta)
+		}
+	case "", "secret", "secrets":
+		list, err := clientset.CoreV1().Secrets(namespace).List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read release record: %w", err)
+		}
+		for _, item := range list.Items {
+			items = append(items, item.ObjectMeta)
+		}
+	default:
+		return nil, fmt.Errorf("storage driver %s has no record labels", c.Driver())
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("release record not found")
+	}
+	return items[0].Labels, nil
 }
 
-// GetCurrentContext returns the current kubectl context
-func (c *Client) GetCurrentContext() (string, error) {
-	cmd := exec.Command("kubectl", "config", "current-context")
-	output, err := cmd.CombinedOutput()
+// deployDuration measures how long a revision took to install or upgrade.
+// Helm only records when a deployment started, so this reads the
+// createdAt/modifiedAt labels of the release storage record: the record is
+// created pending and updated once the deployment finishes.
+func deployDuration(labels map[string]string) (time.Duration, error) {
+	created, err1 := strconv.ParseInt(labels["createdAt"], 10, 64)
+	modified, err2 := strconv.ParseInt(labels["modifiedAt"], 10, 64)
+	if err1 != nil || err2 != nil || modified < created {
+		return 0, fmt.Errorf("release record has no timestamps")
+	}
+	return time.Duration(modified-created) * time.Second, nil
+}
+
+// GetClusterVersion returns the Kubernetes version of the current context's
+// API server, e.g. v1.29.4
+func (c *Client) GetClusterVersion(ctx context.Context) (string, error) {
+	config, err := c.restClientGetter(ctx, c.resolveNamespace("")).ToRESTConfig()
+	if err != nil {
+		return "", fmt.Errorf("failed to get cluster version: %w", err)
+	}
+	config = rest.CopyConfig(config)
+	config.Timeout = 5 * time.Second
+
+	client, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return "", fmt.Errorf("failed to get cluster version: %w", err)
+	}
+	version, err := client.ServerVersion()
+	if err != nil {
+		return "", canceled(ctx, fmt.Errorf("failed to get cluster version: %w", err))
+	}
+	if version.GitVersion == "" {
+		return "", fmt.Errorf("cluster did not report a version")
+	}
+	return version.GitVersion, nil
+}
+
+// KubeContext is a context defined in the kubeconfig
+type KubeContext struct {
+	Name      string
+	Cluster   string
+	Namespace string
+	Current   bool // The kubeconfig's current-context
+}
+
+// ListContexts returns the contexts of the kubeconfig, sorted by name
+func (c *Client) ListContexts() ([]KubeContext, error) {
+	config, err := c.restClientGetter(context.Background(), "").ToRawKubeConfigLoader().RawConfig()
 	if err != nil {
-		return "", fmt.Errorf("failed to get kubectl context: %w\nOutput: %s", err, string(output))
+		return nil, fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+
+	contexts := make([]KubeContext, 0, len(config.Contexts))
+	for name, ctx := range config.Contexts {
+		contexts = append(contexts, KubeContext{
+			Name:      name,
+			Cluster:   ctx.Cluster,
+			Namespace: ctx.Namespace,
+			Current:   name == config.CurrentContext,
+		})
 	}
+	sort.Slice(contexts, func(i, j int) bool { return contexts[i].Name < contexts[j].Name })
+	return contexts, nil
+}
+
+// SetContext runs every following cluster operation against the kube context
+// name, like --kube-context. An empty name uses the kubeconfig's current
+// context again. Loads already running may finish against either context.
+func (c *Client) SetContext(name string) {
+	c.kubeMu.Lock()
+	defer c.kubeMu.Unlock()
+	c.settings.KubeContext = name
+}
 
-	context := string(output)
-	// Trim newline
-	if len(context) > 0 && context[len(context)-1] == '\n' {
-		context = context[:len(context)-1]
+// GetCurrentContext returns the kube context in use: --kube-context or
+// HELM_KUBECONTEXT when set, otherwise the kubeconfig's current context
+func (c *Client) GetCurrentContext() (string, error) {
+	if kubeContext, _ := c.kubeFlags(); kubeContext != "" {
+		return kubeContext, nil
 	}
 
-	return context, nil
+	config, err := c.restClientGetter(context.Background(), "").ToRawKubeConfigLoader().RawConfig()
+	if err != nil {
+		return "", fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+	if config.CurrentContext == "" {
+		return "", fmt.Errorf("no current kube context")
+	}
+	return config.CurrentContext, nil
 }
@@ -0,0 +1,267 @@
+// Copyright 2025 Alessandro Pitocchi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/action"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apiresource "k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// FieldDrift is a field whose live value differs from the release manifest
+type FieldDrift struct {
+	Path     string // e.g. spec.template.spec.containers[0].image
+	Expected string // JSON encoded, as rendered by helm
+	Live     string // JSON encoded, empty when the field was removed
+}
+
+// ResourceDrift is a release object changed out-of-band
+type ResourceDrift struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Missing   bool // Deleted from the cluster
+	Fields    []FieldDrift
+}
+
+// ReleaseDrift compares the manifest of a release with the live cluster
+type ReleaseDrift struct {
+	Checked   int // Objects in the manifest
+	Resources []ResourceDrift
+}
+
+// GetReleaseDrift fetches every object of the current release manifest (like
+// helm get manifest | kubectl get -f -) and reports the fields set by the
+// manifest whose live value differs. Fields the manifest doesn't set, like
+// status or server defaults, aren't compared.
+func (c *Client) GetReleaseDrift(ctx context.Context, releaseName, namespace string) (*ReleaseDrift, error) {
+	cfg, err := c.actionConfig(ctx, c.resolveNamespace(namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := c.releaseObjects(ctx, cfg, releaseName)
+	if err != nil {
+		return nil, err
+	}
+
+	drift := &ReleaseDrift{Checked: len(objects)}
+	for _, obj := range objects {
+		res := ResourceDrift{Kind: obj.kind, Namespace: obj.namespace, Name: obj.name}
+		if obj.live == nil {
+			res.Missing = true
+			drift.Resources = append(drift.Resources, res)
+			continue
+		}
+		compareFields(normalizeManifest(obj.expected), obj.live, "", &res.Fields)
+		if len(res.Fields) > 0 {
+			drift.Resources = append(drift.Resources, res)
+		}
+	}
+
+	sort.Slice(drift.Resources, func(i, j int) bool {
+		a, b := drift.Resources[i], drift.Resources[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Namespace+"/"+a.Name < b.Namespace+"/"+b.Name
+	})
+	return drift, nil
+}
+
+// releaseObject is an object of a release manifest with its live state
+type releaseObject struct {
+	kind      string
+	namespace string
+	name      string
+	expected  map[string]interface{} // As rendered in the manifest
+	live      map[string]interface{} // Nil when the object doesn't exist
+}
+
+// releaseObjects fetches the live state of every object in the current
+// manifest of a release, in manifest order. It stops with ctx.Err() once ctx
+// is cancelled.
+func (c *Client) releaseObjects(ctx context.Context, cfg *action.Configuration, releaseName string) ([]releaseObject, error) {
+	rel, err := action.NewGet(cfg).Run(releaseName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get manifest of '%s': %w", releaseName, err)
+	}
+	resources, err := cfg.KubeClient.Build(bytes.NewBufferString(rel.Manifest), false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse manifest of '%s': %w", releaseName, err)
+	}
+
+	objects := make([]releaseObject, 0, len(resources))
+	for _, info := range resources {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		expected, ok := info.Object.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		obj := releaseObject{kind: expected.GetKind(), namespace: info.Namespace, name: info.Name, expected: expected.Object}
+
+		live, err := resource.NewHelper(info.Client, info.Mapping).Get(info.Namespace, info.Name)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get %s %s: %w", obj.kind, obj.name, err)
+		}
+		if err == nil {
+			if obj.live, err = runtime.DefaultUnstructuredConverter.ToUnstructured(live); err != nil {
+				return nil, err
+			}
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// compareFields walks the fields set in expected and records those whose
+// value in live differs. Maps are compared key by key and lists of the same
+// length item by item; anything else is compared as a whole.
+func compareFields(expected, live interface{}, path string, fields *[]FieldDrift) {
+	switch exp := expected.(type) {
+	case map[string]interface{}:
+		liveMap, ok := live.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(exp))
+		for key := range exp {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if path == "" && key == "status" {
+				continue
+			}
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			liveValue, exists := liveMap[key]
+			if !exists {
+				if !isEmptyValue(exp[key]) {
+					*fields = append(*fields, FieldDrift{Path: childPath, Expected: jsonValue(exp[key])})
+				}
+				continue
+			}
+			compareFields(exp[key], liveValue, childPath, fields)
+		}
+		return
+	case []interface{}:
+		liveList, ok := live.([]interface{})
+		if !ok || len(liveList) != len(exp) {
+			break
+		}
+		for i := range exp {
+			compareFields(exp[i], liveList[i], path+"["+strconv.Itoa(i)+"]", fields)
+		}
+		return
+	}
+
+	if !sameValue(path, expected, live) {
+		*fields = append(*fields, FieldDrift{Path: path, Expected: jsonValue(expected), Live: jsonValue(live)})
+	}
+}
+
+// normalizeManifest rewrites what the API server stores differently from the
+// manifest: a Secret's stringData is merged into data, base64 encoded, and
+// never read back
+func normalizeManifest(obj map[string]interface{}) map[string]interface{} {
+	stringData, ok := obj["stringData"].(map[string]interface{})
+	if obj["kind"] != "Secret" || !ok {
+		return obj
+	}
+	normalized := make(map[string]interface{}, len(obj))
+	for key, value := range obj {
+		normalized[key] = value
+	}
+	delete(normalized, "stringData")
+	data := make(map[string]interface{})
+	if existing, ok := obj["data"].(map[string]interface{}); ok {
+		for key, value := range existing {
+			data[key] = value
+		}
+	}
+	for key, value := range stringData {
+		data[key] = base64.StdEncoding.EncodeToString([]byte(fmt.Sprint(value)))
+	}
+	normalized["data"] = data
+	return normalized
+}
+
+// sameValue compares decoded JSON values, treating 1 and 1.0 alike, and
+// quantities such as 1000m and 1 alike under path
+func sameValue(path string, a, b interface{}) bool {
+	if reflect.DeepEqual(a, b) || jsonValue(a) == jsonValue(b) {
+		return true
+	}
+	if !isQuantityPath(path) {
+		return false
+	}
+	qa, errA := apiresource.ParseQuantity(strings.Trim(jsonValue(a), `"`))
+	qb, errB := apiresource.ParseQuantity(strings.Trim(jsonValue(b), `"`))
+	return errA == nil && errB == nil && qa.Cmp(qb) == 0
+}
+
+// isQuantityPath reports fields holding resource quantities, which the API
+// server stores in canonical form: container resources, quota limits,
+// volume sizes and capacities
+func isQuantityPath(path string) bool {
+	segments := strings.Split(path, ".")
+	if len(segments) < 2 {
+		return false
+	}
+	switch segments[len(segments)-2] {
+	case "limits", "requests", "hard", "capacity", "overhead":
+		return true
+	}
+	return segments[len(segments)-1] == "sizeLimit"
+}
+
+// isEmptyValue reports values the API server drops when unset, e.g. {} or null
+func isEmptyValue(v interface{}) bool {
+	switch value := v.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		return len(value) == 0
+	case []interface{}:
+		return len(value) == 0
+	}
+	return false
+}
+
+func jsonValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,173 @@
+// Copyright 2025 Alessandro Pitocchi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/resource"
+	"sigs.k8s.io/yaml"
+)
+
+// DryRunOptions configures DryRun
+type DryRunOptions struct {
+	Chart      string   // Chart reference, e.g. bitnami/nginx
+	Version    string   // Empty means the latest version
+	ValuesFile string   // Empty reuses the values of the release, if it exists
+	Set        []string // key=value overrides, like --set
+}
+
+// DryRunObject is an object of a release as the API server would store it
+type DryRunObject struct {
+	Source    string // Template it was rendered from, e.g. mychart/templates/service.yaml
+	Kind      string
+	Namespace string
+	Name      string
+	Manifest  string // With server defaults filled in, or as rendered when rejected
+	Err       error  // Why the API server or an admission webhook rejected it
+}
+
+// DryRunResult is the outcome of DryRun
+type DryRunResult struct {
+	Upgrade bool // The release exists, so it was upgraded rather than installed
+	Objects []DryRunObject
+}
+
+// DryRun installs a release, or upgrades it when it exists, with
+// --dry-run=server, then sends each rendered object to the API server as a
+// dry-run server-side apply. That fills in server defaults and runs admission
+// webhooks without changing anything. Hooks are left out, as an install
+// would only create them later. Objects the API server rejects are returned
+// with their error; an error is only returned when helm itself fails.
+func (c *Client) DryRun(ctx context.Context, releaseName, namespace string, opts DryRunOptions) (*DryRunResult, error) {
+	ns := c.resolveNamespace(namespace)
+	cfg, err := c.actionConfig(ctx, ns)
+	if err != nil {
+		return nil, err
+	}
+	chrt, err := c.loadChart(opts.Chart, opts.Version)
+	if err != nil {
+		return nil, err
+	}
+	valueOpts := values.Options{Values: opts.Set}
+	if opts.ValuesFile != "" {
+		valueOpts.ValueFiles = []string{opts.ValuesFile}
+	}
+	vals, err := valueOpts.MergeValues(getter.All(c.settings))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read values: %w", err)
+	}
+
+	history, err := cfg.Releases.History(releaseName)
+	if err != nil && !errors.Is(err, driver.ErrReleaseNotFound) {
+		return nil, fmt.Errorf("failed to get '%s': %w", releaseName, err)
+	}
+	result := &DryRunResult{Upgrade: len(history) > 0}
+
+	var rel *release.Release
+	if result.Upgrade {
+		upgrade := action.NewUpgrade(cfg)
+		upgrade.DryRun = true
+		upgrade.DryRunOption = "server"
+		upgrade.Namespace = ns
+		upgrade.Version = opts.Version
+		upgrade.ReuseValues = opts.ValuesFile == ""
+		rel, err = upgrade.RunWithContext(ctx, releaseName, chrt, vals)
+	} else {
+		install := action.NewInstall(cfg)
+		install.DryRun = true
+		install.DryRunOption = "server"
+		install.ReleaseName = releaseName
+		install.Namespace = ns
+		install.Version = opts.Version
+		rel, err = install.RunWithContext(ctx, chrt, vals)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("dry run of '%s' failed: %w", releaseName, err)
+	}
+
+	for _, doc := range strings.Split(rel.Manifest, "---\n# Source: ") {
+		source, body, ok := strings.Cut(doc, "\n")
+		if !ok || strings.TrimSpace(body) == "" {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		resources, err := cfg.KubeClient.Build(bytes.NewBufferString(body), false)
+		if err != nil {
+			result.Objects = append(result.Objects, DryRunObject{Source: source, Manifest: strings.TrimSpace(body), Err: err})
+			continue
+		}
+		for _, info := range resources {
+			result.Objects = append(result.Objects, dryRunApply(info, source))
+		}
+	}
+	return result, nil
+}
+
+// dryRunApply sends an object to the API server as a dry-run server-side
+// apply, the way helm's field manager would own it
+func dryRunApply(info *resource.Info, source string) DryRunObject {
+	obj := DryRunObject{Source: source, Kind: info.Mapping.GroupVersionKind.Kind, Namespace: info.Namespace, Name: info.Name}
+	rendered, ok := info.Object.(*unstructured.Unstructured)
+	if !ok {
+		obj.Err = fmt.Errorf("unexpected object %T", info.Object)
+		return obj
+	}
+	if data, err := yaml.Marshal(rendered.Object); err == nil {
+		obj.Manifest = strings.TrimSpace(string(data))
+	}
+
+	data, err := json.Marshal(rendered.Object)
+	if err != nil {
+		obj.Err = err
+		return obj
+	}
+	force := true
+	applied, err := resource.NewHelper(info.Client, info.Mapping).
+		DryRun(true).
+		WithFieldManager("helm").
+		Patch(info.Namespace, info.Name, types.ApplyPatchType, data, &metav1.PatchOptions{Force: &force})
+	if err != nil {
+		obj.Err = err
+		return obj
+	}
+	stored, err := runtime.DefaultUnstructuredConverter.ToUnstructured(applied)
+	if err != nil {
+		obj.Err = err
+		return obj
+	}
+	// Server bookkeeping only gets in the way of reading the object
+	unstructured.RemoveNestedField(stored, "metadata", "managedFields")
+	if data, err := yaml.Marshal(stored); err == nil {
+		obj.Manifest = strings.TrimSpace(string(data))
+	}
+	return obj
+}
@@ -0,0 +1,131 @@
+// Copyright 2025 Alessandro Pitocchi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ReleaseEvent is a Kubernetes event about an object of a release
+type ReleaseEvent struct {
+	Type     string // Normal or Warning
+	Reason   string // e.g. FailedScheduling, BackOff
+	Object   string // e.g. Pod/web-5d9c7b-x2k4f
+	Message  string
+	Count    int32
+	LastSeen time.Time
+}
+
+// GetReleaseEvents lists the events about the objects of a release, like
+// kubectl get events filtered on the release manifest. Events about the
+// ReplicaSets and pods a workload creates are included too, matched on the
+// workload name prefix. Warnings come first, then the most recent events.
+func (c *Client) GetReleaseEvents(ctx context.Context, releaseName, namespace string) ([]ReleaseEvent, error) {
+	ns := c.resolveNamespace(namespace)
+	cfg, err := c.actionConfig(ctx, ns)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := c.releaseObjects(ctx, cfg, releaseName)
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := cfg.KubernetesClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := map[string]bool{ns: true}
+	names := make(map[string]bool)
+	var prefixes []string
+	for _, obj := range objects {
+		if obj.namespace != "" {
+			namespaces[obj.namespace] = true
+		}
+		names[obj.kind+"/"+obj.name] = true
+		switch obj.kind {
+		case "Deployment", "StatefulSet", "DaemonSet", "Job", "CronJob":
+			prefixes = append(prefixes, obj.name+"-")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var events []ReleaseEvent
+	for eventNamespace := range namespaces {
+		list, err := clientset.CoreV1().Events(eventNamespace).List(ctx, metav1.ListOptions{})
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list events in %s: %w", eventNamespace, err)
+		}
+		for _, ev := range list.Items {
+			object := ev.InvolvedObject.Kind + "/" + ev.InvolvedObject.Name
+			if !names[object] && !hasAnyPrefix(ev.InvolvedObject.Name, prefixes) {
+				continue
+			}
+			events = append(events, ReleaseEvent{
+				Type:     ev.Type,
+				Reason:   ev.Reason,
+				Object:   object,
+				Message:  strings.TrimSpace(ev.Message),
+				Count:    max(ev.Count, 1),
+				LastSeen: eventTime(ev),
+			})
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		wi, wj := events[i].Type == corev1.EventTypeWarning, events[j].Type == corev1.EventTypeWarning
+		if wi != wj {
+			return wi
+		}
+		return events[i].LastSeen.After(events[j].LastSeen)
+	})
+	return events, nil
+}
+
+// eventTime is when an event was last seen; newer clients only set
+// EventTime or the series, older ones LastTimestamp
+func eventTime(ev corev1.Event) time.Time {
+	switch {
+	case ev.Series != nil && !ev.Series.LastObservedTime.IsZero():
+		return ev.Series.LastObservedTime.Time
+	case !ev.LastTimestamp.IsZero():
+		return ev.LastTimestamp.Time
+	case !ev.EventTime.IsZero():
+		return ev.EventTime.Time
+	}
+	return ev.CreationTimestamp.Time
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,304 @@
+// Copyright 2025 Alessandro Pitocchi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/repo"
+	"sigs.k8s.io/yaml"
+)
+
+// GitSource is a git repository whose charts, e.g. those kept in an app
+// monorepo, are browsed like the charts of a chart repository named Name
+type GitSource struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	Ref  string `json:"ref,omitempty"`  // Branch or tag; the default branch when empty
+	Path string `json:"path,omitempty"` // Directory the charts are searched in, or a chart; the root when empty
+}
+
+// String describes where the charts come from, e.g.
+// https://github.com/org/app.git@main:deploy/charts
+func (s GitSource) String() string {
+	location := s.URL
+	if s.Ref != "" {
+		location += "@" + s.Ref
+	}
+	if s.Path != "" {
+		location += ":" + s.Path
+	}
+	return location
+}
+
+// IsGitURL reports whether a repository URL is a git repository rather than
+// a chart repository: an scp-like or ssh URL, or one ending in .git
+func IsGitURL(rawURL string) bool {
+	u := strings.TrimSpace(rawURL)
+	return strings.HasPrefix(u, "git@") || strings.HasPrefix(u, "ssh://") ||
+		strings.HasPrefix(u, "git://") || strings.HasSuffix(strings.TrimRight(u, "/"), ".git")
+}
+
+// gitSourcesFile keeps the git sources next to the repositories file, so
+// --repository-config switches both
+func (c *Client) gitSourcesFile() string {
+	return strings.TrimSuffix(c.settings.RepositoryConfig, filepath.Ext(c.settings.RepositoryConfig)) + "-git.yaml"
+}
+
+// gitSources reads the configured git sources
+func (c *Client) gitSources() ([]GitSource, error) {
+	data, err := os.ReadFile(c.gitSourcesFile())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load git sources: %w", err)
+	}
+	var sources []GitSource
+	if err := yaml.Unmarshal(data, &sources); err != nil {
+		return nil, fmt.Errorf("invalid git sources %s: %w", c.gitSourcesFile(), err)
+	}
+	return sources, nil
+}
+
+func (c *Client) saveGitSources(sources []GitSource) error {
+	data, err := yaml.Marshal(sources)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.gitSourcesFile()), 0755); err != nil {
+		return fmt.Errorf("failed to create repository config directory: %w", err)
+	}
+	if err := os.WriteFile(c.gitSourcesFile(), data, 0644); err != nil {
+		return fmt.Errorf("failed to write git sources: %w", err)
+	}
+	return nil
+}
+
+// gitSource returns the git source named name, if there is one
+func (c *Client) gitSource(name string) (GitSource, bool) {
+	sources, _ := c.gitSources()
+	for _, src := range sources {
+		if src.Name == name {
+			return src, true
+		}
+	}
+	return GitSource{}, false
+}
+
+// AddGitSource clones a git repository and indexes its charts, then saves
+// it as a chart source. Its name shares the namespace of repositories.
+func (c *Client) AddGitSource(src GitSource) error {
+	if err := validateGitSource(src); err != nil {
+		return err
+	}
+	if f, err := repo.LoadFile(c.settings.RepositoryConfig); err == nil && f.Has(src.Name) {
+		return fmt.Errorf("repository '%s' already exists", src.Name)
+	}
+	sources, err := c.gitSources()
+	if err != nil {
+		return err
+	}
+	for _, existing := range sources {
+		if existing.Name == src.Name {
+			return fmt.Errorf("repository '%s' already exists", src.Name)
+		}
+	}
+
+	// Clone first so a bad URL, ref or path never ends up in the file
+	if err := c.syncGitSource(src); err != nil {
+		os.RemoveAll(c.gitCheckout(src.Name))
+		return err
+	}
+	return c.saveGitSources(append(sources, src))
+}
+
+// removeGitSource drops a git source with its checkout and index
+func (c *Client) removeGitSource(name string) error {
+	if err := validateRepoName(name); err != nil {
+		return err
+	}
+	sources, err := c.gitSources()
+	if err != nil {
+		return err
+	}
+	kept := make([]GitSource, 0, len(sources))
+	for _, src := range sources {
+		if src.Name != name {
+			kept = append(kept, src)
+		}
+	}
+	if err := c.saveGitSources(kept); err != nil {
+		return err
+	}
+	os.RemoveAll(c.gitCheckout(name))
+	os.Remove(filepath.Join(c.settings.RepositoryCache, name+"-index.yaml"))
+	return nil
+}
+
+// validateGitSource checks the name, used as a path in the repository cache,
+// and the ref, passed to git, which mustn't read as an option
+func validateGitSource(src GitSource) error {
+	if err := validateRepoName(src.Name); err != nil {
+		return err
+	}
+	if strings.HasPrefix(src.Ref, "-") {
+		return fmt.Errorf("invalid ref '%s'", src.Ref)
+	}
+	return nil
+}
+
+// gitCheckout is where a git source is cloned, in helm's repository cache
+func (c *Client) gitCheckout(name string) string {
+	return filepath.Join(c.settings.RepositoryCache, "git", name)
+}
+
+// syncGitSource shallow clones a git source, or fetches the latest commit of
+// its ref when it's already cloned, then writes an index of its charts to
+// the repository cache, where the charts of chart repositories are searched
+func (c *Client) syncGitSource(src GitSource) error {
+	// The sources file can be edited by hand
+	if err := validateGitSource(src); err != nil {
+		return err
+	}
+	dir := c.gitCheckout(src.Name)
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		ref := src.Ref
+		if ref == "" {
+			ref = "HEAD"
+		}
+		if err := runGit(dir, "fetch", "--depth", "1", "--", "origin", ref); err != nil {
+			return fmt.Errorf("failed to fetch '%s' (%s): %w", src.Name, src, err)
+		}
+		if err := runGit(dir, "reset", "--hard", "FETCH_HEAD"); err != nil {
+			return fmt.Errorf("failed to check out '%s' (%s): %w", src.Name, src, err)
+		}
+	} else {
+		if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+			return fmt.Errorf("failed to create git cache directory: %w", err)
+		}
+		os.RemoveAll(dir)
+		args := []string{"clone", "--depth", "1"}
+		if src.Ref != "" {
+			args = append(args, "--branch", src.Ref)
+		}
+		if err := runGit("", append(args, "--", src.URL, dir)...); err != nil {
+			return fmt.Errorf("failed to clone '%s' (%s): %w", src.Name, src, err)
+		}
+	}
+
+	index, err := indexGitCharts(dir, src.Path)
+	if err != nil {
+		return fmt.Errorf("failed to index '%s' (%s): %w", src.Name, src, err)
+	}
+	if len(index.Entries) == 0 {
+		return fmt.Errorf("no charts found in '%s' (%s)", src.Name, src)
+	}
+	if err := os.MkdirAll(c.settings.RepositoryCache, 0755); err != nil {
+		return fmt.Errorf("failed to create repository cache: %w", err)
+	}
+	return index.WriteFile(filepath.Join(c.settings.RepositoryCache, src.Name+"-index.yaml"), 0644)
+}
+
+// indexGitCharts indexes the charts under path of a checkout, each with the
+// chart directory, relative to the checkout, as its URL. Subcharts in a
+// chart's charts/ directory are left out.
+func indexGitCharts(checkout, path string) (*repo.IndexFile, error) {
+	root := filepath.Join(checkout, filepath.FromSlash(path))
+	if _, err := os.Stat(root); err != nil {
+		return nil, fmt.Errorf("path '%s' not found in the repository", path)
+	}
+
+	index := repo.NewIndexFile()
+	err := filepath.WalkDir(root, func(dir string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if _, err := os.Stat(filepath.Join(dir, "Chart.yaml")); err != nil {
+			return nil
+		}
+		chrt, err := loader.LoadDir(dir)
+		if err != nil {
+			// A broken chart shouldn't hide the others
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(checkout, dir)
+		if err != nil {
+			return err
+		}
+		if !index.Has(chrt.Metadata.Name, chrt.Metadata.Version) {
+			if err := index.MustAdd(chrt.Metadata, filepath.ToSlash(rel), "", ""); err != nil {
+				return err
+			}
+		}
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, err
+	}
+	index.SortEntries()
+	return index, nil
+}
+
+// gitChartDir returns the directory of a chart of a git source, "source/chart",
+// in its checkout; ok is false when chartName isn't from a git source
+func (c *Client) gitChartDir(chartName, version string) (dir string, ok bool, err error) {
+	repoName, name, found := strings.Cut(chartName, "/")
+	if !found {
+		return "", false, nil
+	}
+	if _, isGit := c.gitSource(repoName); !isGit {
+		return "", false, nil
+	}
+	index, err := c.loadIndex(repoName)
+	if err != nil {
+		return "", true, err
+	}
+	cv, err := index.Get(name, version)
+	if err != nil || len(cv.URLs) == 0 {
+		return "", true, fmt.Errorf("chart '%s' not found in git source '%s'", chartName, repoName)
+	}
+	return filepath.Join(c.gitCheckout(repoName), filepath.FromSlash(cv.URLs[0])), true, nil
+}
+
+func runGit(dir string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	// Never wait for credentials on a terminal the TUI owns
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return errors.New(msg)
+		}
+		return err
+	}
+	return nil
+}
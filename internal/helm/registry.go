@@ -0,0 +1,177 @@
+// Copyright 2025 Alessandro Pitocchi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/registry"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/credentials"
+)
+
+// Sources of registry credentials
+const (
+	RegistrySourceHelm   = "helm"
+	RegistrySourceDocker = "docker"
+)
+
+// dockerHubAddress is the key Docker Hub credentials are stored under
+const dockerHubAddress = "https://index.docker.io/v1/"
+
+// Registry is an OCI registry with credentials stored for it
+type Registry struct {
+	Host   string // e.g. ghcr.io
+	Source string // Config file the credentials are in: RegistrySourceHelm or RegistrySourceDocker
+	Helper string // Credential helper keeping the secret, e.g. osxkeychain; empty when it's in the file
+}
+
+// dockerConfig is the part of a Docker config.json, which helm also uses for
+// its registry config, that says where credentials are kept
+type dockerConfig struct {
+	Auths       map[string]json.RawMessage `json:"auths"`
+	CredsStore  string                     `json:"credsStore"`
+	CredHelpers map[string]string          `json:"credHelpers"`
+}
+
+// ListRegistries lists the registries with credentials in the helm registry
+// config or, which helm falls back to, the Docker config, like the ones
+// helm registry login and docker login store. A registry in both is listed
+// once, from helm's config.
+func (c *Client) ListRegistries() ([]Registry, error) {
+	dockerPath := os.Getenv("DOCKER_CONFIG")
+	if dockerPath == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			dockerPath = filepath.Join(home, ".docker")
+		}
+	}
+
+	var registries []Registry
+	seen := make(map[string]bool)
+	for _, source := range []struct{ name, path string }{
+		{RegistrySourceHelm, c.settings.RegistryConfig},
+		{RegistrySourceDocker, filepath.Join(dockerPath, "config.json")},
+	} {
+		data, err := os.ReadFile(source.path)
+		if os.IsNotExist(err) || (err == nil && len(strings.TrimSpace(string(data))) == 0) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", source.path, err)
+		}
+		var cfg dockerConfig
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", source.path, err)
+		}
+
+		addresses := make(map[string]bool)
+		for address := range cfg.Auths {
+			addresses[address] = true
+		}
+		for address := range cfg.CredHelpers {
+			addresses[address] = true
+		}
+		for address := range addresses {
+			host := registryHost(address)
+			if seen[host] {
+				continue
+			}
+			seen[host] = true
+			helper := cfg.CredsStore
+			if h, ok := cfg.CredHelpers[address]; ok {
+				helper = h
+			}
+			registries = append(registries, Registry{Host: host, Source: source.name, Helper: helper})
+		}
+	}
+
+	sort.Slice(registries, func(i, j int) bool { return registries[i].Host < registries[j].Host })
+	return registries, nil
+}
+
+// registryHost turns a credentials key, e.g. https://index.docker.io/v1/, into
+// the registry host
+func registryHost(address string) string {
+	if address == dockerHubAddress {
+		return "docker.io"
+	}
+	if _, rest, ok := strings.Cut(address, "://"); ok {
+		address = rest
+	}
+	host, _, _ := strings.Cut(address, "/")
+	return host
+}
+
+// RegistryLogin logs in to an OCI registry like helm registry login,
+// storing the credentials in the helm registry config
+func (c *Client) RegistryLogin(host, username, password string) error {
+	client, err := c.registryClient()
+	if err != nil {
+		return err
+	}
+	if err := client.Login(host, registry.LoginOptBasicAuth(username, password)); err != nil {
+		return fmt.Errorf("failed to log in to %s: %w", host, err)
+	}
+	return nil
+}
+
+// RegistryLogout removes the credentials of an OCI registry from the helm
+// registry config, like helm registry logout
+func (c *Client) RegistryLogout(host string) error {
+	client, err := c.registryClient()
+	if err != nil {
+		return err
+	}
+	if err := client.Logout(host); err != nil {
+		return fmt.Errorf("failed to log out of %s: %w", host, err)
+	}
+	return nil
+}
+
+// CheckRegistry authenticates to a registry with its stored credentials,
+// returning why it failed, if it did
+func (c *Client) CheckRegistry(ctx context.Context, host string) error {
+	opts := credentials.StoreOptions{DetectDefaultNativeStore: true}
+	helmStore, err := credentials.NewStore(c.settings.RegistryConfig, opts)
+	if err != nil {
+		return err
+	}
+	var store credentials.Store = helmStore
+	if dockerStore, err := credentials.NewStoreFromDocker(opts); err == nil {
+		store = credentials.NewStoreWithFallbacks(helmStore, dockerStore)
+	}
+
+	// Docker Hub is served from another host than its name
+	address := host
+	if host == "docker.io" {
+		address = "registry-1.docker.io"
+	}
+	reg, err := remote.NewRegistry(address)
+	if err != nil {
+		return err
+	}
+	reg.Client = &auth.Client{
+		Credential: credentials.Credential(store),
+		Cache:      auth.NewCache(),
+	}
+	return reg.Ping(ctx)
+}
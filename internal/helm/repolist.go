@@ -0,0 +1,138 @@
+// Copyright 2025 Alessandro Pitocchi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"helm.sh/helm/v3/pkg/repo"
+	"sigs.k8s.io/yaml"
+)
+
+// RepositoryImport is the outcome of ImportRepositories, by repository name
+type RepositoryImport struct {
+	Added   []string
+	Updated []string // Pointed at the URL of the file, when replacing
+	Removed []string // Not in the file, when replacing
+	Skipped []string // Already configured, or taken by another URL when merging
+}
+
+// ExportRepositories returns the configured repositories as a repositories
+// file with only names and URLs, so it can be shared without credentials
+func (c *Client) ExportRepositories() ([]byte, error) {
+	f, err := repo.LoadFile(c.settings.RepositoryConfig)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to load repositories: %w", err)
+	}
+
+	shared := repo.NewFile()
+	for _, entry := range f.Repositories {
+		shared.Add(&repo.Entry{Name: entry.Name, URL: entry.URL})
+	}
+	data, err := yaml.Marshal(shared)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export repositories: %w", err)
+	}
+	return data, nil
+}
+
+// ImportRepositories adds the repositories of a file in the format of
+// ExportRepositories or helm's repositories.yaml. Merging keeps every
+// configured repository; replacing also removes those missing from the file
+// and points the others at the file's URL. Git sources are left alone. Each
+// added repository has its index downloaded like AddRepository, and one
+// failing doesn't stop the rest.
+func (c *Client) ImportRepositories(path string, replace bool) (*RepositoryImport, error) {
+	imported, err := repo.LoadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if len(imported.Repositories) == 0 {
+		return nil, fmt.Errorf("no repositories in %s", path)
+	}
+	current, err := c.ListRepositories()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RepositoryImport{}
+	var errs []error
+	inFile := make(map[string]bool)
+	for _, entry := range imported.Repositories {
+		inFile[entry.Name] = true
+	}
+	if replace {
+		for _, r := range current {
+			// Git sources aren't in repositories files, so they stay
+			if inFile[r.Name] || r.Git {
+				continue
+			}
+			if _, err := c.RemoveRepository(r.Name); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			result.Removed = append(result.Removed, r.Name)
+		}
+	}
+
+	for _, entry := range imported.Repositories {
+		var existing *Repository
+		for i := range current {
+			if current[i].Name == entry.Name {
+				existing = &current[i]
+			}
+		}
+		switch {
+		case existing != nil && NormalizeRepoURL(existing.URL) == NormalizeRepoURL(entry.URL):
+			result.Skipped = append(result.Skipped, entry.Name)
+		case existing != nil && (!replace || existing.Git):
+			result.Skipped = append(result.Skipped, entry.Name)
+		case existing != nil:
+			if err := c.repointRepository(entry.Name, entry.URL); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			result.Updated = append(result.Updated, entry.Name)
+		default:
+			if err := c.AddRepository(entry.Name, entry.URL, RepositoryOptions{}); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			result.Added = append(result.Added, entry.Name)
+		}
+	}
+	return result, errors.Join(errs...)
+}
+
+// repointRepository points a repository at another URL, dropping its
+// credentials, which were for the old one. The new index is downloaded first
+// so a bad URL leaves the repository as it was.
+func (c *Client) repointRepository(name, url string) error {
+	entry := &repo.Entry{Name: name, URL: url}
+	if err := c.downloadIndex(entry); err != nil {
+		return err
+	}
+	f, err := repo.LoadFile(c.settings.RepositoryConfig)
+	if err != nil {
+		return fmt.Errorf("failed to load repositories: %w", err)
+	}
+	f.Update(entry)
+	if err := f.WriteFile(c.settings.RepositoryConfig, 0600); err != nil {
+		return fmt.Errorf("failed to write repositories: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+// Copyright 2025 Alessandro Pitocchi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// ReleaseResource is a Kubernetes object of a release with its current state
+type ReleaseResource struct {
+	Kind       string
+	Namespace  string
+	Name       string
+	Status     string // e.g. "2/3 ready", "Running", "LoadBalancer 10.0.0.1"
+	Ready      bool
+	Pod        bool     // A pod of a workload of the release, not in the manifest
+	Containers []string // Container names, for pods
+}
+
+// GetReleaseResources lists the objects of the current release manifest with
+// their readiness, each workload followed by its pods
+func (c *Client) GetReleaseResources(ctx context.Context, releaseName, namespace string) ([]ReleaseResource, error) {
+	cfg, err := c.actionConfig(ctx, c.resolveNamespace(namespace))
+	if err != nil {
+		return nil, err
+	}
+	objects, err := c.releaseObjects(ctx, cfg, releaseName)
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := cfg.KubernetesClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var resources []ReleaseResource
+	for _, obj := range objects {
+		res := ReleaseResource{Kind: obj.kind, Namespace: obj.namespace, Name: obj.name}
+		if obj.live == nil {
+			res.Status = "missing"
+			resources = append(resources, res)
+			continue
+		}
+		res.Status, res.Ready = resourceStatus(obj.kind, obj.live)
+		if obj.kind == "Pod" {
+			containers, _, _ := unstructured.NestedSlice(obj.live, "spec", "containers")
+			for _, container := range containers {
+				if m, ok := container.(map[string]interface{}); ok {
+					if name, ok := m["name"].(string); ok {
+						res.Containers = append(res.Containers, name)
+					}
+				}
+			}
+		}
+		resources = append(resources, res)
+
+		switch obj.kind {
+		case "Deployment", "StatefulSet", "DaemonSet", "Job":
+		default:
+			continue
+		}
+		matchLabels, found, _ := unstructured.NestedStringMap(obj.live, "spec", "selector", "matchLabels")
+		if !found || len(matchLabels) == 0 {
+			continue
+		}
+		pods, err := clientset.CoreV1().Pods(obj.namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: labels.SelectorFromSet(matchLabels).String(),
+		})
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pods of %s %s: %w", obj.kind, obj.name, err)
+		}
+		for _, pod := range pods.Items {
+			status, ready := podStatus(&pod)
+			containers := make([]string, 0, len(pod.Spec.Containers))
+			for _, container := range pod.Spec.Containers {
+				containers = append(containers, container.Name)
+			}
+			resources = append(resources, ReleaseResource{
+				Kind:       "Pod",
+				Namespace:  pod.Namespace,
+				Name:       pod.Name,
+				Status:     status,
+				Ready:      ready,
+				Pod:        true,
+				Containers: containers,
+			})
+		}
+	}
+	return resources, nil
+}
+
+// resourceStatus summarizes the state of a live object the way kubectl get
+// does for the common kinds; other kinds are ready once they exist
+func resourceStatus(kind string, obj map[string]interface{}) (string, bool) {
+	nestedInt := func(fields ...string) int64 {
+		v, _, _ := unstructured.NestedInt64(obj, fields...)
+		return v
+	}
+
+	switch kind {
+	case "Deployment", "StatefulSet", "ReplicaSet":
+		want, found, _ := unstructured.NestedInt64(obj, "spec", "replicas")
+		if !found {
+			want = 1
+		}
+		ready := nestedInt("status", "readyReplicas")
+		return fmt.Sprintf("%d/%d ready", ready, want), ready >= want
+	case "DaemonSet":
+		want := nestedInt("status", "desiredNumberScheduled")
+		ready := nestedInt("status", "numberReady")
+		return fmt.Sprintf("%d/%d ready", ready, want), ready >= want
+	case "Job":
+		want, found, _ := unstructured.NestedInt64(obj, "spec", "completions")
+		if !found {
+			want = 1
+		}
+		succeeded := nestedInt("status", "succeeded")
+		if nestedInt("status", "failed") > 0 && succeeded < want {
+			return fmt.Sprintf("%d/%d completed, %d failed", succeeded, want, nestedInt("status", "failed")), false
+		}
+		return fmt.Sprintf("%d/%d completed", succeeded, want), succeeded >= want
+	case "Service":
+		svcType, _, _ := unstructured.NestedString(obj, "spec", "type")
+		if svcType != "LoadBalancer" {
+			clusterIP, _, _ := unstructured.NestedString(obj, "spec", "clusterIP")
+			return svcType + " " + clusterIP, true
+		}
+		ingress, _, _ := unstructured.NestedSlice(obj, "status", "loadBalancer", "ingress")
+		for _, entry := range ingress {
+			if m, ok := entry.(map[string]interface{}); ok {
+				if ip, ok := m["ip"].(string); ok {
+					return "LoadBalancer " + ip, true
+				}
+				if host, ok := m["hostname"].(string); ok {
+					return "LoadBalancer " + host, true
+				}
+			}
+		}
+		return "LoadBalancer pending", false
+	case "PersistentVolumeClaim":
+		phase, _, _ := unstructured.NestedString(obj, "status", "phase")
+		return phase, phase == "Bound"
+	case "Pod":
+		phase, _, _ := unstructured.NestedString(obj, "status", "phase")
+		return phase, phase == "Running" || phase == "Succeeded"
+	}
+	return "exists", true
+}
+
+// podStatus reports a pod's phase, or the reason a container is waiting
+// (e.g. CrashLoopBackOff), and whether all its containers are ready
+func podStatus(pod *corev1.Pod) (string, bool) {
+	ready := 0
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.State.Waiting != nil && cs.State.Waiting.Reason != "" {
+			return cs.State.Waiting.Reason, false
+		}
+		if cs.Ready {
+			ready++
+		}
+	}
+	total := len(pod.Spec.Containers)
+	status := fmt.Sprintf("%s %d/%d", pod.Status.Phase, ready, total)
+	return status, pod.Status.Phase == corev1.PodSucceeded || (pod.Status.Phase == corev1.PodRunning && ready == total)
+}
+
+// logTailLines is how much of the existing log is shown before following
+const logTailLines = 500
+
+// StreamPodLogs follows the logs of a pod container like kubectl logs -f
+// --tail, sending them to lines one line at a time. lines is closed when the
+// log ends or ctx is cancelled; cancelling isn't reported as an error.
+func (c *Client) StreamPodLogs(ctx context.Context, namespace, pod, container string, lines chan<- string) error {
+	defer close(lines)
+
+	cfg, err := c.actionConfig(ctx, c.resolveNamespace(namespace))
+	if err != nil {
+		return err
+	}
+	clientset, err := cfg.KubernetesClientSet()
+	if err != nil {
+		return err
+	}
+
+	tail := int64(logTailLines)
+	stream, err := clientset.CoreV1().Pods(namespace).GetLogs(pod, &corev1.PodLogOptions{
+		Container: container,
+		Follow:    true,
+		TailLines: &tail,
+	}).Stream(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("failed to get logs of %s: %w", pod, err)
+	}
+	defer stream.Close()
+
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		select {
+		case lines <- scanner.Text():
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return scanner.Err()
+}
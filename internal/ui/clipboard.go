@@ -0,0 +1,56 @@
+// Copyright 2025 Alessandro Pitocchi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ui
+
+import (
+	"os"
+	"strings"
+
+	"github.com/alessandropitocchi/lazyhelm/internal/config"
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+)
+
+// CopyToClipboard puts text on the clipboard using the given mode. In auto
+// mode the system clipboard is tried first, except over SSH where it would
+// land on the remote host, and OSC52 is used as the fallback.
+func CopyToClipboard(text, mode string) error {
+	switch mode {
+	case config.ClipboardOSC52:
+		return copyOSC52(text)
+	case config.ClipboardSystem:
+		return clipboard.WriteAll(text)
+	}
+
+	if os.Getenv("SSH_TTY") == "" && os.Getenv("SSH_CONNECTION") == "" {
+		if err := clipboard.WriteAll(text); err == nil {
+			return nil
+		}
+	}
+	return copyOSC52(text)
+}
+
+// copyOSC52 asks the terminal to set its clipboard through an OSC52 escape
+// sequence, wrapped for tmux and screen so it reaches the outer terminal
+func copyOSC52(text string) error {
+	seq := osc52.New(text)
+	if os.Getenv("TMUX") != "" {
+		seq = seq.Tmux()
+	} else if strings.HasPrefix(os.Getenv("TERM"), "screen") {
+		seq = seq.Screen()
+	}
+	_, err := seq.WriteTo(os.Stderr)
+	return err
+}
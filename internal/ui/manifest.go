@@ -0,0 +1,66 @@
+// Copyright 2025 Alessandro Pitocchi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ui
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestResource is a Kubernetes object of a rendered manifest
+type ManifestResource struct {
+	Line   int // First line of its document, the --- separator if any
+	Kind   string
+	Name   string
+	Source string // Template it was rendered from, e.g. mychart/templates/service.yaml
+}
+
+// ManifestResources lists the objects of a multi-document manifest, as
+// printed by helm template, in order. Documents without a kind, e.g. empty
+// ones, are left out.
+func ManifestResources(manifest string) []ManifestResource {
+	var resources []ManifestResource
+	lines := strings.Split(manifest, "\n")
+	start := 0
+	addDocument := func(end int) {
+		var obj struct {
+			Kind     string `yaml:"kind"`
+			Metadata struct {
+				Name string `yaml:"name"`
+			} `yaml:"metadata"`
+		}
+		if err := yaml.Unmarshal([]byte(strings.Join(lines[start:end], "\n")), &obj); err != nil || obj.Kind == "" {
+			return
+		}
+		res := ManifestResource{Line: start, Kind: obj.Kind, Name: obj.Metadata.Name}
+		for _, line := range lines[start:end] {
+			if source, ok := strings.CutPrefix(line, "# Source: "); ok {
+				res.Source = source
+				break
+			}
+		}
+		resources = append(resources, res)
+	}
+
+	for i, line := range lines {
+		if documentSeparator.MatchString(line) {
+			addDocument(i)
+			start = i
+		}
+	}
+	addDocument(len(lines))
+	return resources
+}
@@ -0,0 +1,339 @@
+// Copyright 2025 Alessandro Pitocchi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+var documentSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+
+// manifestObjects splits a multi-document manifest into its Kubernetes
+// objects, keyed by "Kind namespace/name"
+func manifestObjects(manifest string) map[string]string {
+	objects := make(map[string]string)
+	for _, doc := range documentSeparator.Split(manifest, -1) {
+		doc = strings.Trim(doc, "\n")
+		var obj struct {
+			Kind     string `yaml:"kind"`
+			Metadata struct {
+				Name      string `yaml:"name"`
+				Namespace string `yaml:"namespace"`
+			} `yaml:"metadata"`
+		}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil || obj.Kind == "" {
+			continue
+		}
+		key := obj.Kind + " " + obj.Metadata.Name
+		if obj.Metadata.Namespace != "" {
+			key = obj.Kind + " " + obj.Metadata.Namespace + "/" + obj.Metadata.Name
+		}
+		objects[key] = doc
+	}
+	return objects
+}
+
+// DiffManifests compares two rendered manifests object by object, matching
+// objects on kind, namespace and name. Every added, removed or changed object
+// starts with a "# Kind namespace/name" line; changed objects only show the
+// changed lines with some context, like diff -u.
+func DiffManifests(oldManifest, newManifest string) []DiffLine {
+	oldObjects := manifestObjects(oldManifest)
+	newObjects := manifestObjects(newManifest)
+
+	keys := make([]string, 0, len(oldObjects)+len(newObjects))
+	for key := range oldObjects {
+		keys = append(keys, key)
+	}
+	for key := range newObjects {
+		if _, ok := oldObjects[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	result := make([]DiffLine, 0)
+	for _, key := range keys {
+		oldDoc, inOld := oldObjects[key]
+		newDoc, inNew := newObjects[key]
+		switch {
+		case !inOld:
+			result = append(result, DiffLine{Type: "unchanged", Line: "# " + key + " (added)"})
+			for i, line := range strings.Split(newDoc, "\n") {
+				result = append(result, DiffLine{Type: "added", Line: line, LineNum: i})
+			}
+		case !inNew:
+			result = append(result, DiffLine{Type: "unchanged", Line: "# " + key + " (removed)"})
+			for i, line := range strings.Split(oldDoc, "\n") {
+				result = append(result, DiffLine{Type: "removed", Line: line, LineNum: i})
+			}
+		case oldDoc != newDoc:
+			result = append(result, DiffLine{Type: "unchanged", Line: "# " + key + " (changed)"})
+			result = append(result, diffLines(strings.Split(oldDoc, "\n"), strings.Split(newDoc, "\n"))...)
+		default:
+			continue
+		}
+		result = append(result, DiffLine{Type: "unchanged"})
+	}
+	return result
+}
+
+// diffLines is a Myers line diff keeping two lines of context around each
+// change. Removed lines come before the lines added in their place.
+func diffLines(oldLines, newLines []string) []DiffLine {
+	all := myersDiff(oldLines, newLines)
+
+	contextLines := 2
+	result := make([]DiffLine, 0)
+	lastShown := -1
+	for k, line := range all {
+		nearChange := false
+		for c := max(0, k-contextLines); c <= min(len(all)-1, k+contextLines); c++ {
+			if all[c].Type != "unchanged" {
+				nearChange = true
+				break
+			}
+		}
+		if !nearChange {
+			continue
+		}
+		if lastShown >= 0 && k > lastShown+1 {
+			result = append(result, DiffLine{Type: "unchanged", Line: "..."})
+		}
+		result = append(result, line)
+		lastShown = k
+	}
+	return result
+}
+
+// myersDiff returns every line of the shortest edit script turning oldLines
+// into newLines (E. Myers, "An O(ND) Difference Algorithm"). Memory grows
+// with the square of the number of changes, not with the file sizes.
+func myersDiff(oldLines, newLines []string) []DiffLine {
+	n, m := len(oldLines), len(newLines)
+	offset := n + m
+	// v[offset+k] is the furthest x reached on diagonal k = x - y
+	v := make([]int, 2*offset+2)
+	// trace[d] is v[offset-d : offset+d+1] before round d
+	var trace [][]int
+
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // Down: a line of newLines is added
+			} else {
+				x = v[offset+k-1] + 1 // Right: a line of oldLines is removed
+			}
+			y := x - k
+			for x < n && y < m && oldLines[x] == newLines[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return myersBacktrack(trace, oldLines, newLines)
+			}
+		}
+	}
+	return nil
+}
+
+// myersBacktrack walks the trace of myersDiff back from the end of both
+// files, collecting the edit script in reverse
+func myersBacktrack(trace [][]int, oldLines, newLines []string) []DiffLine {
+	var reversed []DiffLine
+	x, y := len(oldLines), len(newLines)
+	for d := len(trace) - 1; d >= 0; d-- {
+		k := x - y
+		prevX, prevY := 0, 0
+		if d > 0 {
+			prev := trace[d] // v before round d, indexed by k+d
+			prevK := k - 1
+			if k == -d || (k != d && prev[k-1+d] < prev[k+1+d]) {
+				prevK = k + 1
+			}
+			prevX = prev[prevK+d]
+			prevY = prevX - prevK
+		}
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, DiffLine{Type: "unchanged", Line: newLines[y], LineNum: y})
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			y--
+			reversed = append(reversed, DiffLine{Type: "added", Line: newLines[y], LineNum: y})
+		} else {
+			x--
+			reversed = append(reversed, DiffLine{Type: "removed", Line: oldLines[x], LineNum: x})
+		}
+	}
+
+	all := make([]DiffLine, len(reversed))
+	for i, line := range reversed {
+		all[len(reversed)-1-i] = line
+	}
+	return all
+}
+
+// Segment is a part of a changed line; Changed parts differ from the line it
+// replaces
+type Segment struct {
+	Text    string
+	Changed bool
+}
+
+// WordDiff compares a removed line with the line added in its place, token by
+// token, so only the changed words need highlighting. Words are runs of
+// letters, digits and ._- (e.g. "1.2.3" or "my-app"); any other character is
+// a token of its own.
+func WordDiff(oldLine, newLine string) (oldSegments, newSegments []Segment) {
+	script := myersDiff(wordTokens(oldLine), wordTokens(newLine))
+	for _, token := range script {
+		switch token.Type {
+		case "removed":
+			oldSegments = appendSegment(oldSegments, token.Line, true)
+		case "added":
+			newSegments = appendSegment(newSegments, token.Line, true)
+		default:
+			oldSegments = appendSegment(oldSegments, token.Line, false)
+			newSegments = appendSegment(newSegments, token.Line, false)
+		}
+	}
+	return oldSegments, newSegments
+}
+
+func wordTokens(line string) []string {
+	var tokens []string
+	var word strings.Builder
+	for _, r := range line {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("._-", r) {
+			word.WriteRune(r)
+			continue
+		}
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+		tokens = append(tokens, string(r))
+	}
+	if word.Len() > 0 {
+		tokens = append(tokens, word.String())
+	}
+	return tokens
+}
+
+// appendSegment adds text to the last segment when it has the same Changed
+func appendSegment(segments []Segment, text string, changed bool) []Segment {
+	if n := len(segments); n > 0 && segments[n-1].Changed == changed {
+		segments[n-1].Text += text
+		return segments
+	}
+	return append(segments, Segment{Text: text, Changed: changed})
+}
+
+// UnifiedDiff formats the changes between two documents as a unified diff
+// (diff -u) with three lines of context, without colors, e.g. to attach to a
+// pull request. It's empty when the documents are the same.
+func UnifiedDiff(oldContent, newContent, oldLabel, newLabel string) string {
+	split := func(content string) []string {
+		if content == "" {
+			return nil
+		}
+		return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	}
+	script := myersDiff(split(oldContent), split(newContent))
+
+	// Line numbers of each entry of the script in both documents
+	oldNums := make([]int, len(script))
+	newNums := make([]int, len(script))
+	oldNum, newNum := 0, 0
+	for i, line := range script {
+		oldNums[i], newNums[i] = oldNum, newNum
+		if line.Type != "added" {
+			oldNum++
+		}
+		if line.Type != "removed" {
+			newNum++
+		}
+	}
+
+	const contextLines = 3
+	var out strings.Builder
+	for i := 0; i < len(script); {
+		if script[i].Type == "unchanged" {
+			i++
+			continue
+		}
+		// Extend the hunk while the next change is within the context
+		start := max(0, i-contextLines)
+		end := i
+		for j := i; j < len(script) && j <= end+2*contextLines; j++ {
+			if script[j].Type != "unchanged" {
+				end = j
+			}
+		}
+		end = min(len(script), end+contextLines+1)
+
+		oldCount, newCount := 0, 0
+		for _, line := range script[start:end] {
+			if line.Type != "added" {
+				oldCount++
+			}
+			if line.Type != "removed" {
+				newCount++
+			}
+		}
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldLabel, newLabel)
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(oldNums[start], oldCount), hunkRange(newNums[start], newCount))
+		for _, line := range script[start:end] {
+			switch line.Type {
+			case "added":
+				out.WriteString("+" + line.Line + "\n")
+			case "removed":
+				out.WriteString("-" + line.Line + "\n")
+			default:
+				out.WriteString(" " + line.Line + "\n")
+			}
+		}
+		i = end
+	}
+	return out.String()
+}
+
+// hunkRange is the start,count of a hunk header; start is 1-based, or the
+// line before the hunk when it's empty
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
@@ -0,0 +1,67 @@
+// Copyright 2025 Alessandro Pitocchi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ui
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/alessandropitocchi/lazyhelm/internal/config"
+)
+
+// Notify tells the user an operation finished using the given mode. Desktop
+// notifications fall back to the terminal bell when no notifier is available.
+func Notify(title, message, mode string) error {
+	switch mode {
+	case config.NotifyOff:
+		return nil
+	case config.NotifyDesktop:
+		if err := notifyDesktop(title, message); err == nil {
+			return nil
+		}
+	}
+	return bell()
+}
+
+// bell rings the terminal bell; stderr is the same terminal the TUI draws on
+func bell() error {
+	_, err := os.Stderr.WriteString("\a")
+	return err
+}
+
+func notifyDesktop(title, message string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := "display notification " + appleScriptString(message) + " with title " + appleScriptString(title)
+		cmd = exec.Command("osascript", "-e", script)
+	default:
+		cmd = exec.Command("notify-send", title, message)
+	}
+	return cmd.Run()
+}
+
+// appleScriptString quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	quoted := []rune{'"'}
+	for _, r := range s {
+		if r == '"' || r == '\\' {
+			quoted = append(quoted, '\\')
+		}
+		quoted = append(quoted, r)
+	}
+	return string(append(quoted, '"'))
+}
@@ -0,0 +1,175 @@
+// Copyright 2025 Alessandro Pitocchi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PathChange is a value that differs between two YAML documents
+type PathChange struct {
+	Path string // e.g. image.tag or hosts[0].name
+	Type string // "added", "removed" or "changed"
+	Old  string // Empty when added
+	New  string // Empty when removed
+}
+
+// DiffPaths compares two YAML documents by value rather than by line:
+// formatting, comments and key order don't count. Every leaf value added,
+// removed or changed is reported with its path, in path order. Lists are
+// compared item by item.
+func DiffPaths(oldContent, newContent string) ([]PathChange, error) {
+	var oldDoc, newDoc interface{}
+	if err := yaml.Unmarshal([]byte(oldContent), &oldDoc); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %w", err)
+	}
+	if err := yaml.Unmarshal([]byte(newContent), &newDoc); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %w", err)
+	}
+
+	var changes []PathChange
+	diffValues(oldDoc, newDoc, "", &changes)
+	return changes, nil
+}
+
+func diffValues(oldValue, newValue interface{}, path string, changes *[]PathChange) {
+	switch oldTyped := oldValue.(type) {
+	case map[string]interface{}:
+		if newTyped, ok := newValue.(map[string]interface{}); ok && len(oldTyped) > 0 && len(newTyped) > 0 {
+			keys := make([]string, 0, len(oldTyped)+len(newTyped))
+			for key := range oldTyped {
+				keys = append(keys, key)
+			}
+			for key := range newTyped {
+				if _, ok := oldTyped[key]; !ok {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				childPath := key
+				if path != "" {
+					childPath = path + "." + key
+				}
+				oldChild, inOld := oldTyped[key]
+				newChild, inNew := newTyped[key]
+				switch {
+				case !inOld:
+					leafValues(newChild, childPath, "added", changes)
+				case !inNew:
+					leafValues(oldChild, childPath, "removed", changes)
+				default:
+					diffValues(oldChild, newChild, childPath, changes)
+				}
+			}
+			return
+		}
+	case []interface{}:
+		if newTyped, ok := newValue.([]interface{}); ok && len(oldTyped) > 0 && len(newTyped) > 0 {
+			for i := 0; i < max(len(oldTyped), len(newTyped)); i++ {
+				childPath := path + "[" + strconv.Itoa(i) + "]"
+				switch {
+				case i >= len(oldTyped):
+					leafValues(newTyped[i], childPath, "added", changes)
+				case i >= len(newTyped):
+					leafValues(oldTyped[i], childPath, "removed", changes)
+				default:
+					diffValues(oldTyped[i], newTyped[i], childPath, changes)
+				}
+			}
+			return
+		}
+	}
+
+	if isContainer(oldValue) || isContainer(newValue) {
+		// A value that changed type, e.g. a string replaced by a map
+		leafValues(oldValue, path, "removed", changes)
+		leafValues(newValue, path, "added", changes)
+		return
+	}
+	oldText, newText := scalarText(oldValue), scalarText(newValue)
+	if oldText != newText {
+		*changes = append(*changes, PathChange{Path: path, Type: "changed", Old: oldText, New: newText})
+	}
+}
+
+// leafValues reports every leaf of an added or removed value
+func leafValues(value interface{}, path, changeType string, changes *[]PathChange) {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		if len(typed) > 0 {
+			keys := make([]string, 0, len(typed))
+			for key := range typed {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				childPath := key
+				if path != "" {
+					childPath = path + "." + key
+				}
+				leafValues(typed[key], childPath, changeType, changes)
+			}
+			return
+		}
+	case []interface{}:
+		if len(typed) > 0 {
+			for i, item := range typed {
+				leafValues(item, path+"["+strconv.Itoa(i)+"]", changeType, changes)
+			}
+			return
+		}
+	}
+
+	change := PathChange{Path: path, Type: changeType}
+	if changeType == "added" {
+		change.New = scalarText(value)
+	} else {
+		change.Old = scalarText(value)
+	}
+	*changes = append(*changes, change)
+}
+
+func isContainer(value interface{}) bool {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		return len(typed) > 0
+	case []interface{}:
+		return len(typed) > 0
+	}
+	return false
+}
+
+// scalarText formats a leaf value the way it reads in YAML
+func scalarText(value interface{}) string {
+	switch typed := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		if typed == "" {
+			return `""`
+		}
+		return typed
+	case map[string]interface{}:
+		return "{}"
+	case []interface{}:
+		return "[]"
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,92 @@
+// Copyright 2025 Alessandro Pitocchi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// regexPrefix starts a search query that is a regular expression
+const regexPrefix = "re:"
+
+// SearchOptions change how a search query matches
+type SearchOptions struct {
+	CaseSensitive bool
+	WholeWord     bool // Matches can't be part of a longer word
+}
+
+// SearchPattern is a search query of the text viewers: a substring, or a
+// regular expression when the query starts with "re:", e.g. re:tag:\s+v?1\.
+// The zero value matches nothing.
+type SearchPattern struct {
+	re        *regexp.Regexp
+	wholeWord bool
+}
+
+// NewSearchPattern parses a search query, failing on invalid regular
+// expressions
+func NewSearchPattern(query string, opts SearchOptions) (*SearchPattern, error) {
+	expr, isRegex := strings.CutPrefix(query, regexPrefix)
+	if !isRegex {
+		expr = regexp.QuoteMeta(query)
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression: %w", err)
+	}
+	if !opts.CaseSensitive {
+		re = regexp.MustCompile("(?i)" + expr)
+	}
+	return &SearchPattern{re: re, wholeWord: opts.WholeWord}, nil
+}
+
+// Index returns the byte range of the first match in s, or -1, -1. Empty
+// regular expression matches don't count, so re:a* doesn't match every line.
+func (p *SearchPattern) Index(s string) (start, end int) {
+	if p.re == nil {
+		return -1, -1
+	}
+	for _, loc := range p.re.FindAllStringIndex(s, -1) {
+		if loc[1] > loc[0] && (!p.wholeWord || isWordBoundary(s, loc[0], loc[1])) {
+			return loc[0], loc[1]
+		}
+	}
+	return -1, -1
+}
+
+// Match reports whether s contains a match
+func (p *SearchPattern) Match(s string) bool {
+	start, _ := p.Index(s)
+	return start >= 0
+}
+
+// isWordBoundary reports whether s[start:end] isn't preceded or followed by
+// a letter, digit or underscore
+func isWordBoundary(s string, start, end int) bool {
+	isWord := func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+	}
+	if before, _ := utf8.DecodeLastRuneInString(s[:start]); start > 0 && isWord(before) {
+		return false
+	}
+	if after, _ := utf8.DecodeRuneInString(s[end:]); end < len(s) && isWord(after) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,58 @@
+// Copyright 2025 Alessandro Pitocchi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ui
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// ScrollLine returns the part of line shown in a viewport width cells wide
+// that is scrolled offset cells to the right. Widths are terminal cells, so
+// multi-byte characters are never split and wide (CJK, emoji) characters
+// count double. more reports whether the line continues past the right edge.
+func ScrollLine(line string, offset, width int) (visible string, more bool) {
+	total := ansi.StringWidth(line)
+	if total <= offset {
+		return "", false
+	}
+	if total-offset > width {
+		return ansi.Cut(line, offset, offset+width), true
+	}
+	return ansi.Cut(line, offset, total), false
+}
+
+// IndexFold finds the first case-insensitive occurrence of substr in s and
+// returns its byte range in s. Unlike searching strings.ToLower(s), the range
+// is valid even when lowercasing changes the byte length of s.
+func IndexFold(s, substr string) (start, end int) {
+	if substr == "" {
+		return -1, -1
+	}
+	n := utf8.RuneCountInString(substr)
+	for i := range s {
+		j := i
+		for k := 0; k < n && j < len(s); k++ {
+			_, size := utf8.DecodeRuneInString(s[j:])
+			j += size
+		}
+		if strings.EqualFold(s[i:j], substr) {
+			return i, j
+		}
+	}
+	return -1, -1
+}
@@ -0,0 +1,49 @@
+// Copyright 2025 Alessandro Pitocchi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ui
+
+import "strings"
+
+// FoldBlocks finds the block each line of a YAML document opens, for code
+// folding: the lines indented below it, like the fields of a map or the
+// items of a list. blockEnd[i] is the index after the last line of the block
+// of line i, or i+1 when line i opens none. Blank lines don't end a block but
+// aren't part of its end.
+func FoldBlocks(lines []string) []int {
+	blockEnd := make([]int, len(lines))
+	for i, line := range lines {
+		blockEnd[i] = i + 1
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := getIndentLevel(line)
+		// "key:" followed by "- item" at the same indent is still its list
+		listParent := strings.HasSuffix(stripYAMLComment(trimmed), ":") && !strings.HasPrefix(trimmed, "-")
+
+		for j := i + 1; j < len(lines); j++ {
+			next := strings.TrimSpace(lines[j])
+			if next == "" {
+				continue
+			}
+			nextIndent := getIndentLevel(lines[j])
+			if nextIndent < indent || (nextIndent == indent && !(listParent && strings.HasPrefix(next, "- "))) {
+				break
+			}
+			blockEnd[i] = j + 1
+		}
+	}
+	return blockEnd
+}
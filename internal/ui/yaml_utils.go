@@ -15,7 +15,12 @@
 package ui
 
 import (
+	"fmt"
+	"sort"
 	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
 )
 
 func GetYAMLPath(lines []string, lineNum int) string {
@@ -107,137 +112,429 @@ func GetYAMLPath(lines []string, lineNum int) string {
 	return strings.Join(path, ".")
 }
 
-func getIndentLevel(line string) int {
-	count := 0
-	for _, ch := range line {
-		if ch == ' ' {
-			count++
-		} else if ch == '\t' {
-			count += 2
-		} else {
-			break
+// GetYAMLSetPath returns the path of the key or list item on a line using
+// Helm --set syntax: dots, commas and brackets in keys are escaped and list
+// items are addressed as [0], e.g. ingress.annotations.kubernetes\.io/tls[0]
+func GetYAMLSetPath(lines []string, lineNum int) string {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(strings.Join(lines, "\n")), &doc); err != nil || len(doc.Content) == 0 {
+		return ""
+	}
+
+	// yaml.Node lines are 1-based
+	segments, ok := findYAMLPath(doc.Content[0], lineNum+1, nil)
+	if !ok {
+		return ""
+	}
+
+	var path strings.Builder
+	for _, segment := range segments {
+		if strings.HasPrefix(segment, "[") {
+			path.WriteString(segment)
+			continue
 		}
+		if path.Len() > 0 {
+			path.WriteString(".")
+		}
+		path.WriteString(escapeSetKey(segment))
 	}
-	return count
+	return path.String()
 }
 
-func extractKey(line string) string {
-	trimmed := strings.TrimSpace(line)
-	if idx := strings.Index(trimmed, ":"); idx > 0 {
-		return trimmed[:idx]
+// GetYAMLSetFlag returns a helm flag setting the scalar on a line to its
+// current value, e.g. --set image.tag=1.2.3 or --set hosts[0]=example.com.
+// Quoted strings that would otherwise be read as another type use
+// --set-string, and flow lists of scalars become {a,b}. The flag is quoted
+// for the shell when needed; it's "" when the line holds no scalar.
+func GetYAMLSetFlag(lines []string, lineNum int) string {
+	path := GetYAMLSetPath(lines, lineNum)
+	raw := GetYAMLValue(lines[lineNum])
+	if path == "" || raw == "" {
+		return ""
 	}
-	return ""
+
+	var value interface{}
+	if err := yaml.Unmarshal([]byte(raw), &value); err != nil {
+		return ""
+	}
+	flag := "--set"
+	var formatted string
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return ""
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			switch item.(type) {
+			case map[string]interface{}, []interface{}:
+				return ""
+			}
+			items[i] = escapeSetValue(fmt.Sprint(item))
+		}
+		formatted = "{" + strings.Join(items, ",") + "}"
+	case nil:
+		formatted = "null"
+	case string:
+		// --set guesses types, so "1.0" or "true" must stay strings
+		var plain interface{}
+		if yaml.Unmarshal([]byte(v), &plain) != nil || plain != v {
+			flag = "--set-string"
+		}
+		formatted = escapeSetValue(v)
+	default:
+		formatted = escapeSetValue(raw)
+	}
+	return flag + " " + shellQuote(path+"="+formatted)
 }
 
-type DiffLine struct {
-	Type    string // "added", "removed", "unchanged", "modified"
-	Line    string
-	LineNum int
+// escapeSetValue escapes the characters --set splits values on
+func escapeSetValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ",", `\,`).Replace(value)
 }
 
-func DiffYAML(oldContent, newContent string) []DiffLine {
-	oldLines := strings.Split(oldContent, "\n")
-	newLines := strings.Split(newContent, "\n")
+// shellQuote single-quotes s unless it only has characters a shell leaves
+// alone
+func shellQuote(s string) string {
+	for _, r := range s {
+		if !(unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_-.,/:=@%+", r)) {
+			return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+		}
+	}
+	return s
+}
 
-	oldMap := make(map[string]struct {
-		line   string
-		lineNum int
-	})
-	newMap := make(map[string]struct {
-		line   string
-		lineNum int
-	})
+// FindInValues looks query up in a values document, as a YAML path (e.g.
+// image.registry, also matching subchart keys like postgresql.image.registry)
+// or else as a case-insensitive string, and describes the first hit, e.g.
+// "image.registry: docker.io" or "line 12: registry: docker.io"
+func FindInValues(values, query string) (string, bool) {
+	lines := strings.Split(values, "\n")
+	for _, keyPath := range KeyPaths(values) {
+		if keyPath.Path != query && !strings.HasSuffix(keyPath.Path, "."+query) {
+			continue
+		}
+		value := GetYAMLValue(lines[keyPath.Line])
+		if value == "" {
+			value = "…"
+		}
+		return keyPath.Path + ": " + value, true
+	}
+	for i, line := range lines {
+		if start, _ := IndexFold(line, query); start >= 0 {
+			return fmt.Sprintf("line %d: %s", i+1, strings.TrimSpace(line)), true
+		}
+	}
+	return "", false
+}
 
-	// Build maps with line numbers
-	for i, line := range oldLines {
-		key := extractKey(line)
-		if key != "" {
-			oldMap[key] = struct {
-				line   string
-				lineNum int
-			}{line, i}
+// KeyPath is a key or list item of a YAML document and the line it's on
+type KeyPath struct {
+	Path string // e.g. image.pullPolicy or hosts[0].name
+	Line int    // 0-based
+}
+
+// KeyPaths lists every key and list item of a YAML document with its dotted
+// path, in document order
+func KeyPaths(content string) []KeyPath {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil || len(doc.Content) == 0 {
+		return nil
+	}
+	var paths []KeyPath
+	collectKeyPaths(doc.Content[0], "", &paths)
+	return paths
+}
+
+func collectKeyPaths(node *yaml.Node, path string, paths *[]KeyPath) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			keyPath := key.Value
+			if path != "" {
+				keyPath = path + "." + key.Value
+			}
+			*paths = append(*paths, KeyPath{Path: keyPath, Line: key.Line - 1})
+			collectKeyPaths(value, keyPath, paths)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			*paths = append(*paths, KeyPath{Path: itemPath, Line: item.Line - 1})
+			collectKeyPaths(item, itemPath, paths)
 		}
 	}
+}
 
-	for i, line := range newLines {
-		key := extractKey(line)
-		if key != "" {
-			newMap[key] = struct {
-				line   string
-				lineNum int
-			}{line, i}
+// findYAMLPath walks node looking for the key or sequence item that starts
+// on line. List indices are returned as "[i]" segments.
+func findYAMLPath(node *yaml.Node, line int, path []string) ([]string, bool) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			keyPath := append(append([]string{}, path...), key.Value)
+			if key.Line == line {
+				return keyPath, true
+			}
+			if found, ok := findYAMLPath(value, line, keyPath); ok {
+				return found, true
+			}
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			itemPath := append(append([]string{}, path...), fmt.Sprintf("[%d]", i))
+			if found, ok := findYAMLPath(item, line, itemPath); ok {
+				return found, true
+			}
+			if item.Line == line {
+				return itemPath, true
+			}
 		}
 	}
+	return nil, false
+}
 
-	result := make([]DiffLine, 0)
-	contextLines := 2 // Number of context lines to show around changes
+// escapeSetKey escapes the characters --set treats as separators in a key
+func escapeSetKey(key string) string {
+	var escaped strings.Builder
+	for _, r := range key {
+		switch r {
+		case '\\', '.', ',', '=', '[':
+			escaped.WriteRune('\\')
+		}
+		escaped.WriteRune(r)
+	}
+	return escaped.String()
+}
 
-	// Track which lines are changes or near changes
-	isChange := make(map[int]bool)
+// UnknownKeys returns the dotted paths of keys set in overrides that don't
+// exist in defaults. Keys below an empty map or a list in defaults (e.g.
+// podAnnotations: {}) are free-form and not reported.
+func UnknownKeys(defaults, overrides string) ([]string, error) {
+	var base, other map[string]interface{}
+	if err := yaml.Unmarshal([]byte(defaults), &base); err != nil {
+		return nil, fmt.Errorf("failed to parse chart values: %w", err)
+	}
+	if err := yaml.Unmarshal([]byte(overrides), &other); err != nil {
+		return nil, fmt.Errorf("failed to parse values file: %w", err)
+	}
 
-	// Find all changes first
-	for key, newData := range newMap {
-		if oldData, exists := oldMap[key]; exists {
-			if oldData.line != newData.line {
-				// Modified line - mark it and add both old and new
-				isChange[newData.lineNum] = true
-			}
-		} else {
-			// Added line
-			isChange[newData.lineNum] = true
+	var unknown []string
+	collectUnknownKeys(base, other, "", &unknown)
+	sort.Strings(unknown)
+	return unknown, nil
+}
+
+func collectUnknownKeys(base, other map[string]interface{}, prefix string, unknown *[]string) {
+	for key, value := range other {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		baseValue, exists := base[key]
+		if !exists {
+			*unknown = append(*unknown, path)
+			continue
 		}
+
+		baseMap, baseIsMap := baseValue.(map[string]interface{})
+		otherMap, otherIsMap := value.(map[string]interface{})
+		if baseIsMap && otherIsMap && len(baseMap) > 0 {
+			collectUnknownKeys(baseMap, otherMap, path, unknown)
+		}
+	}
+}
+
+// DiffOverrides lists the values set in overrides that differ from defaults,
+// one line per overridden value: the chart default (when there is one) as
+// removed and the override as added, by dotted path
+func DiffOverrides(defaults, overrides string) ([]DiffLine, error) {
+	var base, other map[string]interface{}
+	if err := yaml.Unmarshal([]byte(defaults), &base); err != nil {
+		return nil, fmt.Errorf("failed to parse chart values: %w", err)
 	}
+	if err := yaml.Unmarshal([]byte(overrides), &other); err != nil {
+		return nil, fmt.Errorf("failed to parse release values: %w", err)
+	}
+
+	baseLeaves := make(map[string]string)
+	flattenValues(base, "", baseLeaves)
+	otherLeaves := make(map[string]string)
+	flattenValues(other, "", otherLeaves)
 
-	// Find removed lines
-	removedKeys := make([]string, 0)
-	for key := range oldMap {
-		if _, exists := newMap[key]; !exists {
-			removedKeys = append(removedKeys, key)
+	paths := make([]string, 0, len(otherLeaves))
+	for path := range otherLeaves {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	result := make([]DiffLine, 0)
+	for i, path := range paths {
+		value := otherLeaves[path]
+		def, exists := baseLeaves[path]
+		if exists && def == value {
+			continue
+		}
+		if exists {
+			result = append(result, DiffLine{Type: "removed", Line: path + ": " + def, LineNum: i})
 		}
+		result = append(result, DiffLine{Type: "added", Line: path + ": " + value, LineNum: i})
 	}
+	return result, nil
+}
 
-	// Build result with changes and context
-	for i, newLine := range newLines {
-		key := extractKey(newLine)
+// flattenValues maps the dotted path of every leaf value (scalars, lists and
+// empty maps) to its flow-style YAML
+func flattenValues(values map[string]interface{}, prefix string, leaves map[string]string) {
+	for key, value := range values {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			flattenValues(nested, path, leaves)
+			continue
+		}
+		leaves[path] = flowYAML(value)
+	}
+}
 
-		// Check if this line or nearby lines are changes
-		hasNearbyChange := false
-		for j := i - contextLines; j <= i + contextLines; j++ {
-			if isChange[j] {
-				hasNearbyChange = true
-				break
-			}
+func flowYAML(value interface{}) string {
+	var node yaml.Node
+	if err := node.Encode(value); err != nil {
+		return fmt.Sprint(value)
+	}
+	node.Style = yaml.FlowStyle
+	for _, child := range node.Content {
+		child.Style |= yaml.FlowStyle
+	}
+	out, err := yaml.Marshal(&node)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// GetYAMLValue returns the scalar value on a line ("key: value" or "- value"),
+// without any trailing comment. It returns "" when the line opens a mapping,
+// a list or a block scalar instead of holding a value.
+func GetYAMLValue(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return ""
+	}
+
+	var value string
+	if strings.HasPrefix(trimmed, "- ") {
+		value = strings.TrimSpace(trimmed[2:])
+		// "- key: value" starts a mapping inside a list
+		if key := extractKey(value); key != "" && !strings.ContainsAny(key[:1], "\"'") {
+			value = strings.TrimSpace(value[len(key)+1:])
 		}
+	} else if idx := strings.Index(trimmed, ":"); idx > 0 {
+		value = strings.TrimSpace(trimmed[idx+1:])
+	} else {
+		return ""
+	}
+
+	if value == "" || strings.HasPrefix(value, "#") || strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+		return ""
+	}
+	return stripYAMLComment(value)
+}
+
+// GetYAMLBlock returns the nested block a line opens (the fields of a map, the
+// items of a list, the text of a block scalar or the fields of a "- key: value"
+// list item) without its indentation, or "" when the line opens none
+func GetYAMLBlock(lines []string, lineNum int) string {
+	end := FoldBlocks(lines[lineNum:])[0] + lineNum
+	if end == lineNum+1 {
+		return ""
+	}
 
-		if !hasNearbyChange {
-			continue // Skip lines far from changes
+	block := append([]string(nil), lines[lineNum:end]...)
+	if trimmed := strings.TrimSpace(block[0]); strings.HasPrefix(trimmed, "- ") {
+		// The item's first field lines up with the others once "- " is blanked
+		block[0] = strings.Replace(block[0], "- ", "  ", 1)
+	} else {
+		block = block[1:]
+	}
+
+	indent := -1
+	for _, line := range block {
+		if strings.TrimSpace(line) != "" && (indent < 0 || getIndentLevel(line) < indent) {
+			indent = getIndentLevel(line)
+		}
+	}
+	for i, line := range block {
+		if len(line) >= indent {
+			block[i] = line[indent:]
+		} else {
+			block[i] = ""
 		}
+	}
+	return strings.Join(block, "\n")
+}
 
-		if key != "" {
-			if oldData, exists := oldMap[key]; exists {
-				if oldData.line != newLine {
-					// Show old line first, then new line
-					result = append(result, DiffLine{Type: "removed", Line: oldData.line, LineNum: oldData.lineNum})
-					result = append(result, DiffLine{Type: "added", Line: newLine, LineNum: i})
-				} else {
-					// Context line (unchanged)
-					result = append(result, DiffLine{Type: "unchanged", Line: newLine, LineNum: i})
+// stripYAMLComment removes a trailing " # comment" outside of quotes
+func stripYAMLComment(value string) string {
+	if value[0] == '"' || value[0] == '\'' {
+		quote := value[0]
+		for i := 1; i < len(value); i++ {
+			if value[i] == '\\' && quote == '"' {
+				i++
+				continue
+			}
+			if value[i] == quote {
+				// '' is an escaped quote in single-quoted scalars
+				if quote == '\'' && i+1 < len(value) && value[i+1] == '\'' {
+					i++
+					continue
 				}
-			} else {
-				// Added line
-				result = append(result, DiffLine{Type: "added", Line: newLine, LineNum: i})
+				return value[:i+1]
 			}
+		}
+		return value
+	}
+	if idx := strings.Index(value, " #"); idx >= 0 {
+		return strings.TrimSpace(value[:idx])
+	}
+	return value
+}
+
+func getIndentLevel(line string) int {
+	count := 0
+	for _, ch := range line {
+		if ch == ' ' {
+			count++
+		} else if ch == '\t' {
+			count += 2
 		} else {
-			// Context line (empty or comment)
-			result = append(result, DiffLine{Type: "unchanged", Line: newLine, LineNum: i})
+			break
 		}
 	}
+	return count
+}
 
-	// Add removed lines at the end with context
-	for _, key := range removedKeys {
-		oldData := oldMap[key]
-		result = append(result, DiffLine{Type: "removed", Line: oldData.line, LineNum: oldData.lineNum})
+func extractKey(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if idx := strings.Index(trimmed, ":"); idx > 0 {
+		return trimmed[:idx]
 	}
+	return ""
+}
 
-	return result
+type DiffLine struct {
+	Type    string // "added", "removed", "unchanged", "modified"
+	Line    string
+	LineNum int
+}
+
+// DiffYAML compares two YAML documents line by line, showing the changed
+// lines with two lines of context. Lines are matched by position in the
+// document, not by key, so repeated keys and list items diff correctly.
+func DiffYAML(oldContent, newContent string) []DiffLine {
+	return diffLines(strings.Split(oldContent, "\n"), strings.Split(newContent, "\n"))
 }